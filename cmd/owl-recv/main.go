@@ -0,0 +1,588 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"qrtransfer/pkg/audit"
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/config"
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/logging"
+	"qrtransfer/pkg/qr"
+	"qrtransfer/pkg/screen"
+	"qrtransfer/pkg/secure"
+)
+
+const keyCodeSize = 360
+
+type options struct {
+	source    string
+	region    image.Rectangle
+	track     bool
+	fps       int
+	autoFPS   bool
+	out       string
+	blockSize int
+	decoders  int
+	tuning    screen.DecodeTuning
+	spoolDir  string
+	wipe      bool
+	report    string
+	timeout   time.Duration
+	keys      chunk.Keys
+	keyCode   string
+	policy    engine.Policy
+	audit     string
+	note      string
+	trusted   [][]byte
+
+	quarantine engine.Quarantine
+	pairing    *secure.Pairing
+}
+
+func parseFlags() (options, error) {
+	var opts options
+	var region, passphraseFile, logLevel, maxSize, extensions, types, pairToken, trust string
+	var keyring bool
+
+	flag.StringVar(&opts.source, "source", "screen", "capture source: screen, display:NAME, window:TITLE, camera:DEVICE, capture:DEVICE, stream:URL, video:PATH, images:DIR or pages:DIR")
+	flag.StringVar(&region, "region", "", "screen region to capture as X,Y,WIDTH,HEIGHT")
+	flag.BoolVar(&opts.track, "track", true, "once a code is found on screen, capture only the area around it")
+	flag.IntVar(&opts.fps, "fps", 2, "capture rate in frames per second for live sources")
+	flag.BoolVar(&opts.autoFPS, "auto-fps", true, "adjust the capture rate of live sources to how fast codes arrive, starting from -fps")
+	flag.StringVar(&opts.out, "o", "", "output path, or - for stdout (default: the transmitted filename in the current directory)")
+	flag.IntVar(&opts.blockSize, "block-size", engine.DefaultBlockSize, "expected QR block size in pixels")
+	flag.IntVar(&opts.decoders, "decoders", 0, "frames decoded in parallel (default: up to 4, one per CPU)")
+	flag.Float64Var(&opts.tuning.Tolerance, "tolerance", qr.DefaultTolerance, "color error tolerated before a block is unreadable, as a fraction of the level step")
+	flag.IntVar(&opts.tuning.Kernel, "kernel", 1, "side in pixels of the square averaged at each block center")
+	flag.BoolVar(&opts.tuning.Dominant, "dominant", false, "read each block as the most common color across the whole block instead of the kernel average, ignoring grid lines and blurred edges")
+	flag.IntVar(&opts.tuning.Threshold, "threshold", 0, "luminance threshold for finding codes, 0 for automatic")
+	flag.BoolVar(&opts.tuning.EInk, "eink", false, "decode monochrome e-ink frames, waiting for each to settle")
+	flag.BoolVar(&opts.tuning.Projector, "projector", false, "decode 8-color projector frames with perspective correction")
+	flag.StringVar(&opts.spoolDir, "spool", "", "directory for spooling received chunks to disk instead of memory")
+	flag.BoolVar(&opts.wipe, "wipe", false, "zero received chunks and decrypted data once they are no longer needed, and never spool encrypted transfers to disk")
+	flag.StringVar(&opts.report, "report", "", "write a per-chunk report to this path on exit, as CSV if it ends in .csv and JSON otherwise")
+	flag.DurationVar(&opts.timeout, "timeout", 0, "give up after this long, 0 to wait forever")
+	flag.StringVar(&passphraseFile, "passphrase-file", "", "decrypt transfers with the passphrase on the first line of this file and reject unencrypted frames (default: $"+secure.PassphraseEnv+" if set)")
+	flag.BoolVar(&keyring, "keyring", false, "decrypt transfers with the passphrase the receiver app saved in the system keyring and reject unencrypted frames")
+	flag.StringVar(&pairToken, "pair", "", "accept only transfers sent with this pairing token, or new to create a token and print it as a pairing event")
+	flag.StringVar(&opts.keyCode, "key-code", "", "create a receiver key for this run, write its key code to this PNG path and print the key as a key event")
+	flag.StringVar(&maxSize, "max-size", "", "refuse files larger than this, such as 20MB (default: no limit)")
+	flag.StringVar(&extensions, "allow-ext", "", "comma-separated file extensions to accept, such as txt,pdf (default: any)")
+	flag.StringVar(&types, "allow-type", "", "comma-separated MIME types to accept, such as text/*,application/pdf (default: any)")
+	flag.BoolVar(&opts.policy.RejectExecutables, "no-executables", false, "refuse executables, scripts and installers by name, type or content")
+	flag.StringVar(&opts.quarantine.Dir, "quarantine", "", "save the file into this directory, readable only by you, and leave it there unless -hook releases it (default with -hook: the quarantine folder in the config directory)")
+	flag.StringVar(&opts.quarantine.Hook, "hook", "", "command run on the quarantined file, with {} replaced by its path or the path added last; exit status 0 moves the file to its output path, anything else deletes it")
+	flag.DurationVar(&opts.quarantine.HookTimeout, "hook-timeout", engine.DefaultHookTimeout, "delete the file if -hook has not finished after this long")
+	flag.StringVar(&opts.audit, "audit", "", "append a record of each received or refused transfer, with the file's hash, to this audit log")
+	flag.StringVar(&opts.note, "note", "", "operator note to store with the -audit records")
+	flag.StringVar(&trust, "trust", "", "comma-separated signing keys of trusted senders; other signatures are reported as untrusted")
+	flag.StringVar(&logLevel, "log-level", logging.DefaultLevel(), "log verbosity: debug, info, warn or error")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: owl-recv [flags]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	logVerbosity, err := logging.ParseLevel(logLevel)
+	if err != nil {
+		return opts, err
+	}
+	logging.Setup(logVerbosity, os.Stderr)
+
+	if region != "" {
+		if opts.region, err = parseRegion(region); err != nil {
+			return opts, err
+		}
+	}
+	passphrase, err := secure.LoadPassphrase(passphraseFile)
+	if err != nil {
+		return opts, err
+	}
+	if keyring && passphraseFile == "" {
+		if passphrase, err = secure.KeyringGet(secure.KeyringReceiverPassphrase); err != nil {
+			return opts, fmt.Errorf("saved passphrase: %w", err)
+		}
+	}
+	opts.keys = secure.Passphrase(passphrase)
+	if opts.policy.MaxSize, err = engine.ParseSize(maxSize); err != nil {
+		return opts, err
+	}
+	opts.policy.Extensions = splitList(extensions)
+	opts.policy.ContentTypes = splitList(types)
+	for _, text := range strings.Split(trust, ",") {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		key, err := secure.ParseSigningKey(text)
+		if err != nil {
+			return opts, fmt.Errorf("trusted signer %q: %w", text, err)
+		}
+		opts.trusted = append(opts.trusted, key)
+	}
+	if pairToken != "" {
+		if pairToken == "new" {
+			if pairToken, err = secure.NewPairingToken(); err != nil {
+				return opts, err
+			}
+		}
+		if opts.pairing, err = secure.Pair(pairToken); err != nil {
+			return opts, err
+		}
+	}
+	if opts.quarantine.Hook != "" && opts.quarantine.Dir == "" {
+		if opts.quarantine.Dir, err = config.QuarantineDir(); err != nil {
+			return opts, err
+		}
+	}
+
+	switch {
+	case opts.fps <= 0:
+		return opts, errors.New("fps must be positive")
+	case opts.blockSize <= 0:
+		return opts, errors.New("block size must be positive")
+	case opts.tuning.Tolerance <= 0 || opts.tuning.Tolerance > 0.5:
+		return opts, errors.New("tolerance must be above 0 and at most 0.5")
+	case opts.tuning.Kernel < 1:
+		return opts, errors.New("kernel must be at least 1")
+	case opts.tuning.Threshold < 0 || opts.tuning.Threshold > 255:
+		return opts, errors.New("threshold must be between 0 and 255")
+	case opts.decoders < 0:
+		return opts, errors.New("decoders must not be negative")
+	case opts.timeout < 0:
+		return opts, errors.New("timeout must not be negative")
+	case opts.quarantine.Enabled() && opts.out == "-":
+		return opts, errors.New("-quarantine and -hook cannot be used with -o -")
+	}
+
+	return opts, nil
+}
+
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+func parseRegion(s string) (image.Rectangle, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return image.Rectangle{}, fmt.Errorf("region %q: want X,Y,WIDTH,HEIGHT", s)
+	}
+
+	var v [4]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("region %q: %w", s, err)
+		}
+		v[i] = n
+	}
+	if v[2] <= 0 || v[3] <= 0 {
+		return image.Rectangle{}, fmt.Errorf("region %q: width and height must be positive", s)
+	}
+	return image.Rect(v[0], v[1], v[0]+v[2], v[1]+v[3]), nil
+}
+
+func openSource(opts options) (engine.Source, int, error) {
+	kind, arg, _ := strings.Cut(opts.source, ":")
+	switch kind {
+	case "screen":
+		return screenSource(opts.region, opts.track), opts.fps, nil
+	case "display":
+		displays, err := screen.ListDisplays()
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, d := range displays {
+			if d.Name == arg || d.ID == arg {
+				return screenSource(d.Bounds, opts.track), opts.fps, nil
+			}
+		}
+		return nil, 0, fmt.Errorf("display %q not found", arg)
+	case "window":
+		src, err := screen.OpenWindow(screen.WindowMatcher{Title: arg}, screen.CaptureConfig{HideCursor: true})
+		return src, opts.fps, err
+	case "camera":
+		src, err := screen.OpenCamera(screen.CameraConfig{Device: arg, FPS: opts.fps})
+		return src, opts.fps, err
+	case "capture":
+		src, err := screen.OpenCaptureCard(screen.CaptureCardConfig{Device: arg, FPS: opts.fps})
+		return src, opts.fps, err
+	case "stream":
+		src, err := screen.OpenNetCamera(screen.NetCameraConfig{URL: arg, FPS: opts.fps})
+		return src, opts.fps, err
+	case "video":
+		src, err := screen.OpenVideo(screen.VideoConfig{Path: arg})
+		return src, 0, err
+	case "images":
+		src, err := screen.OpenImageDir(screen.ImageDirConfig{Path: arg})
+		return src, 0, err
+	default:
+		return nil, 0, fmt.Errorf("unknown source %q", opts.source)
+	}
+}
+
+type regionSource struct {
+	capturer *screen.Capturer
+	region   image.Rectangle
+	track    bool
+}
+
+func screenSource(region image.Rectangle, track bool) engine.Source {
+	return &regionSource{
+		capturer: screen.NewCapturer(screen.CaptureConfig{Region: region, HideCursor: true, Track: track}),
+		region:   region,
+		track:    track,
+	}
+}
+
+func (s *regionSource) Capture() (image.Image, error) {
+	if s.track {
+		return s.capturer.CaptureTracked()
+	}
+	return s.capturer.CaptureRegion(s.region)
+}
+
+func (s *regionSource) Close() error {
+	return s.capturer.Close()
+}
+
+type event struct {
+	Event       string   `json:"event"`
+	Session     uint64   `json:"session,omitempty"`
+	File        string   `json:"file,omitempty"`
+	Size        uint64   `json:"size,omitempty"`
+	Chunks      uint32   `json:"chunks,omitempty"`
+	Received    uint32   `json:"received,omitempty"`
+	Bytes       uint64   `json:"bytes,omitempty"`
+	Percent     float64  `json:"percent,omitempty"`
+	Missing     int      `json:"missing,omitempty"`
+	Path        string   `json:"path,omitempty"`
+	Codes       int      `json:"codes,omitempty"`
+	Decoded     int      `json:"decoded,omitempty"`
+	Failed      []string `json:"failed,omitempty"`
+	Key         string   `json:"key,omitempty"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	Trusted     bool     `json:"trusted,omitempty"`
+	Token       string   `json:"token,omitempty"`
+	Status      string   `json:"status,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+type reporter struct {
+	enc    *json.Encoder
+	recv   *engine.Receiver
+	cancel context.CancelFunc
+	done   bool
+	locked bool
+	signed engine.ManifestStatus
+
+	audit *audit.Log
+	note  string
+}
+
+func (r *reporter) record(e audit.Entry) error {
+	if r.audit == nil {
+		return nil
+	}
+	e.Note = r.note
+	if e.Settings == nil {
+		e.Settings = map[string]string{}
+	}
+	e.Settings["tool"] = "owl-recv"
+	if err := r.audit.Record(e); err != nil {
+		err = fmt.Errorf("writing audit log: %w", err)
+		r.emit(event{Event: "error", Error: err.Error()})
+		return err
+	}
+	return nil
+}
+
+func (r *reporter) emit(e event) {
+	if err := r.enc.Encode(e); err != nil {
+		fmt.Fprintln(os.Stderr, "owl-recv:", err)
+	}
+}
+
+func (r *reporter) Frame(img image.Image, results []engine.FrameResult, perf screen.MetricsSnapshot) {
+	session := r.recv.Session()
+	r.manifest(session)
+
+	progressed := false
+	for _, res := range results {
+		switch {
+		case res.Auth == engine.AuthLocked && !r.locked:
+			r.locked = true
+			r.emit(event{Event: "error", Session: res.Session, Error: "transfer is encrypted: set -passphrase-file or $" + secure.PassphraseEnv})
+		case res.Auth == engine.AuthFailed:
+			r.emit(event{Event: "rejected", Session: res.Session, Error: fmt.Sprintf("chunk %d failed authentication", res.Chunk.Index)})
+		case res.Inconsistent != nil:
+			r.emit(event{Event: "rejected", Session: res.Session, Error: res.Inconsistent.Error()})
+		case res.Refused != nil:
+			r.emit(event{Event: "refused", Session: res.Session, Error: res.Refused.Error()})
+			r.record(audit.Entry{Event: audit.EventRefused, Session: res.Session, Settings: map[string]string{"reason": res.Refused.Error()}})
+		}
+		if res.Session != session {
+			continue
+		}
+		if res.Metadata {
+			metadata := r.recv.Metadata()
+			r.emit(event{Event: "metadata", Session: session, File: metadata.Filename, Size: metadata.FileSize, Chunks: metadata.TotalChunks})
+		}
+		progressed = progressed || res.New || res.Metadata || chunk.IsParity(res.Chunk)
+	}
+	if !progressed {
+		return
+	}
+
+	p := r.recv.Progress()
+	r.emit(event{Event: "progress", Session: session, Chunks: p.TotalChunks, Received: p.CurrentChunk, Bytes: p.BytesReceived, Percent: p.PercentComplete})
+
+	if !r.done && r.recv.Complete() {
+		r.done = true
+		r.cancel()
+	}
+}
+
+func (r *reporter) manifest(session uint64) {
+	v := r.recv.Verification()
+	if v.Status == r.signed || v.Status == engine.ManifestPending || v.Status == engine.ManifestVerified {
+		return
+	}
+	r.signed = v.Status
+	if v.Status == engine.ManifestNone {
+		return
+	}
+
+	e := event{Event: "manifest", Session: session, Status: v.Status.String(), Trusted: v.Trusted}
+	if v.Signer != nil {
+		e.Key = secure.FormatSigningKey(v.Signer)
+		e.Fingerprint = secure.Fingerprint(v.Signer)
+	}
+	if v.Err != nil {
+		e.Error = v.Err.Error()
+	}
+	r.emit(e)
+}
+
+func (r *reporter) page(p engine.PageResult) {
+	e := event{Event: "page", Path: p.Path, Codes: len(p.Codes), Decoded: p.Decoded(), Failed: p.Failures()}
+	if p.Err != nil {
+		e.Error = p.Err.Error()
+	}
+	r.emit(e)
+	r.Frame(nil, p.Results(), screen.MetricsSnapshot{})
+}
+
+func (r *reporter) CaptureError(err error) {
+	r.emit(event{Event: "error", Error: err.Error()})
+}
+
+func outputPath(out string, name string) string {
+	if out != "" {
+		return out
+	}
+	return engine.UniquePath(".", engine.SanitizeFilename(name))
+}
+
+func writeOutput(recv *engine.Receiver, out string) (string, int, error) {
+	if out == "-" {
+		w := bufio.NewWriter(os.Stdout)
+		missing, err := recv.Assemble(w)
+		if err == nil {
+			err = w.Flush()
+		}
+		return out, missing, err
+	}
+
+	path := outputPath(out, recv.Metadata().Filename)
+	missing, err := recv.SaveFile(path)
+	return path, missing, err
+}
+
+func (r *reporter) quarantine(ctx context.Context, q engine.Quarantine, out string) (string, int, error) {
+	held, missing, err := r.recv.SaveQuarantined(q)
+	if err != nil {
+		return "", 0, err
+	}
+	session, metadata := r.recv.Session(), r.recv.Metadata()
+	r.emit(event{Event: "quarantined", Session: session, Path: held})
+
+	path, err := q.Release(ctx, held, outputPath(out, metadata.Filename))
+	if errors.Is(err, engine.ErrHookRejected) {
+		r.emit(event{Event: "refused", Session: session, Path: held, Error: err.Error()})
+		r.record(audit.Entry{Event: audit.EventRefused, Session: session, File: metadata.Filename, Path: held, Size: metadata.FileSize, Settings: map[string]string{"reason": err.Error()}})
+	}
+	return path, missing, err
+}
+
+func writeReport(recv *engine.Receiver, path string) {
+	report := recv.Report()
+	err := engine.WriteAtomic(path, func(w io.Writer) error {
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			return report.WriteCSV(w)
+		}
+		return report.WriteJSON(w)
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "owl-recv: writing report:", err)
+	}
+}
+
+func writeKeyCode(path string) (*secure.Identity, error) {
+	id, err := secure.NewIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := engine.NewRenderer(engine.BackchannelConfig).Render(engine.PublicKeyCode(id.PublicKey()), image.Pt(keyCodeSize, keyCodeSize), "")
+	if err != nil {
+		return nil, err
+	}
+	err = engine.WriteAtomic(path, func(w io.Writer) error {
+		return png.Encode(w, img)
+	})
+	return id, err
+}
+
+func run(ctx context.Context, opts options) error {
+	hookCtx := ctx
+	var src engine.Source
+	var fps int
+	var pages []string
+	if kind, dir, _ := strings.Cut(opts.source, ":"); kind == "pages" {
+		images, err := screen.OpenImageDir(screen.ImageDirConfig{Path: dir})
+		if err != nil {
+			return err
+		}
+		pages = images.Files()
+	} else {
+		var err error
+		if src, fps, err = openSource(opts); err != nil {
+			return err
+		}
+		defer src.Close()
+	}
+
+	recv := engine.NewReceiver()
+	recv.BlockSize = opts.blockSize
+	recv.SpoolDir = opts.spoolDir
+	recv.SetSecureWipe(opts.wipe)
+	recv.Decoders = opts.decoders
+	recv.AutoFPS = opts.autoFPS
+	recv.SetTuning(opts.tuning)
+	recv.SetKeys(opts.keys)
+	recv.SetPolicy(opts.policy)
+	recv.SetPairing(opts.pairing)
+	recv.SetTrustedSigners(opts.trusted)
+	defer recv.Reset()
+
+	events := os.Stdout
+	if opts.out == "-" {
+		events = os.Stderr
+	}
+
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rep := &reporter{enc: json.NewEncoder(events), recv: recv, cancel: cancel, note: opts.note}
+	if opts.audit != "" {
+		rep.audit = audit.Open(opts.audit)
+	}
+	if opts.pairing != nil {
+		rep.emit(event{Event: "pairing", Token: opts.pairing.Token})
+	}
+	if opts.keyCode != "" {
+		id, err := writeKeyCode(opts.keyCode)
+		if err != nil {
+			return err
+		}
+		recv.SetIdentity(id)
+		rep.emit(event{Event: "key", Path: opts.keyCode, Key: secure.FormatPublicKey(id.PublicKey()), Fingerprint: secure.Fingerprint(id.PublicKey())})
+	}
+	if opts.report != "" {
+		defer writeReport(recv, opts.report)
+	}
+	var err error
+	if pages != nil {
+		recv.DecodePages(ctx, pages, rep.page)
+	} else {
+		err = recv.Capture(ctx, src, fps, nil, rep)
+	}
+	if !rep.done {
+		if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			p := recv.Progress()
+			err = fmt.Errorf("transfer incomplete: %d of %d chunks received", p.CurrentChunk, p.TotalChunks)
+		}
+		rep.emit(event{Event: "error", Error: err.Error()})
+		return err
+	}
+
+	settings := map[string]string{"source": opts.source}
+	var path string
+	var missing int
+	if opts.quarantine.Enabled() {
+		path, missing, err = rep.quarantine(hookCtx, opts.quarantine, opts.out)
+		settings["quarantine"] = opts.quarantine.Dir
+	} else {
+		path, missing, err = writeOutput(recv, opts.out)
+	}
+	if err != nil {
+		if !errors.Is(err, engine.ErrHookRejected) {
+			rep.emit(event{Event: "error", Error: err.Error()})
+		}
+		return err
+	}
+	if err := rep.record(audit.ReceiveEntry(recv, path, settings, "")); err != nil {
+		return err
+	}
+
+	v := recv.Verification()
+	e := event{Event: "complete", Session: recv.Session(), Path: path, Size: recv.Metadata().FileSize, Missing: missing, Status: v.Status.String(), Trusted: v.Trusted}
+	if v.Signer != nil {
+		e.Fingerprint = secure.Fingerprint(v.Signer)
+	}
+	rep.emit(e)
+	return nil
+}
+
+func main() {
+	opts, err := parseFlags()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "owl-recv:", err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, opts); err != nil {
+		fmt.Fprintln(os.Stderr, "owl-recv:", err)
+		os.Exit(1)
+	}
+}
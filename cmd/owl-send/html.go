@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+
+	"qrtransfer/pkg/engine"
+)
+
+type playerData struct {
+	Name     string
+	Frames   []string
+	Interval int64
+}
+
+var playerTemplate = template.Must(template.New("player").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Name}} - owl-send</title>
+<style>
+html, body { margin: 0; height: 100%; background: #fff; color: #333; font: 14px sans-serif; }
+#frame { display: block; margin: 0 auto; width: 100vw; height: calc(100vh - 3em); object-fit: contain; image-rendering: pixelated; image-rendering: crisp-edges; }
+#bar { height: 3em; display: flex; gap: 1.5em; align-items: center; justify-content: center; }
+</style>
+</head>
+<body>
+<img id="frame" alt="transfer frame">
+<div id="bar">
+<button id="play">Pause</button>
+<label><input type="checkbox" id="loop" checked> Loop</label>
+<span id="count"></span>
+<span>{{.Name}}</span>
+</div>
+<script>
+var frames = {{.Frames}}, interval = {{.Interval}};
+var img = document.getElementById("frame"), play = document.getElementById("play");
+var loop = document.getElementById("loop"), count = document.getElementById("count");
+var i = 0, timer = null;
+
+function show(n) {
+	i = (n + frames.length) % frames.length;
+	img.src = frames[i];
+	count.textContent = "frame " + (i + 1) + "/" + frames.length;
+}
+function tick() {
+	if (i + 1 >= frames.length && !loop.checked) {
+		stop();
+		return;
+	}
+	show(i + 1);
+}
+function start() {
+	timer = setInterval(tick, interval);
+	play.textContent = "Pause";
+}
+function stop() {
+	clearInterval(timer);
+	timer = null;
+	play.textContent = "Play";
+}
+
+play.onclick = function () { timer ? stop() : start(); };
+img.ondblclick = function () {
+	var root = document.documentElement;
+	if (root.requestFullscreen) {
+		root.requestFullscreen();
+	}
+};
+document.onkeydown = function (e) {
+	if (e.key === " ") {
+		timer ? stop() : start();
+		e.preventDefault();
+	} else if (e.key === "ArrowRight") {
+		stop();
+		show(i + 1);
+	} else if (e.key === "ArrowLeft") {
+		stop();
+		show(i - 1);
+	}
+};
+
+show(0);
+start();
+</script>
+</body>
+</html>
+`))
+
+func writeHTML(payloads [][]byte, opts options) error {
+	data := playerData{
+		Name:     filepath.Base(opts.file),
+		Frames:   make([]string, len(payloads)),
+		Interval: opts.rate.Milliseconds(),
+	}
+
+	var buf bytes.Buffer
+	for i, payload := range payloads {
+		img, err := renderGrid(payload, opts, settledMarker(i))
+		if err != nil {
+			return fmt.Errorf("frame %d: %w", i, err)
+		}
+
+		buf.Reset()
+		if err := png.Encode(&buf, img); err != nil {
+			return err
+		}
+		data.Frames[i] = "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+
+	err := engine.WriteAtomic(opts.out, func(w io.Writer) error {
+		return playerTemplate.Execute(w, data)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "wrote %d frames to %s\n", len(payloads), opts.out)
+	return nil
+}
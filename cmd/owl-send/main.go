@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"qrtransfer/pkg/audit"
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/config"
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/logging"
+	"qrtransfer/pkg/paper"
+	"qrtransfer/pkg/qr"
+	"qrtransfer/pkg/secure"
+)
+
+type options struct {
+	file       string
+	mode       string
+	out        string
+	chunkSize  int
+	errorLevel qr.ErrorLevel
+	redundancy int
+	strategy   chunk.Strategy
+	rate       time.Duration
+	size       int
+	fullscreen bool
+	loop       bool
+	eink       bool
+	projector  bool
+	paper      paper.Size
+	columns    int
+	keys       chunk.Keys
+	recipient  []byte
+	signer     *secure.Signer
+	pairing    *secure.Pairing
+	wipe       bool
+	audit      string
+	note       string
+	estimate   bool
+}
+
+func parseFlags() (options, error) {
+	var opts options
+	var level, strategy, paperSize, passphraseFile, recipient, signingKey, pairToken, logLevel string
+	var sign, keyring bool
+
+	flag.StringVar(&opts.mode, "mode", defaultMode, "output mode: window, png, terminal, html or pdf")
+	flag.StringVar(&opts.out, "out", "", "output directory for png mode (default frames), or file for html and pdf modes (default FILE.html or FILE.pdf)")
+	flag.IntVar(&opts.chunkSize, "chunk-size", 100, "payload bytes per frame")
+	flag.StringVar(&level, "error-level", "low", "error correction level: low, medium or high")
+	flag.IntVar(&opts.redundancy, "redundancy", 1, "number of times each chunk is shown, or parity level with -strategy parity")
+	flag.StringVar(&strategy, "strategy", "immediate", "redundancy strategy: immediate, delayed or parity")
+	flag.DurationVar(&opts.rate, "rate", 2*time.Second, "time each frame is displayed")
+	flag.IntVar(&opts.size, "size", 400, "frame size in pixels for window and png modes")
+	flag.BoolVar(&opts.fullscreen, "fullscreen", false, "show the window full screen")
+	flag.BoolVar(&opts.loop, "loop", false, "repeat the frames in terminal mode until interrupted")
+	flag.BoolVar(&opts.eink, "eink", false, "monochrome frames with a settle marker for e-ink and other slow displays (default rate 10s)")
+	flag.BoolVar(&opts.projector, "projector", false, "8-color frames with heavy parity and a timing border for projector-to-camera transfers (default chunk size 40)")
+	flag.StringVar(&paperSize, "paper", "a4", "page size for pdf mode: a4 or letter")
+	flag.IntVar(&opts.columns, "columns", 3, "codes across each page in pdf mode")
+	flag.StringVar(&passphraseFile, "passphrase-file", "", "encrypt the transfer with the passphrase on the first line of this file (default: $"+secure.PassphraseEnv+" if set)")
+	flag.BoolVar(&keyring, "keyring", false, "encrypt the transfer with the passphrase the sender app saved in the system keyring")
+	flag.StringVar(&recipient, "recipient", "", "encrypt the transfer to the receiver with this key, as shown under its key code")
+	flag.BoolVar(&sign, "sign", false, "sign a manifest of the file so the receiver can verify it came from this sender")
+	flag.StringVar(&signingKey, "signing-key", "", "signing key file for -sign, created if missing (default: the key in the system keyring, or the signing key in the config directory)")
+	flag.StringVar(&pairToken, "pair", "", "only let a receiver with this pairing token accept the transfer, or new to create a token and print it")
+	flag.BoolVar(&opts.wipe, "wipe", false, "zero the file's chunks and frames in memory as soon as they are no longer needed")
+	flag.StringVar(&opts.audit, "audit", "", "append a record of the transfer, with the file's hash and settings, to this audit log")
+	flag.StringVar(&opts.note, "note", "", "operator note to store with the -audit record")
+	flag.BoolVar(&opts.estimate, "estimate", false, "print the predicted throughput and transfer time for these settings and exit")
+	flag.StringVar(&logLevel, "log-level", logging.DefaultLevel(), "log verbosity: debug, info, warn or error")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: owl-send [flags] FILE\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts.file = flag.Arg(0)
+	if opts.eink && !flagSet("rate") {
+		opts.rate = engine.EInkInterval
+	}
+	switch {
+	case opts.mode == "pdf":
+		opts.projector = true
+		if !flagSet("chunk-size") {
+			opts.chunkSize = paperChunkSize
+		}
+	case opts.projector && !flagSet("chunk-size"):
+		opts.chunkSize = engine.ProjectorChunkSize
+	}
+	if opts.out == "" {
+		opts.out = "frames"
+		if opts.mode == "html" || opts.mode == "pdf" {
+			opts.out = filepath.Base(opts.file) + "." + opts.mode
+		}
+	}
+
+	var err error
+	if opts.errorLevel, err = qr.ParseErrorLevel(level); err != nil {
+		return opts, err
+	}
+
+	logVerbosity, err := logging.ParseLevel(logLevel)
+	if err != nil {
+		return opts, err
+	}
+	logging.Setup(logVerbosity, os.Stderr)
+
+	if opts.strategy, err = chunk.ParseStrategy(strategy); err != nil {
+		return opts, err
+	}
+	if opts.paper, err = paper.ParseSize(paperSize); err != nil {
+		return opts, err
+	}
+	passphrase, err := secure.LoadPassphrase(passphraseFile)
+	if err != nil {
+		return opts, err
+	}
+	if keyring && passphraseFile == "" {
+		if passphrase, err = secure.KeyringGet(secure.KeyringSenderPassphrase); err != nil {
+			return opts, fmt.Errorf("saved passphrase: %w", err)
+		}
+	}
+	opts.keys = secure.Passphrase(passphrase)
+	if recipient != "" {
+		if opts.recipient, err = secure.ParsePublicKey(recipient); err != nil {
+			return opts, err
+		}
+	}
+
+	switch {
+	case signingKey != "":
+		if opts.signer, err = secure.LoadSigner(signingKey); err != nil {
+			return opts, err
+		}
+	case sign:
+		path, err := config.SigningKeyPath()
+		if err != nil {
+			return opts, err
+		}
+		if opts.signer, signingKey, err = secure.FindSigner(path); err != nil {
+			return opts, err
+		}
+	}
+	if pairToken != "" {
+		if opts.pairing, err = pair(pairToken); err != nil {
+			return opts, err
+		}
+		if pairToken == "new" {
+			fmt.Fprintln(os.Stderr, "owl-send: pairing token", opts.pairing.Token)
+		}
+	}
+	if opts.signer != nil {
+		slog.Info("signing transfer", "key", signingKey, "fingerprint", secure.Fingerprint(opts.signer.PublicKey()), "public_key", secure.FormatSigningKey(opts.signer.PublicKey()))
+	}
+
+	switch {
+	case opts.mode != "window" && opts.mode != "png" && opts.mode != "terminal" && opts.mode != "html" && opts.mode != "pdf":
+		return opts, fmt.Errorf("unknown mode %q", opts.mode)
+	case opts.chunkSize <= 0:
+		return opts, errors.New("chunk size must be positive")
+	case opts.redundancy < 1 || opts.redundancy > 256:
+		return opts, errors.New("redundancy must be between 1 and 256")
+	case opts.rate <= 0:
+		return opts, errors.New("rate must be positive")
+	case opts.eink && opts.projector:
+		return opts, errors.New("-eink cannot be combined with -projector or pdf mode")
+	case opts.columns < 1:
+		return opts, errors.New("columns must be positive")
+	case opts.keys != nil && opts.recipient != nil:
+		return opts, engine.ErrKeyConflict
+	}
+
+	return opts, nil
+}
+
+func pair(token string) (*secure.Pairing, error) {
+	if token == "new" {
+		var err error
+		if token, err = secure.NewPairingToken(); err != nil {
+			return nil, err
+		}
+	}
+	return secure.Pair(token)
+}
+
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+func buildPayloads(opts options) (*engine.Payload, [][]byte, error) {
+	payload, err := engine.PrepareFile(opts.file, "", engine.Options{
+		ChunkSize:  opts.chunkSize,
+		Redundancy: opts.redundancy,
+		Strategy:   opts.strategy,
+		Keys:       opts.keys,
+		Recipient:  opts.recipient,
+		Signer:     opts.signer,
+		Pairing:    opts.pairing,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payloads := make([][]byte, payload.FrameCount())
+	for i := range payloads {
+		if payloads[i], err = payload.FrameData(i); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return payload, payloads, nil
+}
+
+func encodeFrame(payload []byte, opts options, minBlockPixels int, marker bool) (engine.EncodedFrame, error) {
+	config := qr.Config{ErrorLevel: opts.errorLevel, MinBlockPixels: minBlockPixels, Monochrome: opts.eink, BorderSize: 1}
+	frame, err := engine.EncodeFrame(payload, config, opts.projector)
+	if err != nil {
+		return frame, err
+	}
+	if opts.eink {
+		qr.SetMarker(frame.Blocks, marker)
+	}
+	return frame, nil
+}
+
+func renderImage(payload []byte, opts options, marker bool) (image.Image, error) {
+	frame, err := encodeFrame(payload, opts, 0, marker)
+	if err != nil {
+		return nil, err
+	}
+	renderer := engine.NewRenderer(frame.Config)
+	renderer.Marker = marker
+	return renderer.Draw(frame, image.Pt(opts.size, opts.size), "")
+}
+
+func renderGrid(payload []byte, opts options, marker bool) (image.Image, error) {
+	frame, err := encodeFrame(payload, opts, 1, marker)
+	if err != nil {
+		return nil, err
+	}
+	config := frame.Config
+	cols := config.GridWidth + 2*config.BorderSize
+	rows := config.GridHeight + 2*config.BorderSize
+	return qr.NewEncoder(config).CreateImage(frame.Blocks, cols, rows)
+}
+
+func writePNGs(payloads [][]byte, opts options) error {
+	if err := os.MkdirAll(opts.out, 0o755); err != nil {
+		return err
+	}
+
+	for i, payload := range payloads {
+		img, err := renderImage(payload, opts, settledMarker(i))
+		if err != nil {
+			return fmt.Errorf("frame %d: %w", i, err)
+		}
+
+		path := filepath.Join(opts.out, fmt.Sprintf("frame-%05d.png", i))
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(f, img); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "wrote %d frames to %s\n", len(payloads), opts.out)
+	return nil
+}
+
+func printEstimate(opts options) error {
+	info, err := os.Stat(opts.file)
+	if err != nil {
+		return err
+	}
+
+	config := qr.Config{ErrorLevel: opts.errorLevel, Monochrome: opts.eink, Palette: opts.projector}
+	est := engine.EstimateThroughput(info.Size(), config, chunk.NewConfig(opts.chunkSize, opts.redundancy), opts.strategy, opts.rate)
+	fmt.Printf("grid: %dx%d blocks, %d bytes per frame, %d kept intact\n", est.Grid, est.Grid, est.FrameBytes, est.Capacity)
+	fmt.Printf("frames: %d for %d chunks of %s\n", est.Frames, est.Chunks, filepath.Base(opts.file))
+	if !est.Intact() {
+		fmt.Printf("throughput: none, %s error correction does not keep every bit of a frame\n", opts.errorLevel)
+		return nil
+	}
+	fmt.Printf("throughput: %.0f bytes/s (%.0f bits/s)\n", est.BytesPerSecond(), est.BitsPerSecond)
+	fmt.Printf("time: %v at %v per frame\n", est.Duration.Round(time.Second), opts.rate)
+	return nil
+}
+
+func settledMarker(frame int) bool {
+	return frame%2 == 0
+}
+
+func main() {
+	opts, err := parseFlags()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "owl-send:", err)
+		os.Exit(2)
+	}
+	if opts.estimate {
+		if err := printEstimate(opts); err != nil {
+			fmt.Fprintln(os.Stderr, "owl-send:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	payload, payloads, err := buildPayloads(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "owl-send:", err)
+		os.Exit(1)
+	}
+	if opts.audit != "" {
+		settings := map[string]string{"mode": opts.mode, "error_level": opts.errorLevel.String(), "rate": opts.rate.String(), "tool": "owl-send"}
+		if err := audit.Open(opts.audit).Record(audit.SendEntry(payload, settings, opts.note)); err != nil {
+			fmt.Fprintln(os.Stderr, "owl-send: writing audit log:", err)
+			os.Exit(1)
+		}
+	}
+	if opts.wipe {
+		payload.Wipe()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch opts.mode {
+	case "png":
+		err = writePNGs(payloads, opts)
+	case "terminal":
+		err = showTerminal(ctx, payloads, opts)
+	case "html":
+		err = writeHTML(payloads, opts)
+	case "pdf":
+		err = writePDF(payload, payloads, opts)
+	default:
+		err = showWindow(ctx, payloads, opts)
+	}
+	if opts.wipe {
+		for _, p := range payloads {
+			clear(p)
+		}
+	}
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "owl-send:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/paper"
+)
+
+const paperChunkSize = 200
+
+func writePDF(payload *engine.Payload, payloads [][]byte, opts options) error {
+	meta := payload.Metadata
+	doc := paper.Document{
+		Title:   meta.Filename,
+		Frames:  make([]paper.Frame, len(payloads)),
+		Size:    opts.paper,
+		Columns: opts.columns,
+	}
+
+	for i, data := range payloads {
+		img, err := renderGrid(data, opts, false)
+		if err != nil {
+			return fmt.Errorf("frame %d: %w", i, err)
+		}
+
+		label := fmt.Sprintf("frame %d/%d", i+1, len(payloads))
+		if i == 0 {
+			label += " (metadata)"
+		}
+		doc.Frames[i] = paper.Frame{Image: img, Label: label}
+	}
+
+	doc.Cover = []string{
+		fmt.Sprintf("File: %s", meta.Filename),
+		fmt.Sprintf("Size: %d bytes in %d chunks of %d bytes", meta.FileSize, meta.TotalChunks, meta.ChunkSize),
+		fmt.Sprintf("Frames: %d on %d pages after this one, %d per page", len(payloads), doc.Pages()-1, doc.PerPage()),
+		fmt.Sprintf("Session: %016x", payload.Session()),
+		fmt.Sprintf("Printed: %s", time.Now().Format("2006-01-02 15:04")),
+		"",
+		"To restore, scan or photograph every page so that each code is whole, flat and in focus,",
+		"put the images in one folder and run:",
+		"    owl-recv -source pages:FOLDER",
+		"Pages may be read in any order. If a frame is missing, rescan just the page it is on.",
+	}
+
+	err := engine.WriteAtomic(opts.out, func(w io.Writer) error {
+		return paper.Write(w, doc)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "wrote %d frames on %d pages to %s\n", len(payloads), doc.Pages(), opts.out)
+	return nil
+}
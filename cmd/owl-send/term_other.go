@@ -0,0 +1,7 @@
+//go:build !unix
+
+package main
+
+func terminalSize() (int, int, bool) {
+	return 0, 0, false
+}
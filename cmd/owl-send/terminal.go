@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"strings"
+	"time"
+
+	"qrtransfer/pkg/engine"
+)
+
+func showTerminal(ctx context.Context, payloads [][]byte, opts options) error {
+	frames := make([][]byte, len(payloads))
+	unsettled := make([][]byte, len(payloads))
+	var cols, rows int
+	for i, payload := range payloads {
+		img, err := renderGrid(payload, opts, settledMarker(i))
+		if err != nil {
+			return fmt.Errorf("frame %d: %w", i, err)
+		}
+
+		var buf bytes.Buffer
+		writeHalfBlocks(&buf, img)
+		frames[i] = buf.Bytes()
+		cols = max(cols, img.Bounds().Dx())
+		rows = max(rows, (img.Bounds().Dy()+1)/2+1)
+
+		if opts.eink {
+			if img, err = renderGrid(payload, opts, settledMarker(i-1)); err != nil {
+				return fmt.Errorf("frame %d: %w", i, err)
+			}
+			var buf bytes.Buffer
+			writeHalfBlocks(&buf, img)
+			unsettled[i] = buf.Bytes()
+		}
+	}
+
+	if width, height, ok := terminalSize(); ok && (cols > width || rows > height) {
+		return fmt.Errorf("frames need a %dx%d terminal, this one is %dx%d; enlarge it or lower -chunk-size", cols, rows, width, height)
+	}
+	if !opts.eink && !truecolor() {
+		fmt.Fprintln(os.Stderr, "owl-send: COLORTERM does not advertise 24-bit color; frames will not decode if the terminal approximates colors")
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	fmt.Fprint(w, "\x1b[?1049h\x1b[?25l\x1b[2J")
+	defer func() {
+		fmt.Fprint(w, "\x1b[0m\x1b[?25h\x1b[?1049l")
+		w.Flush()
+	}()
+
+	show := func(frame []byte, i, pass int, d time.Duration) error {
+		fmt.Fprint(w, "\x1b[H")
+		w.Write(frame)
+		fmt.Fprintf(w, "\x1b[0m\x1b[Kframe %d/%d", i+1, len(frames))
+		if opts.loop {
+			fmt.Fprintf(w, ", pass %d", pass)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		select {
+		case <-time.After(d):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for pass := 1; ; pass++ {
+		for i, frame := range frames {
+			rest := opts.rate
+			if opts.eink {
+				settle := engine.SettleTime(opts.rate)
+				if err := show(unsettled[i], i, pass, settle); err != nil {
+					return err
+				}
+				rest -= settle
+			}
+			if err := show(frame, i, pass, rest); err != nil {
+				return err
+			}
+		}
+		if !opts.loop {
+			return nil
+		}
+	}
+}
+
+func writeHalfBlocks(w *bytes.Buffer, img image.Image) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		var lastTop, lastBottom string
+		for x := b.Min.X; x < b.Max.X; x++ {
+			tr, tg, tb, _ := img.At(x, y).RGBA()
+			br, bg, bb := uint32(0xffff), uint32(0xffff), uint32(0xffff)
+			if y+1 < b.Max.Y {
+				br, bg, bb, _ = img.At(x, y+1).RGBA()
+			}
+
+			if top := fmt.Sprintf("\x1b[38;2;%d;%d;%dm", tr>>8, tg>>8, tb>>8); top != lastTop {
+				w.WriteString(top)
+				lastTop = top
+			}
+			if bottom := fmt.Sprintf("\x1b[48;2;%d;%d;%dm", br>>8, bg>>8, bb>>8); bottom != lastBottom {
+				w.WriteString(bottom)
+				lastBottom = bottom
+			}
+			w.WriteString("▀")
+		}
+		w.WriteString("\x1b[0m\n")
+	}
+}
+
+func truecolor() bool {
+	colorTerm := strings.ToLower(os.Getenv("COLORTERM"))
+	return colorTerm == "truecolor" || colorTerm == "24bit"
+}
@@ -0,0 +1,81 @@
+//go:build !headless
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+
+	"qrtransfer/pkg/engine"
+)
+
+const defaultMode = "window"
+
+func showWindow(ctx context.Context, payloads [][]byte, opts options) error {
+	a := app.New()
+
+	var w fyne.Window
+	if drv, ok := a.Driver().(desktop.Driver); ok {
+		w = drv.CreateSplashWindow()
+	} else {
+		w = a.NewWindow("owl-send")
+	}
+
+	img := &canvas.Image{FillMode: canvas.ImageFillContain}
+	img.SetMinSize(fyne.NewSize(float32(opts.size), float32(opts.size)))
+	w.SetContent(img)
+	w.SetFullScreen(opts.fullscreen)
+	w.Canvas().SetOnTypedKey(func(ev *fyne.KeyEvent) {
+		if ev.Name == fyne.KeyEscape {
+			a.Quit()
+		}
+	})
+
+	var renderErr error
+	go func() {
+		defer fyne.Do(a.Quit)
+
+		show := func(i int, payload []byte, marker bool, d time.Duration) bool {
+			frame, err := renderImage(payload, opts, marker)
+			if err != nil {
+				renderErr = fmt.Errorf("frame %d: %w", i, err)
+				return false
+			}
+
+			fyne.DoAndWait(func() {
+				img.Image = frame
+				img.Refresh()
+			})
+
+			select {
+			case <-time.After(d):
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for i, payload := range payloads {
+			rest := opts.rate
+			if opts.eink {
+				settle := engine.SettleTime(opts.rate)
+				if !show(i, payload, settledMarker(i-1), settle) {
+					return
+				}
+				rest -= settle
+			}
+			if !show(i, payload, settledMarker(i), rest) {
+				return
+			}
+		}
+	}()
+
+	w.ShowAndRun()
+	return renderErr
+}
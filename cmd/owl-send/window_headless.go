@@ -0,0 +1,14 @@
+//go:build headless
+
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+const defaultMode = "terminal"
+
+func showWindow(ctx context.Context, payloads [][]byte, opts options) error {
+	return errors.New("window mode is not available in headless builds, use -mode terminal, png or html")
+}
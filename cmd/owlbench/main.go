@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"qrtransfer/pkg/ec"
+	"qrtransfer/pkg/qr"
+)
+
+const minFrames = 3
+
+type options struct {
+	grids      []int
+	modes      []string
+	levels     []qr.ErrorLevel
+	blockSize  int
+	duration   time.Duration
+	json       bool
+	cpuProfile string
+	memProfile string
+}
+
+type result struct {
+	Mode           string  `json:"mode"`
+	Level          string  `json:"level,omitempty"`
+	Grid           int     `json:"grid"`
+	BlockSize      int     `json:"block_size"`
+	FrameBytes     int     `json:"frame_bytes"`
+	Frames         int     `json:"frames"`
+	EncodeFPS      float64 `json:"encode_fps"`
+	DecodeFPS      float64 `json:"decode_fps"`
+	FPS            float64 `json:"fps"`
+	BytesPerSec    float64 `json:"bytes_per_sec"`
+	AllocsPerFrame float64 `json:"allocs_per_frame"`
+	AllocPerFrame  float64 `json:"alloc_bytes_per_frame"`
+	Errors         int     `json:"errors"`
+}
+
+func parseFlags() (options, error) {
+	var opts options
+	var grids, modes, levels string
+
+	flag.StringVar(&grids, "grids", "21,41,81,161", "comma-separated grid sides in blocks")
+	flag.StringVar(&modes, "modes", "color,mono,projector", "comma-separated frame modes: color, mono and projector")
+	flag.StringVar(&levels, "levels", "low,medium,high", "comma-separated error levels for color frames")
+	flag.IntVar(&opts.blockSize, "block-size", qr.DefaultMinBlockPixels, "block size in pixels")
+	flag.DurationVar(&opts.duration, "duration", time.Second, "time spent on each combination")
+	flag.BoolVar(&opts.json, "json", false, "print one JSON object per combination instead of a table")
+	flag.StringVar(&opts.cpuProfile, "cpuprofile", "", "write a CPU profile of the whole run to this file")
+	flag.StringVar(&opts.memProfile, "memprofile", "", "write a heap profile to this file when the run ends")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: owlbench [flags]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	for _, s := range strings.Split(grids, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n < 3 {
+			return opts, fmt.Errorf("grid %q: want a side of at least 3 blocks", s)
+		}
+		opts.grids = append(opts.grids, n)
+	}
+	for _, s := range strings.Split(modes, ",") {
+		mode := strings.ToLower(strings.TrimSpace(s))
+		if mode != "color" && mode != "mono" && mode != "projector" {
+			return opts, fmt.Errorf("unknown mode %q", s)
+		}
+		opts.modes = append(opts.modes, mode)
+	}
+	for _, s := range strings.Split(levels, ",") {
+		level, err := qr.ParseErrorLevel(strings.TrimSpace(s))
+		if err != nil {
+			return opts, err
+		}
+		opts.levels = append(opts.levels, level)
+	}
+
+	switch {
+	case opts.blockSize < 1:
+		return opts, errors.New("block size must be positive")
+	case opts.duration <= 0:
+		return opts, errors.New("duration must be positive")
+	}
+	return opts, nil
+}
+
+type bench struct {
+	mode   string
+	level  qr.ErrorLevel
+	config qr.Config
+	rs     *ec.RS
+}
+
+func newBench(mode string, level qr.ErrorLevel, grid int) bench {
+	b := bench{
+		mode:   mode,
+		level:  level,
+		config: qr.Config{GridWidth: grid, GridHeight: grid, BorderSize: 1, ErrorLevel: level},
+	}
+	switch mode {
+	case "mono":
+		b.config.Monochrome = true
+	case "projector":
+		b.config.Palette, b.config.Timing = true, true
+		b.config.BorderSize = qr.TimingRings + 1
+		b.rs = ec.NewRS255_191()
+	}
+	return b
+}
+
+func (b bench) frameBytes() int {
+	blocks := b.config.GridWidth * b.config.GridHeight
+	switch b.mode {
+	case "mono":
+		return qr.MonoCapacity(blocks)
+	case "projector":
+		return b.rs.FrameDataSize(qr.PaletteCapacity(blocks))
+	}
+	return blocks * 3
+}
+
+func (b bench) payload() ([]byte, []byte, error) {
+	data := make([]byte, b.frameBytes())
+	if _, err := rand.Read(data); err != nil {
+		return nil, nil, err
+	}
+	if b.rs != nil {
+		frame, err := b.rs.EncodeFrame(data, qr.PaletteCapacity(b.config.GridWidth*b.config.GridHeight))
+		return data, frame, err
+	}
+	return data, data, nil
+}
+
+func (b bench) decode(dec *qr.Decoder, blocks []qr.Block) ([]byte, error) {
+	data := dec.BlocksToData(blocks)
+	if b.rs != nil {
+		return b.rs.DecodeFrame(data, nil)
+	}
+	return data, nil
+}
+
+func (b bench) run(blockSize int, duration time.Duration) (result, error) {
+	res := result{
+		Mode:       b.mode,
+		Grid:       b.config.GridWidth,
+		BlockSize:  blockSize,
+		FrameBytes: b.frameBytes(),
+	}
+	if b.mode == "color" {
+		res.Level = b.level.String()
+	}
+
+	data, frame, err := b.payload()
+	if err != nil {
+		return res, err
+	}
+	side := (b.config.GridWidth + 2*b.config.BorderSize) * blockSize
+	enc := qr.NewEncoder(b.config)
+	dec := qr.NewDecoder(b.config)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	var encoding, decoding time.Duration
+	delivered := 0
+	start := time.Now()
+	for res.Frames < minFrames || time.Since(start) < duration {
+		t0 := time.Now()
+		blocks := enc.Encode(frame)
+		img, err := enc.CreateImage(blocks, side, side)
+		if err != nil {
+			return res, err
+		}
+		t1 := time.Now()
+		got, _, err := dec.DecodeWithStats(img)
+		var decoded []byte
+		if err == nil {
+			decoded, err = b.decode(dec, got)
+		}
+		t2 := time.Now()
+
+		encoding += t1.Sub(t0)
+		decoding += t2.Sub(t1)
+		res.Frames++
+		if err != nil || len(decoded) < len(data) || !bytes.Equal(decoded[:len(data)], data) {
+			res.Errors++
+		} else {
+			delivered += len(data)
+		}
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	frames := float64(res.Frames)
+	res.EncodeFPS = frames / encoding.Seconds()
+	res.DecodeFPS = frames / decoding.Seconds()
+	res.FPS = frames / elapsed.Seconds()
+	res.BytesPerSec = float64(delivered) / elapsed.Seconds()
+	res.AllocsPerFrame = float64(after.Mallocs-before.Mallocs) / frames
+	res.AllocPerFrame = float64(after.TotalAlloc-before.TotalAlloc) / frames
+	return res, nil
+}
+
+func cases(opts options) []bench {
+	var out []bench
+	for _, mode := range opts.modes {
+		levels := opts.levels
+		if mode != "color" {
+			levels = []qr.ErrorLevel{qr.ErrorLevelLow}
+		}
+		for _, level := range levels {
+			for _, grid := range opts.grids {
+				out = append(out, newBench(mode, level, grid))
+			}
+		}
+	}
+	return out
+}
+
+func printTable(results []result) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "mode\tlevel\tgrid\tbytes/frame\tencode fps\tdecode fps\tfps\tbytes/s\tallocs/frame\tKB/frame\terrors\t")
+	for _, r := range results {
+		level := r.Level
+		if level == "" {
+			level = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%.1f\t%.1f\t%.1f\t%s\t%.0f\t%.1f\t%d\t\n",
+			r.Mode, level, r.Grid, r.FrameBytes, r.EncodeFPS, r.DecodeFPS, r.FPS, rate(r.BytesPerSec), r.AllocsPerFrame, r.AllocPerFrame/1024, r.Errors)
+	}
+	w.Flush()
+}
+
+func rate(bps float64) string {
+	switch {
+	case bps >= 1<<20:
+		return fmt.Sprintf("%.1fM", bps/(1<<20))
+	case bps >= 1<<10:
+		return fmt.Sprintf("%.1fK", bps/(1<<10))
+	}
+	return fmt.Sprintf("%.0f", bps)
+}
+
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func run(opts options) error {
+	if opts.cpuProfile != "" {
+		f, err := os.Create(opts.cpuProfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return err
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	var results []result
+	out := json.NewEncoder(os.Stdout)
+	for _, b := range cases(opts) {
+		res, err := b.run(opts.blockSize, opts.duration)
+		if err != nil {
+			return fmt.Errorf("%s grid %d: %w", b.mode, b.config.GridWidth, err)
+		}
+		if opts.json {
+			if err := out.Encode(res); err != nil {
+				return err
+			}
+			continue
+		}
+		results = append(results, res)
+	}
+	if !opts.json {
+		printTable(results)
+	}
+
+	if opts.memProfile != "" {
+		return writeHeapProfile(opts.memProfile)
+	}
+	return nil
+}
+
+func main() {
+	opts, err := parseFlags()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "owlbench:", err)
+		os.Exit(2)
+	}
+
+	if err := run(opts); err != nil {
+		fmt.Fprintln(os.Stderr, "owlbench:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/screen"
+)
+
+const (
+	alignEdgeMargin  = 0.02
+	alignMinFraction = 0.1
+	alignMinSkew     = 0.8
+	alignMaxOffset   = 0.2
+	alignHintInset   = 8
+)
+
+var (
+	alignedColor    = color.NRGBA{R: 64, G: 200, B: 96, A: 255}
+	misalignedColor = color.NRGBA{R: 255, G: 176, B: 32, A: 255}
+)
+
+type alignOverlay struct {
+	widget.BaseWidget
+
+	mu     sync.Mutex
+	active bool
+	frame  image.Point
+	quad   screen.Quad
+	found  bool
+
+	edges [4]*canvas.Line
+	hint  *canvas.Text
+}
+
+func newAlignOverlay() *alignOverlay {
+	o := &alignOverlay{hint: canvas.NewText("", misalignedColor)}
+	o.hint.TextStyle = fyne.TextStyle{Bold: true}
+	for i := range o.edges {
+		o.edges[i] = canvas.NewLine(misalignedColor)
+		o.edges[i].StrokeWidth = 3
+	}
+	o.ExtendBaseWidget(o)
+	return o
+}
+
+func (o *alignOverlay) CreateRenderer() fyne.WidgetRenderer {
+	objects := []fyne.CanvasObject{o.hint}
+	for _, e := range o.edges {
+		objects = append(objects, e)
+	}
+	return &alignRenderer{overlay: o, objects: objects}
+}
+
+func (o *alignOverlay) SetActive(active bool) {
+	o.mu.Lock()
+	o.active = active
+	o.found = false
+	o.mu.Unlock()
+
+	o.Refresh()
+}
+
+func (o *alignOverlay) Process(img image.Image) {
+	o.mu.Lock()
+	active := o.active
+	o.mu.Unlock()
+	if !active {
+		return
+	}
+
+	bounds := img.Bounds()
+	quad, found := screen.LocateCode(img, 0)
+	for i := range quad {
+		quad[i] = quad[i].Sub(bounds.Min)
+	}
+
+	o.mu.Lock()
+	o.frame, o.quad, o.found = bounds.Size(), quad, found
+	o.mu.Unlock()
+
+	fyne.Do(o.Refresh)
+}
+
+func (o *alignOverlay) advice() (string, bool) {
+	if !o.found {
+		return "Point the camera at the projected code", false
+	}
+
+	bounds := o.quad.Bounds()
+	marginX := int(float64(o.frame.X) * alignEdgeMargin)
+	marginY := int(float64(o.frame.Y) * alignEdgeMargin)
+	if bounds.Min.X <= marginX || bounds.Min.Y <= marginY || bounds.Max.X >= o.frame.X-marginX || bounds.Max.Y >= o.frame.Y-marginY {
+		return "Code is cut off: move back or re-aim", false
+	}
+	if o.quad.Area() < alignMinFraction*float64(o.frame.X*o.frame.Y) {
+		return "Move closer or zoom in", false
+	}
+
+	var sides [4]float64
+	for i, p := range o.quad {
+		d := o.quad[(i+1)%4].Sub(p)
+		sides[i] = math.Hypot(float64(d.X), float64(d.Y))
+	}
+	for i := 0; i < 2; i++ {
+		if min(sides[i], sides[i+2]) < alignMinSkew*max(sides[i], sides[i+2]) {
+			return "Face the projection more squarely", false
+		}
+	}
+
+	center := bounds.Min.Add(bounds.Max).Div(2)
+	offX := math.Abs(float64(center.X)/float64(o.frame.X) - 0.5)
+	offY := math.Abs(float64(center.Y)/float64(o.frame.Y) - 0.5)
+	if offX > alignMaxOffset || offY > alignMaxOffset {
+		return "Center the code in view", false
+	}
+	return "Aligned", true
+}
+
+func (o *alignOverlay) toWidget(p image.Point) fyne.Position {
+	size := o.Size()
+	scale := math.Min(float64(size.Width)/float64(o.frame.X), float64(size.Height)/float64(o.frame.Y))
+	offsetX := (float64(size.Width) - float64(o.frame.X)*scale) / 2
+	offsetY := (float64(size.Height) - float64(o.frame.Y)*scale) / 2
+	return fyne.NewPos(float32(offsetX+float64(p.X)*scale), float32(offsetY+float64(p.Y)*scale))
+}
+
+type alignRenderer struct {
+	overlay *alignOverlay
+	objects []fyne.CanvasObject
+}
+
+func (r *alignRenderer) Layout(size fyne.Size) {
+	o := r.overlay
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.active || o.frame.X <= 0 || o.frame.Y <= 0 || size.Width <= 0 || size.Height <= 0 {
+		for _, obj := range r.objects {
+			obj.Hide()
+		}
+		return
+	}
+
+	text, aligned := o.advice()
+	fill := misalignedColor
+	if aligned {
+		fill = alignedColor
+	}
+
+	o.hint.Text = text
+	o.hint.Color = fill
+	o.hint.Move(fyne.NewPos((size.Width-o.hint.MinSize().Width)/2, alignHintInset))
+	o.hint.Resize(o.hint.MinSize())
+	o.hint.Show()
+
+	for i, e := range o.edges {
+		if !o.found {
+			e.Hide()
+			continue
+		}
+		e.StrokeColor = fill
+		e.Position1 = o.toWidget(o.quad[i])
+		e.Position2 = o.toWidget(o.quad[(i+1)%4])
+		e.Show()
+	}
+}
+
+func (r *alignRenderer) MinSize() fyne.Size {
+	return fyne.Size{}
+}
+
+func (r *alignRenderer) Refresh() {
+	r.Layout(r.overlay.Size())
+	for _, obj := range r.objects {
+		obj.Refresh()
+	}
+}
+
+func (r *alignRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *alignRenderer) Destroy() {}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/audit"
+	"qrtransfer/pkg/config"
+	"qrtransfer/pkg/engine"
+)
+
+func openAudit() *audit.Log {
+	path, err := config.AuditLogPath()
+	if err != nil {
+		slog.Warn("audit log disabled", "err", err)
+		return nil
+	}
+	return audit.Open(path)
+}
+
+func (r *ReceiverApp) recordReceive(path string) {
+	if r.audit == nil {
+		return
+	}
+
+	settings := map[string]string{
+		"source":    r.sourceName,
+		"auto_save": fmt.Sprint(r.autoSave),
+		"tool":      "receiver",
+	}
+	r.recordAudit(audit.ReceiveEntry(r.engine, path, settings, ""))
+}
+
+func uriPath(u fyne.URI) string {
+	if u.Scheme() == "file" {
+		return u.Path()
+	}
+	return u.String()
+}
+
+func (r *ReceiverApp) recordRefused(res engine.FrameResult) {
+	if r.audit == nil {
+		return
+	}
+	r.recordAudit(audit.Entry{
+		Event:    audit.EventRefused,
+		Session:  res.Session,
+		Settings: map[string]string{"reason": res.Refused.Error(), "tool": "receiver"},
+	})
+}
+
+func (r *ReceiverApp) recordAudit(e audit.Entry) {
+	if err := r.audit.Record(e); err != nil {
+		slog.Error("writing audit log failed", "path", r.audit.Path(), "err", err)
+		r.status.SetText(fmt.Sprintf("Audit log error: %v", err))
+	}
+}
+
+func (r *ReceiverApp) showAudit() {
+	if r.audit == nil {
+		dialog.ShowInformation("Audit Log", "The audit log is unavailable: no settings directory.", r.window)
+		return
+	}
+	if r.auditWin != nil {
+		r.auditWin.RequestFocus()
+		return
+	}
+
+	grid := widget.NewTextGrid()
+	check := widget.NewLabel("")
+	check.Wrapping = fyne.TextWrapWord
+	refresh := func() {
+		entries, err := r.audit.Entries()
+		if err == nil {
+			err = audit.Verify(entries)
+		}
+		lines := make([]string, len(entries))
+		for i, e := range entries {
+			lines[i] = audit.Summary(e)
+		}
+		grid.SetText(strings.Join(lines, "\n"))
+		grid.ScrollToBottom()
+
+		switch {
+		case err != nil:
+			check.SetText("Warning: " + err.Error())
+			check.Importance = widget.DangerImportance
+		default:
+			check.SetText(fmt.Sprintf("%d entries in %s, chain intact", len(entries), r.audit.Path()))
+			check.Importance = widget.MediumImportance
+		}
+		check.Refresh()
+	}
+
+	note := widget.NewMultiLineEntry()
+	note.SetPlaceHolder("Operator note, such as who brought the sender or where the file went next")
+	addBtn := widget.NewButton("Add Note", func() {
+		text := strings.TrimSpace(note.Text)
+		if text == "" {
+			return
+		}
+		e := audit.Entry{Event: audit.EventNote, Note: text, Settings: map[string]string{"tool": "receiver"}}
+		if metadata := r.engine.Metadata(); metadata.TotalChunks > 0 {
+			e.Session, e.File = r.engine.Session(), metadata.Filename
+		}
+		if err := r.audit.Record(e); err != nil {
+			dialog.ShowError(err, r.auditWin)
+			return
+		}
+		note.SetText("")
+		refresh()
+	})
+	exportBtn := widget.NewButton("Export...", func() {
+		exportAudit(r.audit, r.auditWin)
+	})
+
+	w := r.app.NewWindow(windowTitle + " - Audit Log")
+	w.SetContent(container.NewBorder(
+		check,
+		container.NewVBox(note, container.NewHBox(addBtn, exportBtn)),
+		nil, nil,
+		grid,
+	))
+	w.Resize(fyne.NewSize(720, 400))
+	w.SetOnClosed(func() {
+		r.auditWin = nil
+	})
+
+	r.auditWin = w
+	refresh()
+	w.Show()
+}
+
+func exportAudit(log *audit.Log, parent fyne.Window) {
+	entries, err := log.Entries()
+	if err != nil {
+		dialog.ShowError(err, parent)
+		return
+	}
+
+	d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, parent)
+			return
+		}
+		if writer == nil {
+			return
+		}
+
+		path := writer.URI().Path()
+		if writer.URI().Scheme() == "file" {
+			writer.Close()
+			err = engine.WriteAtomic(path, func(w io.Writer) error {
+				return audit.Export(w, path, entries)
+			})
+		} else {
+			err = audit.Export(writer, writer.URI().Name(), entries)
+			if cerr := writer.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err != nil {
+			dialog.ShowError(err, parent)
+		}
+	}, parent)
+	d.SetFileName("audit.json")
+	d.Show()
+}
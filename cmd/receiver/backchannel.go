@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/qr"
+)
+
+const (
+	statusCodeInterval = 2 * time.Second
+	statusCodeSize     = 360
+)
+
+func (r *ReceiverApp) showStatusCode() {
+	if r.statusWin != nil {
+		r.statusWin.RequestFocus()
+		return
+	}
+
+	img := &canvas.Image{FillMode: canvas.ImageFillContain}
+	img.SetMinSize(fyne.NewSize(statusCodeSize, statusCodeSize))
+	label := widget.NewLabel("Waiting for file metadata")
+
+	w := r.app.NewWindow(windowTitle + " - Status Code")
+	w.SetContent(container.NewBorder(nil, label, nil, nil,
+		container.NewStack(canvas.NewRectangle(qr.QuietZoneColor), img)))
+
+	stop := make(chan struct{})
+	w.SetOnClosed(func() {
+		close(stop)
+		r.statusWin = nil
+	})
+
+	renderer := engine.NewRenderer(engine.BackchannelConfig)
+	update := func() {
+		st := r.engine.Status()
+		if st.Total == 0 {
+			return
+		}
+
+		data, err := st.MarshalBinary()
+		if err == nil {
+			var frame image.Image
+			if frame, err = renderer.Render(data, image.Pt(statusCodeSize, statusCodeSize), ""); err == nil {
+				img.Image = frame
+			}
+		}
+		if err != nil {
+			slog.Warn("rendering status code failed", "err", err)
+			return
+		}
+
+		text := fmt.Sprintf("%d of %d chunks received", st.Received, st.Total)
+		if st.Truncated {
+			text += fmt.Sprintf(", first %d gaps shown", engine.MaxStatusRanges)
+		}
+		label.SetText(text)
+		img.Refresh()
+	}
+
+	go func() {
+		ticker := time.NewTicker(statusCodeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				fyne.Do(update)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	update()
+	r.statusWin = w
+	w.Show()
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/qr"
+)
+
+const (
+	calibrationRefresh = time.Second
+	estimateSize       = 1 << 20
+)
+
+func (r *ReceiverApp) showCalibration() {
+	if r.calWin != nil {
+		r.calWin.RequestFocus()
+		return
+	}
+
+	r.setCalibrator(engine.NewCalibrator())
+	if !r.capturing() {
+		r.startCapture()
+	}
+
+	intro := widget.NewLabel("Press Calibrate on the sender while this receiver is capturing it. " +
+		"The test patterns take about a minute; press Finish once the sender reports that calibration is done.")
+	intro.Wrapping = fyne.TextWrapWord
+	progress := widget.NewLabel("No calibration frames read yet")
+	result := widget.NewLabel("")
+	result.Wrapping = fyne.TextWrapWord
+
+	var cal engine.Calibration
+	applyBtn := widget.NewButton("Apply", func() {
+		r.applyCalibration(cal)
+		progress.SetText(fmt.Sprintf("Receiver block size set to %d px", cal.BlockSize))
+	})
+	applyBtn.Disable()
+	copyBtn := widget.NewButton("Copy Sender Settings", func() {
+		r.app.Clipboard().SetContent(cal.String())
+		progress.SetText("Copied " + cal.String() + " for Apply Calibration on the sender")
+	})
+	copyBtn.Disable()
+
+	finishBtn := widget.NewButton("Finish", func() {
+		var err error
+		cal, err = r.calibrator().Result()
+		result.SetText(describeCalibration(cal, err))
+		if err != nil {
+			applyBtn.Disable()
+			copyBtn.Disable()
+			return
+		}
+		applyBtn.Enable()
+		copyBtn.Enable()
+	})
+	restartBtn := widget.NewButton("Restart", func() {
+		r.setCalibrator(engine.NewCalibrator())
+		result.SetText("")
+		applyBtn.Disable()
+		copyBtn.Disable()
+	})
+
+	w := r.app.NewWindow(windowTitle + " - Calibration")
+	w.SetContent(container.NewVBox(
+		intro,
+		progress,
+		container.NewGridWithColumns(4, restartBtn, finishBtn, applyBtn, copyBtn),
+		result,
+	))
+	w.Resize(fyne.NewSize(520, 360))
+
+	stop := make(chan struct{})
+	w.SetOnClosed(func() {
+		close(stop)
+		r.setCalibrator(nil)
+		r.calWin = nil
+	})
+
+	go func() {
+		ticker := time.NewTicker(calibrationRefresh)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				fyne.Do(func() {
+					if c := r.calibrator(); c != nil && c.Frames() > 0 {
+						progress.SetText(fmt.Sprintf("%d calibration frames read", c.Frames()))
+					}
+				})
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	r.calWin = w
+	w.Show()
+}
+
+func (r *ReceiverApp) calibrator() *engine.Calibrator {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cal
+}
+
+func (r *ReceiverApp) setCalibrator(c *engine.Calibrator) {
+	r.mu.Lock()
+	r.cal = c
+	r.mu.Unlock()
+}
+
+func (r *ReceiverApp) calibrate(img image.Image) {
+	if c := r.calibrator(); c != nil {
+		c.Process(img)
+	}
+}
+
+func (r *ReceiverApp) applyCalibration(cal engine.Calibration) {
+	if cal.BlockSize <= 0 || cal.BlockSize == r.blockSize {
+		return
+	}
+
+	r.blockSize = cal.BlockSize
+	if r.capturing() {
+		r.stopCapture()
+		r.startCapture()
+	}
+}
+
+func describeCalibration(cal engine.Calibration, err error) string {
+	if err != nil {
+		return fmt.Sprintf("%v. Check that the sender's code is inside the capture region and run the calibration again.", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d calibration frames read.\n\n", cal.Frames)
+	for _, s := range cal.Sizes {
+		fmt.Fprintf(&b, "%dx%d blocks at %.1f px: mean color error %.1f, misread at", s.Side, s.Side, s.BlockPixels, s.MeanError)
+		for level := qr.ErrorLevelLow; level <= qr.ErrorLevelHigh; level++ {
+			fmt.Fprintf(&b, " %s %.1f%%", level, s.FailRate[level]*100)
+		}
+		b.WriteString("\n")
+	}
+	for _, rate := range cal.Rates {
+		fmt.Fprintf(&b, "%s per frame: %d of %d frames seen\n", rate.Interval, rate.Seen, rate.Shown)
+	}
+
+	fmt.Fprintf(&b, "\nRecommended: %s error correction, chunk size up to %d bytes", cal.ErrorLevel, cal.ChunkSize)
+	if cal.Interval > 0 {
+		fmt.Fprintf(&b, ", %s per frame", cal.Interval)
+	} else {
+		b.WriteString(", no frame rate was fully captured, keep the current refresh rate")
+	}
+	fmt.Fprintf(&b, ". Apply sets this receiver's expected block size to %d px.", cal.BlockSize)
+
+	if cal.ChunkSize > 0 {
+		interval := cal.Interval
+		if interval <= 0 {
+			interval = engine.DefaultInterval
+		}
+		est := engine.EstimateThroughput(estimateSize, qr.Config{ErrorLevel: cal.ErrorLevel}, chunk.NewConfig(cal.ChunkSize, 1), chunk.StrategyImmediate, interval)
+		if est.Intact() {
+			fmt.Fprintf(&b, "\n\nEstimate at these settings: %s/s, %v for a 1 MB file.", formatBytes(est.BytesPerSecond()), est.Duration.Round(time.Second))
+		} else {
+			fmt.Fprintf(&b, "\n\nEstimate at these settings: nothing arrives intact, %s error correction keeps %d of the %d bytes in each frame.", cal.ErrorLevel, est.Capacity, est.FrameBytes)
+		}
+	}
+	return b.String()
+}
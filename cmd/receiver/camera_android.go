@@ -0,0 +1,49 @@
+//go:build android
+
+package main
+
+/*
+#include <jni.h>
+#include <stdint.h>
+
+static void owl_request_camera(uintptr_t jniEnv, uintptr_t ctx) {
+	JNIEnv *env = (JNIEnv *)jniEnv;
+	jobject activity = (jobject)ctx;
+
+	jclass cls = (*env)->GetObjectClass(env, activity);
+	jmethodID request = (*env)->GetMethodID(env, cls, "requestPermissions", "([Ljava/lang/String;I)V");
+	if (request == NULL) {
+		(*env)->ExceptionClear(env);
+		return;
+	}
+
+	jstring camera = (*env)->NewStringUTF(env, "android.permission.CAMERA");
+	jobjectArray permissions = (*env)->NewObjectArray(env, 1, (*env)->FindClass(env, "java/lang/String"), camera);
+	(*env)->CallVoidMethod(env, activity, request, permissions, 0);
+	if ((*env)->ExceptionCheck(env)) {
+		(*env)->ExceptionClear(env);
+	}
+
+	(*env)->DeleteLocalRef(env, permissions);
+	(*env)->DeleteLocalRef(env, camera);
+	(*env)->DeleteLocalRef(env, cls);
+}
+*/
+import "C"
+
+import (
+	"log/slog"
+
+	"fyne.io/fyne/v2/driver"
+)
+
+func requestCameraAccess() {
+	err := driver.RunNative(func(ctx any) error {
+		android := ctx.(*driver.AndroidContext)
+		C.owl_request_camera(C.uintptr_t(android.Env), C.uintptr_t(android.Ctx))
+		return nil
+	})
+	if err != nil {
+		slog.Warn("requesting camera permission failed", "err", err)
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !android
+
+package main
+
+func requestCameraAccess() {}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/engine"
+)
+
+var chunkColors = map[engine.ChunkState]color.RGBA{
+	engine.ChunkMissing:  {R: 0xd9, G: 0x4a, B: 0x4a, A: 0xff},
+	engine.ChunkReceived: {R: 0xf0, G: 0xb4, B: 0x29, A: 0xff},
+	engine.ChunkVerified: {R: 0x3c, G: 0xa5, B: 0x5c, A: 0xff},
+}
+
+type chunkMap struct {
+	widget.BaseWidget
+
+	mu     sync.Mutex
+	states []engine.ChunkState
+	raster *canvas.Raster
+
+	OnTapped func(states []engine.ChunkState, index int)
+}
+
+func newChunkMap() *chunkMap {
+	m := &chunkMap{}
+	m.raster = canvas.NewRaster(m.draw)
+	m.raster.ScaleMode = canvas.ImageScalePixels
+	m.raster.SetMinSize(fyne.NewSize(240, 80))
+	m.ExtendBaseWidget(m)
+	return m
+}
+
+func (m *chunkMap) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(m.raster)
+}
+
+func (m *chunkMap) SetStates(states []engine.ChunkState) {
+	m.mu.Lock()
+	m.states = states
+	m.mu.Unlock()
+
+	fyne.Do(m.raster.Refresh)
+}
+
+func (m *chunkMap) Tapped(ev *fyne.PointEvent) {
+	m.mu.Lock()
+	states := m.states
+	m.mu.Unlock()
+
+	size := m.Size()
+	cols, rows := chunkGrid(len(states), float64(size.Width), float64(size.Height))
+	if cols == 0 {
+		return
+	}
+
+	col := int(float64(ev.Position.X) / float64(size.Width) * float64(cols))
+	row := int(float64(ev.Position.Y) / float64(size.Height) * float64(rows))
+	if index := row*cols + col; col < cols && index >= 0 && index < len(states) && m.OnTapped != nil {
+		m.OnTapped(states, index)
+	}
+}
+
+func (m *chunkMap) draw(w, h int) image.Image {
+	m.mu.Lock()
+	states := m.states
+	m.mu.Unlock()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	cols, rows := chunkGrid(len(states), float64(w), float64(h))
+	if cols == 0 {
+		return img
+	}
+
+	for y := 0; y < h; y++ {
+		row := y * rows / h
+		for x := 0; x < w; x++ {
+			index := row*cols + x*cols/w
+			if index < len(states) {
+				img.SetRGBA(x, y, chunkColors[states[index]])
+			}
+		}
+	}
+	return img
+}
+
+func chunkGrid(n int, w, h float64) (int, int) {
+	if n == 0 || w <= 0 || h <= 0 {
+		return 0, 0
+	}
+
+	cols := max(1, min(n, int(math.Ceil(math.Sqrt(float64(n)*w/h)))))
+	rows := (n + cols - 1) / cols
+	return cols, rows
+}
+
+func missingRun(states []engine.ChunkState, index int) []uint32 {
+	if states[index] == engine.ChunkVerified {
+		return nil
+	}
+
+	start, end := index, index
+	for start > 0 && states[start-1] != engine.ChunkVerified {
+		start--
+	}
+	for end+1 < len(states) && states[end+1] != engine.ChunkVerified {
+		end++
+	}
+
+	run := make([]uint32, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		run = append(run, uint32(i))
+	}
+	return run
+}
+
+func (r *ReceiverApp) setupChunkMap() fyne.CanvasObject {
+	r.chunkMap = newChunkMap()
+	r.chunkMap.OnTapped = func(states []engine.ChunkState, index int) {
+		run := missingRun(states, index)
+		if len(run) == 0 {
+			r.status.SetText(fmt.Sprintf("Chunk %d verified", index))
+			return
+		}
+		r.copyMissing(run)
+	}
+
+	copyBtn := widget.NewButton("Copy Missing", func() {
+		r.copyMissing(r.engine.Missing())
+	})
+
+	exportBtn := widget.NewButton("Export Report", r.exportReport)
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("Chunks:"), container.NewHBox(copyBtn, exportBtn)),
+		r.chunkMap,
+	)
+}
+
+func (r *ReceiverApp) copyMissing(indices []uint32) {
+	if len(indices) == 0 {
+		r.status.SetText("No missing chunks")
+		return
+	}
+
+	text := chunk.FormatIndices(indices)
+	r.app.Clipboard().SetContent(text)
+	if len(text) > 60 {
+		text = fmt.Sprintf("%d chunks", len(indices))
+	}
+	r.status.SetText("Copied missing chunks " + text)
+}
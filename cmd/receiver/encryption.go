@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/qr"
+	"qrtransfer/pkg/secure"
+)
+
+func (r *ReceiverApp) setupPassphrase() fyne.CanvasObject {
+	entry := widget.NewPasswordEntry()
+	entry.SetPlaceHolder("Leave empty to accept unencrypted transfers")
+	entry.OnChanged = func(text string) {
+		r.engine.SetKeys(secure.Passphrase(text))
+		r.schedulePassphraseSave(text)
+	}
+
+	wipeCheck := widget.NewCheck("Wipe received data after saving", func(on bool) {
+		r.engine.SetSecureWipe(on)
+	})
+	wipeCheck.Checked = r.engine.SecureWipe()
+
+	return container.NewVBox(widget.NewLabel("Decryption Passphrase:"), entry, r.setupRemember(entry), wipeCheck)
+}
+
+func (r *ReceiverApp) wipeSaved(saved string) {
+	if !r.engine.SecureWipe() {
+		return
+	}
+
+	r.engine.Discard(r.session)
+	r.updateSessions()
+	r.showSession()
+	r.status.SetText(saved + ", received data wiped from memory")
+}
+
+func (r *ReceiverApp) reportAuth(res engine.FrameResult) {
+	switch res.Auth {
+	case engine.AuthLocked:
+		if r.engine.Identity() != nil {
+			r.status.SetText("This transfer is encrypted: waiting for the sender's key exchange frame, or enter its passphrase")
+			return
+		}
+		r.status.SetText("This transfer is encrypted: enter its passphrase, or show the key code before the sender starts")
+	case engine.AuthFailed:
+		r.status.SetText(fmt.Sprintf("Rejected chunk %d: it failed authentication (wrong passphrase, or a frame from another sender)", res.Chunk.Index))
+	}
+}
+
+func (r *ReceiverApp) showKeyCode() {
+	if r.keyWin != nil {
+		r.keyWin.RequestFocus()
+		return
+	}
+
+	id := r.engine.Identity()
+	if id == nil {
+		var err error
+		if id, err = secure.NewIdentity(); err != nil {
+			dialog.ShowError(err, r.window)
+			return
+		}
+		r.engine.SetIdentity(id)
+		slog.Info("receiver key created", "fingerprint", secure.Fingerprint(id.PublicKey()))
+	}
+
+	frame, err := engine.NewRenderer(engine.BackchannelConfig).Render(engine.PublicKeyCode(id.PublicKey()), image.Pt(statusCodeSize, statusCodeSize), "")
+	if err != nil {
+		dialog.ShowError(err, r.window)
+		return
+	}
+	img := canvas.NewImageFromImage(frame)
+	img.FillMode = canvas.ImageFillContain
+	img.SetMinSize(fyne.NewSize(statusCodeSize, statusCodeSize))
+
+	key := secure.FormatPublicKey(id.PublicKey())
+	info := widget.NewLabel(fmt.Sprintf("Show this code to the sender's camera, or type the key into its Receiver Key field:\n%s\nFingerprint: %s", key, secure.Fingerprint(id.PublicKey())))
+	info.Wrapping = fyne.TextWrapWord
+	copyBtn := widget.NewButton("Copy Key", func() {
+		r.app.Clipboard().SetContent(key)
+	})
+
+	w := r.app.NewWindow(windowTitle + " - Key Code")
+	w.SetContent(container.NewBorder(nil, container.NewVBox(info, copyBtn), nil, nil,
+		container.NewStack(canvas.NewRectangle(qr.QuietZoneColor), img)))
+	w.SetOnClosed(func() {
+		r.keyWin = nil
+	})
+
+	r.keyWin = w
+	w.Show()
+}
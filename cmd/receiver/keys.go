@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/secure"
+)
+
+const passphraseSaveDelay = time.Second
+
+func (r *ReceiverApp) setupRemember(entry *widget.Entry) fyne.CanvasObject {
+	r.passEntry = entry
+	r.rememberPass = widget.NewCheck("Remember in system keyring", func(on bool) {
+		if r.passSave != nil {
+			r.passSave.Stop()
+		}
+		text := ""
+		if on {
+			text = r.passEntry.Text
+		}
+		go r.storePassphrase(text)
+	})
+	if !secure.KeyringAvailable() {
+		r.rememberPass.Disable()
+		return r.rememberPass
+	}
+
+	saved, err := secure.KeyringGet(secure.KeyringReceiverPassphrase)
+	switch {
+	case err == nil:
+		entry.SetText(saved)
+		r.rememberPass.Checked = true
+	case !errors.Is(err, secure.ErrNotInKeyring):
+		slog.Warn("reading saved passphrase failed", "err", err)
+	}
+	return r.rememberPass
+}
+
+func (r *ReceiverApp) schedulePassphraseSave(text string) {
+	if r.rememberPass == nil || !r.rememberPass.Checked {
+		return
+	}
+	if r.passSave != nil {
+		r.passSave.Stop()
+	}
+	r.passSave = time.AfterFunc(passphraseSaveDelay, func() { r.storePassphrase(text) })
+}
+
+func (r *ReceiverApp) storePassphrase(text string) {
+	err := secure.KeyringDelete(secure.KeyringReceiverPassphrase)
+	if text != "" {
+		err = secure.KeyringSet(secure.KeyringReceiverPassphrase, text)
+	}
+	if err != nil {
+		slog.Error("saving passphrase failed", "err", err)
+		fyne.Do(func() { r.status.SetText("Saving passphrase failed: " + err.Error()) })
+	}
+}
+
+func (r *ReceiverApp) showKeys() {
+	info := widget.NewLabel("")
+	info.Wrapping = fyne.TextWrapWord
+	var forgetBtn *widget.Button
+	forgetBtn = widget.NewButton("Forget Passphrase", func() {
+		if r.rememberPass.Checked {
+			r.rememberPass.SetChecked(false)
+		} else if err := secure.KeyringDelete(secure.KeyringReceiverPassphrase); err != nil {
+			dialog.ShowError(err, r.window)
+		}
+		info.SetText("No passphrase is saved.")
+		forgetBtn.Disable()
+	})
+
+	_, err := secure.KeyringGet(secure.KeyringReceiverPassphrase)
+	switch {
+	case !secure.KeyringAvailable():
+		info.SetText("No system keyring is available, so passphrases cannot be saved.")
+		forgetBtn.Disable()
+	case err == nil:
+		info.SetText("A passphrase is saved in the system keyring.")
+	default:
+		info.SetText("No passphrase is saved.")
+		forgetBtn.Disable()
+	}
+
+	keyInfo := widget.NewLabel("The receiver key behind the key code is created for each run and never stored.")
+	keyInfo.Wrapping = fyne.TextWrapWord
+	if id := r.engine.Identity(); id != nil {
+		keyInfo.SetText("Receiver key for this run: " + secure.Fingerprint(id.PublicKey()) + "\nIt is never stored and is gone when the receiver closes.")
+	}
+
+	d := dialog.NewCustom("Keys", "Close", container.NewVBox(info, forgetBtn, widget.NewSeparator(), keyInfo), r.window)
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/logging"
+)
+
+func (r *ReceiverApp) showLog() {
+	if r.logWin != nil {
+		r.logWin.RequestFocus()
+		return
+	}
+
+	grid := widget.NewTextGridFromString(strings.Join(r.log.History.Lines(), "\n"))
+	grid.ScrollToBottom()
+
+	levelSelect := widget.NewSelect(logging.Levels(), func(value string) {
+		if level, err := logging.ParseLevel(value); err == nil {
+			r.log.Level.Set(level)
+		}
+	})
+	levelSelect.SetSelected(logging.LevelName(r.log.Level.Level()))
+
+	clearBtn := widget.NewButton("Clear", func() {
+		r.log.History.Clear()
+		grid.SetText("")
+	})
+
+	w := r.app.NewWindow(windowTitle + " - Log")
+	w.SetContent(container.NewBorder(
+		container.NewHBox(widget.NewLabel("Level:"), levelSelect, clearBtn),
+		nil, nil, nil,
+		grid,
+	))
+	w.Resize(fyne.NewSize(720, 400))
+
+	r.log.History.OnLine(func(line string) {
+		fyne.Do(func() {
+			grid.Append(line)
+			grid.ScrollToBottom()
+		})
+	})
+	w.SetOnClosed(func() {
+		r.log.History.OnLine(nil)
+		r.logWin = nil
+	})
+
+	r.logWin = w
+	w.Show()
+}
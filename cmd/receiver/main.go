@@ -1,62 +1,160 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/widget"
 	"image"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 	
+	"qrtransfer/pkg/audit"
 	"qrtransfer/pkg/chunk"
-	"qrtransfer/pkg/qr"
+	"qrtransfer/pkg/config"
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/logging"
+	"qrtransfer/pkg/metrics"
 	"qrtransfer/pkg/screen"
 )
 
 type ReceiverApp struct {
-	app        fyne.App
-	window     fyne.Window
-	preview    *canvas.Image
-	status     *widget.Label
-	progress   *widget.ProgressBar
+	app         fyne.App
+	window      fyne.Window
+	preview     *canvas.Image
+	overlay     *regionOverlay
+	align       *alignOverlay
+	status      *widget.Label
+	verified    *widget.Label
+	trustBtn    *widget.Button
+	progress    *widget.ProgressBar
+	stats       *statsPanel
+	perfLabel   *widget.Label
+	regionLabel *widget.Label
+	rateSlider  *widget.Slider
+	startBtn    *widget.Button
+	stopBtn     *widget.Button
+	saveBtn     *widget.Button
+	copyBtn     *widget.Button
+	chunkMap    *chunkMap
+	
+	sessionSelect *widget.Select
+	sessionBox    *fyne.Container
+	sessionIDs    []uint64
+	session       uint64
 	
 	screenCap  *screen.Capturer
-	qrDec      *qr.Decoder
-	chunkProc  *chunk.Processor
-	
-	receivedChunks map[uint32][]chunk.Chunk
-	mu             sync.Mutex
-	
-	running      bool
-	captureRate  time.Duration
+	source     screen.Source
+	metrics    *screen.Metrics
+	engine     *engine.Receiver
+	mu         sync.Mutex
+	
+	cancel       context.CancelFunc
+	done         chan struct{}
+	fps          int
+	autoFPS      bool
+	blockSize    int
+	tuning       screen.DecodeTuning
 	targetRegion image.Rectangle
+	hideCursor   bool
+	maskSelf     bool
+	trackCode    bool
+	sourceName   string
+	streamURL    string
+	mobile       bool
 	
-	metadata    chunk.FileMetadata
 	currentFile *os.File
+	saveDir     string
+	autoSave    bool
+	downloadDir string
+	autoSaved   map[uint64]bool
+	
+	notify     bool
+	sound      bool
+	stallAfter time.Duration
+	notified   map[uint64]bool
+	tampered   map[uint64]bool
+	watchdog   *watchdog
+	health     *decodeHealth
+	
+	log       *logging.Log
+	logWin    fyne.Window
+	statusWin fyne.Window
+	keyWin    fyne.Window
+	calWin    fyne.Window
+	cal       *engine.Calibrator
+	
+	trayMenu     *fyne.Menu
+	trayStatus   *fyne.MenuItem
+	trayPause    *fyne.MenuItem
+	trayProgress string
+	
+	audit    *audit.Log
+	auditWin fyne.Window
+	
+	quarantine engine.Quarantine
+	
+	passEntry    *widget.Entry
+	rememberPass *widget.Check
+	passSave     *time.Timer
+	
+	theme      string
+	configPath string
 }
 
-func NewReceiverApp() *ReceiverApp {
+const windowTitle = "QR File Receiver"
+
+func NewReceiverApp(log *logging.Log, cfg config.Config, configPath string) *ReceiverApp {
 	a := app.New()
-	w := a.NewWindow("QR File Receiver")
+	w := a.NewWindow(windowTitle)
 	
 	receiver := &ReceiverApp{
 		app:        a,
 		window:     w,
 		screenCap:  screen.NewCapturer(screen.CaptureConfig{FPS: 10}),
-		qrDec:      qr.NewDecoder(qr.Config{}),
-		chunkProc:  chunk.NewProcessor(chunk.NewConfig(100, 1)),
-		
-		receivedChunks: make(map[uint32][]chunk.Chunk),
-		captureRate:    500 * time.Millisecond,
-		running:        false,
+		engine:     engine.NewReceiver(),
+		metrics:    screen.NewMetrics(),
+		fps:        2,
+		autoFPS:    true,
+		blockSize:  engine.DefaultBlockSize,
+		hideCursor: true,
+		maskSelf:   true,
+		trackCode:  true,
+		autoSaved:  make(map[uint64]bool),
+		notified:   make(map[uint64]bool),
+		tampered:   make(map[uint64]bool),
+		log:        log,
+		audit:      openAudit(),
+		theme:      cfg.Theme,
+		configPath: configPath,
+	}
+	receiver.mobile = fyne.CurrentDevice().IsMobile()
+	receiver.applySettings(cfg.Receiver)
+	if path, err := config.SnapshotPath(); err == nil {
+		receiver.engine.SnapshotPath = path
+	} else {
+		slog.Warn("receive snapshots disabled", "err", err)
+	}
+	if dir, err := config.SpoolDir(); err == nil {
+		receiver.engine.SpoolDir = dir
+	} else {
+		slog.Warn("chunk spool disabled, received chunks stay in memory", "err", err)
 	}
 	
 	receiver.setupUI()
+	receiver.setupTray()
+	go receiver.watchStats()
 	
 	return receiver
 }
@@ -68,38 +166,120 @@ func (r *ReceiverApp) setupUI() {
 	
 	r.preview.SetMinSize(fyne.NewSize(400, 400))
 	
-	startBtn := widget.NewButton("Start Capture", r.startCapture)
-	startBtn.Disable()
+	r.overlay = newRegionOverlay()
+	r.overlay.OnChanged = r.setTargetRegion
+	r.align = newAlignOverlay()
+	
+	r.startBtn = widget.NewButton("Start Capture", r.startCapture)
+	
+	r.stopBtn = widget.NewButton("Stop Capture", r.stopCapture)
+	r.stopBtn.Disable()
 	
-	stopBtn := widget.NewButton("Stop Capture", r.stopCapture)
-	stopBtn.Disable()
+	r.saveBtn = widget.NewButton("Save File", r.saveFile)
+	r.saveBtn.Disable()
 	
-	saveBtn := widget.NewButton("Save File", r.saveFile)
-	saveBtn.Disable()
+	r.copyBtn = widget.NewButton("Copy to Clipboard", r.copyText)
+	r.copyBtn.Disable()
 	
 	r.status = widget.NewLabel("Not capturing")
 	r.progress = widget.NewProgressBar()
+	r.stats = newStatsPanel()
+	r.watchdog = newWatchdog()
+	r.perfLabel = widget.NewLabel("")
 	
-	rateSlider := widget.NewSlider(0.2, 2.0)
-	rateSlider.Value = 0.5
-	rateSlider.OnChanged = func(value float64) {
-		r.captureRate = time.Duration(value * float64(time.Second))
+	r.regionLabel = widget.NewLabel("Region: full screen")
+	clearRegionBtn := widget.NewButton("Clear", func() {
+		r.setTargetRegion(image.Rectangle{})
+	})
+	
+	sources := r.sourceNames()
+	sourceSelect := widget.NewSelect(sources, r.selectSource)
+	if name := initialSource(r.sourceName, sources); name != "" {
+		sourceSelect.SetSelected(name)
+	}
+	
+	cursorCheck := widget.NewCheck("Hide cursor", func(on bool) {
+		r.hideCursor = on
+	})
+	cursorCheck.SetChecked(r.hideCursor)
+	
+	maskCheck := widget.NewCheck("Mask receiver window", func(on bool) {
+		r.maskSelf = on
+	})
+	maskCheck.SetChecked(r.maskSelf)
+	
+	trackCheck := widget.NewCheck("Capture only around the code", func(on bool) {
+		r.trackCode = on
+	})
+	trackCheck.SetChecked(r.trackCode)
+	
+	r.rateSlider = widget.NewSlider(1, engine.MaxCaptureFPS)
+	r.rateSlider.Value = float64(r.fps)
+	r.rateSlider.OnChanged = func(value float64) {
+		r.fps = int(value)
+		if r.capturing() && r.metrics.TargetFPS() > 0 {
+			r.metrics.SetTargetFPS(r.fps)
+		}
+	}
+	
+	autoFPSCheck := widget.NewCheck("Adjust capture rate automatically", func(on bool) {
+		r.autoFPS = on
+	})
+	autoFPSCheck.SetChecked(r.autoFPS)
+	
+	regionRow := container.NewBorder(nil, nil, nil, clearRegionBtn, r.regionLabel)
+	if r.mobile {
+		regionRow.Hide()
+		cursorCheck.Hide()
+		maskCheck.Hide()
+		trackCheck.Hide()
 	}
 	
 	controls := container.NewVBox(
-		widget.NewLabel("Capture Rate (seconds):"),
-		rateSlider,
-		startBtn,
-		stopBtn,
-		saveBtn,
+		widget.NewLabel("Source:"),
+		sourceSelect,
+		regionRow,
+		widget.NewLabel("Capture Rate (FPS):"),
+		r.rateSlider,
+		autoFPSCheck,
+		cursorCheck,
+		maskCheck,
+		trackCheck,
+		r.setupPassphrase(),
+		r.setupPairing(),
+		r.startBtn,
+		r.stopBtn,
+		widget.NewButton("Decode Pages...", r.pickPages),
+		r.setupSessions(),
+		r.saveBtn,
+		r.setupAutoSave(),
+		r.setupNotify(),
+		r.copyBtn,
 		r.status,
+		r.setupVerification(),
 		r.progress,
+		r.setupChunkMap(),
+		r.stats.content(),
+		r.setupTuning(),
+		r.perfLabel,
+		widget.NewLabel("Theme:"),
+		r.setupTheme(),
+		widget.NewButton("Save Settings as Default", r.saveDefaults),
+		widget.NewButton("Show Status Code", r.showStatusCode),
+		widget.NewButton("Show Key Code", r.showKeyCode),
+		widget.NewButton("Receive Policy...", r.showPolicy),
+		widget.NewButton("Calibrate...", r.showCalibration),
+		widget.NewButton("Show Log", r.showLog),
+		widget.NewButton("Audit Log...", r.showAudit),
+		widget.NewButton("Keys...", r.showKeys),
 	)
 	
-	content := container.NewHSplit(
-		container.NewCenter(r.preview),
-		controls,
-	)
+	preview := container.NewCenter(container.NewStack(r.preview, r.align, r.overlay))
+	var body fyne.CanvasObject = container.NewHSplit(preview, controls)
+	if r.mobile {
+		body = mobileLayout(preview, controls)
+	}
+	content := container.NewBorder(r.watchdog.box, nil, nil, nil, body)
 	
 	r.window.SetContent(content)
 	r.window.Resize(fyne.NewSize(800, 600))
@@ -108,95 +288,270 @@ func (r *ReceiverApp) setupUI() {
 	r.preview.Refresh()
 }
 
-func (r *ReceiverApp) startCapture() {
-	if r.running {
-		return
-	}
+func (r *ReceiverApp) setTargetRegion(rect image.Rectangle) {
+	restart := r.capturing()
+	r.stopCapture()
 	
-	r.running = true
+	r.targetRegion = rect
+	if rect.Empty() {
+		r.regionLabel.SetText("Region: full screen")
+	} else {
+		r.regionLabel.SetText(fmt.Sprintf("Region: %dx%d at (%d, %d)", rect.Dx(), rect.Dy(), rect.Min.X, rect.Min.Y))
+	}
+	r.overlay.SetSelection(rect)
 	
-	go r.captureLoop()
+	if restart {
+		r.startCapture()
+	}
 }
 
-func (r *ReceiverApp) stopCapture() {
-	r.running = false
+func (r *ReceiverApp) updateControls() {
+	if r.startBtn == nil {
+		return
+	}
+	
+	if r.capturing() {
+		r.startBtn.Disable()
+		r.stopBtn.Enable()
+	} else {
+		r.startBtn.Enable()
+		r.stopBtn.Disable()
+	}
+	r.updateTray()
 }
 
-func (r *ReceiverApp) captureLoop() {
-	for r.running {
-		r.captureFrame()
-		time.Sleep(r.captureRate)
+func (r *ReceiverApp) capturing() bool {
+	if r.cancel == nil {
+		return false
+	}
+	
+	select {
+	case <-r.done:
+		r.cancel()
+		r.cancel = nil
+		return false
+	default:
+		return true
 	}
 }
 
-func (r *ReceiverApp) captureFrame() {
-	img, err := r.screenCap.CaptureRegion(r.targetRegion)
-	if err != nil {
+func (r *ReceiverApp) startCapture() {
+	if r.capturing() {
 		return
 	}
 	
-	if img == nil {
+	ctx, cancel := context.WithCancel(context.Background())
+	frames, err := r.openStream(ctx)
+	if err != nil {
+		cancel()
+		r.reportCaptureError(err)
 		return
 	}
 	
-	r.preview.Image = img
-	r.preview.Refresh()
-	
-	gridWidth, gridHeight := screen.EstimateGridSize(img, 20)
+	r.cancel = cancel
+	r.done = make(chan struct{})
 	
-	if gridWidth == 0 || gridHeight == 0 {
+	r.overlay.SetArea(r.previewArea())
+	r.engine.BlockSize = r.blockSize
+	r.engine.AutoFPS = r.autoFPS
+	go r.captureLoop(ctx, frames, r.metrics)
+	r.status.SetText("Capturing...")
+	r.updateControls()
+}
+
+func (r *ReceiverApp) stopCapture() {
+	if r.cancel == nil {
 		return
 	}
 	
-	r.qrDec = qr.NewDecoder(qr.Config{
-		GridWidth:  gridWidth,
-		GridHeight: gridHeight,
-		BorderSize: 1,
-	})
+	r.cancel()
+	<-r.done
+	r.cancel = nil
+	r.updateControls()
+}
+
+func (r *ReceiverApp) openStream(ctx context.Context) (<-chan screen.Frame, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	
-	blocks, err := r.qrDec.Decode(img)
-	if err != nil {
-		return
+	switch src := r.source.(type) {
+	case nil:
+		r.screenCap.Close()
+		config := screen.CaptureConfig{Region: r.targetRegion, FPS: r.fps, HideCursor: r.hideCursor, Track: r.trackCode}
+		if r.maskSelf {
+			config.MaskWindows = []screen.WindowMatcher{{Title: windowTitle}}
+		}
+		r.screenCap = screen.NewCapturer(config)
+		r.metrics = r.screenCap.Metrics()
+		return r.screenCap.Stream(ctx)
+	case *screen.VideoFile, *screen.ImageDir:
+		r.metrics = screen.NewMetrics()
+		return screen.StreamWithMetrics(ctx, src, 0, r.metrics)
+	default:
+		r.metrics = screen.NewMetrics()
+		return screen.StreamWithMetrics(ctx, src, r.fps, r.metrics)
 	}
+}
+
+func (r *ReceiverApp) captureLoop(ctx context.Context, frames <-chan screen.Frame, metrics *screen.Metrics) {
+	defer fyne.Do(r.updateControls)
+	defer close(r.done)
 	
-	data := r.qrDec.BlocksToData(blocks)
+	if err := r.engine.Run(ctx, frames, metrics, r); err == nil {
+		r.status.SetText("End of input")
+	}
+}
+
+func (r *ReceiverApp) Frame(img image.Image, results []engine.FrameResult, perf screen.MetricsSnapshot) {
+	r.preview.Image = img
+	r.preview.Refresh()
+	r.overlay.SetFrameSize(img.Bounds().Size())
+	r.calibrate(img)
+	r.align.Process(img)
+	r.handleResults(results)
+	r.updatePerf(perf)
+}
+
+func (r *ReceiverApp) handleResults(results []engine.FrameResult) {
+	if len(results) > 0 {
+		r.updateSessions()
+	}
 	
-	chunkData, err := r.chunkProc.DeserializeChunk(data)
-	if err != nil {
+	progressed := false
+	for _, res := range results {
+		if res.Auth.Rejected() {
+			r.reportAuth(res)
+			continue
+		}
+		if res.Inconsistent != nil {
+			r.reportInconsistent(res)
+		}
+		if res.Refused != nil {
+			r.reportRefused(res)
+			r.recordRefused(res)
+			continue
+		}
+		if res.Session != r.session {
+			continue
+		}
+		if res.Metadata {
+			r.saveBtn.Enable()
+		}
+		if res.Metadata && strings.HasPrefix(r.engine.Metadata().ContentType, "text/") {
+			r.copyBtn.Enable()
+		}
+		if res.Stored || res.Metadata {
+			r.updateStatus()
+		}
+		progressed = progressed || res.New || res.Metadata || chunk.IsParity(res.Chunk)
+	}
+	if len(results) > 0 {
+		r.chunkMap.SetStates(r.engine.ChunkStates())
+	}
+	if progressed && !r.notified[r.session] && r.engine.Complete() {
+		r.notified[r.session] = true
+		path := ""
+		switch {
+		case r.mobile:
+			fyne.Do(r.saveFile)
+		case r.autoSave && !r.autoSaved[r.session]:
+			path = r.autoSaveFile()
+		}
+		r.notifyComplete(path)
+	}
+	r.updateVerification()
+}
+
+func (r *ReceiverApp) CaptureError(err error) {
+	r.reportCaptureError(err)
+}
+
+func (r *ReceiverApp) reportCaptureError(err error) {
+	if errors.Is(err, screen.ErrNoSignal) {
+		r.status.SetText("No signal from the capture card: check the cable and that the sender's display output is on")
 		return
 	}
+	r.status.SetText(fmt.Sprintf("Capture error: %v", err))
 	
-	if !chunk.VerifyChunk(chunkData) {
+	var perr *screen.PermissionError
+	if !errors.As(err, &perr) {
 		return
 	}
 	
-	r.mu.Lock()
+	steps := ""
+	for i, step := range perr.Steps() {
+		steps += fmt.Sprintf("%d. %s\n", i+1, step)
+	}
 	
-	if chunkData.Index == 0 && r.metadata.TotalChunks == 0 {
-		metadataData, err := r.chunkProc.DeserializeMetadata(chunkData.Data)
-		if err == nil {
-			r.metadata = metadataData
-		}
+	message := widget.NewLabel(perr.Error() + "\n\n" + steps)
+	message.Wrapping = fyne.TextWrapWord
+	
+	title := "Screen Capture Permission"
+	if perr.Kind == screen.PermissionCamera {
+		title = "Camera Permission"
+		requestCameraAccess()
+	}
+	
+	if !perr.CanOpenSettings() {
+		d := dialog.NewCustom(title, "Close", message, r.window)
+		d.Resize(fyne.NewSize(480, 280))
+		d.Show()
+		return
 	}
 	
-	r.receivedChunks[chunkData.Index] = append(r.receivedChunks[chunkData.Index], chunkData)
+	d := dialog.NewCustomConfirm(title, "Open Settings", "Close", message, func(open bool) {
+		if open {
+			screen.OpenPermissionSettings(perr.Kind)
+		}
+	}, r.window)
+	d.Resize(fyne.NewSize(480, 280))
+	d.Show()
+}
+
+func (r *ReceiverApp) samples() []metrics.Sample {
+	r.mu.Lock()
+	m := r.metrics
 	r.mu.Unlock()
 	
-	r.updateStatus(chunkData.Total, uint32(len(r.receivedChunks)))
+	return r.engine.Metrics(m.Snapshot())
+}
+
+func (r *ReceiverApp) updatePerf(m screen.MetricsSnapshot) {
+	r.perfLabel.SetText(fmt.Sprintf(
+		"Capture: %d dropped, %d duplicates skipped\nLatency: capture %v, decode %v (max %v)\nBottleneck: %s",
+		m.Dropped, m.Duplicates,
+		m.CaptureLatency.Round(time.Millisecond), m.DecodeLatency.Round(time.Millisecond), m.MaxDecodeLatency.Round(time.Millisecond),
+		m.Bottleneck(),
+	))
+	
+	if r.autoFPS && m.TargetFPS > 0 {
+		fyne.Do(func() {
+			if m.TargetFPS != r.fps {
+				r.rateSlider.SetValue(float64(m.TargetFPS))
+			}
+		})
+	}
 }
 
-func (r *ReceiverApp) updateStatus(total, received uint32) {
-	percent := float64(0)
-	if total > 0 {
-		percent = float64(received) / float64(total) * 100
+func (r *ReceiverApp) updateStatus() {
+	p := r.engine.Progress()
+	if p.TotalChunks == 0 {
+		r.progress.SetValue(0)
+		r.status.SetText(fmt.Sprintf("Received %d chunks, waiting for file metadata", p.CurrentChunk))
+		r.setTrayProgress(fmt.Sprintf("Received %d chunks", p.CurrentChunk))
+		return
 	}
 	
+	fileSize := r.engine.Metadata().FileSize
+	percent := min(p.PercentComplete, 100)
 	r.progress.SetValue(percent / 100)
-	r.status.SetText(fmt.Sprintf("Received %d/%d chunks (%.1f%%)", received, total, percent))
+	r.status.SetText(fmt.Sprintf("Received %d/%d chunks, %s of %s (%.1f%%)",
+		p.CurrentChunk, p.TotalChunks, formatBytes(float64(p.BytesReceived)), formatBytes(float64(fileSize)), percent))
+	r.setTrayProgress(fmt.Sprintf("Received %d/%d chunks (%.0f%%)", p.CurrentChunk, p.TotalChunks, percent))
 }
 
 func (r *ReceiverApp) saveFile() {
-	dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+	d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
 		if err != nil {
 			dialog.ShowError(err, r.window)
 			return
@@ -204,53 +559,84 @@ func (r *ReceiverApp) saveFile() {
 		if writer == nil {
 			return
 		}
-		defer writer.Close()
 		
-		r.assembleFile(writer)
+		path, missing, err := r.writeFile(writer)
+		switch {
+		case err != nil:
+			slog.Error("writing received file failed", "err", err)
+			r.status.SetText(fmt.Sprintf("Error writing file: %v", err))
+		case missing > 0:
+			slog.Warn("saved file is incomplete", "missing", missing)
+			r.status.SetText(fmt.Sprintf("Warning: %d chunks missing", missing))
+		case path != uriPath(writer.URI()):
+			r.status.SetText("Held in quarantine at " + path)
+			r.discardSnapshot()
+		default:
+			r.status.SetText("File assembled successfully!")
+			r.recordReceive(path)
+			r.discardSnapshot()
+		}
+		r.updateVerification()
+		if err == nil && missing == 0 {
+			r.wipeSaved("File assembled successfully")
+		}
+		if err == nil && writer.URI().Scheme() == "file" {
+			r.saveDir = filepath.Dir(writer.URI().Path())
+		}
 	}, r.window)
+	
+	name := engine.SanitizeFilename(r.engine.Metadata().Filename)
+	if path, dir := r.saveLocation(); dir != nil {
+		d.SetLocation(dir)
+		name = filepath.Base(engine.UniquePath(path, name))
+	}
+	d.SetFileName(name)
+	if ext := filepath.Ext(name); ext != "" {
+		d.SetFilter(storage.NewExtensionFileFilter([]string{ext}))
+	}
+	d.Show()
 }
 
-func (r *ReceiverApp) assembleFile(writer fyne.URIWriteCloser) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	
-	missingChunks := make(map[uint32]bool)
-	for i := uint32(0); i < r.metadata.TotalChunks; i++ {
-		missingChunks[i] = true
+func (r *ReceiverApp) writeFile(writer fyne.URIWriteCloser) (string, int, error) {
+	path := uriPath(writer.URI())
+	if writer.URI().Scheme() != "file" {
+		defer writer.Close()
+		missing, err := r.engine.Assemble(writer)
+		return path, missing, err
 	}
 	
-	for i := uint32(0); i < r.metadata.TotalChunks; i++ {
-		chunks, ok := r.receivedChunks[i]
-		if !ok {
-			continue
-		}
-		
-		delete(missingChunks, i)
-		
-		if len(chunks) == 0 {
-			continue
-		}
-		
-		data := chunks[0].Data
-		for _, c := range chunks {
-			if chunk.VerifyChunk(c) {
-				data = c.Data
-				break
-			}
-		}
-		
-		_, err := writer.Write(data)
-		if err != nil {
-			r.status.SetText(fmt.Sprintf("Error writing file: %v", err))
-			return
-		}
+	writer.Close()
+	if r.quarantine.Enabled() {
+		removePlaceholder(path)
+		return r.quarantineFile(path)
+	}
+	missing, err := r.engine.SaveFile(path)
+	return path, missing, err
+}
+
+func (r *ReceiverApp) discardSnapshot() {
+	if r.engine.SnapshotPath == "" {
+		return
+	}
+	if err := os.Remove(r.engine.SnapshotPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("removing receive snapshot failed", "err", err)
+	}
+}
+
+func (r *ReceiverApp) copyText() {
+	var buf strings.Builder
+	missing, err := r.engine.Assemble(&buf)
+	if err != nil {
+		r.status.SetText(fmt.Sprintf("Error assembling text: %v", err))
+		return
 	}
 	
-	if len(missingChunks) > 0 {
-		r.status.SetText(fmt.Sprintf("Warning: %d chunks missing", len(missingChunks)))
-	} else {
-		r.status.SetText("File assembled successfully!")
+	r.app.Clipboard().SetContent(buf.String())
+	if missing > 0 {
+		r.status.SetText(fmt.Sprintf("Copied partial text to clipboard: %d chunks missing", missing))
+		return
 	}
+	r.status.SetText(fmt.Sprintf("Copied %d bytes to clipboard", buf.Len()))
 }
 
 func (r *ReceiverApp) createPlaceholderImage() image.Image {
@@ -266,10 +652,56 @@ func (r *ReceiverApp) createPlaceholderImage() image.Image {
 }
 
 func (r *ReceiverApp) Run() {
+	r.offerResume()
 	r.window.ShowAndRun()
 }
 
 func main() {
-	app := NewReceiverApp()
+	logLevel := flag.String("log-level", logging.DefaultLevel(), "log verbosity: debug, info, warn or error")
+	configFile := flag.String("config", "", "settings file (default ~/.config/owl-transfer/config.yaml)")
+	fps := flag.Int("fps", 0, "capture rate in frames per second, overriding the settings file")
+	saveDir := flag.String("save-dir", "", "initial directory for saved files, overriding the settings file")
+	source := flag.String("source", "", "capture source: \"Full Screen\", \"Display: <name>\" or a camera name, overriding the settings file")
+	theme := flag.String("theme", "", "UI theme: system, light, dark or high-contrast, overriding the settings file")
+	metricsAddr := flag.String("metrics", "", "serve Prometheus metrics at /metrics on this address, e.g. 127.0.0.1:9464")
+	flag.Parse()
+	
+	level, err := logging.ParseLevel(*logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "receiver:", err)
+		os.Exit(2)
+	}
+	log := logging.Setup(level, os.Stderr)
+	
+	configPath, err := config.Resolve(*configFile)
+	if err != nil {
+		slog.Warn("settings file unavailable", "err", err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		slog.Warn("using default settings", "err", err)
+	}
+	
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "fps":
+			cfg.Receiver.FPS = *fps
+		case "save-dir":
+			cfg.Receiver.SaveDir = *saveDir
+		case "source":
+			cfg.Receiver.Source = *source
+		case "theme":
+			cfg.Theme = *theme
+		}
+	})
+	
+	app := NewReceiverApp(log, cfg, configPath)
+	if *metricsAddr != "" {
+		go func() {
+			if err := metrics.Serve(*metricsAddr, app.samples); err != nil {
+				slog.Error("metrics endpoint stopped", "err", err)
+			}
+		}()
+	}
 	app.Run()
 }
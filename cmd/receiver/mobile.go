@@ -0,0 +1,24 @@
+package main
+
+import (
+	"log/slog"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+
+	"qrtransfer/pkg/screen"
+)
+
+func mobileSources() []string {
+	cameras, err := screen.ListCameras()
+	if err != nil {
+		slog.Warn("cannot list cameras", "err", err)
+	}
+	return append(cameras, sourceStream)
+}
+
+func mobileLayout(preview, controls fyne.CanvasObject) fyne.CanvasObject {
+	split := container.NewVSplit(preview, container.NewVScroll(controls))
+	split.Offset = 0.45
+	return split
+}
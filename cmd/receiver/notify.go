@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"runtime"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/engine"
+)
+
+func (r *ReceiverApp) setupNotify() fyne.CanvasObject {
+	notifyCheck := widget.NewCheck("Notify when done or stalled", func(on bool) {
+		r.notify = on
+	})
+	notifyCheck.SetChecked(r.notify)
+
+	soundCheck := widget.NewCheck("Play a sound", func(on bool) {
+		r.sound = on
+	})
+	soundCheck.SetChecked(r.sound)
+
+	return container.NewHBox(notifyCheck, soundCheck)
+}
+
+func (r *ReceiverApp) alert(title, content string) {
+	slog.Info(title, "detail", content)
+	if r.notify {
+		fyne.Do(func() {
+			r.app.SendNotification(fyne.NewNotification(title, content))
+		})
+	}
+	if r.sound {
+		go playSound()
+	}
+}
+
+func (r *ReceiverApp) notifyComplete(path string) {
+	name := r.engine.Metadata().Filename
+	content := name + " was received completely."
+	if path != "" {
+		content = fmt.Sprintf("%s was saved to %s.", name, path)
+	}
+	r.alert("Transfer complete", content)
+}
+
+func (r *ReceiverApp) reportInconsistent(res engine.FrameResult) {
+	r.status.SetText("Rejected: " + res.Inconsistent.Error())
+	if r.tampered[res.Session] {
+		return
+	}
+
+	r.tampered[res.Session] = true
+	r.alert("Transfer may have been tampered with", fmt.Sprintf("Session %d: %v. The conflicting data was ignored.", res.Session, res.Inconsistent))
+}
+
+func playSound() {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("afplay", "/System/Library/Sounds/Glass.aiff")
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", "[System.Media.SystemSounds]::Asterisk.Play()")
+	default:
+		cmd = exec.Command("canberra-gtk-play", "--id", "complete")
+		if _, err := exec.LookPath("canberra-gtk-play"); err != nil {
+			cmd = exec.Command("paplay", "/usr/share/sounds/freedesktop/stereo/complete.oga")
+		}
+	}
+
+	if err := cmd.Run(); err != nil {
+		slog.Debug("playing sound failed", "cmd", cmd.Path, "err", err)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/screen"
+)
+
+const handleSize = 10
+
+type dragMode int
+
+const (
+	dragNone dragMode = iota
+	dragNew
+	dragMove
+	dragCorner
+)
+
+type regionOverlay struct {
+	widget.BaseWidget
+
+	mu        sync.Mutex
+	area      image.Rectangle
+	frame     image.Point
+	selection image.Rectangle
+
+	mode   dragMode
+	corner int
+	start  fyne.Position
+	origin image.Rectangle
+
+	outline *canvas.Rectangle
+	handles [4]*canvas.Rectangle
+
+	OnChanged func(image.Rectangle)
+}
+
+func newRegionOverlay() *regionOverlay {
+	o := &regionOverlay{
+		outline: canvas.NewRectangle(color.NRGBA{R: 64, G: 160, B: 255, A: 40}),
+	}
+	o.outline.StrokeColor = color.NRGBA{R: 64, G: 160, B: 255, A: 255}
+	o.outline.StrokeWidth = 2
+	for i := range o.handles {
+		o.handles[i] = canvas.NewRectangle(color.NRGBA{R: 64, G: 160, B: 255, A: 255})
+		o.handles[i].Resize(fyne.NewSize(handleSize, handleSize))
+	}
+	o.ExtendBaseWidget(o)
+	return o
+}
+
+func (o *regionOverlay) CreateRenderer() fyne.WidgetRenderer {
+	objects := []fyne.CanvasObject{o.outline}
+	for _, h := range o.handles {
+		objects = append(objects, h)
+	}
+	return &overlayRenderer{overlay: o, objects: objects}
+}
+
+func (o *regionOverlay) Cursor() desktop.Cursor {
+	return desktop.CrosshairCursor
+}
+
+func (o *regionOverlay) SetArea(area image.Rectangle) {
+	o.mu.Lock()
+	o.area = area
+	o.mu.Unlock()
+
+	o.Refresh()
+}
+
+func (o *regionOverlay) SetSelection(selection image.Rectangle) {
+	o.mu.Lock()
+	o.selection = selection
+	o.mu.Unlock()
+
+	o.Refresh()
+}
+
+func (o *regionOverlay) SetFrameSize(size image.Point) {
+	o.mu.Lock()
+	changed := size != o.frame
+	o.frame = size
+	o.mu.Unlock()
+
+	if changed {
+		fyne.Do(o.Refresh)
+	}
+}
+
+func (o *regionOverlay) imageRect() (fyne.Position, fyne.Size, bool) {
+	size := o.Size()
+	if o.area.Empty() || o.frame.X <= 0 || o.frame.Y <= 0 || size.Width <= 0 || size.Height <= 0 {
+		return fyne.Position{}, fyne.Size{}, false
+	}
+
+	scale := math.Min(float64(size.Width)/float64(o.frame.X), float64(size.Height)/float64(o.frame.Y))
+	shown := fyne.NewSize(float32(float64(o.frame.X)*scale), float32(float64(o.frame.Y)*scale))
+	offset := fyne.NewPos((size.Width-shown.Width)/2, (size.Height-shown.Height)/2)
+	return offset, shown, true
+}
+
+func (o *regionOverlay) toScreen(pos fyne.Position) image.Point {
+	offset, shown, _ := o.imageRect()
+	fx := math.Max(0, math.Min(1, float64((pos.X-offset.X)/shown.Width)))
+	fy := math.Max(0, math.Min(1, float64((pos.Y-offset.Y)/shown.Height)))
+	return image.Pt(
+		o.area.Min.X+int(math.Round(fx*float64(o.area.Dx()))),
+		o.area.Min.Y+int(math.Round(fy*float64(o.area.Dy()))),
+	)
+}
+
+func (o *regionOverlay) toWidget(p image.Point) fyne.Position {
+	offset, shown, _ := o.imageRect()
+	return fyne.NewPos(
+		offset.X+float32(p.X-o.area.Min.X)/float32(o.area.Dx())*shown.Width,
+		offset.Y+float32(p.Y-o.area.Min.Y)/float32(o.area.Dy())*shown.Height,
+	)
+}
+
+func corners(r image.Rectangle) [4]image.Point {
+	return [4]image.Point{r.Min, {r.Max.X, r.Min.Y}, {r.Min.X, r.Max.Y}, r.Max}
+}
+
+func (o *regionOverlay) Dragged(ev *fyne.DragEvent) {
+	o.mu.Lock()
+	if _, _, ok := o.imageRect(); !ok {
+		o.mu.Unlock()
+		return
+	}
+
+	if o.mode == dragNone {
+		o.start = ev.Position.Subtract(ev.Dragged)
+		o.origin = o.selection
+		o.mode = dragNew
+		if !o.selection.Empty() {
+			for i, c := range corners(o.selection) {
+				p := o.toWidget(c)
+				if math.Abs(float64(p.X-o.start.X)) <= handleSize && math.Abs(float64(p.Y-o.start.Y)) <= handleSize {
+					o.mode, o.corner = dragCorner, i
+				}
+			}
+			if o.mode == dragNew && o.toScreen(o.start).In(o.selection) {
+				o.mode = dragMove
+			}
+		}
+	}
+
+	from, to := o.toScreen(o.start), o.toScreen(ev.Position)
+	switch o.mode {
+	case dragNew:
+		o.selection = image.Rectangle{Min: from, Max: to}.Canon()
+	case dragMove:
+		moved := o.origin.Add(to.Sub(from))
+		moved = moved.Sub(image.Pt(max(0, moved.Max.X-o.area.Max.X), max(0, moved.Max.Y-o.area.Max.Y)))
+		moved = moved.Add(image.Pt(max(0, o.area.Min.X-moved.Min.X), max(0, o.area.Min.Y-moved.Min.Y)))
+		o.selection = moved
+	case dragCorner:
+		c := corners(o.origin)
+		opposite := c[3-o.corner]
+		o.selection = image.Rectangle{Min: opposite, Max: c[o.corner].Add(to.Sub(from))}.Canon().Intersect(o.area)
+	}
+	o.mu.Unlock()
+
+	o.Refresh()
+}
+
+func (o *regionOverlay) DragEnd() {
+	o.mu.Lock()
+	mode := o.mode
+	o.mode = dragNone
+	selection := o.selection
+	if mode != dragNone && (selection.Dx() < minRegionSize || selection.Dy() < minRegionSize) {
+		o.selection = o.origin
+		mode = dragNone
+	}
+	o.mu.Unlock()
+
+	if mode == dragNone {
+		o.Refresh()
+		return
+	}
+	if o.OnChanged != nil {
+		o.OnChanged(selection)
+	}
+}
+
+type overlayRenderer struct {
+	overlay *regionOverlay
+	objects []fyne.CanvasObject
+}
+
+func (r *overlayRenderer) Layout(fyne.Size) {
+	o := r.overlay
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	_, _, ok := o.imageRect()
+	if !ok || o.selection.Empty() {
+		for _, obj := range r.objects {
+			obj.Hide()
+		}
+		return
+	}
+
+	topLeft, bottomRight := o.toWidget(o.selection.Min), o.toWidget(o.selection.Max)
+	o.outline.Move(topLeft)
+	o.outline.Resize(fyne.NewSize(bottomRight.X-topLeft.X, bottomRight.Y-topLeft.Y))
+	o.outline.Show()
+	for i, c := range corners(o.selection) {
+		p := o.toWidget(c)
+		o.handles[i].Move(fyne.NewPos(p.X-handleSize/2, p.Y-handleSize/2))
+		o.handles[i].Show()
+	}
+}
+
+func (r *overlayRenderer) MinSize() fyne.Size {
+	return fyne.Size{}
+}
+
+func (r *overlayRenderer) Refresh() {
+	r.Layout(r.overlay.Size())
+	for _, obj := range r.objects {
+		obj.Refresh()
+	}
+}
+
+func (r *overlayRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *overlayRenderer) Destroy() {}
+
+func (r *ReceiverApp) previewArea() image.Rectangle {
+	r.mu.Lock()
+	screenSource := r.source == nil
+	r.mu.Unlock()
+
+	switch {
+	case !screenSource:
+		return image.Rectangle{}
+	case !r.targetRegion.Empty():
+		return r.targetRegion
+	}
+	return screenArea()
+}
+
+func screenArea() image.Rectangle {
+	displays, err := screen.ListDisplays()
+	if err != nil {
+		return image.Rectangle{}
+	}
+
+	var area image.Rectangle
+	for _, d := range displays {
+		area = area.Union(d.Bounds)
+	}
+	return area
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/screen"
+)
+
+func (r *ReceiverApp) pickPages() {
+	dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil || dir == nil {
+			return
+		}
+
+		images, err := screen.OpenImageDir(screen.ImageDirConfig{Path: dir.Path()})
+		if err != nil {
+			r.status.SetText(fmt.Sprintf("Page folder error: %v", err))
+			return
+		}
+		r.decodePages(images.Files())
+	}, r.window)
+}
+
+func (r *ReceiverApp) decodePages(files []string) {
+	r.stopCapture()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	r.updateControls()
+
+	go func() {
+		defer fyne.Do(r.updateControls)
+		defer close(r.done)
+
+		read := 0
+		pages := r.engine.DecodePages(ctx, files, func(p engine.PageResult) {
+			read++
+			r.status.SetText(fmt.Sprintf("Read page %d of %d: %s", read, len(files), filepath.Base(p.Path)))
+			r.handleResults(p.Results())
+		})
+		report := engine.FormatPages(pages, r.engine.Missing())
+		fyne.Do(func() { r.showPageReport(report) })
+	}()
+}
+
+func (r *ReceiverApp) showPageReport(report string) {
+	text := widget.NewLabel(report)
+	text.TextStyle = fyne.TextStyle{Monospace: true}
+
+	d := dialog.NewCustom("Page Report", "Close", container.NewVScroll(text), r.window)
+	d.Resize(fyne.NewSize(560, 400))
+	d.Show()
+}
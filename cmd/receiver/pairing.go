@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/secure"
+)
+
+func (r *ReceiverApp) setupPairing() fyne.CanvasObject {
+	info := widget.NewLabel("")
+	info.Wrapping = fyne.TextWrapWord
+
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("Optional: the sender's token, or New")
+	entry.OnChanged = func(text string) {
+		token, err := secure.ParsePairingToken(text)
+		switch {
+		case strings.TrimSpace(text) == "":
+			info.SetText("")
+			r.engine.SetPairing(nil)
+		case err != nil:
+			info.SetText(err.Error())
+			r.engine.SetPairing(nil)
+		default:
+			info.SetText("Pairing...")
+			go r.pair(token, entry, info)
+		}
+	}
+	newBtn := widget.NewButton("New", func() {
+		token, err := secure.NewPairingToken()
+		if err != nil {
+			info.SetText(err.Error())
+			return
+		}
+		entry.SetText(token)
+	})
+
+	return container.NewVBox(
+		widget.NewLabel("Pairing Token:"),
+		container.NewBorder(nil, nil, nil, newBtn, entry),
+		info,
+	)
+}
+
+func (r *ReceiverApp) pair(token string, entry *widget.Entry, info *widget.Label) {
+	pairing, err := secure.Pair(token)
+	fyne.Do(func() {
+		if current, _ := secure.ParsePairingToken(entry.Text); current != token {
+			return
+		}
+		if err != nil {
+			info.SetText(err.Error())
+			return
+		}
+		r.engine.SetPairing(pairing)
+		info.SetText("Accepting only transfers sent with token " + token)
+	})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/screen"
+)
+
+const minRegionSize = 16
+
+type regionPicker struct {
+	widget.BaseWidget
+
+	background *canvas.Image
+	shade      *canvas.Rectangle
+	selection  *canvas.Rectangle
+
+	start    fyne.Position
+	end      fyne.Position
+	dragging bool
+
+	onPicked func(x0, y0, x1, y1 float64)
+}
+
+func newRegionPicker(screenshot image.Image, onPicked func(x0, y0, x1, y1 float64)) *regionPicker {
+	p := &regionPicker{
+		background: canvas.NewImageFromImage(screenshot),
+		shade:      canvas.NewRectangle(color.NRGBA{A: 96}),
+		selection:  canvas.NewRectangle(color.NRGBA{R: 64, G: 160, B: 255, A: 48}),
+		onPicked:   onPicked,
+	}
+	p.background.FillMode = canvas.ImageFillStretch
+	p.selection.StrokeColor = color.NRGBA{R: 64, G: 160, B: 255, A: 255}
+	p.selection.StrokeWidth = 2
+	p.selection.Hide()
+
+	p.ExtendBaseWidget(p)
+	return p
+}
+
+func (p *regionPicker) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewStack(
+		p.background,
+		p.shade,
+		container.NewWithoutLayout(p.selection),
+	))
+}
+
+func (p *regionPicker) Cursor() desktop.Cursor {
+	return desktop.CrosshairCursor
+}
+
+func (p *regionPicker) Dragged(ev *fyne.DragEvent) {
+	if !p.dragging {
+		p.start = ev.Position.Subtract(ev.Dragged)
+		p.dragging = true
+		p.selection.Show()
+	}
+	p.end = ev.Position
+
+	minX, minY := math.Min(float64(p.start.X), float64(p.end.X)), math.Min(float64(p.start.Y), float64(p.end.Y))
+	maxX, maxY := math.Max(float64(p.start.X), float64(p.end.X)), math.Max(float64(p.start.Y), float64(p.end.Y))
+
+	p.selection.Move(fyne.NewPos(float32(minX), float32(minY)))
+	p.selection.Resize(fyne.NewSize(float32(maxX-minX), float32(maxY-minY)))
+	p.selection.Refresh()
+}
+
+func (p *regionPicker) DragEnd() {
+	if !p.dragging {
+		return
+	}
+	p.dragging = false
+
+	size := p.Size()
+	if size.Width <= 0 || size.Height <= 0 {
+		return
+	}
+
+	fraction := func(v, total float32) float64 {
+		return math.Max(0, math.Min(1, float64(v/total)))
+	}
+
+	x0, x1 := fraction(p.start.X, size.Width), fraction(p.end.X, size.Width)
+	y0, y1 := fraction(p.start.Y, size.Height), fraction(p.end.Y, size.Height)
+
+	p.onPicked(math.Min(x0, x1), math.Min(y0, y1), math.Max(x0, x1), math.Max(y0, y1))
+}
+
+func (r *ReceiverApp) pickRegion() {
+	capturer := screen.NewCapturer(screen.CaptureConfig{})
+	defer capturer.Close()
+
+	shot, err := capturer.Capture()
+	if err != nil {
+		r.reportCaptureError(err)
+		return
+	}
+	if shot == nil {
+		r.status.SetText("Screen capture is not available on this platform")
+		return
+	}
+	area := capturer.LogicalRect(shot.Bounds())
+
+	overlay := r.app.NewWindow("Select Capture Region")
+	picker := newRegionPicker(shot, func(x0, y0, x1, y1 float64) {
+		overlay.Close()
+
+		rect := image.Rect(
+			area.Min.X+int(x0*float64(area.Dx())),
+			area.Min.Y+int(y0*float64(area.Dy())),
+			area.Min.X+int(math.Ceil(x1*float64(area.Dx()))),
+			area.Min.Y+int(math.Ceil(y1*float64(area.Dy()))),
+		)
+		if rect.Dx() < minRegionSize || rect.Dy() < minRegionSize {
+			return
+		}
+		r.setTargetRegion(rect)
+	})
+
+	overlay.Canvas().SetOnTypedKey(func(ev *fyne.KeyEvent) {
+		if ev.Name == fyne.KeyEscape {
+			overlay.Close()
+		}
+	})
+	overlay.SetContent(picker)
+	overlay.SetPadded(false)
+	overlay.SetFullScreen(true)
+	overlay.Show()
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/config"
+	"qrtransfer/pkg/engine"
+)
+
+func (r *ReceiverApp) applyPolicy(cfg config.Receiver) {
+	maxSize, err := engine.ParseSize(cfg.MaxFileSize)
+	if err != nil {
+		slog.Warn("ignoring max file size from settings", "err", err)
+	}
+	r.engine.SetPolicy(engine.Policy{
+		MaxSize:           maxSize,
+		Extensions:        cfg.AllowedExtensions,
+		ContentTypes:      cfg.AllowedTypes,
+		RejectExecutables: cfg.RejectExecutables,
+	})
+}
+
+func (r *ReceiverApp) showPolicy() {
+	p := r.engine.Policy()
+
+	sizeEntry := widget.NewEntry()
+	sizeEntry.SetPlaceHolder("No limit, or a size such as 20MB")
+	sizeEntry.SetText(formatLimit(p.MaxSize))
+	extEntry := widget.NewEntry()
+	extEntry.SetPlaceHolder("Any, or a list such as txt, pdf, png")
+	extEntry.SetText(strings.Join(p.Extensions, ", "))
+	typeEntry := widget.NewEntry()
+	typeEntry.SetPlaceHolder("Any, or a list such as text/*, application/pdf")
+	typeEntry.SetText(strings.Join(p.ContentTypes, ", "))
+	execCheck := widget.NewCheck("Refuse executables, scripts and installers", nil)
+	execCheck.SetChecked(p.RejectExecutables)
+
+	holdCheck := widget.NewCheck("Save into a private quarantine folder", nil)
+	holdCheck.SetChecked(r.quarantine.Enabled())
+	holdEntry := widget.NewEntry()
+	holdEntry.SetText(r.quarantine.Dir)
+	if dir, err := config.QuarantineDir(); err == nil {
+		holdEntry.SetPlaceHolder(dir)
+	}
+	hookEntry := widget.NewEntry()
+	hookEntry.SetPlaceHolder("Optional, such as clamscan --no-summary {}")
+	hookEntry.SetText(r.quarantine.Hook)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Max file size", sizeEntry),
+		widget.NewFormItem("Allowed extensions", extEntry),
+		widget.NewFormItem("Allowed types", typeEntry),
+		widget.NewFormItem("", execCheck),
+		widget.NewFormItem("", holdCheck),
+		widget.NewFormItem("Quarantine folder", holdEntry),
+		widget.NewFormItem("Release command", hookEntry),
+	}
+	items[len(items)-1].HintText = "Exit status 0 moves the file out of quarantine, anything else deletes it"
+	dialog.ShowForm("Receive Policy", "Apply", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		maxSize, err := engine.ParseSize(sizeEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, r.window)
+			return
+		}
+		r.engine.SetPolicy(engine.Policy{
+			MaxSize:           maxSize,
+			Extensions:        splitList(extEntry.Text),
+			ContentTypes:      splitList(typeEntry.Text),
+			RejectExecutables: execCheck.Checked,
+		})
+		r.applyQuarantine(config.Receiver{
+			Quarantine:      holdCheck.Checked,
+			QuarantineDir:   strings.TrimSpace(holdEntry.Text),
+			PostReceiveHook: strings.TrimSpace(hookEntry.Text),
+		})
+		r.status.SetText("Receive policy applied to new transfers")
+	}, r.window)
+}
+
+func (r *ReceiverApp) reportRefused(res engine.FrameResult) {
+	r.status.SetText(fmt.Sprintf("Refused: %v", res.Refused))
+	r.alert("Transfer refused", res.Refused.Error())
+}
+
+func formatLimit(n uint64) string {
+	switch {
+	case n == 0:
+		return ""
+	case n%(1<<30) == 0:
+		return fmt.Sprintf("%dGB", n>>30)
+	case n%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", n>>10)
+	}
+	return fmt.Sprint(n)
+}
+
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"fyne.io/fyne/v2"
+
+	"qrtransfer/pkg/audit"
+	"qrtransfer/pkg/config"
+	"qrtransfer/pkg/engine"
+)
+
+func (r *ReceiverApp) applyQuarantine(cfg config.Receiver) {
+	r.quarantine = engine.Quarantine{Hook: cfg.PostReceiveHook}
+	if !cfg.Quarantine {
+		return
+	}
+
+	r.quarantine.Dir = cfg.QuarantineDir
+	if r.quarantine.Dir == "" {
+		dir, err := config.QuarantineDir()
+		if err != nil {
+			slog.Warn("quarantine disabled", "err", err)
+			return
+		}
+		r.quarantine.Dir = dir
+	}
+}
+
+func (r *ReceiverApp) quarantineFile(dest string) (string, int, error) {
+	held, missing, err := r.engine.SaveQuarantined(r.quarantine)
+	if err != nil {
+		return "", 0, err
+	}
+
+	r.recordReceive(held)
+	if r.quarantine.Hook != "" {
+		e := audit.Entry{Session: r.session, File: r.engine.Metadata().Filename}
+		go r.release(r.quarantine, held, dest, e)
+	}
+	return held, missing, nil
+}
+
+func (r *ReceiverApp) release(q engine.Quarantine, held, dest string, e audit.Entry) {
+	fyne.Do(func() { r.status.SetText("Checking " + held + "...") })
+	path, err := q.Release(context.Background(), held, dest)
+
+	fyne.Do(func() {
+		switch {
+		case errors.Is(err, engine.ErrHookRejected):
+			r.status.SetText("Deleted: " + err.Error())
+			r.alert("File deleted", err.Error())
+			e.Event, e.Path = audit.EventRefused, held
+			e.Settings = map[string]string{"reason": err.Error(), "hook": q.Hook, "tool": "receiver"}
+			r.recordQuarantine(e)
+		case err != nil:
+			slog.Error("releasing quarantined file failed", "path", held, "err", err)
+			r.status.SetText(fmt.Sprintf("Release failed, the file is still in %s: %v", held, err))
+		default:
+			r.status.SetText("Released to " + path)
+			r.alert("File released", fmt.Sprintf("%s passed the post-receive check and was moved to %s.", held, path))
+			e.Event, e.Path, e.Note = audit.EventNote, path, "released from quarantine"
+			e.Settings = map[string]string{"quarantined_as": held, "hook": q.Hook, "tool": "receiver"}
+			r.recordQuarantine(e)
+		}
+	})
+}
+
+func (r *ReceiverApp) recordQuarantine(e audit.Entry) {
+	if r.audit != nil {
+		r.recordAudit(e)
+	}
+}
+
+func removePlaceholder(path string) {
+	if info, err := os.Stat(path); err == nil && info.Size() == 0 {
+		os.Remove(path)
+	}
+}
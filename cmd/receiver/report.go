@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+
+	"qrtransfer/pkg/engine"
+)
+
+func (r *ReceiverApp) exportReport() {
+	rep := r.engine.Report()
+	if rep.TotalChunks == 0 {
+		r.status.SetText("No transfer to report on yet")
+		return
+	}
+
+	d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, r.window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if strings.EqualFold(writer.URI().Extension(), ".csv") {
+			err = rep.WriteCSV(writer)
+		} else {
+			err = rep.WriteJSON(writer)
+		}
+		if err != nil {
+			slog.Error("exporting report failed", "err", err)
+			r.status.SetText(fmt.Sprintf("Error exporting report: %v", err))
+			return
+		}
+		r.status.SetText("Report exported to " + writer.URI().Name())
+	}, r.window)
+
+	base := engine.SanitizeFilename(rep.Filename)
+	name := strings.TrimSuffix(base, filepath.Ext(base)) + "-report.json"
+	if path, dir := r.saveLocation(); dir != nil {
+		d.SetLocation(dir)
+		name = filepath.Base(engine.UniquePath(path, name))
+	}
+	d.SetFileName(name)
+	d.SetFilter(storage.NewExtensionFileFilter([]string{".json", ".csv"}))
+	d.Show()
+}
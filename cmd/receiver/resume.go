@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"fyne.io/fyne/v2/dialog"
+
+	"qrtransfer/pkg/engine"
+)
+
+func (r *ReceiverApp) offerResume() {
+	path := r.engine.SnapshotPath
+	if path == "" {
+		r.pruneSpool()
+		return
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		r.pruneSpool()
+		return
+	}
+
+	prev := engine.NewReceiver()
+	if err == nil {
+		err = prev.ReadSnapshot(path)
+		prev.Close()
+	}
+	if err != nil {
+		slog.Warn("discarding unreadable receive snapshot", "path", path, "err", err)
+		r.discardSnapshot()
+		r.pruneSpool()
+		return
+	}
+
+	metadata := prev.Metadata()
+	if prev.Received() == 0 && metadata.TotalChunks == 0 {
+		r.discardSnapshot()
+		r.pruneSpool()
+		return
+	}
+
+	name := metadata.Filename
+	if name == "" {
+		name = "an unknown file"
+	}
+	message := fmt.Sprintf("A previous transfer of %s was interrupted on %s with %d of %d chunks received.\n\nResume it?",
+		name, info.ModTime().Format(time.DateTime), prev.Received(), metadata.TotalChunks)
+
+	dialog.ShowConfirm("Resume Previous Session", message, func(resume bool) {
+		if resume {
+			r.resume()
+		} else {
+			r.discardSnapshot()
+		}
+		r.pruneSpool()
+	}, r.window)
+}
+
+func (r *ReceiverApp) pruneSpool() {
+	if err := r.engine.PruneSpool(); err != nil {
+		slog.Warn("cleaning up chunk spool failed", "dir", r.engine.SpoolDir, "err", err)
+	}
+}
+
+func (r *ReceiverApp) resume() {
+	if err := r.engine.ReadSnapshot(r.engine.SnapshotPath); err != nil {
+		slog.Error("resuming previous session failed", "err", err)
+		r.status.SetText(fmt.Sprintf("Resume failed: %v", err))
+		return
+	}
+
+	slog.Info("previous session resumed", "file", r.engine.Metadata().Filename, "chunks", r.engine.Received())
+	r.updateSessions()
+	r.showSession()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/engine"
+)
+
+func (r *ReceiverApp) setupSessions() fyne.CanvasObject {
+	r.sessionSelect = widget.NewSelect(nil, func(string) {
+		if i := r.sessionSelect.SelectedIndex(); i >= 0 && i < len(r.sessionIDs) {
+			r.selectSession(r.sessionIDs[i])
+		}
+	})
+
+	r.sessionBox = container.NewVBox(widget.NewLabel("Transfer:"), r.sessionSelect)
+	r.sessionBox.Hide()
+	return r.sessionBox
+}
+
+func sessionLabel(info engine.SessionInfo) string {
+	name := info.Metadata.Filename
+	if name == "" {
+		name = "Unknown file"
+	}
+	return fmt.Sprintf("%s (started %s)", name, info.FirstSeen.Format("15:04:05"))
+}
+
+func (r *ReceiverApp) updateSessions() {
+	infos := r.engine.Sessions()
+	current := r.engine.Session()
+
+	ids := make([]uint64, len(infos))
+	labels := make([]string, len(infos))
+	selected := -1
+	for i, info := range infos {
+		ids[i] = info.ID
+		labels[i] = sessionLabel(info)
+		if info.ID == current {
+			selected = i
+		}
+	}
+
+	if len(infos) > 1 && r.sessionBox.Hidden {
+		r.status.SetText(fmt.Sprintf("%d transfers detected, choose one under Transfer", len(infos)))
+		r.sessionBox.Show()
+	}
+	if !slices.Equal(ids, r.sessionIDs) || !slices.Equal(labels, r.sessionSelect.Options) {
+		r.sessionIDs = ids
+		r.sessionSelect.SetOptions(labels)
+	}
+	if selected >= 0 && r.sessionSelect.SelectedIndex() != selected {
+		r.sessionSelect.SetSelectedIndex(selected)
+	}
+
+	if current != r.session {
+		r.session = current
+		r.showSession()
+	}
+}
+
+func (r *ReceiverApp) selectSession(id uint64) {
+	if id == r.engine.Session() || !r.engine.Select(id) {
+		return
+	}
+	r.session = id
+	r.showSession()
+}
+
+func (r *ReceiverApp) showSession() {
+	metadata := r.engine.Metadata()
+	if metadata.TotalChunks > 0 {
+		r.saveBtn.Enable()
+	} else {
+		r.saveBtn.Disable()
+	}
+	if metadata.TotalChunks > 0 && strings.HasPrefix(metadata.ContentType, "text/") {
+		r.copyBtn.Enable()
+	} else {
+		r.copyBtn.Disable()
+	}
+
+	r.updateStatus()
+	r.updateVerification()
+	r.chunkMap.SetStates(r.engine.ChunkStates())
+}
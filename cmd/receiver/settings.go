@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/config"
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/screen"
+	"qrtransfer/pkg/uitheme"
+)
+
+func (r *ReceiverApp) applySettings(cfg config.Receiver) {
+	if cfg.FPS > 0 {
+		r.fps = min(cfg.FPS, engine.MaxCaptureFPS)
+	}
+	r.autoFPS = cfg.AutoFPS
+	if cfg.BlockSize > 0 {
+		r.blockSize = cfg.BlockSize
+	}
+	r.saveDir = cfg.SaveDir
+	r.autoSave = cfg.AutoSave
+	r.downloadDir = cfg.DownloadDir
+	r.sourceName = cfg.Source
+	r.streamURL = cfg.StreamURL
+	r.hideCursor = cfg.HideCursor
+	r.maskSelf = cfg.MaskSelf
+	r.trackCode = cfg.TrackCode
+	r.notify = cfg.Notify
+	r.sound = cfg.Sound
+	r.stallAfter = time.Duration(max(cfg.StallAfter, 0)) * time.Second
+	r.tuning = screen.DecodeTuning{Tolerance: cfg.Tolerance, Kernel: cfg.Kernel, Dominant: cfg.Dominant, Threshold: cfg.Threshold, EInk: cfg.EInk, Projector: cfg.Projector}
+	r.engine.SetTuning(r.tuning)
+	r.engine.SetSecureWipe(cfg.Wipe)
+	r.applyPolicy(cfg)
+	r.applyQuarantine(cfg)
+	r.applyTrustedSigners(cfg)
+}
+
+func (r *ReceiverApp) settings() config.Receiver {
+	policy := r.engine.Policy()
+	holdDir := r.quarantine.Dir
+	if dir, err := config.QuarantineDir(); err == nil && holdDir == dir {
+		holdDir = ""
+	}
+	return config.Receiver{
+		FPS:         r.fps,
+		AutoFPS:     r.autoFPS,
+		BlockSize:   r.blockSize,
+		SaveDir:     r.saveDir,
+		AutoSave:    r.autoSave,
+		DownloadDir: r.downloadDir,
+		Source:      r.sourceName,
+		StreamURL:   r.streamURL,
+		HideCursor:  r.hideCursor,
+		MaskSelf:    r.maskSelf,
+		TrackCode:   r.trackCode,
+		Notify:      r.notify,
+		Sound:       r.sound,
+		StallAfter:  int(r.stallAfter / time.Second),
+		Wipe:        r.engine.SecureWipe(),
+		Tolerance:   r.tuning.Tolerance,
+		Kernel:      r.tuning.Kernel,
+		Dominant:    r.tuning.Dominant,
+		Threshold:   r.tuning.Threshold,
+		EInk:        r.tuning.EInk,
+		Projector:   r.tuning.Projector,
+
+		MaxFileSize:       formatLimit(policy.MaxSize),
+		AllowedExtensions: policy.Extensions,
+		AllowedTypes:      policy.ContentTypes,
+		RejectExecutables: policy.RejectExecutables,
+
+		Quarantine:      r.quarantine.Enabled(),
+		QuarantineDir:   holdDir,
+		PostReceiveHook: r.quarantine.Hook,
+
+		TrustedSigners: trustedSignerKeys(r.engine.TrustedSigners()),
+	}
+}
+
+func (r *ReceiverApp) saveLocation() (string, fyne.ListableURI) {
+	path := r.saveDir
+	if path == "" {
+		path = r.autoSaveDir()
+	}
+
+	dir, err := storage.ListerForURI(storage.NewFileURI(path))
+	if err != nil {
+		slog.Warn("save directory unavailable", "dir", path, "err", err)
+		return "", nil
+	}
+	return path, dir
+}
+
+func (r *ReceiverApp) setupTheme() *widget.Select {
+	r.theme = strings.ToLower(r.theme)
+	if _, err := uitheme.Parse(r.theme); err != nil {
+		slog.Warn("ignoring theme from settings", "err", err)
+		r.theme = uitheme.Default
+	}
+
+	themeSelect := widget.NewSelect(uitheme.Names(), func(name string) {
+		if err := uitheme.Apply(r.app, name); err == nil {
+			r.theme = name
+		}
+	})
+	themeSelect.SetSelected(r.theme)
+	return themeSelect
+}
+
+func (r *ReceiverApp) saveDefaults() {
+	err := errors.New("no settings file location")
+	if r.configPath != "" {
+		cfg, _ := config.Load(r.configPath)
+		cfg.Theme = r.theme
+		cfg.Receiver = r.settings()
+		err = cfg.Save(r.configPath)
+	}
+
+	if err != nil {
+		slog.Error("saving settings failed", "err", err)
+		dialog.ShowError(fmt.Errorf("saving settings: %w", err), r.window)
+		return
+	}
+	r.status.SetText("Settings saved to " + r.configPath)
+}
+
+func (r *ReceiverApp) setupAutoSave() fyne.CanvasObject {
+	dirLabel := widget.NewLabel(r.autoSaveDir())
+	dirLabel.Truncation = fyne.TextTruncateEllipsis
+
+	check := widget.NewCheck("Auto-save completed files", func(on bool) {
+		r.autoSave = on
+	})
+	check.SetChecked(r.autoSave)
+
+	dirBtn := widget.NewButton("Download Folder...", func() {
+		d := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err != nil || dir == nil {
+				return
+			}
+			r.downloadDir = dir.Path()
+			dirLabel.SetText(r.downloadDir)
+		}, r.window)
+		if dir, err := storage.ListerForURI(storage.NewFileURI(r.autoSaveDir())); err == nil {
+			d.SetLocation(dir)
+		}
+		d.Show()
+	})
+
+	return container.NewVBox(check, container.NewBorder(nil, nil, nil, dirBtn, dirLabel))
+}
+
+func (r *ReceiverApp) autoSaveDir() string {
+	if r.downloadDir != "" {
+		return r.downloadDir
+	}
+	return config.DownloadDir()
+}
+
+func (r *ReceiverApp) autoSaveFile() string {
+	r.autoSaved[r.session] = true
+
+	dir := r.autoSaveDir()
+	path := engine.UniquePath(dir, engine.SanitizeFilename(r.engine.Metadata().Filename))
+	saved := path
+	err := os.MkdirAll(dir, 0o755)
+	if err == nil {
+		if r.quarantine.Enabled() {
+			saved, _, err = r.quarantineFile(path)
+		} else {
+			_, err = r.engine.SaveFile(path)
+		}
+	}
+	if err != nil {
+		slog.Error("auto-save failed", "path", path, "err", err)
+		r.status.SetText(fmt.Sprintf("Auto-save failed: %v", err))
+		return ""
+	}
+
+	msg := "Saved to " + saved
+	if saved != path {
+		msg = "Held in quarantine at " + saved
+	} else {
+		r.recordReceive(path)
+	}
+	r.status.SetText(msg)
+	r.discardSnapshot()
+	r.wipeSaved(msg)
+	return saved
+}
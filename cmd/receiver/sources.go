@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/screen"
+)
+
+const (
+	sourceScreen  = "Full Screen"
+	sourceRegion  = "Region..."
+	sourceWindow  = "Window..."
+	sourceVideo   = "Video File..."
+	sourceImages  = "Image Folder..."
+	sourceStream  = "Stream URL..."
+	displayPrefix = "Display: "
+	cardPrefix    = "Capture card: "
+)
+
+var interactiveSources = []string{sourceRegion, sourceWindow, sourceVideo, sourceImages, sourceStream}
+
+func (r *ReceiverApp) sourceNames() []string {
+	if r.mobile {
+		return mobileSources()
+	}
+
+	sources := []string{sourceScreen}
+	if displays := r.screenCap.Displays(); len(displays) > 1 {
+		for _, d := range displays {
+			sources = append(sources, displayPrefix+d.Name)
+		}
+	}
+	sources = append(sources, interactiveSources...)
+	if cards, err := screen.ListCaptureCards(); err == nil {
+		for _, c := range cards {
+			sources = append(sources, cardSourceName(c))
+		}
+	}
+	if cameras, err := screen.ListCameras(); err == nil {
+		sources = append(sources, cameras...)
+	}
+	return sources
+}
+
+func cardSourceName(c screen.CaptureCardInfo) string {
+	return fmt.Sprintf("%s%s (%s)", cardPrefix, c.Name, c.Device)
+}
+
+func initialSource(name string, sources []string) string {
+	if name == "Screen" {
+		name = sourceScreen
+	}
+	if name != "" && !slices.Contains(interactiveSources, name) && slices.Contains(sources, name) {
+		return name
+	}
+
+	fallback := ""
+	if i := slices.IndexFunc(sources, func(s string) bool { return !slices.Contains(interactiveSources, s) }); i >= 0 {
+		fallback = sources[i]
+	}
+	if name != "" {
+		slog.Warn("configured source not available", "source", name, "using", fallback)
+	}
+	return fallback
+}
+
+func (r *ReceiverApp) selectSource(name string) {
+	switch {
+	case name == sourceScreen:
+		r.sourceName = name
+		r.setSource(nil)
+		r.setTargetRegion(image.Rectangle{})
+	case strings.HasPrefix(name, displayPrefix):
+		for _, d := range r.screenCap.Displays() {
+			if displayPrefix+d.Name == name {
+				r.sourceName = name
+				r.setSource(nil)
+				r.setTargetRegion(d.Bounds)
+				return
+			}
+		}
+		r.status.SetText("Display not found: " + strings.TrimPrefix(name, displayPrefix))
+	case name == sourceRegion:
+		r.setSource(nil)
+		r.pickRegion()
+	case name == sourceWindow:
+		r.pickWindow()
+	case name == sourceVideo:
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+
+			video, err := screen.OpenVideo(screen.VideoConfig{Path: reader.URI().Path()})
+			if err != nil {
+				r.status.SetText(fmt.Sprintf("Video error: %v", err))
+				return
+			}
+			r.setSource(video)
+			r.status.SetText("Decoding " + reader.URI().Name())
+		}, r.window)
+	case name == sourceImages:
+		dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err != nil || dir == nil {
+				return
+			}
+
+			images, err := screen.OpenImageDir(screen.ImageDirConfig{Path: dir.Path()})
+			if err != nil {
+				r.status.SetText(fmt.Sprintf("Image folder error: %v", err))
+				return
+			}
+			r.setSource(images)
+			r.status.SetText(fmt.Sprintf("Decoding %d images", len(images.Files())))
+		}, r.window)
+	case name == sourceStream:
+		r.pickStream()
+	case strings.HasPrefix(name, cardPrefix):
+		cards, _ := screen.ListCaptureCards()
+		i := slices.IndexFunc(cards, func(c screen.CaptureCardInfo) bool { return cardSourceName(c) == name })
+		if i < 0 {
+			r.status.SetText("Capture card not found: " + strings.TrimPrefix(name, cardPrefix))
+			return
+		}
+
+		card, err := screen.OpenCaptureCard(screen.CaptureCardConfig{Device: cards[i].Device, FPS: r.fps})
+		if err != nil {
+			r.status.SetText(fmt.Sprintf("Capture card error: %v", err))
+			return
+		}
+		r.sourceName = name
+		r.setSource(card)
+	default:
+		cam, err := screen.OpenCamera(screen.CameraConfig{Device: name, FPS: 10})
+		if errors.Is(err, screen.ErrPermissionDenied) {
+			r.reportCaptureError(err)
+			return
+		}
+		if err != nil {
+			r.status.SetText(fmt.Sprintf("Camera error: %v", err))
+			return
+		}
+		r.sourceName = name
+		r.setSource(cam)
+	}
+}
+
+func (r *ReceiverApp) setSource(src screen.Source) {
+	r.stopCapture()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.source != nil {
+		r.source.Close()
+	}
+	r.source = src
+}
+
+func (r *ReceiverApp) pickStream() {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("http://phone:8080/video or rtsp://...")
+	entry.SetText(r.streamURL)
+
+	items := []*widget.FormItem{widget.NewFormItem("URL", entry)}
+	dialog.ShowForm("Stream URL", "Connect", "Cancel", items, func(ok bool) {
+		address := strings.TrimSpace(entry.Text)
+		if !ok || address == "" {
+			return
+		}
+
+		r.status.SetText("Connecting to " + address)
+		config := screen.NetCameraConfig{URL: address, FPS: r.fps}
+		go func() {
+			cam, err := screen.OpenNetCamera(config)
+			fyne.Do(func() {
+				if err != nil {
+					r.status.SetText(fmt.Sprintf("Stream error: %v", err))
+					return
+				}
+				r.streamURL = address
+				r.setSource(cam)
+				r.status.SetText("Stream selected: " + address)
+			})
+		}()
+	}, r.window)
+}
+
+func (r *ReceiverApp) pickWindow() {
+	windows, err := r.screenCap.ListWindows()
+	if err != nil {
+		r.status.SetText(fmt.Sprintf("Cannot list windows: %v", err))
+		return
+	}
+
+	var titles []string
+	var matches []screen.WindowMatcher
+	for _, w := range windows {
+		if w.Bounds.Empty() || w.Title == "" || w.Title == windowTitle {
+			continue
+		}
+		titles = append(titles, w.Title)
+		matches = append(matches, screen.WindowMatcher{ID: w.ID, Title: w.Title})
+	}
+	if len(titles) == 0 {
+		r.status.SetText("No capturable windows found")
+		return
+	}
+
+	choice := widget.NewSelect(titles, nil)
+	dialog.ShowCustomConfirm("Capture Window", "Capture", "Cancel", choice, func(ok bool) {
+		i := choice.SelectedIndex()
+		if !ok || i < 0 {
+			return
+		}
+
+		src, err := screen.OpenWindow(matches[i], screen.CaptureConfig{HideCursor: r.hideCursor})
+		if err != nil {
+			r.status.SetText(fmt.Sprintf("Window error: %v", err))
+			return
+		}
+		r.setSource(src)
+		r.status.SetText("Window selected: " + titles[i])
+	}, r.window)
+}
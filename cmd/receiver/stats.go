@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/screen"
+)
+
+const (
+	statsInterval = time.Second
+	stallAfter    = 10 * time.Second
+)
+
+type statsPanel struct {
+	captures   *widget.Label
+	decodes    *widget.Label
+	decoded    *widget.Label
+	failed     *widget.Label
+	headers    *widget.Label
+	checksums  *widget.Label
+	auth       *widget.Label
+	unpaired   *widget.Label
+	conflicts  *widget.Label
+	duplicates *widget.Label
+	correction *widget.Label
+	lastNew    *widget.Label
+
+	prev     engine.ReceiveStats
+	prevTime time.Time
+}
+
+func newStatsPanel() *statsPanel {
+	return &statsPanel{
+		captures:   widget.NewLabel("-"),
+		decodes:    widget.NewLabel("-"),
+		decoded:    widget.NewLabel("0"),
+		failed:     widget.NewLabel("0"),
+		headers:    widget.NewLabel("0"),
+		checksums:  widget.NewLabel("0"),
+		auth:       widget.NewLabel("0"),
+		unpaired:   widget.NewLabel("0"),
+		conflicts:  widget.NewLabel("0"),
+		duplicates: widget.NewLabel("0"),
+		correction: widget.NewLabel("-"),
+		lastNew:    widget.NewLabel("never"),
+	}
+}
+
+func (p *statsPanel) content() fyne.CanvasObject {
+	return container.New(layout.NewFormLayout(),
+		widget.NewLabel("Captures/sec:"), p.captures,
+		widget.NewLabel("Decodes/sec:"), p.decodes,
+		widget.NewLabel("Codes decoded:"), p.decoded,
+		widget.NewLabel("Undecodable regions:"), p.failed,
+		widget.NewLabel("Header CRC failures:"), p.headers,
+		widget.NewLabel("Checksum failures:"), p.checksums,
+		widget.NewLabel("Authentication failures:"), p.auth,
+		widget.NewLabel("Unpaired frames ignored:"), p.unpaired,
+		widget.NewLabel("Inconsistent chunks:"), p.conflicts,
+		widget.NewLabel("Duplicate chunks:"), p.duplicates,
+		widget.NewLabel("Error correction:"), p.correction,
+		widget.NewLabel("Last new chunk:"), p.lastNew,
+	)
+}
+
+func (p *statsPanel) update(stats engine.ReceiveStats, perf screen.MetricsSnapshot, capturing bool, now time.Time) {
+	decodeRate := 0.0
+	if elapsed := now.Sub(p.prevTime).Seconds(); !p.prevTime.IsZero() && elapsed > 0 {
+		decodeRate = max(float64(stats.Frames-p.prev.Frames)/elapsed, 0)
+	}
+	p.prev, p.prevTime = stats, now
+
+	if capturing {
+		p.captures.SetText(fmt.Sprintf("%.1f (target %d)", perf.FPS, perf.TargetFPS))
+		p.decodes.SetText(fmt.Sprintf("%.1f", decodeRate))
+	} else {
+		p.captures.SetText("-")
+		p.decodes.SetText("-")
+	}
+
+	p.decoded.SetText(fmt.Sprintf("%d in %d captures", stats.Frames, stats.Captures))
+	p.failed.SetText(fmt.Sprintf("%d of %d", stats.DecodeFailures, stats.Regions))
+	p.headers.SetText(fmt.Sprint(stats.HeaderFailures))
+	p.checksums.SetText(fmt.Sprint(stats.ChecksumFails))
+	p.auth.SetText(fmt.Sprint(stats.AuthFailures))
+	if stats.Locked > 0 {
+		p.auth.SetText(fmt.Sprintf("%d (%d encrypted frames without a passphrase)", stats.AuthFailures, stats.Locked))
+	}
+	p.unpaired.SetText(fmt.Sprint(stats.Unpaired))
+	p.conflicts.SetText(fmt.Sprint(stats.Inconsistent))
+	p.duplicates.SetText(fmt.Sprint(stats.Duplicates))
+	if stats.Frames > 0 {
+		p.correction.SetText(fmt.Sprintf(
+			"last %.1f%% corrected, %.1f%% erased\noverall %.1f%% corrected, %.1f%% erased",
+			stats.Last.ErrorRate()*100, stats.Last.ErasureRate()*100,
+			stats.Blocks.ErrorRate()*100, stats.Blocks.ErasureRate()*100,
+		))
+	}
+
+	stalled := capturing && stats.Captures > 0 && (stats.LastNew.IsZero() || now.Sub(stats.LastNew) > stallAfter)
+	if stats.LastNew.IsZero() {
+		p.lastNew.SetText("never")
+	} else {
+		p.lastNew.SetText(fmt.Sprintf("%s ago", now.Sub(stats.LastNew).Round(time.Second)))
+	}
+	setWarning(p.lastNew, stalled)
+	setWarning(p.failed, stats.Regions > 0 && stats.DecodeFailures == stats.Regions)
+	setWarning(p.checksums, stats.ChecksumFails > 0)
+	setWarning(p.auth, stats.AuthFailures > 0 || stats.Locked > 0)
+	setWarning(p.conflicts, stats.Inconsistent > 0)
+}
+
+func setWarning(label *widget.Label, on bool) {
+	importance := widget.MediumImportance
+	if on {
+		importance = widget.WarningImportance
+	}
+	setImportance(label, importance)
+}
+
+func (r *ReceiverApp) watchStats() {
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		fyne.Do(r.updateStats)
+	}
+}
+
+func (r *ReceiverApp) updateStats() {
+	r.mu.Lock()
+	m := r.metrics
+	r.mu.Unlock()
+
+	stats := r.engine.Stats()
+	r.stats.update(stats, m.Snapshot(), r.capturing(), time.Now())
+	r.health.update(stats, r.capturing())
+	r.checkStall(stats)
+}
+
+func formatBytes(n float64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", n, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", n, units[i])
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"log/slog"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+func (r *ReceiverApp) setupTray() {
+	desk, ok := r.app.(desktop.App)
+	if !ok {
+		return
+	}
+
+	r.trayStatus = fyne.NewMenuItem("Not capturing", nil)
+	r.trayStatus.Disabled = true
+	r.trayPause = fyne.NewMenuItem("Pause Capture", r.toggleCapture)
+	r.trayMenu = fyne.NewMenu(windowTitle,
+		r.trayStatus,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Show Window", r.window.Show),
+		r.trayPause,
+	)
+
+	desk.SetSystemTrayMenu(r.trayMenu)
+	desk.SetSystemTrayWindow(r.window)
+	r.window.SetCloseIntercept(r.closeWindow)
+	r.updateTray()
+}
+
+func (r *ReceiverApp) closeWindow() {
+	if !r.capturing() {
+		r.app.Quit()
+		return
+	}
+
+	r.window.Hide()
+	slog.Info("window hidden to the system tray while capture continues")
+}
+
+func (r *ReceiverApp) toggleCapture() {
+	if r.capturing() {
+		r.stopCapture()
+	} else {
+		r.startCapture()
+	}
+}
+
+func (r *ReceiverApp) setTrayProgress(text string) {
+	if r.trayMenu == nil {
+		return
+	}
+
+	fyne.Do(func() {
+		r.trayProgress = text
+		r.updateTray()
+	})
+}
+
+func (r *ReceiverApp) updateTray() {
+	if r.trayMenu == nil {
+		return
+	}
+
+	capturing := r.capturing()
+	text := r.trayProgress
+	pause := "Pause Capture"
+	switch {
+	case !capturing && text == "":
+		text = "Not capturing"
+		pause = "Start Capture"
+	case !capturing:
+		text = "Paused: " + text
+		pause = "Resume Capture"
+	case text == "":
+		text = "Waiting for frames"
+	}
+	if text == r.trayStatus.Label && pause == r.trayPause.Label {
+		return
+	}
+
+	r.trayStatus.Label = text
+	r.trayPause.Label = pause
+	r.trayMenu.Refresh()
+	setTrayTooltip(windowTitle + ": " + text)
+}
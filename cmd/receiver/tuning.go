@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/qr"
+	"qrtransfer/pkg/screen"
+)
+
+const (
+	healthWindow   = 5
+	healthGood     = 0.8
+	healthMarginal = 0.3
+)
+
+var kernelNames = []string{"1 px", "3x3 px", "5x5 px", "7x7 px"}
+
+type decodeHealth struct {
+	label   *widget.Label
+	history []engine.ReceiveStats
+}
+
+func newDecodeHealth() *decodeHealth {
+	return &decodeHealth{label: widget.NewLabel("Decode health: -")}
+}
+
+func (h *decodeHealth) update(stats engine.ReceiveStats, capturing bool) {
+	if !capturing {
+		h.history = h.history[:0]
+		h.label.SetText("Decode health: -")
+		setImportance(h.label, widget.MediumImportance)
+		return
+	}
+
+	h.history = append(h.history, stats)
+	if len(h.history) > healthWindow {
+		h.history = h.history[1:]
+	}
+	first := h.history[0]
+
+	regions := stats.Regions - first.Regions
+	if regions <= 0 {
+		h.label.SetText("Decode health: no codes in view")
+		setImportance(h.label, widget.WarningImportance)
+		return
+	}
+
+	verified := float64(stats.Chunks-first.Chunks) / float64(regions)
+	erased := 0.0
+	if read := stats.Blocks.BlocksRead - first.Blocks.BlocksRead; read > 0 {
+		erased = float64(stats.Blocks.BlocksUncorrectable-first.Blocks.BlocksUncorrectable) / float64(read)
+	}
+
+	verdict, importance := "poor", widget.DangerImportance
+	switch {
+	case verified >= healthGood:
+		verdict, importance = "good", widget.SuccessImportance
+	case verified >= healthMarginal:
+		verdict, importance = "marginal", widget.WarningImportance
+	}
+	h.label.SetText(fmt.Sprintf("Decode health: %s, %.0f%% of codes verified, %.1f%% of blocks unreadable", verdict, verified*100, erased*100))
+	setImportance(h.label, importance)
+}
+
+func setImportance(label *widget.Label, importance widget.Importance) {
+	if label.Importance != importance {
+		label.Importance = importance
+		label.Refresh()
+	}
+}
+
+func (r *ReceiverApp) setupTuning() fyne.CanvasObject {
+	tuning := r.tuning
+
+	toleranceLabel := widget.NewLabel("")
+	toleranceSlider := widget.NewSlider(0.05, 0.5)
+	toleranceSlider.Step = 0.05
+	toleranceSlider.OnChanged = func(value float64) {
+		r.tuning.Tolerance = value
+		toleranceLabel.SetText(fmt.Sprintf("Color tolerance: %.0f%% of a level step", value*100))
+		r.engine.SetTuning(r.tuning)
+	}
+
+	kernelSelect := widget.NewSelect(kernelNames, func(value string) {
+		for i, name := range kernelNames {
+			if name == value {
+				r.tuning.Kernel = 2*i + 1
+				r.engine.SetTuning(r.tuning)
+			}
+		}
+	})
+
+	dominantCheck := widget.NewCheck("Sample each block's dominant color", func(on bool) {
+		r.tuning.Dominant = on
+		r.engine.SetTuning(r.tuning)
+	})
+
+	thresholdLabel := widget.NewLabel("")
+	thresholdSlider := widget.NewSlider(1, 254)
+	thresholdSlider.OnChanged = func(value float64) {
+		r.tuning.Threshold = int(value)
+		thresholdLabel.SetText(fmt.Sprintf("Luminance threshold: %d", r.tuning.Threshold))
+		r.engine.SetTuning(r.tuning)
+	}
+	autoCheck := widget.NewCheck("Automatic luminance threshold", func(on bool) {
+		if on {
+			thresholdSlider.Disable()
+			r.tuning.Threshold = 0
+			thresholdLabel.SetText("Luminance threshold: automatic")
+			r.engine.SetTuning(r.tuning)
+			return
+		}
+		thresholdSlider.Enable()
+		thresholdSlider.OnChanged(thresholdSlider.Value)
+	})
+
+	einkCheck := widget.NewCheck("E-ink sender: monochrome, wait for settled frames", func(on bool) {
+		r.tuning.EInk = on
+		r.engine.SetTuning(r.tuning)
+	})
+	projectorCheck := widget.NewCheck("Projector sender: 8-color frames, show alignment guide", func(on bool) {
+		r.tuning.Projector = on
+		r.engine.SetTuning(r.tuning)
+		r.align.SetActive(on)
+	})
+
+	set := func(t screen.DecodeTuning) {
+		if t.Tolerance <= 0 {
+			t.Tolerance = qr.DefaultTolerance
+		}
+		toleranceSlider.SetValue(t.Tolerance)
+		toleranceSlider.OnChanged(t.Tolerance)
+		kernelSelect.SetSelectedIndex(min(max(t.Kernel-1, 0)/2, len(kernelNames)-1))
+		dominantCheck.SetChecked(t.Dominant)
+		thresholdSlider.SetValue(128)
+		if t.Threshold > 0 {
+			thresholdSlider.SetValue(float64(t.Threshold))
+		}
+		autoCheck.SetChecked(t.Threshold <= 0)
+		autoCheck.OnChanged(t.Threshold <= 0)
+		einkCheck.SetChecked(t.EInk)
+		projectorCheck.SetChecked(t.Projector)
+	}
+	set(tuning)
+
+	resetBtn := widget.NewButton("Reset to Defaults", func() {
+		set(screen.DecodeTuning{})
+	})
+
+	r.health = newDecodeHealth()
+	return container.NewVBox(
+		r.health.label,
+		widget.NewAccordion(widget.NewAccordionItem("Decode Tuning", container.NewVBox(
+			toleranceLabel,
+			toleranceSlider,
+			widget.NewLabel("Sampling kernel:"),
+			kernelSelect,
+			dominantCheck,
+			autoCheck,
+			thresholdLabel,
+			thresholdSlider,
+			einkCheck,
+			projectorCheck,
+			resetBtn,
+		))),
+	)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/config"
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/secure"
+)
+
+func (r *ReceiverApp) setupVerification() fyne.CanvasObject {
+	r.verified = widget.NewLabel("")
+	r.verified.TextStyle.Bold = true
+	r.verified.Wrapping = fyne.TextWrapWord
+	r.verified.Hide()
+	r.trustBtn = widget.NewButton("Trust This Sender...", r.trustSigner)
+	r.trustBtn.Hide()
+	return container.NewVBox(r.verified, r.trustBtn)
+}
+
+func (r *ReceiverApp) updateVerification() {
+	v := r.engine.Verification()
+	if v.Status == engine.ManifestNone && r.engine.Metadata().TotalChunks == 0 {
+		r.verified.Hide()
+		r.trustBtn.Hide()
+		return
+	}
+
+	if v.Signer != nil && !v.Trusted && v.Status != engine.ManifestInvalid {
+		r.trustBtn.Show()
+	} else {
+		r.trustBtn.Hide()
+	}
+
+	text, importance := verificationBadge(v)
+	if r.verified.Text == text && r.verified.Visible() {
+		return
+	}
+	r.verified.Importance = importance
+	r.verified.SetText(text)
+	r.verified.Show()
+}
+
+func verificationBadge(v engine.Verification) (string, widget.Importance) {
+	signer := "unknown key " + secure.Fingerprint(v.Signer)
+	if v.Trusted {
+		signer = "trusted sender " + secure.Fingerprint(v.Signer)
+	}
+
+	switch v.Status {
+	case engine.ManifestVerified:
+		if !v.Trusted {
+			return "Signed by " + signer + ": the file matches the signature, but this key is not one you trust", widget.WarningImportance
+		}
+		return "✔ Verified: signed by " + signer + " and the file matches", widget.SuccessImportance
+	case engine.ManifestSigned:
+		return "Signed by " + signer + ": the file is checked against the signature before saving", widget.MediumImportance
+	case engine.ManifestPending:
+		return "Receiving the sender's signature...", widget.MediumImportance
+	case engine.ManifestInvalid:
+		return "✘ Verification failed: " + v.Err.Error(), widget.DangerImportance
+	default:
+		return "Unverified: the sender did not sign this transfer", widget.WarningImportance
+	}
+}
+
+func (r *ReceiverApp) trustSigner() {
+	v := r.engine.Verification()
+	if v.Signer == nil || v.Trusted {
+		return
+	}
+
+	message := fmt.Sprintf("Trust files signed by key %s?\n\nOnly trust it if the sender has confirmed this fingerprint to you another way, such as in person or by phone.", secure.Fingerprint(v.Signer))
+	dialog.ShowConfirm("Trust This Sender", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		r.engine.SetTrustedSigners(append(r.engine.TrustedSigners(), v.Signer))
+		r.saveTrustedSigners()
+		r.updateVerification()
+	}, r.window)
+}
+
+func (r *ReceiverApp) applyTrustedSigners(cfg config.Receiver) {
+	var keys [][]byte
+	for _, text := range cfg.TrustedSigners {
+		key, err := secure.ParseSigningKey(text)
+		if err != nil {
+			slog.Warn("ignoring trusted signer from settings", "key", text, "err", err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	r.engine.SetTrustedSigners(keys)
+}
+
+func (r *ReceiverApp) saveTrustedSigners() {
+	if r.configPath == "" {
+		return
+	}
+	cfg, _ := config.Load(r.configPath)
+	cfg.Receiver.TrustedSigners = trustedSignerKeys(r.engine.TrustedSigners())
+	if err := cfg.Save(r.configPath); err != nil {
+		slog.Error("saving trusted senders failed", "err", err)
+		dialog.ShowError(fmt.Errorf("saving trusted senders: %w", err), r.window)
+	}
+}
+
+func trustedSignerKeys(keys [][]byte) []string {
+	var texts []string
+	for _, key := range keys {
+		texts = append(texts, secure.FormatSigningKey(key))
+	}
+	return texts
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/engine"
+)
+
+type watchdog struct {
+	box    *fyne.Container
+	title  *widget.Label
+	cause  *widget.Label
+	advice *widget.Label
+
+	since  time.Time
+	base   engine.ReceiveStats
+	warned bool
+}
+
+func newWatchdog() *watchdog {
+	w := &watchdog{
+		title:  widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		cause:  widget.NewLabel(""),
+		advice: widget.NewLabel(""),
+	}
+	w.title.Importance = widget.WarningImportance
+	w.cause.Importance = widget.WarningImportance
+	w.cause.Wrapping = fyne.TextWrapWord
+	w.advice.Wrapping = fyne.TextWrapWord
+
+	w.box = container.NewVBox(w.title, w.cause, w.advice, widget.NewSeparator())
+	w.box.Hide()
+	return w
+}
+
+func (w *watchdog) reset(stats engine.ReceiveStats, since time.Time) {
+	w.base, w.since, w.warned = stats, since, false
+	w.box.Hide()
+}
+
+func (r *ReceiverApp) checkStall(stats engine.ReceiveStats) {
+	w := r.watchdog
+	now := time.Now()
+	if r.stallAfter <= 0 || !r.capturing() || r.calibrator() != nil {
+		w.reset(stats, now)
+		return
+	}
+	if stats.LastNew.After(w.since) {
+		w.reset(stats, stats.LastNew)
+		return
+	}
+
+	idle := now.Sub(w.since)
+	if idle < r.stallAfter || r.engine.Complete() {
+		return
+	}
+
+	cause, advice := diagnoseStall(stats, w.base, r.engine.Metadata().TotalChunks > 0)
+	w.title.SetText(fmt.Sprintf("No new chunk for %s", idle.Round(time.Second)))
+	w.cause.SetText(cause)
+	w.advice.SetText(advice)
+	w.box.Show()
+
+	if !w.warned {
+		w.warned = true
+		p := r.engine.Progress()
+		r.alert("Transfer stalled", fmt.Sprintf("%s %d of %d chunks received.", cause, p.CurrentChunk, p.TotalChunks))
+	}
+}
+
+func diagnoseStall(stats, base engine.ReceiveStats, haveMetadata bool) (string, string) {
+	captures := stats.Captures - base.Captures
+	decoded := stats.Frames - base.Frames
+	damaged := stats.HeaderFailures - base.HeaderFailures + stats.ChecksumFails - base.ChecksumFails
+	duplicates := stats.Duplicates - base.Duplicates
+
+	switch {
+	case captures == 0:
+		return "No frames are being captured.",
+			"The source may have ended or lost screen recording permission. Stop and start capture, or choose another source."
+	case decoded == 0:
+		return "No code could be read in the captured frames.",
+			"Make sure the sender is running and its window is visible inside the capture region (Clear captures the full screen). If the code is shown very small, enlarge the sender window so its blocks do not blur together."
+	case duplicates == 0 && damaged > 0:
+		return "Codes are found but every one fails its header or checksum.",
+			"The grid is probably misread: capture at 100% display scaling, enlarge the sender window, or pick a higher error correction level or a slower refresh rate on the sender."
+	case duplicates > 0 && !haveMetadata:
+		return "Chunks are repeating but the file metadata has not been seen.",
+			"The sender shows the metadata code at the start of each pass. Keep capturing until it comes round, or restart the sender."
+	case duplicates > 0:
+		return "Only chunks that were already received are being shown.",
+			"The sender has probably stopped or finished its passes. Use Copy Missing and paste the list into the sender's Resend field, or restart the sender."
+	default:
+		return "Codes are being read but none belong to this transfer.",
+			"The sender may be showing a different transfer; check the Transfer selector."
+	}
+}
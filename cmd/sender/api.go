@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"qrtransfer/pkg/engine"
+)
+
+type apiStatus struct {
+	Running     bool    `json:"running"`
+	Paused      bool    `json:"paused"`
+	File        string  `json:"file"`
+	Frame       int     `json:"frame"`
+	Frames      int     `json:"frames"`
+	Chunks      uint32  `json:"chunks"`
+	Pass        int     `json:"pass"`
+	RefreshRate float64 `json:"refresh_rate"`
+	Pending     int     `json:"pending"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+var (
+	errAPIAddress     = errors.New("the control API only listens on a loopback address")
+	errAPIHost        = errors.New("requests must be addressed to a loopback host")
+	errAPIToken       = errors.New("missing or wrong bearer token")
+	errAPIContentType = errors.New("requests must be sent as application/json")
+)
+
+func (s *SenderApp) serveAPI(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "" || host == "localhost" {
+		host = "127.0.0.1"
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("%w: %s", errAPIAddress, addr)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return err
+	}
+	token := rand.Text()
+	fmt.Fprintf(os.Stderr, "control API listening on http://%s, token %s\n", listener.Addr(), token)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("POST /start", s.handleStart)
+	mux.HandleFunc("POST /stop", s.handleStop)
+	mux.HandleFunc("POST /pause", s.handlePause)
+	mux.HandleFunc("POST /resume", s.handleResume)
+	mux.HandleFunc("POST /load", s.handleLoad)
+	mux.HandleFunc("POST /rate", s.handleRate)
+	mux.HandleFunc("POST /retransmit", s.handleRetransmit)
+
+	server := &http.Server{
+		Handler:           guardAPI(token, mux),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return server.Serve(listener)
+}
+
+func guardAPI(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !loopbackHost(r.Host) {
+			writeError(w, http.StatusForbidden, errAPIHost)
+			return
+		}
+		auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errAPIToken)
+			return
+		}
+		if r.Method == http.MethodPost {
+			if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, errAPIContentType)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func loopbackHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+func apiState(st engine.SenderStatus) apiStatus {
+	status := apiStatus{
+		Running:     st.State.Active(),
+		Paused:      st.State == engine.SenderPaused,
+		Frame:       st.Position,
+		Frames:      st.Frames,
+		Pass:        st.Pass,
+		RefreshRate: st.Config.Interval.Seconds(),
+		Pending:     st.Pending,
+	}
+	if st.Payload != nil {
+		status.File = st.Payload.Metadata.Filename
+		status.Chunks = st.Payload.Metadata.TotalChunks
+	}
+	return status
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, apiError{Error: err.Error()})
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	defer r.Body.Close()
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func (s *SenderApp) state() apiStatus {
+	return apiState(s.engine.Status())
+}
+
+func (s *SenderApp) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.state())
+}
+
+func (s *SenderApp) handleStart(w http.ResponseWriter, r *http.Request) {
+	if err := s.engine.Start(); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.state())
+}
+
+func (s *SenderApp) handleStop(w http.ResponseWriter, r *http.Request) {
+	s.engine.Stop()
+	writeJSON(w, http.StatusOK, s.state())
+}
+
+func (s *SenderApp) handlePause(w http.ResponseWriter, r *http.Request) {
+	s.engine.Pause()
+	writeJSON(w, http.StatusOK, s.state())
+}
+
+func (s *SenderApp) handleResume(w http.ResponseWriter, r *http.Request) {
+	s.engine.Resume()
+	writeJSON(w, http.StatusOK, s.state())
+}
+
+func (s *SenderApp) handleLoad(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Path string `json:"path"`
+	}
+	if err := readJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Path == "" {
+		writeError(w, http.StatusBadRequest, errors.New("path is required"))
+		return
+	}
+
+	code := http.StatusBadRequest
+	var err error
+	s.call(func() {
+		if s.running() {
+			code, err = http.StatusConflict, errors.New("stop the transfer before loading a file")
+			return
+		}
+
+		err = s.openFile(req.Path, filepath.Base(req.Path))
+		if err == nil {
+			fyne.DoAndWait(func() { s.status.SetText("Selected: " + s.origName) })
+		}
+	})
+	if err != nil {
+		writeError(w, code, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.state())
+}
+
+func (s *SenderApp) handleRate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Seconds float64 `json:"seconds"`
+	}
+	if err := readJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var err error
+	fyne.DoAndWait(func() {
+		if req.Seconds < s.rateSlider.Min || req.Seconds > s.rateSlider.Max {
+			err = fmt.Errorf("rate must be between %.1f and %.1f seconds", s.rateSlider.Min, s.rateSlider.Max)
+			return
+		}
+		s.rateSlider.SetValue(req.Seconds)
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.state())
+}
+
+func (s *SenderApp) handleRetransmit(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Indices []uint32 `json:"indices"`
+	}
+	if err := readJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(req.Indices) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("indices is required"))
+		return
+	}
+
+	code := http.StatusBadRequest
+	err := s.engine.Retransmit(req.Indices)
+	if errors.Is(err, engine.ErrNoPayload) {
+		code = http.StatusConflict
+	}
+	if err != nil {
+		writeError(w, code, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.state())
+}
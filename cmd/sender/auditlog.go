@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/audit"
+	"qrtransfer/pkg/config"
+	"qrtransfer/pkg/engine"
+)
+
+func openAudit() *audit.Log {
+	path, err := config.AuditLogPath()
+	if err != nil {
+		slog.Warn("audit log disabled", "err", err)
+		return nil
+	}
+	return audit.Open(path)
+}
+
+func (s *SenderApp) recordSend(st engine.SenderStatus) {
+	if s.audit == nil {
+		return
+	}
+
+	settings := map[string]string{
+		"error_level": s.errorLevel.String(),
+		"rate":        s.refreshRate.String(),
+		"eink":        fmt.Sprint(s.eink),
+		"projector":   fmt.Sprint(s.projector),
+		"tool":        "sender",
+	}
+	s.recordAudit(audit.SendEntry(st.Payload, settings, ""))
+}
+
+func (s *SenderApp) recordAudit(e audit.Entry) {
+	go func() {
+		if err := s.audit.Record(e); err != nil {
+			slog.Error("writing audit log failed", "path", s.audit.Path(), "err", err)
+			fyne.Do(func() { s.status.SetText(fmt.Sprintf("Audit log error: %v", err)) })
+		}
+	}()
+}
+
+func (s *SenderApp) showAudit() {
+	if s.audit == nil {
+		dialog.ShowInformation("Audit Log", "The audit log is unavailable: no settings directory.", s.window)
+		return
+	}
+	if s.auditWin != nil {
+		s.auditWin.RequestFocus()
+		return
+	}
+
+	grid := widget.NewTextGrid()
+	check := widget.NewLabel("")
+	check.Wrapping = fyne.TextWrapWord
+	refresh := func() {
+		entries, err := s.audit.Entries()
+		if err == nil {
+			err = audit.Verify(entries)
+		}
+		lines := make([]string, len(entries))
+		for i, e := range entries {
+			lines[i] = audit.Summary(e)
+		}
+		grid.SetText(strings.Join(lines, "\n"))
+		grid.ScrollToBottom()
+
+		switch {
+		case err != nil:
+			check.SetText("Warning: " + err.Error())
+			check.Importance = widget.DangerImportance
+		default:
+			check.SetText(fmt.Sprintf("%d entries in %s, chain intact", len(entries), s.audit.Path()))
+			check.Importance = widget.MediumImportance
+		}
+		check.Refresh()
+	}
+
+	note := widget.NewMultiLineEntry()
+	note.SetPlaceHolder("Operator note, such as who carried the media or why the file was sent")
+	addBtn := widget.NewButton("Add Note", func() {
+		text := strings.TrimSpace(note.Text)
+		if text == "" {
+			return
+		}
+		e := audit.Entry{Event: audit.EventNote, Note: text, Settings: map[string]string{"tool": "sender"}}
+		if p := s.last.Payload; p != nil {
+			e.Session, e.File = p.Session(), p.Metadata.Filename
+		}
+		if err := s.audit.Record(e); err != nil {
+			dialog.ShowError(err, s.auditWin)
+			return
+		}
+		note.SetText("")
+		refresh()
+	})
+	exportBtn := widget.NewButton("Export...", func() {
+		exportAudit(s.audit, s.auditWin)
+	})
+
+	w := s.app.NewWindow("QR File Sender - Audit Log")
+	w.SetContent(container.NewBorder(
+		check,
+		container.NewVBox(note, container.NewHBox(addBtn, exportBtn)),
+		nil, nil,
+		grid,
+	))
+	w.Resize(fyne.NewSize(720, 400))
+	w.SetOnClosed(func() {
+		s.auditWin = nil
+	})
+
+	s.auditWin = w
+	refresh()
+	w.Show()
+}
+
+func exportAudit(log *audit.Log, parent fyne.Window) {
+	entries, err := log.Entries()
+	if err != nil {
+		dialog.ShowError(err, parent)
+		return
+	}
+
+	d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, parent)
+			return
+		}
+		if writer == nil {
+			return
+		}
+
+		path := writer.URI().Path()
+		if writer.URI().Scheme() == "file" {
+			writer.Close()
+			err = engine.WriteAtomic(path, func(w io.Writer) error {
+				return audit.Export(w, path, entries)
+			})
+		} else {
+			err = audit.Export(writer, writer.URI().Name(), entries)
+			if cerr := writer.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err != nil {
+			dialog.ShowError(err, parent)
+		}
+	}, parent)
+	d.SetFileName("audit.json")
+	d.Show()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/screen"
+)
+
+const (
+	backchannelOff      = "Off"
+	backchannelFPS      = 2
+	backchannelCooldown = 10 * time.Second
+)
+
+func (s *SenderApp) setupBackchannel() fyne.CanvasObject {
+	options := []string{backchannelOff}
+	if cameras, err := screen.ListCameras(); err == nil {
+		options = append(options, cameras...)
+	}
+
+	cameraSelect := widget.NewSelect(options, s.setBackchannel)
+	cameraSelect.SetSelected(backchannelOff)
+
+	autoRateCheck := widget.NewCheck("Adjust refresh rate from receiver status", func(on bool) {
+		s.do(func() { s.autoRate = on })
+	})
+	autoRateCheck.SetChecked(s.autoRate)
+
+	return container.NewVBox(widget.NewLabel("Receiver status camera:"), cameraSelect, autoRateCheck)
+}
+
+func (s *SenderApp) setBackchannel(name string) {
+	if s.backCancel != nil {
+		s.backCancel()
+		s.backCancel = nil
+	}
+	if name == backchannelOff {
+		return
+	}
+
+	cam, err := screen.OpenCamera(screen.CameraConfig{Device: name, FPS: backchannelFPS})
+	if err != nil {
+		s.status.SetText(fmt.Sprintf("Camera error: %v", err))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	frames, err := screen.StreamSource(ctx, cam, backchannelFPS)
+	if err != nil {
+		cancel()
+		cam.Close()
+		s.status.SetText(fmt.Sprintf("Camera error: %v", err))
+		return
+	}
+
+	s.backCancel = cancel
+	go s.watchBackchannel(frames, cam)
+}
+
+func (s *SenderApp) watchBackchannel(frames <-chan screen.Frame, cam screen.Source) {
+	defer cam.Close()
+
+	for f := range frames {
+		if f.Err != nil {
+			continue
+		}
+		for _, region := range screen.DecodeRegions(f.Image, engine.DefaultBlockSize) {
+			if region.Err == nil && engine.IsPublicKeyCode(region.Data) {
+				if pub, err := engine.ParsePublicKeyCode(region.Data); err == nil {
+					fyne.Do(func() { s.scannedRecipient(pub) })
+				}
+				continue
+			}
+			if region.Err != nil || !engine.IsStatus(region.Data) {
+				continue
+			}
+			st, err := engine.ParseStatus(region.Data)
+			if err != nil {
+				slog.Debug("ignoring unreadable receiver status", "err", err)
+				continue
+			}
+			s.do(func() { s.applyReceiverStatus(st) })
+		}
+	}
+}
+
+func (s *SenderApp) applyReceiverStatus(rs engine.ReceiverStatus) {
+	st := s.engine.Status()
+	if st.Payload == nil || rs.Session != st.Payload.Session() {
+		return
+	}
+	s.tuneRate(st, rs)
+
+	if len(rs.Missing) == 0 {
+		if !s.backDone {
+			s.backDone = true
+			fyne.DoAndWait(func() { s.status.SetText("Receiver reports every chunk received") })
+		}
+		return
+	}
+	s.backDone = false
+
+	if st.Pending > 0 || time.Since(s.backAsked) < backchannelCooldown {
+		return
+	}
+	s.backAsked = time.Now()
+
+	slog.Info("resending chunks reported missing by the receiver", "count", len(rs.Missing), "truncated", rs.Truncated)
+	s.retransmit(rs.Missing)
+}
+
+type backSample struct {
+	session  uint64
+	sent     int
+	pass     int
+	received uint32
+}
+
+func (s *SenderApp) tuneRate(st engine.SenderStatus, rs engine.ReceiverStatus) {
+	prev := s.backLast
+	s.backLast = backSample{session: rs.Session, sent: st.Sent, pass: st.Pass, received: rs.Received}
+	if prev.session != rs.Session {
+		s.rateTuner.Reset()
+		return
+	}
+	if !s.autoRate || st.State != engine.SenderRunning || st.Config.Manual || st.Pass > 0 || prev.pass != st.Pass || st.Pending > 0 || len(rs.Missing) == 0 || st.Frames == 0 {
+		return
+	}
+
+	frames := st.Sent - prev.sent
+	received := int(rs.Received) - int(prev.received)
+	if frames <= 0 || received < 0 {
+		return
+	}
+	expected := float64(frames) * float64(rs.Total) / float64(st.Frames)
+
+	s.rateTuner.Min = 1 / s.rateLimit()
+	s.rateTuner.Max = 1 / minRate
+	current := 1 / s.refreshRate.Seconds()
+	next := s.rateTuner.Observe(time.Now(), current, expected, float64(received))
+	if next == current {
+		return
+	}
+
+	s.refreshRate = time.Duration(float64(time.Second) / next)
+	slog.Info("refresh rate adjusted from receiver status", "interval", s.refreshRate, "success", s.rateTuner.Ratio())
+	s.engine.SetInterval(s.refreshRate)
+	seconds := s.refreshRate.Seconds()
+	fyne.Do(func() {
+		s.rateSlider.Value = seconds
+		s.rateSlider.Refresh()
+		s.updateChunkInfo()
+	})
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/engine"
+)
+
+func (s *SenderApp) setupCalibration() fyne.CanvasObject {
+	s.calibrateBtn = widget.NewButton("Calibrate", s.toggleCalibration)
+	applyBtn := widget.NewButton("Apply Calibration...", s.showApplyCalibration)
+	return container.NewGridWithColumns(2, s.calibrateBtn, applyBtn)
+}
+
+func (s *SenderApp) toggleCalibration() {
+	if s.calCancel != nil {
+		s.stopCalibration()
+		return
+	}
+
+	s.do(func() {
+		if s.running() {
+			fyne.Do(func() { s.status.SetText("Stop the transfer before calibrating") })
+			return
+		}
+		fyne.DoAndWait(s.startCalibration)
+	})
+}
+
+func (s *SenderApp) startCalibration() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.calCancel = cancel
+	s.calibrateBtn.SetText("Stop Calibration")
+	s.status.SetText("Calibrating: start capture and open Calibration on the receiver")
+
+	go func() {
+		err := engine.PlayCalibration(ctx, s.surface, func(done, total int) {
+			fyne.Do(func() { s.status.SetText(fmt.Sprintf("Calibration frame %d of %d", done, total)) })
+		})
+		fyne.Do(func() {
+			if s.calCancel != nil {
+				s.calCancel()
+				s.calCancel = nil
+			}
+			s.calibrateBtn.SetText("Calibrate")
+			s.image.Image = s.createPlaceholderImage()
+			s.image.Refresh()
+
+			switch {
+			case errors.Is(err, context.Canceled):
+				s.status.SetText("Calibration stopped")
+			case err != nil:
+				s.status.SetText(fmt.Sprintf("Calibration failed: %v", err))
+			default:
+				s.status.SetText("Calibration finished: press Finish on the receiver and apply its settings here")
+			}
+		})
+	}()
+}
+
+func (s *SenderApp) stopCalibration() {
+	if s.calCancel != nil {
+		s.calCancel()
+		s.calCancel = nil
+	}
+}
+
+func (s *SenderApp) showApplyCalibration() {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("level=high chunk=1200 interval=500ms")
+
+	items := []*widget.FormItem{widget.NewFormItem("Settings", entry)}
+	dialog.ShowForm("Apply Calibration", "Apply", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		cal, err := engine.ParseCalibration(entry.Text)
+		if err != nil {
+			dialog.ShowError(err, s.window)
+			return
+		}
+		s.applyCalibration(cal)
+	}, s.window)
+}
+
+func (s *SenderApp) applyCalibration(cal engine.Calibration) {
+	s.levelSelect.SetSelectedIndex(int(cal.ErrorLevel))
+
+	size := cal.ChunkSize
+	if limit := s.maxChunkSize(); size > limit {
+		size = max(limit, 1)
+	}
+	s.chunkEntry.SetText(strconv.Itoa(size))
+
+	if cal.Interval > 0 {
+		rate := min(max(cal.Interval.Seconds(), minRate), maxRate)
+		s.rateSlider.SetValue(rate)
+		if rate != cal.Interval.Seconds() {
+			s.status.SetText(fmt.Sprintf("Applied calibration; refresh rate limited to %s", time.Duration(rate*float64(time.Second))))
+			return
+		}
+	}
+	s.status.SetText("Applied calibration: " + cal.String())
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/qr"
+	"qrtransfer/pkg/secure"
+)
+
+const (
+	defaultChunkSize = 100
+	estimateSize     = 1 << 20
+)
+
+var errChunkSize = errors.New("chunk size must be a positive number of bytes")
+
+func (s *SenderApp) setupChunkSize() fyne.CanvasObject {
+	entry := widget.NewEntry()
+	entry.SetText(strconv.Itoa(s.chunkSize))
+	entry.Validator = func(text string) error {
+		if size, err := strconv.Atoi(text); err != nil || size <= 0 {
+			return errChunkSize
+		}
+		return nil
+	}
+	entry.OnChanged = func(text string) {
+		s.do(func() {
+			size, err := s.parseChunkSize(text)
+			if err != nil {
+				fyne.DoAndWait(func() { s.chunkInfo.SetText(err.Error()) })
+				return
+			}
+			s.setChunkSize(size)
+		})
+	}
+
+	s.chunkEntry = entry
+	s.chunkInfo = widget.NewLabel("")
+	s.chunkInfo.Wrapping = fyne.TextWrapWord
+	s.updateChunkInfo()
+
+	return container.NewVBox(entry, s.chunkInfo)
+}
+
+func (s *SenderApp) maxChunkSize() int {
+	return engine.MaxChunkSize(s.surface.Size(), s.caption, qr.Config{Monochrome: s.eink, Palette: s.projector})
+}
+
+func (s *SenderApp) parseChunkSize(text string) (int, error) {
+	size, err := strconv.Atoi(text)
+	if err != nil || size <= 0 {
+		return 0, errChunkSize
+	}
+
+	if limit := s.maxChunkSize(); size > limit {
+		frame := s.surface.Size()
+		return 0, fmt.Errorf("too large: at most %d bytes fit a %dx%d frame", max(limit, 0), frame.X, frame.Y)
+	}
+	return size, nil
+}
+
+func (s *SenderApp) setChunkSize(size int) {
+	if size == s.chunkSize {
+		return
+	}
+
+	s.chunkSize = size
+	s.reload()
+	fyne.DoAndWait(s.updateChunkInfo)
+}
+
+func (s *SenderApp) updateChunkInfo() {
+	if s.chunkInfo == nil {
+		return
+	}
+
+	size, sizeName := int64(estimateSize), "1 MB"
+	if p := s.last.Payload; p != nil {
+		size, sizeName = int64(p.Metadata.FileSize), "this file"
+	}
+	est := engine.EstimateThroughput(size, qr.Config{ErrorLevel: s.errorLevel, Monochrome: s.eink, Palette: s.projector},
+		chunk.NewConfig(s.chunkSize, s.redundancy), s.strategy, s.refreshRate)
+
+	text := fmt.Sprintf("Grid: %dx%d blocks (max %d bytes)", est.Grid, est.Grid, s.maxChunkSize())
+	if frames := s.last.Frames; frames > 0 {
+		text += fmt.Sprintf("\nFrames: %d", frames)
+	}
+	if est.Intact() {
+		text += fmt.Sprintf("\nEstimate: %s/s, %v for %s", formatBytes(est.BytesPerSecond()), est.Duration.Round(time.Second), sizeName)
+	} else {
+		text += fmt.Sprintf("\nEstimate: nothing arrives intact, %s error correction keeps %d of the %d bytes in each frame", errorLevelNames[s.errorLevel], est.Capacity, est.FrameBytes)
+	}
+	switch {
+	case s.recipient != nil:
+		text += "\nEncrypted to receiver " + secure.Fingerprint(s.recipient)
+	case s.keys != nil:
+		text += "\nEncrypted with passphrase"
+	}
+	if s.signer != nil {
+		text += "\nSigned by " + secure.Fingerprint(s.signer.PublicKey())
+	}
+	s.chunkInfo.SetText(text)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/secure"
+)
+
+func (s *SenderApp) setupPassphrase() fyne.CanvasObject {
+	entry := widget.NewPasswordEntry()
+	entry.SetPlaceHolder("Leave empty to send unencrypted")
+	entry.OnChanged = func(text string) {
+		s.schedulePassphraseSave(text)
+		s.do(func() {
+			s.keys = secure.Passphrase(text)
+			s.reload()
+			fyne.DoAndWait(s.updateChunkInfo)
+		})
+	}
+
+	s.recipientEntry = widget.NewEntry()
+	s.recipientEntry.SetPlaceHolder("Paste the receiver's key or scan its key code")
+	recipientInfo := widget.NewLabel("")
+	recipientInfo.Wrapping = fyne.TextWrapWord
+	s.recipientEntry.OnChanged = func(text string) {
+		pub, err := secure.ParsePublicKey(text)
+		switch {
+		case text == "":
+			recipientInfo.SetText("")
+		case err != nil:
+			recipientInfo.SetText(err.Error())
+		default:
+			recipientInfo.SetText("Encrypting to receiver " + secure.Fingerprint(pub))
+		}
+		s.do(func() {
+			if bytes.Equal(pub, s.recipient) {
+				return
+			}
+			s.recipient = pub
+			s.reload()
+			fyne.DoAndWait(s.updateChunkInfo)
+		})
+	}
+
+	wipeCheck := widget.NewCheck("Wipe file data from memory after use", func(checked bool) {
+		s.do(func() {
+			s.wipe = checked
+			s.engine.SetWipe(checked)
+		})
+	})
+	wipeCheck.Checked = s.wipe
+
+	return container.NewVBox(
+		widget.NewLabel("Encryption Passphrase:"), entry, s.setupRemember(entry),
+		widget.NewLabel("Receiver Key:"), s.recipientEntry, recipientInfo,
+		wipeCheck,
+	)
+}
+
+func (s *SenderApp) scannedRecipient(pub []byte) {
+	text := secure.FormatPublicKey(pub)
+	if s.recipientEntry.Text != text {
+		s.recipientEntry.SetText(text)
+		s.status.SetText("Scanned receiver key " + secure.Fingerprint(pub))
+	}
+}
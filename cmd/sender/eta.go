@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"qrtransfer/pkg/engine"
+)
+
+func formatBytes(n float64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", n, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", n, units[i])
+}
+
+func (s *SenderApp) updateETA() {
+	st := s.last
+	frames := st.Frames
+	if frames == 0 {
+		s.etaLabel.SetText("")
+		return
+	}
+
+	remaining := frames
+	if st.State.Active() {
+		remaining = max(frames-st.Position, 0)
+	}
+
+	total := time.Duration(frames) * st.Config.Interval
+	left := time.Duration(remaining) * st.Config.Interval
+	rate := float64(st.Payload.Metadata.FileSize) / total.Seconds()
+
+	text := fmt.Sprintf("Throughput: %s/s\nFrames remaining: %d of %d\nTime remaining: %v (total %v)",
+		formatBytes(rate), remaining, frames, left.Round(time.Second), total.Round(time.Second))
+	if st.State == engine.SenderRunning {
+		text += "\nFinishes at " + time.Now().Add(left).Format("15:04:05")
+	}
+	s.etaLabel.SetText(text)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/config"
+	"qrtransfer/pkg/secure"
+)
+
+const passphraseSaveDelay = time.Second
+
+func (s *SenderApp) setupRemember(entry *widget.Entry) fyne.CanvasObject {
+	s.passEntry = entry
+	s.rememberPass = widget.NewCheck("Remember in system keyring", func(on bool) {
+		if s.passSave != nil {
+			s.passSave.Stop()
+		}
+		text := ""
+		if on {
+			text = s.passEntry.Text
+		}
+		go s.storePassphrase(text)
+	})
+	if !secure.KeyringAvailable() {
+		s.rememberPass.Disable()
+		return s.rememberPass
+	}
+
+	saved, err := secure.KeyringGet(secure.KeyringSenderPassphrase)
+	switch {
+	case err == nil:
+		entry.SetText(saved)
+		s.rememberPass.Checked = true
+	case !errors.Is(err, secure.ErrNotInKeyring):
+		slog.Warn("reading saved passphrase failed", "err", err)
+	}
+	return s.rememberPass
+}
+
+func (s *SenderApp) schedulePassphraseSave(text string) {
+	if s.rememberPass == nil || !s.rememberPass.Checked {
+		return
+	}
+	if s.passSave != nil {
+		s.passSave.Stop()
+	}
+	s.passSave = time.AfterFunc(passphraseSaveDelay, func() { s.storePassphrase(text) })
+}
+
+func (s *SenderApp) storePassphrase(text string) {
+	err := secure.KeyringDelete(secure.KeyringSenderPassphrase)
+	if text != "" {
+		err = secure.KeyringSet(secure.KeyringSenderPassphrase, text)
+	}
+	if err != nil {
+		slog.Error("saving passphrase failed", "err", err)
+		fyne.Do(func() { s.status.SetText("Saving passphrase failed: " + err.Error()) })
+	}
+}
+
+func (s *SenderApp) showKeys() {
+	path, pathErr := config.SigningKeyPath()
+	available := secure.KeyringAvailable()
+
+	keyInfo := widget.NewLabel("")
+	keyInfo.Wrapping = fyne.TextWrapWord
+	passInfo := widget.NewLabel("")
+	passInfo.Wrapping = fyne.TextWrapWord
+	var moveBtn, newBtn, forgetBtn *widget.Button
+
+	refresh := func() {
+		signer, where, err := secure.StoredSigner(path)
+		switch {
+		case pathErr != nil:
+			keyInfo.SetText("Signing key unavailable: " + pathErr.Error())
+		case err != nil:
+			keyInfo.SetText("Signing key unreadable: " + err.Error())
+		case signer == nil:
+			keyInfo.SetText("No signing key yet: one is created the first time a transfer is signed.")
+		default:
+			keyInfo.SetText(fmt.Sprintf("Signing key %s\nStored in %s", secure.Fingerprint(signer.PublicKey()), where))
+		}
+		if available && signer != nil && where == path {
+			moveBtn.Enable()
+		} else {
+			moveBtn.Disable()
+		}
+		if pathErr != nil {
+			newBtn.Disable()
+		}
+
+		_, err = secure.KeyringGet(secure.KeyringSenderPassphrase)
+		switch {
+		case !available:
+			passInfo.SetText("No system keyring is available, so passphrases cannot be saved and the signing key stays in a file in the settings folder.")
+			forgetBtn.Disable()
+		case err == nil:
+			passInfo.SetText("A passphrase is saved in the system keyring.")
+			forgetBtn.Enable()
+		default:
+			passInfo.SetText("No passphrase is saved.")
+			forgetBtn.Disable()
+		}
+	}
+
+	changed := func(err error) {
+		if err != nil {
+			dialog.ShowError(err, s.window)
+		}
+		s.do(func() {
+			if s.sign {
+				s.setSigning(true)
+			}
+		})
+		refresh()
+	}
+	moveBtn = widget.NewButton("Move to Keyring", func() {
+		_, err := secure.MoveSignerToKeyring(path)
+		changed(err)
+	})
+	newBtn = widget.NewButton("New Signing Key", func() {
+		dialog.ShowConfirm("New Signing Key",
+			"Replace the signing key? Receivers that check the current fingerprint will not recognize transfers signed with the new one.",
+			func(ok bool) {
+				if ok {
+					_, _, err := secure.ReplaceSigner(path)
+					changed(err)
+				}
+			}, s.window)
+	})
+	forgetBtn = widget.NewButton("Forget Passphrase", func() {
+		if s.rememberPass.Checked {
+			s.rememberPass.SetChecked(false)
+		} else if err := secure.KeyringDelete(secure.KeyringSenderPassphrase); err != nil {
+			dialog.ShowError(err, s.window)
+		}
+		passInfo.SetText("No passphrase is saved.")
+		forgetBtn.Disable()
+	})
+
+	refresh()
+	dialog.NewCustom("Keys", "Close", container.NewVBox(
+		keyInfo, container.NewHBox(moveBtn, newBtn),
+		widget.NewSeparator(),
+		passInfo, forgetBtn,
+	), s.window).Show()
+}
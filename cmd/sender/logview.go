@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/logging"
+)
+
+func (s *SenderApp) showLog() {
+	if s.logWin != nil {
+		s.logWin.RequestFocus()
+		return
+	}
+
+	grid := widget.NewTextGridFromString(strings.Join(s.log.History.Lines(), "\n"))
+	grid.ScrollToBottom()
+
+	levelSelect := widget.NewSelect(logging.Levels(), func(value string) {
+		if level, err := logging.ParseLevel(value); err == nil {
+			s.log.Level.Set(level)
+		}
+	})
+	levelSelect.SetSelected(logging.LevelName(s.log.Level.Level()))
+
+	clearBtn := widget.NewButton("Clear", func() {
+		s.log.History.Clear()
+		grid.SetText("")
+	})
+
+	w := s.app.NewWindow("QR File Sender - Log")
+	w.SetContent(container.NewBorder(
+		container.NewHBox(widget.NewLabel("Level:"), levelSelect, clearBtn),
+		nil, nil, nil,
+		grid,
+	))
+	w.Resize(fyne.NewSize(720, 400))
+
+	s.log.History.OnLine(func(line string) {
+		fyne.Do(func() {
+			grid.Append(line)
+			grid.ScrollToBottom()
+		})
+	})
+	w.SetOnClosed(func() {
+		s.log.History.OnLine(nil)
+		s.logWin = nil
+	})
+
+	s.logWin = w
+	w.Show()
+}
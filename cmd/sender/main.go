@@ -1,6 +1,10 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/canvas"
@@ -8,50 +12,142 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 	"image"
+	"log/slog"
 	"os"
+	"strconv"
 	"time"
 
+	"qrtransfer/pkg/audit"
 	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/config"
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/logging"
 	"qrtransfer/pkg/qr"
+	"qrtransfer/pkg/screen"
+	"qrtransfer/pkg/secure"
 )
 
-type SenderApp struct {
-	app       fyne.App
-	window    fyne.Window
-	image     *canvas.Image
-	filename  string
-	origName  string
-	startBtn  *widget.Button
-	stopBtn   *widget.Button
-	chunkProc *chunk.Processor
-	qrEnc     *qr.Encoder
-	qrConfig  qr.Config
-	status    *widget.Label
-
-	currentChunk uint32
-	totalChunks  uint32
-	metadata     chunk.FileMetadata
-	chunks       [][]chunk.Chunk
+const previewSize = 400
+
+var strategyLabels = []string{"Immediate repeat", "Delayed repeat", "Parity"}
 
+type SenderApp struct {
+	app        fyne.App
+	window     fyne.Window
+	image      *canvas.Image
+	filename   string
+	origName   string
+	startBtn   *widget.Button
+	stopBtn    *widget.Button
+	pauseBtn   *widget.Button
+	nextBtn    *widget.Button
+	status     *widget.Label
+	etaLabel   *widget.Label
+	rateSlider *widget.Slider
+	chunkInfo  *widget.Label
+
+	seekSlider *widget.Slider
+	seekLabel  *widget.Label
+
+	engine  *engine.Sender
+	surface *surface
+	last    engine.SenderStatus
+
+	chunkSize   int
+	errorLevel  qr.ErrorLevel
 	refreshRate time.Duration
-	running     bool
+	redundancy  int
+	strategy    chunk.Strategy
+	caption     bool
+	eink        bool
+	projector   bool
+	keys        chunk.Keys
+	recipient   []byte
+	sign        bool
+	signer      *secure.Signer
+	pairing     *secure.Pairing
+	wipe        bool
+	text        string
+
+	commands chan command
+	cancel   context.CancelFunc
+
+	displays      []screen.Display
+	displaySelect *widget.Select
+	presentWin    fyne.Window
+	presentImg    *canvas.Image
+
+	queue        []queueItem
+	queueSel     int
+	queueNames   []string
+	queueView    []string
+	queueList    *widget.List
+	queueLabel   *widget.Label
+	fileProgress *widget.ProgressBar
+	addQueueBtn  *widget.Button
+
+	log    *logging.Log
+	logWin fyne.Window
+
+	audit    *audit.Log
+	auditWin fyne.Window
+
+	passEntry    *widget.Entry
+	rememberPass *widget.Check
+	passSave     *time.Timer
+
+	trayMenu   *fyne.Menu
+	trayStatus *fyne.MenuItem
+	trayPause  *fyne.MenuItem
+	trayStop   *fyne.MenuItem
+
+	backCancel context.CancelFunc
+	backAsked  time.Time
+	backDone   bool
+
+	autoRate  bool
+	rateTuner engine.RateTuner
+	backLast  backSample
+
+	chunkEntry     *widget.Entry
+	recipientEntry *widget.Entry
+	signInfo       *widget.Label
+	levelSelect    *widget.Select
+	calibrateBtn   *widget.Button
+	calCancel      context.CancelFunc
+
+	theme      string
+	configPath string
 }
 
-func NewSenderApp() *SenderApp {
+func NewSenderApp(log *logging.Log, cfg config.Config, configPath string) *SenderApp {
 	a := app.New()
 	w := a.NewWindow("QR File Sender")
 
 	sender := &SenderApp{
 		app:         a,
 		window:      w,
-		chunkProc:   chunk.NewProcessor(chunk.NewConfig(100, 1)),
-		qrEnc:       qr.NewEncoder(qr.Config{}),
-		qrConfig:    qr.Config{},
-		refreshRate: 2 * time.Second,
-		running:     false,
+		chunkSize:   defaultChunkSize,
+		refreshRate: engine.DefaultInterval,
+		redundancy:  1,
+		queueSel:    -1,
+		commands:    make(chan command, commandBuffer),
+		log:         log,
+		audit:       openAudit(),
+		theme:       cfg.Theme,
+		configPath:  configPath,
 	}
+	sender.applySettings(cfg.Sender)
+	sender.surface = &surface{app: sender, size: image.Pt(previewSize, previewSize)}
+	sender.engine = engine.NewSender(sender.surface, engine.SenderConfig{ErrorLevel: sender.errorLevel, Interval: sender.refreshRate, EInk: sender.eink, Projector: sender.projector, Wipe: sender.wipe}, sender.notify)
 
 	sender.setupUI()
+	sender.setupTray()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sender.cancel = cancel
+	go sender.engine.Run(ctx)
+	go sender.worker(ctx)
 
 	return sender
 }
@@ -61,65 +157,188 @@ func (s *SenderApp) setupUI() {
 		FillMode: canvas.ImageFillContain,
 	}
 
-	s.image.SetMinSize(fyne.NewSize(400, 400))
+	s.image.SetMinSize(fyne.NewSize(previewSize, previewSize))
 
 	selectBtn := widget.NewButton("Select File", s.selectFile)
 
 	s.startBtn = widget.NewButton("Start Transfer", s.startTransfer)
 	s.startBtn.Disable()
 
-	s.stopBtn = widget.NewButton("Stop Transfer", s.stopTransfer)
+	s.stopBtn = widget.NewButton("Stop Transfer", func() { s.do(s.engine.Stop) })
 	s.stopBtn.Disable()
 
-	s.status = widget.NewLabel("No file selected")
+	s.pauseBtn = widget.NewButton("Pause", func() { s.do(s.engine.TogglePause) })
+	s.pauseBtn.Disable()
 
-	rateSlider := widget.NewSlider(0.5, 5.0)
-	rateSlider.Value = 2.0
-	rateSlider.OnChanged = func(value float64) {
-		s.refreshRate = time.Duration(value * float64(time.Second))
+	s.nextBtn = widget.NewButton("Next Frame", func() { s.do(s.engine.Step) })
+	s.nextBtn.Disable()
+
+	s.status = widget.NewLabel("No file selected")
+	s.etaLabel = widget.NewLabel("")
+
+	s.rateSlider = widget.NewSlider(minRate, s.rateLimit())
+	s.rateSlider.Value = s.refreshRate.Seconds()
+	s.rateSlider.OnChanged = func(value float64) {
+		s.do(func() {
+			s.refreshRate = time.Duration(value * float64(time.Second))
+			s.engine.SetInterval(s.refreshRate)
+			fyne.DoAndWait(s.updateChunkInfo)
+		})
 	}
 
 	redundancySelect := widget.NewSelect([]string{"1x", "2x", "3x"}, func(value string) {
-		_ = value
+		s.do(func() {
+			s.redundancy = int(value[0] - '0')
+			s.reload()
+			fyne.DoAndWait(s.updateChunkInfo)
+		})
 	})
-	redundancySelect.SetSelectedIndex(0)
-
-	errorLevelSelect := widget.NewSelect([]string{"Low", "Medium", "High"}, func(value string) {
-		switch value {
-		case "Low":
-			s.qrConfig.ErrorLevel = qr.ErrorLevelLow
-		case "Medium":
-			s.qrConfig.ErrorLevel = qr.ErrorLevelMedium
-		case "High":
-			s.qrConfig.ErrorLevel = qr.ErrorLevelHigh
+	redundancySelect.SetSelectedIndex(s.redundancy - 1)
+
+	strategySelect := widget.NewSelect(strategyLabels, func(value string) {
+		for i, label := range strategyLabels {
+			if label == value {
+				s.do(func() {
+					s.strategy = chunk.Strategy(i)
+					s.reload()
+					fyne.DoAndWait(s.updateChunkInfo)
+				})
+			}
 		}
 	})
-	errorLevelSelect.SetSelectedIndex(1)
+	strategySelect.SetSelectedIndex(int(s.strategy))
+
+	s.levelSelect = widget.NewSelect(errorLevelNames, func(value string) {
+		for i, name := range errorLevelNames {
+			if name == value {
+				s.do(func() {
+					s.errorLevel = qr.ErrorLevel(i)
+					s.engine.SetErrorLevel(s.errorLevel)
+					fyne.DoAndWait(s.updateChunkInfo)
+				})
+			}
+		}
+	})
+	s.levelSelect.SetSelectedIndex(int(s.errorLevel))
+
+	manualCheck := widget.NewCheck("Manual stepping", func(checked bool) {
+		if checked {
+			s.nextBtn.Enable()
+		} else {
+			s.nextBtn.Disable()
+		}
+		s.do(func() { s.engine.SetManual(checked) })
+	})
+
+	loopCheck := widget.NewCheck("Loop", func(checked bool) {
+		s.do(func() { s.engine.SetLoop(checked) })
+	})
+
+	captionCheck := widget.NewCheck("Frame caption", func(checked bool) {
+		s.do(func() {
+			s.caption = checked
+			s.engine.SetCaption(checked)
+			fyne.DoAndWait(s.updateChunkInfo)
+		})
+	})
+
+	einkCheck := widget.NewCheck("E-ink display", func(checked bool) {
+		s.rateSlider.Max = maxRate
+		if checked {
+			s.rateSlider.Max = einkMaxRate
+			s.rateSlider.SetValue(max(s.rateSlider.Value, engine.EInkInterval.Seconds()))
+		} else {
+			s.rateSlider.SetValue(min(s.rateSlider.Value, maxRate))
+		}
+		s.rateSlider.Refresh()
+		s.do(func() {
+			s.eink = checked
+			s.engine.SetEInk(checked)
+			fyne.DoAndWait(s.updateChunkInfo)
+		})
+	})
+	einkCheck.Checked = s.eink
+
+	projectorCheck := widget.NewCheck("Projector (long range)", func(checked bool) {
+		s.do(func() {
+			s.projector = checked
+			s.engine.SetProjector(checked)
+			fyne.DoAndWait(s.updateChunkInfo)
+		})
+		if size, err := strconv.Atoi(s.chunkEntry.Text); checked && (err != nil || size > engine.ProjectorChunkSize) {
+			s.chunkEntry.SetText(strconv.Itoa(engine.ProjectorChunkSize))
+		}
+	})
+	projectorCheck.Checked = s.projector
+
+	s.displaySelect = widget.NewSelect(nil, nil)
+	s.loadDisplays()
+
+	presentBtn := widget.NewButton("Present", s.present)
+	logBtn := widget.NewButton("Show Log", s.showLog)
+	auditBtn := widget.NewButton("Audit Log...", s.showAudit)
+	keysBtn := widget.NewButton("Keys...", s.showKeys)
+	saveDefaultsBtn := widget.NewButton("Save Settings as Default", s.saveDefaults)
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Send File", container.NewVBox(selectBtn, s.setupQueue())),
+		container.NewTabItem("Send Text", s.setupText()),
+	)
 
 	controls := container.NewVBox(
-		widget.NewLabel("File:"),
-		selectBtn,
+		tabs,
 		widget.NewLabel("Error Correction:"),
-		errorLevelSelect,
+		s.levelSelect,
 		widget.NewLabel("Redundancy:"),
 		redundancySelect,
+		strategySelect,
+		widget.NewLabel("Chunk Size (bytes):"),
+		s.setupChunkSize(),
 		widget.NewLabel("Refresh Rate (seconds):"),
-		rateSlider,
+		s.rateSlider,
+		loopCheck,
+		manualCheck,
+		captionCheck,
+		einkCheck,
+		projectorCheck,
+		s.setupPassphrase(),
+		s.setupSigning(),
+		s.setupPairing(),
+		widget.NewLabel("Present on:"),
+		s.displaySelect,
+		presentBtn,
 		s.startBtn,
+		s.pauseBtn,
+		s.nextBtn,
 		s.stopBtn,
+		s.setupSeek(),
+		s.setupBackchannel(),
+		s.setupCalibration(),
 		s.status,
+		s.etaLabel,
+		widget.NewLabel("Theme:"),
+		s.setupTheme(),
+		saveDefaultsBtn,
+		logBtn,
+		auditBtn,
+		keysBtn,
 	)
 
 	content := container.NewHSplit(
-		container.NewCenter(s.image),
+		container.NewStack(canvas.NewRectangle(qr.QuietZoneColor), container.NewCenter(s.image)),
 		controls,
 	)
 
 	s.window.SetContent(content)
 	s.window.Resize(fyne.NewSize(800, 600))
+	s.window.Canvas().SetOnTypedKey(s.typedKey)
 
 	s.image.Image = s.createPlaceholderImage()
 	s.image.Refresh()
+	s.refreshQueue()
+	s.updateETA()
+	s.updateChunkInfo()
+	s.updateSeek()
 }
 
 func (s *SenderApp) selectFile() {
@@ -134,162 +353,278 @@ func (s *SenderApp) selectFile() {
 		}
 
 		uri := reader.URI()
-		s.filename = uri.String()
+		path := uri.String()
 		if uri.Scheme() == "file" {
-			s.filename = uri.Path()
+			path = uri.Path()
 		}
-		s.origName = uri.Name()
-		s.status.SetText("Selected: " + s.origName)
+		name := uri.Name()
+		s.status.SetText("Selected: " + name)
 		reader.Close()
 
-		s.loadFile()
+		s.do(func() {
+			if err := s.openFile(path, name); err != nil {
+				fyne.Do(func() { dialog.ShowError(err, s.window) })
+			}
+		})
 	}, s.window)
 }
 
-func (s *SenderApp) loadFile() {
-	file, err := os.Open(s.filename)
-	if err != nil {
-		dialog.ShowError(err, s.window)
-		return
+func (s *SenderApp) options() engine.Options {
+	return engine.Options{
+		ChunkSize:  s.chunkSize,
+		Redundancy: s.redundancy,
+		Strategy:   s.strategy,
+		Keys:       s.keys,
+		Recipient:  s.recipient,
+		Signer:     s.signer,
+		Pairing:    s.pairing,
 	}
-	defer file.Close()
+}
 
-	fileInfo, err := file.Stat()
-	if err != nil {
-		dialog.ShowError(err, s.window)
+func (s *SenderApp) running() bool {
+	return s.engine.Status().State.Active()
+}
+
+func (s *SenderApp) reload() {
+	if s.running() {
 		return
 	}
 
-	s.metadata = chunk.FileMetadata{
-		Filename:   s.origName,
-		FileSize:   uint64(fileInfo.Size()),
-		ChunkSize:  uint32(s.chunkProc.Config().ChunkSize),
-		Timestamp:  uint64(time.Now().UnixNano()),
-		Redundancy: 1,
+	var err error
+	switch {
+	case s.filename != "":
+		err = s.openFile(s.filename, s.origName)
+	case s.text != "":
+		err = s.loadText(s.text)
 	}
-
-	s.metadata.TotalChunks = uint32((fileInfo.Size() + int64(s.chunkProc.Config().ChunkSize) - 1) / int64(s.chunkProc.Config().ChunkSize))
-
-	file.Seek(0, 0)
-	chunks, err := s.chunkProc.CreateChunks(file, s.metadata, 1)
 	if err != nil {
-		dialog.ShowError(err, s.window)
-		return
+		fyne.Do(func() { dialog.ShowError(err, s.window) })
 	}
+}
 
-	s.chunks = chunks
-	s.currentChunk = 0
-	s.totalChunks = s.metadata.TotalChunks
-	s.startBtn.Enable()
+func (s *SenderApp) openFile(path, name string) error {
+	payload, err := engine.PrepareFile(path, name, s.options())
+	if err != nil {
+		return err
+	}
+	if err := s.setPayload(payload); err != nil {
+		return err
+	}
 
-	s.image.Image = s.createPlaceholderImage()
-	s.image.Refresh()
+	s.filename = path
+	s.origName = name
+	s.text = ""
+	fyne.DoAndWait(s.addQueueBtn.Enable)
+	return nil
 }
 
-func (s *SenderApp) startTransfer() {
-	if len(s.chunks) == 0 {
-		return
+func (s *SenderApp) setPayload(payload *engine.Payload) error {
+	if err := s.engine.Load(payload); err != nil {
+		return err
 	}
 
-	s.running = true
 	fyne.DoAndWait(func() {
-		s.startBtn.Disable()
-		s.stopBtn.Enable()
-		s.status.SetText("Transfer running...")
+		s.image.Image = s.createPlaceholderImage()
+		s.image.Refresh()
+		s.updateChunkInfo()
 	})
-	s.displayCurrentChunk()
+	return nil
 }
 
-func (s *SenderApp) stopTransfer() {
-	s.running = false
-	fyne.DoAndWait(func() {
-		s.stopBtn.Disable()
-		s.startBtn.Enable()
-		s.status.SetText("Transfer stopped")
-	})
-}
+func (s *SenderApp) startTransfer() {
+	s.stopCalibration()
+	s.do(func() {
+		if s.running() {
+			return
+		}
 
-func (s *SenderApp) displayCurrentChunk() {
-	if !s.running || s.currentChunk > s.totalChunks {
-		s.running = false
-		fyne.DoAndWait(func() {
-			s.stopBtn.Disable()
-			s.startBtn.Enable()
-			if s.currentChunk > s.totalChunks {
-				s.status.SetText("Transfer complete!")
+		var items []engine.QueueItem
+		if s.text == "" {
+			for _, item := range s.queue {
+				items = append(items, item.engineItem())
 			}
-		})
-		return
-	}
-
-	var serialized []byte
-	var err error
-
-	if s.currentChunk == 0 {
-		metadataBytes, err := s.chunkProc.SerializeMetadata(s.metadata)
-		if err != nil {
-			s.running = false
-			return
 		}
+		s.engine.SetQueue(items)
 
-		metadataChunk := chunk.Chunk{
-			Index:     0,
-			Total:     s.totalChunks + 1,
-			Data:      metadataBytes,
-			Timestamp: s.metadata.Timestamp,
+		if len(items) > 0 {
+			if err := s.engine.LoadQueue(0); err != nil {
+				fyne.Do(func() { dialog.ShowError(err, s.window) })
+				return
+			}
 		}
-		serialized, err = s.chunkProc.SerializeChunk(metadataChunk)
-		if err != nil {
-			s.running = false
+
+		st := s.engine.Status()
+		if st.Payload == nil {
 			return
 		}
+
+		summary := s.summary(st)
+		fyne.Do(func() {
+			s.showSummary(summary, func() { s.do(s.beginTransfer) })
+		})
+	})
+}
+
+func (s *SenderApp) beginTransfer() {
+	if err := s.engine.Start(); err != nil && !errors.Is(err, engine.ErrRunning) {
+		fyne.Do(func() { dialog.ShowError(err, s.window) })
+	}
+}
+
+func (s *SenderApp) applyStatus(st engine.SenderStatus) {
+	prev := s.last
+	s.last = st
+
+	active := st.State.Active()
+	if active || st.Payload == nil {
+		s.startBtn.Disable()
 	} else {
-		chunkSet := s.chunks[s.currentChunk-1]
-		serialized, err = s.chunkProc.SerializeChunk(chunkSet[0])
-		if err != nil {
-			s.running = false
-			return
+		s.startBtn.Enable()
+	}
+	if active {
+		s.stopBtn.Enable()
+		s.pauseBtn.Enable()
+	} else {
+		s.stopBtn.Disable()
+		s.pauseBtn.Disable()
+	}
+	if st.State == engine.SenderPaused {
+		s.pauseBtn.SetText("Resume")
+	} else {
+		s.pauseBtn.SetText("Pause")
+	}
+
+	if st.State == engine.SenderRunning && st.Payload != nil && (!prev.State.Active() || st.Payload != prev.Payload) {
+		s.recordSend(st)
+	}
+
+	if st.State != prev.State || st.Pass != prev.Pass {
+		if text := statusText(st, prev); text != "" {
+			s.status.SetText(text)
 		}
 	}
 
-	s.qrConfig.GridWidth, s.qrConfig.GridHeight = qr.OptimalGridSize(len(serialized))
+	s.refreshQueue()
+	s.updateETA()
+	s.updateSeek()
+	s.updateTray(st)
+}
 
-	s.qrEnc = qr.NewEncoder(s.qrConfig)
+func statusText(st, prev engine.SenderStatus) string {
+	switch st.State {
+	case engine.SenderRunning:
+		if prev.State == engine.SenderRunning && st.Pass > prev.Pass {
+			return fmt.Sprintf("Looping: pass %d", st.Pass)
+		}
+		return "Transfer running..."
+	case engine.SenderPaused:
+		return fmt.Sprintf("Paused on frame %d of %d", st.Position, st.Frames)
+	case engine.SenderStopped:
+		return "Transfer stopped"
+	case engine.SenderFinished:
+		return "Transfer complete!"
+	case engine.SenderFailed:
+		return st.Err.Error()
+	}
+	return ""
+}
 
-	blocks := s.qrEnc.Encode(serialized)
+func (s *SenderApp) typedKey(ev *fyne.KeyEvent) {
+	switch ev.Name {
+	case fyne.KeySpace:
+		s.do(s.engine.TogglePause)
+	case fyne.KeyRight, fyne.KeyReturn, fyne.KeyEnter, fyne.KeyN:
+		s.do(s.engine.Step)
+	}
+}
 
-	img := s.qrEnc.CreateImage(blocks, 400, 400)
+func (s *SenderApp) codeArea() image.Point {
+	return engine.CodeArea(s.surface.Size(), s.caption)
+}
 
-	fyne.DoAndWait(func() {
-		s.image.Image = img
-		s.image.Refresh()
-	})
+func (s *SenderApp) loadDisplays() {
+	displays, err := screen.ListDisplays()
+	if err != nil || len(displays) == 0 {
+		width, height := screen.GetDisplaySize()
+		displays = []screen.Display{{
+			Name:    "Current monitor",
+			Bounds:  image.Rect(0, 0, width, height),
+			Pixels:  image.Rect(0, 0, width, height),
+			Scale:   1,
+			Primary: true,
+		}}
+	}
+	s.displays = displays
+
+	options := make([]string, len(displays))
+	selected := 0
+	for i, d := range displays {
+		options[i] = fmt.Sprintf("%s (%dx%d)", d.Name, d.Pixels.Dx(), d.Pixels.Dy())
+		if d.Primary {
+			selected = i
+		}
+	}
+	s.displaySelect.SetOptions(options)
+	s.displaySelect.SetSelectedIndex(selected)
+}
 
-	s.currentChunk++
+func (s *SenderApp) selectedDisplay() screen.Display {
+	i := s.displaySelect.SelectedIndex()
+	if i < 0 || i >= len(s.displays) {
+		i = 0
+	}
+	return s.displays[i]
+}
 
-	if s.currentChunk > s.totalChunks+1 {
-		s.running = false
-		fyne.DoAndWait(func() {
-			s.stopBtn.Disable()
-			s.startBtn.Enable()
-			s.status.SetText("Transfer complete!")
-		})
+func (s *SenderApp) present() {
+	if s.presentWin != nil {
+		s.presentWin.RequestFocus()
 		return
 	}
 
-	go func() {
-		time.Sleep(s.refreshRate)
-		if s.running {
-			s.displayCurrentChunk()
+	d := s.selectedDisplay()
+	s.setFrameSize(image.Pt(d.Pixels.Dx(), d.Pixels.Dy()))
+
+	s.presentImg = &canvas.Image{
+		FillMode:  canvas.ImageFillContain,
+		ScaleMode: canvas.ImageScalePixels,
+		Image:     s.image.Image,
+	}
+
+	w := s.app.NewWindow("QR File Sender - Present")
+	w.SetPadded(false)
+	w.SetContent(container.NewStack(canvas.NewRectangle(qr.QuietZoneColor), s.presentImg))
+	w.Canvas().SetOnTypedKey(func(ev *fyne.KeyEvent) {
+		if ev.Name == fyne.KeyEscape {
+			w.Close()
+			return
 		}
-	}()
+		s.typedKey(ev)
+	})
+	w.SetOnClosed(func() {
+		s.presentWin = nil
+		s.presentImg = nil
+		s.setFrameSize(image.Pt(previewSize, previewSize))
+	})
+	w.SetFullScreen(true)
+
+	s.presentWin = w
+	w.Show()
+	s.status.SetText("Presenting on " + d.Name + " (Esc to exit)")
+}
+
+func (s *SenderApp) setFrameSize(size image.Point) {
+	s.do(func() {
+		s.surface.setSize(size)
+		fyne.DoAndWait(s.updateChunkInfo)
+	})
 }
 
 func (s *SenderApp) createPlaceholderImage() image.Image {
-	img := image.NewRGBA(image.Rect(0, 0, 400, 400))
+	img := image.NewRGBA(image.Rect(0, 0, previewSize, previewSize))
 
-	for y := 0; y < 400; y++ {
-		for x := 0; x < 400; x++ {
+	for y := 0; y < previewSize; y++ {
+		for x := 0; x < previewSize; x++ {
 			img.Set(x, y, image.White)
 		}
 	}
@@ -299,9 +634,55 @@ func (s *SenderApp) createPlaceholderImage() image.Image {
 
 func (s *SenderApp) Run() {
 	s.window.ShowAndRun()
+	s.cancel()
 }
 
 func main() {
-	app := NewSenderApp()
+	apiAddr := flag.String("api", "", "serve the control API on this loopback address, e.g. 127.0.0.1:8765")
+	logLevel := flag.String("log-level", logging.DefaultLevel(), "log verbosity: debug, info, warn or error")
+	configFile := flag.String("config", "", "settings file (default ~/.config/owl-transfer/config.yaml)")
+	chunkSize := flag.Int("chunk-size", 0, "payload bytes per frame, overriding the settings file")
+	rate := flag.Float64("rate", 0, "seconds each frame is shown, overriding the settings file")
+	errorLevel := flag.String("error-level", "", "error correction level: low, medium or high, overriding the settings file")
+	theme := flag.String("theme", "", "UI theme: system, light, dark or high-contrast, overriding the settings file")
+	flag.Parse()
+
+	level, err := logging.ParseLevel(*logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sender:", err)
+		os.Exit(2)
+	}
+	log := logging.Setup(level, os.Stderr)
+
+	configPath, err := config.Resolve(*configFile)
+	if err != nil {
+		slog.Warn("settings file unavailable", "err", err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		slog.Warn("using default settings", "err", err)
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "chunk-size":
+			cfg.Sender.ChunkSize = *chunkSize
+		case "rate":
+			cfg.Sender.Rate = *rate
+		case "error-level":
+			cfg.Sender.ErrorLevel = *errorLevel
+		case "theme":
+			cfg.Theme = *theme
+		}
+	})
+
+	app := NewSenderApp(log, cfg, configPath)
+	if *apiAddr != "" {
+		go func() {
+			if err := app.serveAPI(*apiAddr); err != nil {
+				slog.Error("control API stopped", "err", err)
+			}
+		}()
+	}
 	app.Run()
 }
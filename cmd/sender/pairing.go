@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/secure"
+)
+
+func (s *SenderApp) setupPairing() fyne.CanvasObject {
+	info := widget.NewLabel("")
+	info.Wrapping = fyne.TextWrapWord
+
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("Optional: the receiver's token, or New")
+	entry.OnChanged = func(text string) {
+		token, err := secure.ParsePairingToken(text)
+		switch {
+		case strings.TrimSpace(text) == "":
+			info.SetText("")
+		case err != nil:
+			info.SetText(err.Error())
+		default:
+			info.SetText("Pairing...")
+		}
+		s.do(func() { s.setPairing(token, info) })
+	}
+	newBtn := widget.NewButton("New", func() {
+		token, err := secure.NewPairingToken()
+		if err != nil {
+			info.SetText(err.Error())
+			return
+		}
+		entry.SetText(token)
+	})
+
+	return container.NewVBox(
+		widget.NewLabel("Pairing Token:"),
+		container.NewBorder(nil, nil, nil, newBtn, entry),
+		info,
+	)
+}
+
+func (s *SenderApp) setPairing(token string, info *widget.Label) {
+	var pairing *secure.Pairing
+	text := ""
+	switch {
+	case token == "" && s.pairing == nil:
+		return
+	case token == "":
+	case s.pairing != nil && s.pairing.Token == token:
+		pairing = s.pairing
+	default:
+		var err error
+		pairing, err = secure.Pair(token)
+		if err != nil {
+			text = err.Error()
+		}
+	}
+	if pairing != nil {
+		text = "Only a receiver with token " + token + " will accept this transfer"
+	}
+
+	changed := pairing != s.pairing
+	s.pairing = pairing
+	if changed {
+		s.reload()
+	}
+	fyne.DoAndWait(func() {
+		if text != "" {
+			info.SetText(text)
+		}
+		s.updateChunkInfo()
+	})
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/qr"
+	"qrtransfer/pkg/secure"
+)
+
+var errorLevelNames = []string{"Low", "Medium", "High"}
+
+type queueItem struct {
+	path        string
+	name        string
+	errorLevel  qr.ErrorLevel
+	refreshRate time.Duration
+	redundancy  int
+	strategy    chunk.Strategy
+	chunkSize   int
+	keys        chunk.Keys
+	recipient   []byte
+	signer      *secure.Signer
+	pairing     *secure.Pairing
+}
+
+func (q queueItem) String() string {
+	encrypted := ""
+	if q.keys != nil || q.recipient != nil {
+		encrypted = ", encrypted"
+	}
+	if q.signer != nil {
+		encrypted += ", signed"
+	}
+	if q.pairing != nil {
+		encrypted += ", paired"
+	}
+	return fmt.Sprintf("%s (%s, %.1fs, %dx %s, %dB chunks%s)", q.name, errorLevelNames[q.errorLevel], q.refreshRate.Seconds(), q.redundancy, q.strategy, q.chunkSize, encrypted)
+}
+
+func (s *SenderApp) setupQueue() fyne.CanvasObject {
+	s.queueList = widget.NewList(
+		func() int { return len(s.queueView) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(s.queueView[id])
+		},
+	)
+	s.queueList.OnSelected = func(id widget.ListItemID) {
+		s.queueSel = id
+	}
+	s.queueList.OnUnselected = func(widget.ListItemID) {
+		s.queueSel = -1
+	}
+
+	s.addQueueBtn = widget.NewButton("Add to Queue", func() { s.do(s.enqueue) })
+	s.addQueueBtn.Disable()
+
+	removeBtn := widget.NewButton("Remove", func() {
+		sel := s.queueSel
+		s.do(func() { s.dequeue(sel) })
+	})
+	clearBtn := widget.NewButton("Clear", func() { s.do(s.clearQueue) })
+
+	s.queueLabel = widget.NewLabel("")
+	s.fileProgress = widget.NewProgressBar()
+
+	list := container.NewGridWrap(fyne.NewSize(260, 120), s.queueList)
+
+	return container.NewVBox(
+		widget.NewLabel("Queue:"),
+		list,
+		container.NewGridWithColumns(3, s.addQueueBtn, removeBtn, clearBtn),
+		s.queueLabel,
+		s.fileProgress,
+	)
+}
+
+func (q queueItem) engineItem() engine.QueueItem {
+	return engine.QueueItem{
+		Name:       q.name,
+		ErrorLevel: q.errorLevel,
+		Interval:   q.refreshRate,
+		Load: func() (*engine.Payload, error) {
+			return engine.PrepareFile(q.path, q.name, engine.Options{
+				ChunkSize:  q.chunkSize,
+				Redundancy: q.redundancy,
+				Strategy:   q.strategy,
+				Keys:       q.keys,
+				Recipient:  q.recipient,
+				Signer:     q.signer,
+				Pairing:    q.pairing,
+			})
+		},
+	}
+}
+
+func (s *SenderApp) enqueue() {
+	if s.filename == "" {
+		return
+	}
+
+	item := queueItem{
+		path:        s.filename,
+		name:        s.origName,
+		errorLevel:  s.errorLevel,
+		refreshRate: s.refreshRate,
+		redundancy:  s.redundancy,
+		strategy:    s.strategy,
+		chunkSize:   s.chunkSize,
+		keys:        s.keys,
+		recipient:   s.recipient,
+		signer:      s.signer,
+		pairing:     s.pairing,
+	}
+	s.queue = append(s.queue, item)
+	if s.running() {
+		s.engine.Enqueue(item.engineItem())
+	}
+	s.publishQueue(false)
+}
+
+func (s *SenderApp) dequeue(i int) {
+	if s.running() || i < 0 || i >= len(s.queue) {
+		return
+	}
+
+	s.queue = append(s.queue[:i], s.queue[i+1:]...)
+	s.publishQueue(true)
+}
+
+func (s *SenderApp) clearQueue() {
+	if s.running() {
+		return
+	}
+
+	s.queue = nil
+	s.publishQueue(true)
+}
+
+func (s *SenderApp) publishQueue(unselect bool) {
+	names := make([]string, len(s.queue))
+	for i, item := range s.queue {
+		names[i] = item.String()
+	}
+
+	fyne.DoAndWait(func() {
+		s.queueNames = names
+		if unselect {
+			s.queueList.UnselectAll()
+		}
+		s.refreshQueue()
+	})
+}
+
+func (s *SenderApp) refreshQueue() {
+	st := s.last
+	sending := st.State.Active() && st.QueueLen > 0
+
+	s.queueView = s.queueView[:0]
+	for i, name := range s.queueNames {
+		if sending && i == st.QueuePos {
+			name = "▶ " + name
+		}
+		s.queueView = append(s.queueView, name)
+	}
+	s.queueList.Refresh()
+
+	switch {
+	case len(s.queueNames) == 0:
+		s.queueLabel.SetText("Queue empty")
+	case sending:
+		s.queueLabel.SetText(fmt.Sprintf("File %d of %d: %s", st.QueuePos+1, st.QueueLen, st.Payload.Metadata.Filename))
+	default:
+		s.queueLabel.SetText(fmt.Sprintf("%d files queued", len(s.queueNames)))
+	}
+
+	if st.Frames == 0 {
+		s.fileProgress.SetValue(0)
+		return
+	}
+	s.fileProgress.SetValue(float64(st.Position) / float64(st.Frames))
+}
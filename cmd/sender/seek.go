@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/chunk"
+)
+
+func (s *SenderApp) setupSeek() fyne.CanvasObject {
+	s.seekSlider = widget.NewSlider(0, 1)
+	s.seekSlider.Step = 1
+	s.seekSlider.OnChangeEnded = func(value float64) {
+		s.do(func() { s.seek(int(value)) })
+	}
+
+	s.seekLabel = widget.NewLabel("")
+
+	chunkEntry := widget.NewEntry()
+	chunkEntry.SetPlaceHolder("Chunk #")
+	goBtn := widget.NewButton("Go", func() {
+		n, err := strconv.Atoi(chunkEntry.Text)
+		if err != nil {
+			s.status.SetText("Enter a chunk number")
+			return
+		}
+		s.do(func() { s.seekChunk(n) })
+	})
+	chunkEntry.OnSubmitted = func(string) { goBtn.OnTapped() }
+
+	resendEntry := widget.NewEntry()
+	resendEntry.SetPlaceHolder("Resend chunks, e.g. 3, 7-12")
+	resendBtn := widget.NewButton("Resend", func() {
+		indices, err := chunk.ParseIndices(resendEntry.Text)
+		if err != nil || len(indices) == 0 {
+			s.status.SetText("Enter chunk numbers or ranges to resend")
+			return
+		}
+		s.do(func() { s.retransmit(indices) })
+	})
+	resendEntry.OnSubmitted = func(string) { resendBtn.OnTapped() }
+
+	return container.NewVBox(
+		s.seekLabel,
+		s.seekSlider,
+		container.NewBorder(nil, nil, nil, goBtn, chunkEntry),
+		container.NewBorder(nil, nil, nil, resendBtn, resendEntry),
+	)
+}
+
+func (s *SenderApp) updateSeek() {
+	st := s.last
+	if st.Frames == 0 {
+		s.seekLabel.SetText("Position: -")
+		return
+	}
+
+	pos := min(st.Position, st.Frames-1)
+	s.seekSlider.Max = float64(max(st.Frames-1, 1))
+	s.seekSlider.SetValue(float64(pos))
+	s.seekLabel.SetText(fmt.Sprintf("Position: frame %d of %d", pos+1, st.Frames))
+}
+
+func (s *SenderApp) seek(frame int) {
+	s.engine.Seek(frame)
+
+	st := s.engine.Status()
+	if st.Payload == nil || st.State.Active() {
+		return
+	}
+	fyne.DoAndWait(func() {
+		s.status.SetText(fmt.Sprintf("Will start at frame %d", st.Position+1))
+	})
+}
+
+func (s *SenderApp) seekChunk(n int) {
+	if err := s.engine.SeekChunk(n); err != nil {
+		fyne.DoAndWait(func() { s.status.SetText(err.Error()) })
+	}
+}
+
+func (s *SenderApp) retransmit(indices []uint32) {
+	text := fmt.Sprintf("Resending %d chunks", len(indices))
+	if err := s.engine.Retransmit(indices); err != nil {
+		text = err.Error()
+	}
+	fyne.DoAndWait(func() { s.status.SetText(text) })
+}
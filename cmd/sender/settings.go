@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/config"
+	"qrtransfer/pkg/qr"
+	"qrtransfer/pkg/uitheme"
+)
+
+const (
+	minRate = 0.5
+	maxRate = 5.0
+
+	einkMaxRate = 30.0
+)
+
+func (s *SenderApp) applySettings(cfg config.Sender) {
+	s.eink = cfg.EInk
+	s.projector = cfg.Projector
+	s.sign = cfg.Sign
+	s.wipe = cfg.Wipe
+	s.autoRate = cfg.AutoRate
+	if cfg.ChunkSize > 0 {
+		s.chunkSize = cfg.ChunkSize
+	}
+	if cfg.Rate > 0 {
+		s.refreshRate = time.Duration(min(max(cfg.Rate, minRate), s.rateLimit()) * float64(time.Second))
+	}
+	if cfg.Redundancy > 0 {
+		s.redundancy = min(cfg.Redundancy, 3)
+	}
+
+	if level, err := qr.ParseErrorLevel(cfg.ErrorLevel); err == nil {
+		s.errorLevel = level
+	} else {
+		slog.Warn("ignoring error level from settings", "err", err)
+	}
+	if strategy, err := chunk.ParseStrategy(cfg.Strategy); err == nil {
+		s.strategy = strategy
+	} else {
+		slog.Warn("ignoring strategy from settings", "err", err)
+	}
+}
+
+func (s *SenderApp) settings() config.Sender {
+	return config.Sender{
+		ChunkSize:  s.chunkSize,
+		Rate:       s.refreshRate.Seconds(),
+		AutoRate:   s.autoRate,
+		ErrorLevel: s.errorLevel.String(),
+		Redundancy: s.redundancy,
+		Strategy:   s.strategy.String(),
+		EInk:       s.eink,
+		Projector:  s.projector,
+		Sign:       s.sign,
+		Wipe:       s.wipe,
+	}
+}
+
+func (s *SenderApp) rateLimit() float64 {
+	if s.eink {
+		return einkMaxRate
+	}
+	return maxRate
+}
+
+func (s *SenderApp) setupTheme() *widget.Select {
+	s.theme = strings.ToLower(s.theme)
+	if _, err := uitheme.Parse(s.theme); err != nil {
+		slog.Warn("ignoring theme from settings", "err", err)
+		s.theme = uitheme.Default
+	}
+
+	themeSelect := widget.NewSelect(uitheme.Names(), func(name string) {
+		if err := uitheme.Apply(s.app, name); err == nil {
+			s.theme = name
+		}
+	})
+	themeSelect.SetSelected(s.theme)
+	return themeSelect
+}
+
+func (s *SenderApp) saveDefaults() {
+	theme := s.theme
+	s.do(func() {
+		err := errors.New("no settings file location")
+		if s.configPath != "" {
+			cfg, _ := config.Load(s.configPath)
+			cfg.Theme = theme
+			cfg.Sender = s.settings()
+			err = cfg.Save(s.configPath)
+		}
+
+		if err != nil {
+			slog.Error("saving settings failed", "err", err)
+			fyne.Do(func() { dialog.ShowError(fmt.Errorf("saving settings: %w", err), s.window) })
+			return
+		}
+		fyne.DoAndWait(func() { s.status.SetText("Settings saved to " + s.configPath) })
+	})
+}
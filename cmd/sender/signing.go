@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/config"
+	"qrtransfer/pkg/secure"
+)
+
+func (s *SenderApp) setupSigning() fyne.CanvasObject {
+	s.signInfo = widget.NewLabel("")
+	s.signInfo.Wrapping = fyne.TextWrapWord
+
+	check := widget.NewCheck("Sign transfers", func(checked bool) {
+		s.do(func() { s.setSigning(checked) })
+	})
+	check.Checked = s.sign
+	if s.sign {
+		s.do(func() { s.setSigning(true) })
+	}
+	return container.NewVBox(check, s.signInfo)
+}
+
+func (s *SenderApp) setSigning(on bool) {
+	s.sign = on
+	s.signer = nil
+	text := ""
+	if on {
+		signer, where, err := loadSigner()
+		if err != nil {
+			slog.Error("loading signing key failed", "err", err)
+			text = "Signing key unavailable: " + err.Error()
+		} else {
+			s.signer = signer
+			text = fmt.Sprintf("Signing as %s, key in %s", secure.Fingerprint(signer.PublicKey()), where)
+		}
+	}
+
+	s.reload()
+	fyne.DoAndWait(func() {
+		s.signInfo.SetText(text)
+		s.updateChunkInfo()
+	})
+}
+
+func loadSigner() (*secure.Signer, string, error) {
+	path, err := config.SigningKeyPath()
+	if err != nil {
+		return nil, "", err
+	}
+	return secure.FindSigner(path)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/qr"
+)
+
+func (s *SenderApp) summaryWarnings(st engine.SenderStatus) []string {
+	var warnings []string
+
+	size := st.Payload.Options.ChunkSize
+	payload := chunk.SerializedSize(size)
+	side, _ := qr.OptimalGridSize(payload)
+	level := st.Config.ErrorLevel
+
+	if capacity := level.Capacity(side * side); payload > capacity {
+		warnings = append(warnings, fmt.Sprintf(
+			"At %s error correction each color channel keeps only %d of its 8 bits, so the %d bytes of each frame will not arrive intact; use Low error correction to send every bit.",
+			errorLevelNames[level], level.BitsPerChannel(), payload))
+	}
+
+	if limit := s.maxChunkSize(); size > limit {
+		frame := s.surface.Size()
+		warnings = append(warnings, fmt.Sprintf(
+			"A %d-byte chunk needs a %dx%d grid, which does not fit a %dx%d frame; the largest chunk that fits is %d bytes.",
+			size, side, side, frame.X, frame.Y, max(limit, 0)))
+	}
+
+	return warnings
+}
+
+func (s *SenderApp) summary(st engine.SenderStatus) string {
+	opts := st.Payload.Options
+	metadata := st.Payload.Metadata
+	side, _ := qr.OptimalGridSize(chunk.SerializedSize(opts.ChunkSize))
+	duration := time.Duration(st.Frames) * st.Config.Interval
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "File: %s (%s)\n", metadata.Filename, formatBytes(float64(metadata.FileSize)))
+	if st.QueueLen > 0 {
+		fmt.Fprintf(&b, "Queue: file %d of %d\n", st.QueuePos+1, st.QueueLen)
+	}
+	fmt.Fprintf(&b, "Total frames: %d (%d chunks, %dx %s)\n", st.Frames, metadata.TotalChunks, opts.Redundancy, strategyLabels[opts.Strategy])
+	fmt.Fprintf(&b, "Bytes per frame: %d payload, %d on screen\n", opts.ChunkSize, chunk.SerializedSize(opts.ChunkSize))
+	fmt.Fprintf(&b, "Grid: %dx%d blocks at %s error correction\n", side, side, errorLevelNames[st.Config.ErrorLevel])
+	fmt.Fprintf(&b, "Estimated duration: %v at %.1fs per frame", duration.Round(time.Second), st.Config.Interval.Seconds())
+
+	for _, w := range s.summaryWarnings(st) {
+		b.WriteString("\n\nWarning: " + w)
+	}
+
+	return b.String()
+}
+
+func (s *SenderApp) showSummary(summary string, onStart func()) {
+	message := widget.NewLabel(summary)
+	message.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomConfirm("Start Transfer", "Start", "Cancel", message, func(start bool) {
+		if start {
+			onStart()
+		}
+	}, s.window)
+	d.Resize(fyne.NewSize(480, 320))
+	d.Show()
+}
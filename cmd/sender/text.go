@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/engine"
+)
+
+const textSnippetName = "snippet.txt"
+
+func (s *SenderApp) setupText() fyne.CanvasObject {
+	entry := widget.NewMultiLineEntry()
+	entry.SetPlaceHolder("Paste text to send")
+	entry.Wrapping = fyne.TextWrapWord
+	entry.SetMinRowsVisible(6)
+
+	useBtn := widget.NewButton("Use Text", func() {
+		s.useText(entry.Text)
+	})
+	clipboardBtn := widget.NewButton("Send Clipboard", func() {
+		text := s.app.Clipboard().Content()
+		entry.SetText(text)
+		s.useText(text)
+	})
+
+	return container.NewVBox(
+		entry,
+		container.NewGridWithColumns(2, useBtn, clipboardBtn),
+	)
+}
+
+func (s *SenderApp) useText(text string) {
+	if text == "" {
+		s.status.SetText("No text to send")
+		return
+	}
+
+	s.do(func() {
+		if err := s.loadText(text); err != nil {
+			fyne.Do(func() { dialog.ShowError(err, s.window) })
+		}
+	})
+}
+
+func (s *SenderApp) loadText(text string) error {
+	if s.running() {
+		return errors.New("stop the transfer before changing the payload")
+	}
+
+	payload, err := engine.Prepare(strings.NewReader(text), int64(len(text)), textSnippetName, chunk.ContentTypeText, s.options())
+	if err != nil {
+		return err
+	}
+	if err := s.setPayload(payload); err != nil {
+		return err
+	}
+
+	s.filename = ""
+	s.text = text
+	s.origName = textSnippetName
+	fyne.DoAndWait(func() {
+		s.addQueueBtn.Disable()
+		s.status.SetText(fmt.Sprintf("Text ready: %d bytes", len(text)))
+	})
+	return nil
+}
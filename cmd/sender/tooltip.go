@@ -0,0 +1,9 @@
+//go:build (darwin && !ios) || windows || ((linux || freebsd || openbsd || netbsd) && !android)
+
+package main
+
+import "fyne.io/systray"
+
+func setTrayTooltip(text string) {
+	systray.SetTooltip(text)
+}
@@ -0,0 +1,5 @@
+//go:build !((darwin && !ios) || windows || ((linux || freebsd || openbsd || netbsd) && !android))
+
+package main
+
+func setTrayTooltip(string) {}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+
+	"qrtransfer/pkg/engine"
+)
+
+func (s *SenderApp) setupTray() {
+	desk, ok := s.app.(desktop.App)
+	if !ok {
+		return
+	}
+
+	s.trayStatus = fyne.NewMenuItem("Idle", nil)
+	s.trayStatus.Disabled = true
+	s.trayPause = fyne.NewMenuItem("Pause", func() { s.do(s.engine.TogglePause) })
+	s.trayStop = fyne.NewMenuItem("Stop", func() { s.do(s.engine.Stop) })
+	s.trayMenu = fyne.NewMenu("QR File Sender",
+		s.trayStatus,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Show Window", s.window.Show),
+		s.trayPause,
+		s.trayStop,
+	)
+
+	desk.SetSystemTrayMenu(s.trayMenu)
+	desk.SetSystemTrayWindow(s.window)
+	s.window.SetCloseIntercept(s.closeWindow)
+	s.updateTray(s.last)
+}
+
+func (s *SenderApp) closeWindow() {
+	if !s.last.State.Active() {
+		s.app.Quit()
+		return
+	}
+
+	s.window.Hide()
+	slog.Info("window hidden to the system tray while the transfer continues")
+}
+
+func (s *SenderApp) updateTray(st engine.SenderStatus) {
+	if s.trayMenu == nil {
+		return
+	}
+
+	text := trayText(st)
+	pause := "Pause"
+	if st.State == engine.SenderPaused {
+		pause = "Resume"
+	}
+	active := st.State.Active()
+	if text == s.trayStatus.Label && pause == s.trayPause.Label && active != s.trayStop.Disabled {
+		return
+	}
+
+	s.trayStatus.Label = text
+	s.trayPause.Label = pause
+	s.trayPause.Disabled = !active
+	s.trayStop.Disabled = !active
+	s.trayMenu.Refresh()
+	setTrayTooltip("QR File Sender: " + text)
+}
+
+func trayText(st engine.SenderStatus) string {
+	switch st.State {
+	case engine.SenderRunning:
+		if st.Pass > 1 {
+			return fmt.Sprintf("Sending frame %d of %d (pass %d)", st.Position, st.Frames, st.Pass)
+		}
+		return fmt.Sprintf("Sending frame %d of %d (%d%%)", st.Position, st.Frames, st.Position*100/max(st.Frames, 1))
+	case engine.SenderPaused:
+		return fmt.Sprintf("Paused on frame %d of %d", st.Position, st.Frames)
+	case engine.SenderFinished:
+		return "Transfer complete"
+	case engine.SenderStopped:
+		return "Transfer stopped"
+	case engine.SenderFailed:
+		return "Transfer failed"
+	}
+	return "Idle"
+}
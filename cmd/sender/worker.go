@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"image"
+	"sync"
+
+	"fyne.io/fyne/v2"
+
+	"qrtransfer/pkg/engine"
+)
+
+const commandBuffer = 64
+
+type command func()
+
+type surface struct {
+	app *SenderApp
+
+	mu   sync.Mutex
+	size image.Point
+}
+
+func (p *surface) Size() image.Point {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.size
+}
+
+func (p *surface) setSize(size image.Point) {
+	p.mu.Lock()
+	p.size = size
+	p.mu.Unlock()
+}
+
+func (p *surface) Show(img image.Image) error {
+	fyne.Do(func() {
+		p.app.image.Image = img
+		p.app.image.Refresh()
+		if p.app.presentImg != nil {
+			p.app.presentImg.Image = img
+			p.app.presentImg.Refresh()
+		}
+	})
+	return nil
+}
+
+func (s *SenderApp) do(cmd command) {
+	s.commands <- cmd
+}
+
+func (s *SenderApp) call(cmd command) {
+	done := make(chan struct{})
+	s.commands <- func() {
+		defer close(done)
+		cmd()
+	}
+	<-done
+}
+
+func (s *SenderApp) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case cmd := <-s.commands:
+			cmd()
+		}
+	}
+}
+
+func (s *SenderApp) notify(st engine.SenderStatus) {
+	fyne.Do(func() { s.applyStatus(st) })
+}
@@ -0,0 +1,202 @@
+package owl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/qr"
+)
+
+const (
+	DefaultChunkSize = 100
+	DefaultFrameSize = 400
+	DefaultName      = "data.bin"
+)
+
+var (
+	ErrIncomplete = errors.New("frames ended before the transfer was complete")
+	ErrErrorLevel = errors.New("frames can only be decoded at ErrorLevelLow")
+)
+
+type Frame struct {
+	Image image.Image
+	Index int
+	Count int
+	Pass  int
+	Err   error
+}
+
+type SendOptions struct {
+	Name        string
+	ContentType string
+	ChunkSize   int
+	Redundancy  int
+	Strategy    chunk.Strategy
+	ErrorLevel  qr.ErrorLevel
+	Size        image.Point
+	Caption     bool
+	Passes      int
+}
+
+func (o SendOptions) withDefaults() SendOptions {
+	if o.Name == "" {
+		o.Name = DefaultName
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultChunkSize
+	}
+	if o.Size.X <= 0 || o.Size.Y <= 0 {
+		o.Size = image.Pt(DefaultFrameSize, DefaultFrameSize)
+	}
+	o.Redundancy = max(o.Redundancy, 1)
+	o.Passes = max(o.Passes, 1)
+	return o
+}
+
+type ReceiveOptions struct {
+	BlockSize int
+}
+
+type File struct {
+	*bytes.Reader
+
+	Name        string
+	ContentType string
+	Size        int64
+	Missing     int
+}
+
+func Send(ctx context.Context, r io.Reader, opts SendOptions) (<-chan Frame, error) {
+	opts = opts.withDefaults()
+	if opts.ErrorLevel.Capacity(1) == 0 {
+		return nil, fmt.Errorf("%w, not %v", ErrErrorLevel, opts.ErrorLevel)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := engine.Prepare(bytes.NewReader(data), int64(len(data)), opts.Name, opts.ContentType, engine.Options{
+		ChunkSize:  opts.ChunkSize,
+		Redundancy: opts.Redundancy,
+		Strategy:   opts.Strategy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	renderer := engine.NewRenderer(qr.Config{ErrorLevel: opts.ErrorLevel})
+	renderer.Caption = opts.Caption
+
+	render := func(i int) (image.Image, error) {
+		c, err := payload.Frame(i)
+		if err != nil {
+			return nil, err
+		}
+		frame, err := payload.FrameData(i)
+		if err != nil {
+			return nil, err
+		}
+		img, err := renderer.Render(frame, opts.Size, payload.Caption(c, i == 0))
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %w", i, err)
+		}
+		return cloneImage(img), nil
+	}
+
+	first, err := render(0)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make(chan Frame)
+	go func() {
+		defer close(frames)
+
+		count := payload.FrameCount()
+		for pass := 1; pass <= opts.Passes; pass++ {
+			for i := 0; i < count; i++ {
+				f := Frame{Index: i, Count: count, Pass: pass}
+				if pass == 1 && i == 0 {
+					f.Image = first
+				} else {
+					f.Image, f.Err = render(i)
+				}
+
+				select {
+				case frames <- f:
+				case <-ctx.Done():
+					return
+				}
+				if f.Err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return frames, nil
+}
+
+func Receive(ctx context.Context, frames <-chan image.Image, opts ReceiveOptions) (*File, error) {
+	recv := engine.NewReceiver()
+	if opts.BlockSize > 0 {
+		recv.BlockSize = opts.BlockSize
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case img, ok := <-frames:
+			if !ok {
+				file, err := assemble(recv)
+				if err != nil {
+					return nil, err
+				}
+				if file.Missing > 0 || !recv.Complete() {
+					return file, ErrIncomplete
+				}
+				return file, nil
+			}
+
+			if len(recv.ProcessFrame(img)) > 0 && recv.Complete() {
+				file, err := assemble(recv)
+				if err != nil || file.Missing == 0 {
+					return file, err
+				}
+			}
+		}
+	}
+}
+
+func assemble(recv *engine.Receiver) (*File, error) {
+	var buf bytes.Buffer
+	missing, err := recv.Assemble(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := recv.Metadata()
+	return &File{
+		Reader:      bytes.NewReader(buf.Bytes()),
+		Name:        metadata.Filename,
+		ContentType: metadata.ContentType,
+		Size:        int64(metadata.FileSize),
+		Missing:     missing,
+	}, nil
+}
+
+func cloneImage(img image.Image) *image.RGBA {
+	clone := image.NewRGBA(img.Bounds())
+	draw.Draw(clone, clone.Bounds(), img, img.Bounds().Min, draw.Src)
+	return clone
+}
@@ -0,0 +1,107 @@
+package owl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/qr"
+)
+
+func loopback(t *testing.T, data []byte, opts SendOptions, drop ...int) *File {
+	t.Helper()
+	ctx := context.Background()
+
+	frames, err := Send(ctx, bytes.NewReader(data), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images := make(chan image.Image)
+	go func() {
+		defer close(images)
+		for f := range frames {
+			if f.Err != nil {
+				t.Error(f.Err)
+				return
+			}
+			if slices.Contains(drop, f.Index) {
+				continue
+			}
+			images <- f.Image
+		}
+	}()
+
+	file, err := Receive(ctx, images, ReceiveOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func testData() []byte {
+	rng := rand.New(rand.NewPCG(1, 2))
+	data := make([]byte, 360)
+	for i := range data {
+		data[i] = byte(rng.IntN(256))
+	}
+	return data
+}
+
+func TestSendReceiveLoopback(t *testing.T) {
+	data := testData()
+
+	for _, opts := range []SendOptions{
+		{Name: "small.bin"},
+		{Name: "parity.bin", Redundancy: 2, Strategy: chunk.StrategyParity, ChunkSize: 64},
+		{Name: "caption.bin", Caption: true, Size: image.Pt(640, 480)},
+	} {
+		t.Run(opts.Name, func(t *testing.T) {
+			file := loopback(t, data, opts)
+			got, err := io.ReadAll(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file.Name != opts.Name || file.Missing != 0 || !bytes.Equal(got, data) {
+				t.Errorf("received %q with %d missing and %d bytes, want %q with all %d bytes", file.Name, file.Missing, len(got), opts.Name, len(data))
+			}
+		})
+	}
+}
+
+func TestReceiveRecoversDroppedFrame(t *testing.T) {
+	data := testData()
+	file := loopback(t, data, SendOptions{Name: "parity.bin", Redundancy: 2, Strategy: chunk.StrategyParity, ChunkSize: 64}, 2)
+	got, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Missing != 0 || !bytes.Equal(got, data) {
+		t.Errorf("received %d bytes with %d missing, want all %d bytes recovered from parity", len(got), file.Missing, len(data))
+	}
+}
+
+func TestSendReceiveEmpty(t *testing.T) {
+	file := loopback(t, nil, SendOptions{Name: "empty.bin"})
+	got, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Name != "empty.bin" || file.Missing != 0 || len(got) != 0 {
+		t.Errorf("received %q with %d missing and %d bytes, want an empty empty.bin", file.Name, file.Missing, len(got))
+	}
+}
+
+func TestSendRejectsLossyLevels(t *testing.T) {
+	for _, level := range []qr.ErrorLevel{qr.ErrorLevelMedium, qr.ErrorLevelHigh} {
+		if _, err := Send(context.Background(), bytes.NewReader(testData()), SendOptions{ErrorLevel: level}); !errors.Is(err, ErrErrorLevel) {
+			t.Errorf("Send at %v: err %v, want ErrErrorLevel", level, err)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EventSend    = "send"
+	EventReceive = "receive"
+	EventRefused = "refused"
+	EventNote    = "note"
+
+	tailSize = 64 << 10
+)
+
+var ErrTampered = errors.New("audit log has been altered")
+
+type Entry struct {
+	Time     time.Time         `json:"time"`
+	Event    string            `json:"event"`
+	Host     string            `json:"host,omitempty"`
+	Session  uint64            `json:"session,omitempty"`
+	File     string            `json:"file,omitempty"`
+	Path     string            `json:"path,omitempty"`
+	Size     uint64            `json:"size,omitempty"`
+	SHA256   string            `json:"sha256,omitempty"`
+	Settings map[string]string `json:"settings,omitempty"`
+	Note     string            `json:"note,omitempty"`
+	Prev     string            `json:"prev"`
+	Hash     string            `json:"hash"`
+}
+
+type Log struct {
+	mu   sync.Mutex
+	path string
+}
+
+func Open(path string) *Log {
+	return &Log{path: path}
+}
+
+func (l *Log) Path() string {
+	return l.path
+}
+
+func (l *Log) Record(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	prev, err := lastHash(f)
+	if err != nil {
+		return err
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Host == "" {
+		e.Host, _ = os.Hostname()
+	}
+	e.Time = e.Time.UTC()
+	e.Prev = prev
+	if e.Hash, err = e.digest(); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if len(line) >= tailSize {
+		return fmt.Errorf("audit entry is %d bytes, the limit is %d", len(line), tailSize-1)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func (l *Log) Entries() ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+func (e Entry) digest() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func lastHash(f *os.File) (string, error) {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return "", err
+	}
+
+	off := max(info.Size()-tailSize, 0)
+	buf := make([]byte, info.Size()-off)
+	if _, err := f.ReadAt(buf, off); err != nil {
+		return "", err
+	}
+	buf = bytes.TrimRight(buf, "\n")
+	line := buf[bytes.LastIndexByte(buf, '\n')+1:]
+
+	var e Entry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return "", fmt.Errorf("%w: last entry unreadable: %v", ErrTampered, err)
+	}
+	return e.Hash, nil
+}
+
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, tailSize)
+	for n := 1; sc.Scan(); n++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("line %d: %w", n, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+func Verify(entries []Entry) error {
+	prev := ""
+	for i, e := range entries {
+		want, err := e.digest()
+		if err != nil {
+			return err
+		}
+		switch {
+		case e.Prev != prev:
+			return fmt.Errorf("%w: entry %d does not follow entry %d", ErrTampered, i+1, i)
+		case e.Hash != want:
+			return fmt.Errorf("%w: entry %d was modified", ErrTampered, i+1)
+		}
+		prev = e.Hash
+	}
+	return nil
+}
+
+func WriteJSON(w io.Writer, entries []Entry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+func WriteCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "event", "host", "session", "file", "path", "size", "sha256", "settings", "note", "hash"})
+	for _, e := range entries {
+		session, size := "", ""
+		if e.Session != 0 {
+			session = strconv.FormatUint(e.Session, 10)
+		}
+		if e.Event == EventSend || e.Event == EventReceive {
+			size = strconv.FormatUint(e.Size, 10)
+		}
+		cw.Write([]string{
+			e.Time.Format(time.RFC3339Nano),
+			e.Event,
+			e.Host,
+			session,
+			e.File,
+			e.Path,
+			size,
+			e.SHA256,
+			formatSettings(e.Settings),
+			e.Note,
+			e.Hash,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func Export(w io.Writer, path string, entries []Entry) error {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return WriteCSV(w, entries)
+	}
+	return WriteJSON(w, entries)
+}
+
+func formatSettings(settings map[string]string) string {
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + settings[k]
+	}
+	return strings.Join(parts, " ")
+}
+
+func Summary(e Entry) string {
+	parts := []string{e.Time.Local().Format("2006-01-02 15:04:05"), e.Event}
+	if e.File != "" {
+		parts = append(parts, e.File)
+	}
+	if e.Event == EventSend || e.Event == EventReceive {
+		parts = append(parts, fmt.Sprintf("%d bytes", e.Size))
+	}
+	if e.SHA256 != "" {
+		parts = append(parts, "sha256 "+e.SHA256[:min(len(e.SHA256), 16)])
+	}
+	if e.Note != "" {
+		parts = append(parts, strconv.Quote(e.Note))
+	}
+	return strings.Join(parts, "  ")
+}
@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"encoding/hex"
+	"strconv"
+
+	"qrtransfer/pkg/engine"
+	"qrtransfer/pkg/secure"
+)
+
+func SendEntry(p *engine.Payload, settings map[string]string, note string) Entry {
+	s := map[string]string{
+		"chunk_size": strconv.Itoa(p.Options.ChunkSize),
+		"redundancy": strconv.Itoa(p.Options.Redundancy),
+		"strategy":   p.Options.Strategy.String(),
+		"frames":     strconv.Itoa(p.FrameCount()),
+		"encryption": "none",
+	}
+	switch {
+	case p.Options.Recipient != nil:
+		s["encryption"] = "recipient " + secure.Fingerprint(p.Options.Recipient)
+	case p.Encrypted():
+		s["encryption"] = "passphrase"
+	}
+	if p.Options.Pairing != nil {
+		s["paired"] = "true"
+	}
+	if p.Signed() {
+		s["signer"] = secure.Fingerprint(p.Options.Signer.PublicKey())
+	}
+	for k, v := range settings {
+		s[k] = v
+	}
+
+	return Entry{
+		Event:    EventSend,
+		Session:  p.Session(),
+		File:     p.Metadata.Filename,
+		Size:     p.Metadata.FileSize,
+		SHA256:   hex.EncodeToString(p.Hash[:]),
+		Settings: s,
+		Note:     note,
+	}
+}
+
+func ReceiveEntry(r *engine.Receiver, path string, settings map[string]string, note string) Entry {
+	metadata := r.Metadata()
+	v := r.Verification()
+	s := map[string]string{
+		"chunk_size":   strconv.FormatUint(uint64(metadata.ChunkSize), 10),
+		"chunks":       strconv.FormatUint(uint64(metadata.TotalChunks), 10),
+		"encrypted":    strconv.FormatBool(r.Sealed()),
+		"verification": v.Status.String(),
+	}
+	if v.Signer != nil {
+		s["signer"] = secure.Fingerprint(v.Signer)
+		s["signer_trusted"] = strconv.FormatBool(v.Trusted)
+	}
+	if r.Pairing() != nil {
+		s["paired"] = "true"
+	}
+	for k, v := range settings {
+		s[k] = v
+	}
+
+	e := Entry{
+		Event:    EventReceive,
+		Session:  r.Session(),
+		File:     metadata.Filename,
+		Path:     path,
+		Size:     metadata.FileSize,
+		Settings: s,
+		Note:     note,
+	}
+	if sum, ok := r.FileHash(); ok {
+		e.SHA256 = hex.EncodeToString(sum[:])
+	}
+	return e
+}
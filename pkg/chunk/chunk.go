@@ -4,7 +4,25 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"hash/crc32"
 	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+const headerSize = 12
+
+const Overhead = headerSize + 4 + 32 + 8
+
+const ContentTypeText = "text/plain; charset=utf-8"
+
+const sessionMask = 1<<8 - 1
+
+var (
+	ErrHeaderCorrupt = errors.New("chunk header CRC mismatch")
 )
 
 type Chunk struct {
@@ -13,6 +31,7 @@ type Chunk struct {
 	Data      []byte
 	Checksum  [32]byte
 	Timestamp uint64
+	Sealed    bool
 }
 
 type FileMetadata struct {
@@ -23,6 +42,16 @@ type FileMetadata struct {
 	Checksum    [32]byte
 	Timestamp   uint64
 	Redundancy  uint8
+	ContentType string
+	ParityGroup uint32
+}
+
+func SessionTimestamp(t time.Time) uint64 {
+	return uint64(t.UnixNano()) &^ sessionMask
+}
+
+func SessionID(c Chunk) uint64 {
+	return c.Timestamp &^ sessionMask
 }
 
 type Progress struct {
@@ -46,6 +75,9 @@ func NewConfig(chunkSize int, redundancy int) Config {
 
 type Processor struct {
 	config Config
+	keys   Keys
+	mac    []byte
+	macs   *sync.Pool
 }
 
 func NewProcessor(config Config) *Processor {
@@ -60,14 +92,15 @@ func (p *Processor) CreateChunks(file io.Reader, metadata FileMetadata, redundan
 	chunks := make([][]Chunk, 0)
 	
 	data := make([]byte, p.config.ChunkSize)
+	defer clear(data)
 	chunkIndex := uint32(0)
 	
 	for {
-		n, err := file.Read(data)
-		if n == 0 && err == io.EOF {
+		n, err := io.ReadFull(file, data)
+		if err == io.EOF {
 			break
 		}
-		if err != nil && err != io.EOF {
+		if err != nil && err != io.ErrUnexpectedEOF {
 			return nil, err
 		}
 		
@@ -105,58 +138,87 @@ func (p *Processor) CreateChunks(file io.Reader, metadata FileMetadata, redundan
 }
 
 func (p *Processor) SerializeChunk(chunk Chunk) ([]byte, error) {
-	serialized := make([]byte, 0)
-	
-	serialized = append(serialized, byte(chunk.Index>>24))
-	serialized = append(serialized, byte(chunk.Index>>16))
-	serialized = append(serialized, byte(chunk.Index>>8))
-	serialized = append(serialized, byte(chunk.Index))
-	
-	serialized = append(serialized, byte(chunk.Total>>24))
-	serialized = append(serialized, byte(chunk.Total>>16))
-	serialized = append(serialized, byte(chunk.Total>>8))
-	serialized = append(serialized, byte(chunk.Total))
-	
-	serialized = append(serialized, byte(len(chunk.Data)>>24))
-	serialized = append(serialized, byte(len(chunk.Data)>>16))
-	serialized = append(serialized, byte(len(chunk.Data)>>8))
-	serialized = append(serialized, byte(len(chunk.Data)))
+	return p.AppendChunk(make([]byte, 0, SerializedSize(len(chunk.Data))), chunk)
+}
+
+func (p *Processor) AppendChunk(dst []byte, chunk Chunk) ([]byte, error) {
+	length := uint32(len(chunk.Data))
+	if p.keys != nil {
+		length |= sealedFlag
+	}
 	
-	serialized = append(serialized, chunk.Data...)
+	dst = slices.Grow(dst, SerializedSize(len(chunk.Data)))
+	start := len(dst)
 	
-	serialized = append(serialized, chunk.Checksum[:]...)
+	dst = binary.BigEndian.AppendUint32(dst, chunk.Index)
+	dst = binary.BigEndian.AppendUint32(dst, chunk.Total)
+	dst = binary.BigEndian.AppendUint32(dst, length)
+	header := dst[start : start+headerSize]
+	dst = binary.BigEndian.AppendUint32(dst, crc32.ChecksumIEEE(header))
 	
-	ts := make([]byte, 8)
-	binary.BigEndian.PutUint64(ts, chunk.Timestamp)
-	serialized = append(serialized, ts...)
+	switch {
+	case p.keys != nil:
+		var err error
+		if dst, err = p.seal(dst, chunk); err != nil {
+			return nil, err
+		}
+	case p.mac != nil:
+		dst = append(dst, chunk.Data...)
+		tag := p.tag(header, chunk.Data, chunk.Timestamp)
+		dst = append(dst, tag[:]...)
+	default:
+		dst = append(dst, chunk.Data...)
+		dst = append(dst, chunk.Checksum[:]...)
+	}
 	
-	return serialized, nil
+	return binary.BigEndian.AppendUint64(dst, chunk.Timestamp), nil
 }
 
 func (p *Processor) DeserializeChunk(data []byte) (Chunk, error) {
-	if len(data) < 45 {
+	if len(data) < headerSize+4 {
+		slog.Debug("chunk shorter than header", "bytes", len(data))
 		return Chunk{}, io.ErrShortBuffer
 	}
 	
-	chunk := Chunk{}
+	if crc32.ChecksumIEEE(data[:headerSize]) != binary.BigEndian.Uint32(data[headerSize:]) {
+		slog.Debug("chunk header CRC mismatch", "bytes", len(data))
+		return Chunk{}, ErrHeaderCorrupt
+	}
 	
-	chunk.Index = uint32(data[0])<<24 | uint32(data[1])<<16 | uint32(data[2])<<8 | uint32(data[3])
-	chunk.Total = uint32(data[4])<<24 | uint32(data[5])<<16 | uint32(data[6])<<8 | uint32(data[7])
+	chunk := Chunk{
+		Index: binary.BigEndian.Uint32(data[0:]),
+		Total: binary.BigEndian.Uint32(data[4:]),
+	}
 	
-	dataLen := uint32(data[8])<<24 | uint32(data[9])<<16 | uint32(data[10])<<8 | uint32(data[11])
+	dataLen := binary.BigEndian.Uint32(data[8:])
+	sealed := dataLen&sealedFlag != 0
+	dataLen &^= sealedFlag
 	
-	expectedLen := 12 + dataLen + 32 + 8
-	if uint32(len(data)) < expectedLen {
+	offset := uint32(headerSize + 4)
+	
+	expectedLen := uint64(offset) + uint64(dataLen) + 32 + 8
+	if uint64(len(data)) < expectedLen {
+		slog.Debug("chunk truncated", "index", chunk.Index, "bytes", len(data), "want", expectedLen)
 		return Chunk{}, io.ErrShortBuffer
 	}
 	
-	chunk.Data = make([]byte, dataLen)
-	copy(chunk.Data, data[12:12+dataLen])
+	body := data[offset : offset+dataLen]
+	copy(chunk.Checksum[:], data[offset+dataLen:offset+dataLen+32])
+	chunk.Timestamp = binary.BigEndian.Uint64(data[offset+dataLen+32:])
 	
-	copy(chunk.Checksum[:], data[12+dataLen:12+dataLen+32])
+	if sealed {
+		return p.open(chunk, body)
+	}
 	
-	chunk.Timestamp = binary.BigEndian.Uint64(data[12+dataLen+32:])
+	chunk.Data = getBuffer(len(body))
+	copy(chunk.Data, body)
 	
+	switch {
+	case p.keys != nil:
+		return chunk, ErrUnsealed
+	case p.mac != nil:
+		return p.checkTag(data[:headerSize], chunk)
+	}
 	return chunk, nil
 }
 
@@ -170,9 +232,17 @@ func (p *Processor) DeserializeMetadata(data []byte) (FileMetadata, error) {
 	return metadata, err
 }
 
+func SerializedSize(dataLen int) int {
+	return dataLen + Overhead
+}
+
 func VerifyChunk(chunk Chunk) bool {
 	checksum := sha256.Sum256(chunk.Data)
-	return checksum == chunk.Checksum
+	if checksum != chunk.Checksum {
+		slog.Debug("chunk checksum mismatch", "index", chunk.Index, "bytes", len(chunk.Data))
+		return false
+	}
+	return true
 }
 
 func CalculateProgress(received, total uint32, bytesReceived, fileSize uint64) Progress {
@@ -0,0 +1,39 @@
+package chunk
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"sync"
+)
+
+func (p *Processor) SetMAC(key []byte) {
+	p.mac = key
+	p.macs = &sync.Pool{New: func() any { return hmac.New(sha256.New, key) }}
+}
+
+func (p *Processor) Paired() bool {
+	return p.mac != nil
+}
+
+func (p *Processor) tag(header, data []byte, timestamp uint64) [32]byte {
+	h := p.macs.Get().(hash.Hash)
+	defer p.macs.Put(h)
+
+	var buf [sha256.Size]byte
+	h.Reset()
+	h.Write(header)
+	h.Write(data)
+	h.Write(binary.BigEndian.AppendUint64(buf[:0], timestamp))
+	return [32]byte(h.Sum(buf[:0]))
+}
+
+func (p *Processor) checkTag(header []byte, c Chunk) (Chunk, error) {
+	tag := p.tag(header, c.Data, c.Timestamp)
+	if !hmac.Equal(tag[:], c.Checksum[:]) {
+		return c, ErrUnauthentic
+	}
+	c.Checksum = sha256.Sum256(c.Data)
+	return c, nil
+}
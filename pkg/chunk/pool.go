@@ -0,0 +1,35 @@
+package chunk
+
+import "sync"
+
+var (
+	buffers = sync.Pool{}
+	boxes   = sync.Pool{New: func() any { return new([]byte) }}
+)
+
+func getBuffer(n int) []byte {
+	b, _ := buffers.Get().(*[]byte)
+	if b == nil {
+		return make([]byte, n)
+	}
+	buf := *b
+	*b = nil
+	boxes.Put(b)
+	if cap(buf) < n {
+		return make([]byte, n)
+	}
+	return buf[:n]
+}
+
+func putBuffer(buf []byte) {
+	if cap(buf) == 0 {
+		return
+	}
+	b := boxes.Get().(*[]byte)
+	*b = buf[:0]
+	buffers.Put(b)
+}
+
+func Release(c Chunk) {
+	putBuffer(c.Data)
+}
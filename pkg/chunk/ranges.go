@@ -0,0 +1,52 @@
+package chunk
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const maxIndexRange = 1 << 20
+
+func FormatIndices(indices []uint32) string {
+	sorted := slices.Clone(indices)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.FormatUint(uint64(sorted[i]), 10))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+func ParseIndices(text string) ([]uint32, error) {
+	var indices []uint32
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' }) {
+		lo, hi, isRange := strings.Cut(field, "-")
+		start, err := strconv.ParseUint(lo, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chunk index %q", field)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.ParseUint(hi, 10, 32); err != nil || end < start || end-start >= maxIndexRange {
+				return nil, fmt.Errorf("invalid chunk range %q", field)
+			}
+		}
+		for i := start; i <= end; i++ {
+			indices = append(indices, uint32(i))
+		}
+	}
+	return indices, nil
+}
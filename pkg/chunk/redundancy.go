@@ -0,0 +1,236 @@
+package chunk
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+type Strategy int
+
+const (
+	StrategyImmediate Strategy = iota
+	StrategyDelayed
+	StrategyParity
+)
+
+const (
+	DefaultParityGroup = 8
+
+	ParityFlag   uint32 = 1 << 31
+	parityColumn uint32 = 1 << 30
+	parityGroup  uint32 = parityColumn - 1
+)
+
+var strategyNames = []string{"immediate", "delayed", "parity"}
+
+func Strategies() []string {
+	return append([]string(nil), strategyNames...)
+}
+
+func (s Strategy) String() string {
+	if s < 0 || int(s) >= len(strategyNames) {
+		return fmt.Sprintf("Strategy(%d)", int(s))
+	}
+	return strategyNames[s]
+}
+
+func ParseStrategy(name string) (Strategy, error) {
+	for i, n := range strategyNames {
+		if strings.EqualFold(name, n) {
+			return Strategy(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown redundancy strategy %q", name)
+}
+
+func IsParity(c Chunk) bool {
+	return c.Index&ParityFlag != 0
+}
+
+func Schedule(chunks [][]Chunk, strategy Strategy, metadata FileMetadata) []Chunk {
+	out := make([]Chunk, 0, len(chunks))
+
+	switch strategy {
+	case StrategyDelayed:
+		copies := 0
+		for _, set := range chunks {
+			copies = max(copies, len(set))
+		}
+		for c := 0; c < copies; c++ {
+			for _, set := range chunks {
+				if c < len(set) {
+					out = append(out, set[c])
+				}
+			}
+		}
+	case StrategyParity:
+		originals := make([]Chunk, 0, len(chunks))
+		for _, set := range chunks {
+			if len(set) > 0 {
+				originals = append(originals, set[0])
+			}
+		}
+		out = append(out, originals...)
+		out = append(out, ParityChunks(originals, metadata)...)
+	default:
+		for _, set := range chunks {
+			out = append(out, set...)
+		}
+	}
+
+	return out
+}
+
+func ParityChunks(chunks []Chunk, metadata FileMetadata) []Chunk {
+	group := int(metadata.ParityGroup)
+	if group <= 0 || len(chunks) == 0 {
+		return nil
+	}
+
+	rows := (len(chunks) + group - 1) / group
+	parity := make([]Chunk, 0, rows+group)
+
+	for r := 0; r < rows; r++ {
+		parity = append(parity, parityChunk(uint32(r), chunks, metadata))
+	}
+
+	if metadata.Redundancy >= 2 && rows > 1 {
+		for c := 0; c < group && c < len(chunks); c++ {
+			parity = append(parity, parityChunk(parityColumn|uint32(c), chunks, metadata))
+		}
+	}
+
+	return parity
+}
+
+func parityChunk(id uint32, chunks []Chunk, metadata FileMetadata) Chunk {
+	data := make([]byte, metadata.ChunkSize)
+	for _, i := range ParityMembers(ParityFlag|id, metadata) {
+		if int(i) < len(chunks) {
+			xorBytes(data, chunks[i].Data)
+		}
+	}
+
+	return Chunk{
+		Index:     ParityFlag | id,
+		Total:     metadata.TotalChunks,
+		Data:      data,
+		Checksum:  sha256.Sum256(data),
+		Timestamp: metadata.Timestamp,
+	}
+}
+
+func ParityMembers(index uint32, metadata FileMetadata) []uint32 {
+	group := metadata.ParityGroup
+	if index&ParityFlag == 0 || group == 0 {
+		return nil
+	}
+
+	id := index & parityGroup
+	members := make([]uint32, 0, group)
+
+	if index&parityColumn != 0 {
+		for i := id; i < metadata.TotalChunks; i += group {
+			members = append(members, i)
+		}
+		return members
+	}
+
+	for i := id * group; i < (id+1)*group && i < metadata.TotalChunks; i++ {
+		members = append(members, i)
+	}
+	return members
+}
+
+func RecoverParity(data map[uint32][]byte, parity []Chunk, metadata FileMetadata) int {
+	recovered := 0
+
+	for progress := true; progress; {
+		progress = false
+
+		for _, p := range parity {
+			members := ParityMembers(p.Index, metadata)
+
+			missing := -1
+			for _, i := range members {
+				if _, ok := data[i]; ok {
+					continue
+				}
+				if missing >= 0 {
+					missing = -2
+					break
+				}
+				missing = int(i)
+			}
+			if missing < 0 {
+				continue
+			}
+
+			buf := make([]byte, len(p.Data))
+			copy(buf, p.Data)
+			for _, i := range members {
+				if int(i) != missing {
+					xorBytes(buf, data[i])
+				}
+			}
+
+			data[uint32(missing)] = buf[:metadata.ChunkLength(uint32(missing))]
+			recovered++
+			progress = true
+		}
+	}
+
+	return recovered
+}
+
+func Recoverable(received, parity map[uint32]bool, metadata FileMetadata) int {
+	have := make(map[uint32]bool, metadata.TotalChunks)
+	for i := range received {
+		if i < metadata.TotalChunks {
+			have[i] = true
+		}
+	}
+
+	for progress := true; progress; {
+		progress = false
+
+		for index := range parity {
+			missing := -1
+			for _, i := range ParityMembers(index, metadata) {
+				if have[i] {
+					continue
+				}
+				if missing >= 0 {
+					missing = -2
+					break
+				}
+				missing = int(i)
+			}
+			if missing < 0 {
+				continue
+			}
+
+			have[uint32(missing)] = true
+			progress = true
+		}
+	}
+
+	return len(have)
+}
+
+func (m FileMetadata) ChunkLength(index uint32) int {
+	start := uint64(index) * uint64(m.ChunkSize)
+	if start >= m.FileSize {
+		return 0
+	}
+	return int(min(uint64(m.ChunkSize), m.FileSize-start))
+}
+
+func xorBytes(dst, src []byte) {
+	for i := range src {
+		if i < len(dst) {
+			dst[i] ^= src[i]
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package chunk
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+const (
+	sealedFlag uint32 = 1 << 31
+	sealCheck         = 32 - 4
+)
+
+var (
+	ErrSealed      = errors.New("chunk is encrypted and no key is set")
+	ErrUnsealed    = errors.New("chunk is not encrypted but a key is set")
+	ErrUnauthentic = errors.New("chunk failed authentication")
+	ErrSealLayout  = errors.New("cipher tag and nonce do not fit the chunk checksum")
+	ErrCorrupt     = errors.New("encrypted chunk failed its checksum")
+)
+
+type Keys func(session uint64) (cipher.AEAD, error)
+
+func (p *Processor) SetKeys(keys Keys) {
+	p.keys = keys
+}
+
+func (p *Processor) Encrypted() bool {
+	return p.keys != nil
+}
+
+func associatedData(c Chunk) []byte {
+	ad := make([]byte, 0, 16)
+	ad = binary.BigEndian.AppendUint32(ad, c.Index)
+	ad = binary.BigEndian.AppendUint32(ad, c.Total)
+	return binary.BigEndian.AppendUint64(ad, SessionID(c))
+}
+
+func sealSum(sealed, nonce []byte) uint32 {
+	return crc32.Update(crc32.ChecksumIEEE(sealed), crc32.IEEETable, nonce)
+}
+
+func (p *Processor) seal(dst []byte, c Chunk) ([]byte, error) {
+	aead, err := p.keys(SessionID(c))
+	if err != nil {
+		return nil, err
+	}
+	if aead.Overhead()+aead.NonceSize() > sealCheck {
+		return nil, ErrSealLayout
+	}
+
+	var check [32]byte
+	nonce := check[aead.Overhead() : aead.Overhead()+aead.NonceSize()]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	start := len(dst)
+	dst = aead.Seal(dst, nonce, c.Data, associatedData(c))
+	copy(check[:], dst[start+len(c.Data):])
+	binary.BigEndian.PutUint32(check[sealCheck:], sealSum(dst[start:], nonce))
+	return append(dst[:start+len(c.Data)], check[:]...), nil
+}
+
+func (p *Processor) open(c Chunk, body []byte) (Chunk, error) {
+	if p.keys == nil {
+		c.Data = getBuffer(len(body))
+		copy(c.Data, body)
+		return c, ErrSealed
+	}
+	aead, err := p.keys(SessionID(c))
+	if err != nil {
+		return c, fmt.Errorf("%w: %v", ErrSealed, err)
+	}
+
+	if aead.Overhead()+aead.NonceSize() > sealCheck {
+		return c, ErrSealLayout
+	}
+
+	sealed := getBuffer(len(body) + aead.Overhead())
+	copy(sealed, body)
+	copy(sealed[len(body):], c.Checksum[:aead.Overhead()])
+	nonce := c.Checksum[aead.Overhead() : aead.Overhead()+aead.NonceSize()]
+	if sealSum(sealed, nonce) != binary.BigEndian.Uint32(c.Checksum[sealCheck:]) {
+		putBuffer(sealed)
+		return c, ErrCorrupt
+	}
+
+	plain, err := aead.Open(sealed[:0], nonce, sealed, associatedData(c))
+	if err != nil {
+		putBuffer(sealed)
+		return c, ErrUnauthentic
+	}
+	c.Data = plain
+	c.Checksum = sha256.Sum256(plain)
+	c.Sealed = true
+	return c, nil
+}
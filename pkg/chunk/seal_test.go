@@ -0,0 +1,52 @@
+package chunk
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testKeys(session uint64) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(make([]byte, 32))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func TestSealNonces(t *testing.T) {
+	p := NewProcessor(NewConfig(64, 1))
+	p.SetKeys(testKeys)
+
+	c := Chunk{Index: 3, Total: 9, Data: []byte("the same chunk sent twice"), Timestamp: SessionTimestamp(time.Now())}
+	first, err := p.SerializeChunk(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := p.SerializeChunk(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first, second) {
+		t.Fatal("two seals of one chunk produced identical frames")
+	}
+
+	for _, frame := range [][]byte{first, second} {
+		got, err := p.DeserializeChunk(frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Sealed || !bytes.Equal(got.Data, c.Data) || !VerifyChunk(got) {
+			t.Errorf("opened chunk %q, want %q", got.Data, c.Data)
+		}
+	}
+
+	tampered := bytes.Clone(first)
+	tampered[headerSize+4] ^= 1
+	if got, err := p.DeserializeChunk(tampered); !errors.Is(err, ErrCorrupt) || got.Data != nil {
+		t.Errorf("tampered chunk: err %v, data %q, want ErrCorrupt and no data", err, got.Data)
+	}
+}
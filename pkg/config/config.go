@@ -0,0 +1,201 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	dirName      = "owl-transfer"
+	fileName     = "config.yaml"
+	snapshotName = "receive.snapshot"
+	spoolName    = "spool"
+	keyName      = "signing.key"
+	auditName    = "audit.log"
+	holdName     = "quarantine"
+)
+
+type Config struct {
+	Theme    string   `yaml:"theme"`
+	Sender   Sender   `yaml:"sender"`
+	Receiver Receiver `yaml:"receiver"`
+}
+
+type Sender struct {
+	ChunkSize  int     `yaml:"chunk_size"`
+	Rate       float64 `yaml:"rate"`
+	AutoRate   bool    `yaml:"auto_rate"`
+	ErrorLevel string  `yaml:"error_level"`
+	Redundancy int     `yaml:"redundancy"`
+	Strategy   string  `yaml:"strategy"`
+	EInk       bool    `yaml:"eink"`
+	Projector  bool    `yaml:"projector"`
+	Sign       bool    `yaml:"sign"`
+	Wipe       bool    `yaml:"secure_wipe"`
+}
+
+type Receiver struct {
+	FPS         int    `yaml:"fps"`
+	AutoFPS     bool   `yaml:"auto_fps"`
+	BlockSize   int    `yaml:"block_size"`
+	SaveDir     string `yaml:"save_dir"`
+	AutoSave    bool   `yaml:"auto_save"`
+	DownloadDir string `yaml:"download_dir"`
+	Source      string `yaml:"source"`
+	StreamURL   string `yaml:"stream_url"`
+	HideCursor  bool   `yaml:"hide_cursor"`
+	MaskSelf    bool   `yaml:"mask_self"`
+	TrackCode   bool   `yaml:"track_code"`
+	Notify      bool   `yaml:"notify"`
+	Sound       bool   `yaml:"sound"`
+	StallAfter  int    `yaml:"stall_seconds"`
+	Wipe        bool   `yaml:"secure_wipe"`
+
+	MaxFileSize       string   `yaml:"max_file_size"`
+	AllowedExtensions []string `yaml:"allowed_extensions"`
+	AllowedTypes      []string `yaml:"allowed_types"`
+	RejectExecutables bool     `yaml:"reject_executables"`
+
+	Quarantine      bool   `yaml:"quarantine"`
+	QuarantineDir   string `yaml:"quarantine_dir"`
+	PostReceiveHook string `yaml:"post_receive_hook"`
+
+	TrustedSigners []string `yaml:"trusted_signers"`
+
+	Tolerance float64 `yaml:"decode_tolerance"`
+	Kernel    int     `yaml:"sample_kernel"`
+	Dominant  bool    `yaml:"sample_dominant"`
+	Threshold int     `yaml:"luminance_threshold"`
+	EInk      bool    `yaml:"eink"`
+	Projector bool    `yaml:"projector"`
+}
+
+func Default() Config {
+	return Config{
+		Theme: "system",
+		Sender: Sender{
+			ChunkSize:  100,
+			Rate:       2,
+			AutoRate:   true,
+			ErrorLevel: "medium",
+			Redundancy: 1,
+			Strategy:   "immediate",
+		},
+		Receiver: Receiver{
+			FPS:        2,
+			AutoFPS:    true,
+			BlockSize:  20,
+			AutoSave:   true,
+			Source:     "Full Screen",
+			HideCursor: true,
+			MaskSelf:   true,
+			TrackCode:  true,
+			Notify:     true,
+			StallAfter: 60,
+		},
+	}
+}
+
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, dirName, fileName), nil
+}
+
+func SnapshotPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, dirName, snapshotName), nil
+}
+
+func SpoolDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, dirName, spoolName), nil
+}
+
+func SigningKeyPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, dirName, keyName), nil
+}
+
+func AuditLogPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, dirName, auditName), nil
+}
+
+func QuarantineDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, dirName, holdName), nil
+}
+
+func DownloadDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return os.TempDir()
+	}
+	if dir := filepath.Join(home, "Downloads"); isDir(dir) {
+		return dir
+	}
+	return home
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func Resolve(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	return Path()
+}
+
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Default(), fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func (c Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
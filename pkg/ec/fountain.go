@@ -0,0 +1,289 @@
+package ec
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+)
+
+const (
+	solitonC     = 0.1
+	solitonDelta = 0.05
+)
+
+var (
+	ErrNeedMoreSymbols = errors.New("not enough symbols to decode")
+	ErrSymbolSize      = errors.New("symbol size mismatch")
+)
+
+type Symbol struct {
+	ID   uint32
+	Data []byte
+}
+
+type Fountain struct {
+	k          int
+	symbolSize int
+	cdf        []float64
+}
+
+func NewFountain(k, symbolSize int) *Fountain {
+	return &Fountain{
+		k:          k,
+		symbolSize: symbolSize,
+		cdf:        robustSoliton(k),
+	}
+}
+
+func NewFountainForData(dataLen, symbolSize int) *Fountain {
+	k := (dataLen + symbolSize - 1) / symbolSize
+	return NewFountain(max(k, 1), symbolSize)
+}
+
+func (f *Fountain) SourceSymbols() int {
+	return f.k
+}
+
+func (f *Fountain) SymbolSize() int {
+	return f.symbolSize
+}
+
+func robustSoliton(k int) []float64 {
+	r := solitonC * math.Log(float64(k)/solitonDelta) * math.Sqrt(float64(k))
+	spike := int(float64(k) / r)
+	if spike < 1 || spike > k {
+		spike = k
+	}
+
+	weights := make([]float64, k+1)
+	total := 0.0
+	for d := 1; d <= k; d++ {
+		rho := 1.0 / float64(k)
+		if d > 1 {
+			rho = 1.0 / float64(d*(d-1))
+		}
+
+		tau := 0.0
+		switch {
+		case d < spike:
+			tau = r / (float64(d) * float64(k))
+		case d == spike:
+			tau = r * math.Log(r/solitonDelta) / float64(k)
+		}
+
+		weights[d] = rho + tau
+		total += weights[d]
+	}
+
+	cdf := make([]float64, k+1)
+	acc := 0.0
+	for d := 1; d <= k; d++ {
+		acc += weights[d] / total
+		cdf[d] = acc
+	}
+	cdf[k] = 1
+
+	return cdf
+}
+
+func (f *Fountain) neighbors(id uint32) []int {
+	if int(id) < f.k {
+		return []int{int(id)}
+	}
+
+	rng := rand.New(rand.NewPCG(uint64(id), uint64(f.k)))
+
+	u := rng.Float64()
+	degree := 1
+	for degree < f.k && f.cdf[degree] < u {
+		degree++
+	}
+
+	picked := make([]int, 0, degree)
+	seen := make(map[int]bool, degree)
+	for j := f.k - degree; j < f.k; j++ {
+		n := rng.IntN(j + 1)
+		if seen[n] {
+			n = j
+		}
+		seen[n] = true
+		picked = append(picked, n)
+	}
+	return picked
+}
+
+type FountainEncoder struct {
+	f      *Fountain
+	source [][]byte
+}
+
+func (f *Fountain) NewEncoder(data []byte) *FountainEncoder {
+	source := make([][]byte, f.k)
+	for i := range source {
+		start := i * f.symbolSize
+		end := start + f.symbolSize
+		if end <= len(data) {
+			source[i] = data[start:end:end]
+			continue
+		}
+		block := make([]byte, f.symbolSize)
+		if start < len(data) {
+			copy(block, data[start:])
+		}
+		source[i] = block
+	}
+	return &FountainEncoder{f: f, source: source}
+}
+
+func (e *FountainEncoder) Symbol(id uint32) Symbol {
+	out := make([]byte, e.f.symbolSize)
+	for _, n := range e.f.neighbors(id) {
+		xorInto(out, e.source[n])
+	}
+	return Symbol{ID: id, Data: out}
+}
+
+func (e *FountainEncoder) Symbols(ids []uint32) []Symbol {
+	symbols := make([]Symbol, len(ids))
+	for i, id := range ids {
+		symbols[i] = e.Symbol(id)
+	}
+	return symbols
+}
+
+func (f *Fountain) EncodeSymbols(data []byte, ids []uint32) []Symbol {
+	return f.NewEncoder(data).Symbols(ids)
+}
+
+func (f *Fountain) DecodeSymbols(symbols []Symbol, dataLen int) ([]byte, error) {
+	dec := f.NewDecoder()
+	for _, s := range symbols {
+		if _, err := dec.AddSymbol(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return dec.Data(dataLen)
+}
+
+type FountainDecoder struct {
+	f        *Fountain
+	decoded  [][]byte
+	resolved int
+	waiting  map[int][]*pendingSymbol
+}
+
+type pendingSymbol struct {
+	neighbors map[int]bool
+	data      []byte
+}
+
+func (f *Fountain) NewDecoder() *FountainDecoder {
+	return &FountainDecoder{
+		f:       f,
+		decoded: make([][]byte, f.k),
+		waiting: make(map[int][]*pendingSymbol),
+	}
+}
+
+func (d *FountainDecoder) AddSymbol(s Symbol) (bool, error) {
+	if len(s.Data) != d.f.symbolSize {
+		return false, ErrSymbolSize
+	}
+	if d.Complete() {
+		return true, nil
+	}
+
+	p := &pendingSymbol{
+		neighbors: make(map[int]bool),
+		data:      make([]byte, len(s.Data)),
+	}
+	copy(p.data, s.Data)
+
+	for _, n := range d.f.neighbors(s.ID) {
+		if d.decoded[n] != nil {
+			xorInto(p.data, d.decoded[n])
+		} else {
+			p.neighbors[n] = true
+		}
+	}
+
+	switch len(p.neighbors) {
+	case 0:
+	case 1:
+		d.resolve(p)
+	default:
+		for n := range p.neighbors {
+			d.waiting[n] = append(d.waiting[n], p)
+		}
+	}
+
+	return d.Complete(), nil
+}
+
+func (d *FountainDecoder) resolve(first *pendingSymbol) {
+	queue := []*pendingSymbol{first}
+
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+
+		if len(p.neighbors) != 1 {
+			continue
+		}
+
+		var index int
+		for n := range p.neighbors {
+			index = n
+		}
+		if d.decoded[index] != nil {
+			continue
+		}
+
+		d.decoded[index] = p.data
+		d.resolved++
+		delete(p.neighbors, index)
+
+		for _, w := range d.waiting[index] {
+			if !w.neighbors[index] {
+				continue
+			}
+			delete(w.neighbors, index)
+			xorInto(w.data, p.data)
+			if len(w.neighbors) == 1 {
+				queue = append(queue, w)
+			}
+		}
+		delete(d.waiting, index)
+	}
+}
+
+func (d *FountainDecoder) Complete() bool {
+	return d.resolved == d.f.k
+}
+
+func (d *FountainDecoder) Progress() (int, int) {
+	return d.resolved, d.f.k
+}
+
+func (d *FountainDecoder) Data(dataLen int) ([]byte, error) {
+	if !d.Complete() {
+		return nil, ErrNeedMoreSymbols
+	}
+
+	data := make([]byte, 0, d.f.k*d.f.symbolSize)
+	for _, block := range d.decoded {
+		data = append(data, block...)
+	}
+
+	if dataLen >= 0 && dataLen < len(data) {
+		data = data[:dataLen]
+	}
+	return data, nil
+}
+
+func xorInto(dst, src []byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
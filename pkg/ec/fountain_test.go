@@ -0,0 +1,72 @@
+package ec
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestFountainDecode(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	data := make([]byte, 10000)
+	for i := range data {
+		data[i] = byte(rng.IntN(256))
+	}
+
+	f := NewFountainForData(len(data), 100)
+	enc := f.NewEncoder(data)
+	dec := f.NewDecoder()
+
+	var received int
+	for id := uint32(0); !dec.Complete(); id++ {
+		if id > uint32(3*f.SourceSymbols()) {
+			t.Fatalf("not decoded after %d symbols", id)
+		}
+		if id < uint32(f.SourceSymbols()) && rng.IntN(4) == 0 {
+			continue
+		}
+		if _, err := dec.AddSymbol(enc.Symbol(id)); err != nil {
+			t.Fatal(err)
+		}
+		received++
+	}
+
+	got, err := dec.Data(len(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("decoded data differs from the original")
+	}
+	t.Logf("decoded %d source symbols from %d received", f.SourceSymbols(), received)
+}
+
+func TestFountainDecodeSymbols(t *testing.T) {
+	data := []byte("the quick brown fox jumps over the lazy dog")
+	f := NewFountainForData(len(data), 8)
+
+	ids := make([]uint32, 0, 4*f.SourceSymbols())
+	for id := uint32(f.SourceSymbols()); len(ids) < cap(ids); id++ {
+		ids = append(ids, id)
+	}
+	got, err := f.DecodeSymbols(f.EncodeSymbols(data, ids), len(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("decoded %q, want %q", got, data)
+	}
+}
+
+func TestFountainNeighborsDistinct(t *testing.T) {
+	f := NewFountain(50, 1)
+	for id := uint32(50); id < 2000; id++ {
+		seen := make(map[int]bool)
+		for _, n := range f.neighbors(id) {
+			if n < 0 || n >= 50 || seen[n] {
+				t.Fatalf("symbol %d has neighbor %d out of range or repeated", id, n)
+			}
+			seen[n] = true
+		}
+	}
+}
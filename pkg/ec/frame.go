@@ -0,0 +1,44 @@
+package ec
+
+import "fmt"
+
+func (rs *RS) frameLength(capacity int) int {
+	if tail := capacity % rs.TotalSize(); tail > 0 && tail <= rs.nroots {
+		capacity -= tail
+	}
+	return capacity
+}
+
+func (rs *RS) FrameDataSize(capacity int) int {
+	length := rs.frameLength(capacity)
+	return max(length-rs.nroots*len(rs.CodewordLengths(length)), 0)
+}
+
+func (rs *RS) EncodeFrame(data []byte, capacity int) ([]byte, error) {
+	size := rs.FrameDataSize(capacity)
+	if size == 0 || len(data) > size {
+		return nil, fmt.Errorf("%w: %d bytes do not fit a %d byte frame with %d parity bytes per codeword", ErrInvalidLength, len(data), capacity, rs.nroots)
+	}
+
+	padded := make([]byte, size)
+	copy(padded, data)
+
+	frame := make([]byte, capacity)
+	copy(frame, rs.EncodeInterleaved(padded))
+	return frame, nil
+}
+
+func (rs *RS) DecodeFrame(frame []byte, erasures []int) ([]byte, error) {
+	length := rs.frameLength(len(frame))
+	if length == 0 {
+		return nil, ErrInvalidLength
+	}
+
+	kept := make([]int, 0, len(erasures))
+	for _, e := range erasures {
+		if e < length {
+			kept = append(kept, e)
+		}
+	}
+	return rs.DecodeInterleaved(frame[:length], kept)
+}
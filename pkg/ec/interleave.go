@@ -0,0 +1,123 @@
+package ec
+
+func Interleave(codewords [][]byte) []byte {
+	total := 0
+	maxLen := 0
+	for _, cw := range codewords {
+		total += len(cw)
+		maxLen = max(maxLen, len(cw))
+	}
+
+	out := make([]byte, 0, total)
+	for j := 0; j < maxLen; j++ {
+		for _, cw := range codewords {
+			if j < len(cw) {
+				out = append(out, cw[j])
+			}
+		}
+	}
+
+	return out
+}
+
+func Deinterleave(data []byte, lengths []int) [][]byte {
+	codewords := make([][]byte, len(lengths))
+	for i, n := range lengths {
+		codewords[i] = make([]byte, 0, n)
+	}
+
+	pos := 0
+	forEachInterleaved(lengths, func(i, _ int) {
+		if pos < len(data) {
+			codewords[i] = append(codewords[i], data[pos])
+		}
+		pos++
+	})
+
+	return codewords
+}
+
+func DeinterleaveErasures(positions []int, lengths []int) [][]int {
+	erased := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		erased[p] = true
+	}
+
+	erasures := make([][]int, len(lengths))
+	pos := 0
+	forEachInterleaved(lengths, func(i, j int) {
+		if erased[pos] {
+			erasures[i] = append(erasures[i], j)
+		}
+		pos++
+	})
+
+	return erasures
+}
+
+func forEachInterleaved(lengths []int, fn func(codeword, symbol int)) {
+	maxLen := 0
+	for _, n := range lengths {
+		maxLen = max(maxLen, n)
+	}
+
+	for j := 0; j < maxLen; j++ {
+		for i, n := range lengths {
+			if j < n {
+				fn(i, j)
+			}
+		}
+	}
+}
+
+func (rs *RS) CodewordLengths(encodedLen int) []int {
+	if encodedLen <= 0 {
+		return nil
+	}
+	count := (encodedLen + rs.TotalSize() - 1) / rs.TotalSize()
+	sizes := splitEven(encodedLen-count*rs.nroots, count)
+	for i := range sizes {
+		sizes[i] += rs.nroots
+	}
+	return sizes
+}
+
+func splitEven(n, count int) []int {
+	sizes := make([]int, count)
+	for i := range sizes {
+		sizes[i] = n / count
+		if i < n%count {
+			sizes[i]++
+		}
+	}
+	return sizes
+}
+
+func (rs *RS) EncodeInterleaved(data []byte) []byte {
+	count := (len(data) + rs.DataSize() - 1) / rs.DataSize()
+	codewords := make([][]byte, 0, count)
+	start := 0
+	for _, size := range splitEven(len(data), count) {
+		codewords = append(codewords, rs.Encode(data[start:start+size]))
+		start += size
+	}
+
+	return Interleave(codewords)
+}
+
+func (rs *RS) DecodeInterleaved(encoded []byte, erasures []int) ([]byte, error) {
+	lengths := rs.CodewordLengths(len(encoded))
+	codewords := Deinterleave(encoded, lengths)
+	codewordErasures := DeinterleaveErasures(erasures, lengths)
+
+	data := make([]byte, 0, len(encoded))
+	for i, cw := range codewords {
+		decoded, err := rs.Decode(cw, codewordErasures[i])
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, decoded[:len(decoded)-rs.nroots]...)
+	}
+
+	return data, nil
+}
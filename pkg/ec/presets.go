@@ -0,0 +1,44 @@
+package ec
+
+import (
+	"fmt"
+)
+
+const (
+	PresetFCR  = 0
+	PresetPrim = 1
+)
+
+func NewRS255_191() *RS {
+	return NewRS(8, PresetFCR, PresetPrim, 64)
+}
+
+func NewRS255_223() *RS {
+	return NewRS(8, PresetFCR, PresetPrim, 32)
+}
+
+func NewRS255_239() *RS {
+	return NewRS(8, PresetFCR, PresetPrim, 16)
+}
+
+func NewRS255_247() *RS {
+	return NewRS(8, PresetFCR, PresetPrim, 8)
+}
+
+func NewShortenedRS(n, k int) (*RS, error) {
+	if k <= 0 || n <= k || n > 255 {
+		return nil, fmt.Errorf("invalid shortened code RS(%d,%d)", n, k)
+	}
+
+	rs, err := NewRSPoly(8, DefaultGFPoly(8), PresetFCR, PresetPrim, n-k)
+	if err != nil {
+		return nil, err
+	}
+	rs.padding = rs.nn - n
+
+	return rs, nil
+}
+
+func NewRSForChunk(chunkSize, nroots int) (*RS, error) {
+	return NewShortenedRS(chunkSize+nroots, chunkSize)
+}
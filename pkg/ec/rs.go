@@ -7,26 +7,70 @@ import (
 var (
 	ErrDecodingFailure = errors.New("decoding failure")
 	ErrTooManyErrors   = errors.New("too many errors to correct")
+	ErrNotPrimitive    = errors.New("field generator polynomial is not primitive")
+	ErrInvalidLength   = errors.New("invalid data length")
+	ErrSymbolRange     = errors.New("symbol out of range for field")
 )
 
+var defaultGFPoly = map[int]int{
+	2:  0x7,
+	3:  0xb,
+	4:  0x13,
+	5:  0x25,
+	6:  0x43,
+	7:  0x89,
+	8:  0x11d,
+	9:  0x211,
+	10: 0x409,
+	11: 0x805,
+	12: 0x1053,
+	13: 0x201b,
+	14: 0x4443,
+	15: 0x8003,
+	16: 0x1100b,
+}
+
 type RS struct {
 	mm       int
 	nn       int
 	alpha_to []int
 	index_of []int
 	genpoly  []int
+	gfpoly   int
 	fcr      int
 	prim     int
+	iprim    int
 	nroots   int
 	padding  int
 }
 
+func DefaultGFPoly(mm int) int {
+	return defaultGFPoly[mm]
+}
+
 func NewRS(mm, fcr, prim, nroots int) *RS {
+	rs, err := NewRSPoly(mm, DefaultGFPoly(mm), fcr, prim, nroots)
+	if err != nil {
+		panic(err)
+	}
+	return rs
+}
+
+func NewRSPoly(mm, gfpoly, fcr, prim, nroots int) (*RS, error) {
+	if mm < 2 || mm > 16 {
+		return nil, errors.New("symbol size out of range")
+	}
+
 	nn := (1 << mm) - 1
 
+	if fcr < 0 || fcr > nn || prim <= 0 || prim > nn || nroots < 0 || nroots >= nn {
+		return nil, errors.New("invalid code parameters")
+	}
+
 	rs := &RS{
 		mm:      mm,
 		nn:      nn,
+		gfpoly:  gfpoly,
 		fcr:     fcr,
 		prim:    prim,
 		nroots:  nroots,
@@ -37,169 +81,449 @@ func NewRS(mm, fcr, prim, nroots int) *RS {
 	rs.index_of = make([]int, nn+1)
 	rs.genpoly = make([]int, nroots+1)
 
-	rs.index_of[0] = -1
-	rs.alpha_to[0] = 1
+	rs.index_of[0] = nn
+	rs.alpha_to[nn] = 0
 
-	for i := 1; i <= nn; i++ {
-		rs.index_of[i] = i - 1
-		rs.alpha_to[i-1] = i
+	sr := 1
+	for i := 0; i < nn; i++ {
+		rs.index_of[sr] = i
+		rs.alpha_to[i] = sr
+		sr <<= 1
+		if sr&(1<<mm) != 0 {
+			sr ^= gfpoly
+		}
+		sr &= nn
+	}
+	if sr != 1 {
+		return nil, ErrNotPrimitive
 	}
 
+	iprim := 1
+	for iprim%prim != 0 {
+		iprim += nn
+	}
+	rs.iprim = iprim / prim
+
 	rs.generate_genpoly()
 
-	return rs
+	return rs, nil
 }
 
 func (rs *RS) generate_genpoly() {
 	rs.genpoly[0] = 1
 
+	root := rs.fcr * rs.prim
 	for i := 0; i < rs.nroots; i++ {
 		rs.genpoly[i+1] = 1
-		for j := i; j >= 0; j-- {
-			rs.genpoly[j] = rs.modnn(rs.alpha_to[rs.index_of[rs.genpoly[j]]+rs.fcr+(i*rs.prim)%rs.nn] ^ rs.genpoly[j])
+		for j := i; j > 0; j-- {
+			if rs.genpoly[j] != 0 {
+				rs.genpoly[j] = rs.genpoly[j-1] ^ rs.alpha_to[rs.modnn(rs.index_of[rs.genpoly[j]]+root)]
+			} else {
+				rs.genpoly[j] = rs.genpoly[j-1]
+			}
 		}
+		rs.genpoly[0] = rs.alpha_to[rs.modnn(rs.index_of[rs.genpoly[0]]+root)]
+		root += rs.prim
+	}
+
+	for i := 0; i <= rs.nroots; i++ {
+		rs.genpoly[i] = rs.index_of[rs.genpoly[i]]
 	}
 }
 
 func (rs *RS) modnn(x int) int {
-	for x > rs.nn {
+	for x >= rs.nn {
 		x -= rs.nn
-		x = (x >> rs.mm) ^ (x&rs.nn)*rs.padding
+		x = (x >> rs.mm) + (x & rs.nn)
 	}
 	return x
 }
 
-func (rs *RS) Encode(data []byte) []byte {
-	parity := make([]byte, rs.nroots)
-
+func (rs *RS) Generator() []int {
 	gen := make([]int, rs.nroots+1)
-	copy(gen, rs.genpoly)
+	for i, g := range rs.genpoly {
+		gen[i] = rs.alpha_to[g]
+	}
+	return gen
+}
 
-	for _, b := range data {
-		shift := int(b) ^ int(parity[rs.nroots-1])
+func (rs *RS) SymbolSize() int {
+	return rs.mm
+}
 
-		for i := rs.nroots - 1; i >= 0; i-- {
-			if i > 0 {
-				parity[i-1] = byte(rs.alpha_to[rs.modnn(rs.index_of[gen[i]]+shift)])
-			} else {
-				parity[0] = byte(rs.alpha_to[rs.modnn(rs.index_of[gen[0]]+shift)])
-			}
-		}
+func (rs *RS) Encode(data []byte) []byte {
+	if rs.mm > 8 {
+		panic("ec: byte encoding requires a symbol size of at most 8 bits, use EncodeWords")
 	}
 
+	symbols := make([]int, len(data))
+	for i, b := range data {
+		symbols[i] = int(b)
+	}
+
+	parity := rs.encodeSymbols(symbols)
+
 	result := make([]byte, len(data)+rs.nroots)
 	copy(result, data)
-	copy(result[len(data):], parity)
+	for i, p := range parity {
+		result[len(data)+i] = byte(p)
+	}
 
 	return result
 }
 
+func (rs *RS) encodeSymbols(data []int) []int {
+	parity := make([]int, rs.nroots)
+	if rs.nroots == 0 {
+		return parity
+	}
+
+	for _, d := range data {
+		feedback := rs.index_of[d^parity[0]]
+		if feedback != rs.nn {
+			for j := 1; j < rs.nroots; j++ {
+				parity[j] ^= rs.alpha_to[rs.modnn(feedback+rs.genpoly[rs.nroots-j])]
+			}
+		}
+
+		copy(parity, parity[1:])
+		if feedback != rs.nn {
+			parity[rs.nroots-1] = rs.alpha_to[rs.modnn(feedback+rs.genpoly[0])]
+		} else {
+			parity[rs.nroots-1] = 0
+		}
+	}
+
+	return parity
+}
+
 func (rs *RS) gfMul(a, b int) int {
 	if a == 0 || b == 0 {
 		return 0
 	}
-	return rs.alpha_to[(rs.index_of[a]+rs.index_of[b])%rs.nn]
+	return rs.alpha_to[rs.modnn(rs.index_of[a]+rs.index_of[b])]
 }
 
 func (rs *RS) Decode(received []byte, erasures []int) ([]byte, error) {
-	if len(received) < rs.DataSize() {
-		return nil, errors.New("invalid data length")
+	if rs.mm > 8 {
+		return nil, ErrSymbolRange
+	}
+	if len(received) <= rs.nroots || len(received) > rs.TotalSize() {
+		return nil, ErrInvalidLength
 	}
 
-	synd := make([]int, rs.nroots+1)
+	symbols := make([]int, len(received))
+	for i, b := range received {
+		symbols[i] = int(b)
+	}
 
-	for i := 1; i <= rs.nroots; i++ {
-		synd[i] = 0
-		for j := 0; j < len(received); j++ {
-			synd[i] ^= rs.gfMul(int(received[j]), rs.gfPow(rs.alpha_to[rs.fcr], (rs.nroots-i)*j))
-		}
+	if _, err := rs.decodeSymbols(symbols, erasures); err != nil {
+		return nil, err
 	}
 
-	totalZeros := 0
-	for i := 1; i <= rs.nroots; i++ {
-		if synd[i] == 0 {
-			totalZeros++
-		}
+	result := make([]byte, len(received))
+	for i, s := range symbols {
+		result[i] = byte(s)
 	}
 
-	if totalZeros == rs.nroots {
-		return received, nil
+	return result, nil
+}
+
+func (rs *RS) decodeSymbols(data []int, erasures []int) (int, error) {
+	nroots := rs.nroots
+	nn := rs.nn
+	a0 := nn
+	pad := nn - len(data)
+
+	if len(erasures) > nroots {
+		return 0, ErrTooManyErrors
+	}
+	if nroots == 0 {
+		return 0, nil
 	}
 
-	C := make([]int, rs.nroots+1)
-	B := make([]int, rs.nroots+1)
+	s, ok := rs.syndromes(data)
+	if ok {
+		return 0, nil
+	}
 
-	for i := 0; i <= rs.nroots; i++ {
-		C[i] = 0
-		B[i] = 0
+	lambda, err := rs.erasureLocator(erasures, len(data))
+	if err != nil {
+		return 0, err
 	}
 
-	B[rs.nroots] = 1
-	C[0] = 1
+	b := make([]int, nroots+1)
+	t := make([]int, nroots+1)
+	reg := make([]int, nroots+1)
+	root := make([]int, nroots)
+	loc := make([]int, nroots)
 
-	reg := make([]int, rs.nroots+1)
+	for i := range b {
+		b[i] = rs.index_of[lambda[i]]
+	}
 
-	for i := 0; i < len(received); i++ {
-		K := reg[rs.nroots]
+	noEras := len(erasures)
+	r := noEras
+	el := noEras
+	for r++; r <= nroots; r++ {
+		discr := 0
+		for i := 0; i < r; i++ {
+			if lambda[i] != 0 && s[r-i-1] != a0 {
+				discr ^= rs.alpha_to[rs.modnn(rs.index_of[lambda[i]]+s[r-i-1])]
+			}
+		}
+		discr = rs.index_of[discr]
 
-		for j := rs.nroots; j >= 1; j-- {
-			reg[j] = reg[j-1]
+		if discr == a0 {
+			copy(b[1:], b[:nroots])
+			b[0] = a0
+			continue
 		}
-		reg[0] = K
 
-		eras := false
-		for _, e := range erasures {
-			if e == i {
-				eras = true
-				break
+		t[0] = lambda[0]
+		for i := 0; i < nroots; i++ {
+			if b[i] != a0 {
+				t[i+1] = lambda[i+1] ^ rs.alpha_to[rs.modnn(discr+b[i])]
+			} else {
+				t[i+1] = lambda[i+1]
 			}
 		}
-		if eras {
-			reg[0] ^= 1
+
+		if 2*el <= r+noEras-1 {
+			el = r + noEras - el
+			for i := 0; i <= nroots; i++ {
+				if lambda[i] == 0 {
+					b[i] = a0
+				} else {
+					b[i] = rs.modnn(rs.index_of[lambda[i]] - discr + nn)
+				}
+			}
+		} else {
+			copy(b[1:], b[:nroots])
+			b[0] = a0
 		}
+		copy(lambda, t)
+	}
 
-		if reg[rs.nroots] != 0 {
-			L := rs.index_of[reg[rs.nroots]]
+	degLambda := rs.toIndexForm(lambda)
 
-			for j := 1; j <= rs.nroots; j++ {
-				C[j] ^= rs.gfMul(reg[rs.nroots], rs.alpha_to[(L*(rs.nroots-j))%rs.nn])
+	copy(reg[1:], lambda[1:])
+	count := 0
+	for i, k := 1, rs.iprim-1; i <= nn; i, k = i+1, rs.modnn(k+rs.iprim) {
+		q := 1
+		for j := degLambda; j > 0; j-- {
+			if reg[j] != a0 {
+				reg[j] = rs.modnn(reg[j] + j)
+				q ^= rs.alpha_to[reg[j]]
 			}
+		}
+		if q != 0 {
+			continue
+		}
 
-			delta := rs.index_of[reg[rs.nroots]] - rs.index_of[B[rs.nroots]]
-			if delta != 0 {
-				scale := rs.alpha_to[rs.modnn(-delta)]
-				for j := 0; j <= rs.nroots; j++ {
-					B[j] = rs.gfMul(B[j], scale)
-				}
+		root[count] = i
+		loc[count] = k
+		count++
+		if count == degLambda {
+			break
+		}
+	}
+
+	if degLambda != count {
+		return 0, ErrTooManyErrors
+	}
+
+	if err := rs.correct(data, s, lambda, degLambda, root[:count], loc[:count], pad); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (rs *RS) DecodeErasures(received []byte, erasures []int) ([]byte, error) {
+	if len(received) <= rs.nroots || len(received) > rs.TotalSize() {
+		return nil, ErrInvalidLength
+	}
+
+	symbols := make([]int, len(received))
+	for i, b := range received {
+		symbols[i] = int(b)
+	}
+
+	if err := rs.decodeErasureSymbols(symbols, erasures); err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, len(received))
+	for i, s := range symbols {
+		result[i] = byte(s)
+	}
+
+	return result, nil
+}
+
+func (rs *RS) decodeErasureSymbols(data []int, erasures []int) error {
+	if len(erasures) > rs.nroots {
+		return ErrTooManyErrors
+	}
+	if rs.nroots == 0 {
+		return nil
+	}
+
+	s, ok := rs.syndromes(data)
+	if ok {
+		return nil
+	}
+	if len(erasures) == 0 {
+		return ErrDecodingFailure
+	}
+
+	lambda, err := rs.erasureLocator(erasures, len(data))
+	if err != nil {
+		return err
+	}
+	degLambda := rs.toIndexForm(lambda)
+	if degLambda != len(erasures) {
+		return ErrDecodingFailure
+	}
+
+	pad := rs.nn - len(data)
+	root := make([]int, len(erasures))
+	loc := make([]int, len(erasures))
+	for i, e := range erasures {
+		loc[i] = e + pad
+		root[i] = rs.modnn(rs.prim * (loc[i] + 1))
+	}
+
+	if err := rs.correct(data, s, lambda, degLambda, root, loc, pad); err != nil {
+		return err
+	}
+
+	if _, ok := rs.syndromes(data); !ok {
+		return ErrDecodingFailure
+	}
+
+	return nil
+}
+
+func (rs *RS) syndromes(data []int) ([]int, bool) {
+	s := make([]int, rs.nroots)
+	for i := range s {
+		s[i] = data[0]
+	}
+	for j := 1; j < len(data); j++ {
+		for i := 0; i < rs.nroots; i++ {
+			if s[i] == 0 {
+				s[i] = data[j]
+			} else {
+				s[i] = data[j] ^ rs.alpha_to[rs.modnn(rs.index_of[s[i]]+(rs.fcr+i)*rs.prim)]
 			}
 		}
 	}
 
-	numRoots := 0
-	for i := rs.nroots; i >= 0; i-- {
-		if C[i] != 0 {
-			numRoots = rs.nroots - i
-			break
+	synError := 0
+	for i := range s {
+		synError |= s[i]
+		s[i] = rs.index_of[s[i]]
+	}
+
+	return s, synError == 0
+}
+
+func (rs *RS) erasureLocator(erasures []int, length int) ([]int, error) {
+	lambda := make([]int, rs.nroots+1)
+	lambda[0] = 1
+
+	if len(erasures) == 0 {
+		return lambda, nil
+	}
+
+	pad := rs.nn - length
+	for _, e := range erasures {
+		if e < 0 || e >= length {
+			return nil, ErrInvalidLength
 		}
 	}
 
-	if numRoots > rs.nroots/2 {
-		return nil, ErrTooManyErrors
+	lambda[1] = rs.alpha_to[rs.modnn(rs.prim*(rs.nn-1-(erasures[0]+pad)))]
+	for i := 1; i < len(erasures); i++ {
+		u := rs.modnn(rs.prim * (rs.nn - 1 - (erasures[i] + pad)))
+		for j := i + 1; j > 0; j-- {
+			tmp := rs.index_of[lambda[j-1]]
+			if tmp != rs.nn {
+				lambda[j] ^= rs.alpha_to[rs.modnn(u+tmp)]
+			}
+		}
 	}
 
-	return received, nil
+	return lambda, nil
+}
+
+func (rs *RS) toIndexForm(poly []int) int {
+	deg := 0
+	for i := range poly {
+		poly[i] = rs.index_of[poly[i]]
+		if poly[i] != rs.nn {
+			deg = i
+		}
+	}
+	return deg
+}
+
+func (rs *RS) correct(data, s, lambda []int, degLambda int, root, loc []int, pad int) error {
+	a0 := rs.nn
+
+	degOmega := degLambda - 1
+	omega := make([]int, degLambda+1)
+	for i := 0; i <= degOmega; i++ {
+		tmp := 0
+		for j := i; j >= 0; j-- {
+			if s[i-j] != a0 && lambda[j] != a0 {
+				tmp ^= rs.alpha_to[rs.modnn(s[i-j]+lambda[j])]
+			}
+		}
+		omega[i] = rs.index_of[tmp]
+	}
+
+	for j := len(root) - 1; j >= 0; j-- {
+		num1 := 0
+		for i := degOmega; i >= 0; i-- {
+			if omega[i] != a0 {
+				num1 ^= rs.alpha_to[rs.modnn(omega[i]+i*root[j])]
+			}
+		}
+
+		num2 := rs.alpha_to[rs.modnn(root[j]*(rs.fcr-1)+rs.nn)]
+
+		den := 0
+		for i := min(degLambda, rs.nroots-1) &^ 1; i >= 0; i -= 2 {
+			if lambda[i+1] != a0 {
+				den ^= rs.alpha_to[rs.modnn(lambda[i+1]+i*root[j])]
+			}
+		}
+		if den == 0 {
+			return ErrDecodingFailure
+		}
+
+		if loc[j] < pad {
+			return ErrDecodingFailure
+		}
+		if num1 != 0 {
+			data[loc[j]-pad] ^= rs.alpha_to[rs.modnn(rs.index_of[num1]+rs.index_of[num2]+rs.nn-rs.index_of[den])]
+		}
+	}
+
+	return nil
 }
 
 func (rs *RS) gfPow(a, n int) int {
-	result := 1
-	for n > 0 {
-		if n&1 == 1 {
-			result = rs.modnn(result * a)
+	if a == 0 {
+		if n == 0 {
+			return 1
 		}
-		a = rs.modnn(a * a)
-		n >>= 1
+		return 0
 	}
-	return result
+	return rs.alpha_to[rs.modnn(rs.index_of[a]*(n%rs.nn))]
 }
 
 func (rs *RS) MaxErrors() int {
@@ -207,9 +531,9 @@ func (rs *RS) MaxErrors() int {
 }
 
 func (rs *RS) TotalSize() int {
-	return rs.nn - rs.padding + 1
+	return rs.nn - rs.padding
 }
 
 func (rs *RS) DataSize() int {
-	return rs.nn - rs.padding + 1 - rs.nroots
+	return rs.nn - rs.padding - rs.nroots
 }
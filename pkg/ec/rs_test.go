@@ -0,0 +1,109 @@
+package ec
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGeneratorKnownCoefficients(t *testing.T) {
+	cases := []struct {
+		nroots int
+		want   []int
+	}{
+		{7, []int{21, 102, 238, 149, 146, 229, 87}},
+		{10, []int{45, 32, 94, 64, 70, 118, 61, 46, 67, 251}},
+	}
+
+	for _, c := range cases {
+		rs := NewRS(8, PresetFCR, PresetPrim, c.nroots)
+		gen := rs.Generator()
+		if len(gen) != c.nroots+1 || gen[c.nroots] != 1 {
+			t.Fatalf("nroots %d: generator %v is not monic of degree %d", c.nroots, gen, c.nroots)
+		}
+		for i, exp := range c.want {
+			if gen[i] != rs.alpha_to[exp] {
+				t.Errorf("nroots %d: coefficient of x^%d is %d, want alpha^%d = %d", c.nroots, i, gen[i], exp, rs.alpha_to[exp])
+			}
+		}
+	}
+}
+
+func TestEncodeKnownCodeword(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	got := NewRS(8, PresetFCR, PresetPrim, len(want)).Encode(data)
+	if !bytes.Equal(got[len(data):], want) {
+		t.Errorf("parity %v, want %v", got[len(data):], want)
+	}
+}
+
+func TestRS255_223Generator(t *testing.T) {
+	rs := NewRS255_223()
+	if rs.TotalSize() != 255 || rs.DataSize() != 223 {
+		t.Fatalf("RS(%d,%d), want RS(255,223)", rs.TotalSize(), rs.DataSize())
+	}
+
+	gen := rs.Generator()
+	if len(gen) != 33 || gen[32] != 1 {
+		t.Fatalf("generator %v is not monic of degree 32", gen)
+	}
+	for i := 0; i < 32; i++ {
+		root := rs.alpha_to[i]
+		v := 0
+		for j := len(gen) - 1; j >= 0; j-- {
+			v = rs.gfMul(v, root) ^ gen[j]
+		}
+		if v != 0 {
+			t.Errorf("alpha^%d is not a root of the generator", i)
+		}
+	}
+}
+
+func TestRS255_223CorrectsErrors(t *testing.T) {
+	rs := NewRS255_223()
+	data := make([]byte, rs.DataSize())
+	for i := range data {
+		data[i] = byte(i*7 + 3)
+	}
+	codeword := rs.Encode(data)
+
+	received := bytes.Clone(codeword)
+	for i := 0; i < rs.MaxErrors(); i++ {
+		received[i*15] ^= byte(i + 1)
+	}
+	decoded, err := rs.Decode(received, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, codeword) {
+		t.Error("decoded codeword differs from the original")
+	}
+}
+
+func TestEncodeInterleavedSplitsEvenly(t *testing.T) {
+	rs := NewRS255_223()
+	data := make([]byte, rs.DataSize()+1)
+	for i := range data {
+		data[i] = byte(i*11 + 5)
+	}
+	encoded := rs.EncodeInterleaved(data)
+
+	lengths := rs.CodewordLengths(len(encoded))
+	if len(lengths) != 2 || lengths[0]-lengths[1] > 1 || lengths[0] < lengths[1] {
+		t.Fatalf("codeword lengths %v, want two that differ by at most one", lengths)
+	}
+
+	erasures := make([]int, 0, 2*rs.nroots)
+	for i := 40; i < 40+2*rs.nroots; i++ {
+		encoded[i] = 0
+		erasures = append(erasures, i)
+	}
+	decoded, err := rs.DecodeInterleaved(encoded, erasures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, data) {
+		t.Error("decoded data differs from the original")
+	}
+}
@@ -0,0 +1,85 @@
+package ec
+
+import (
+	"encoding/binary"
+)
+
+func (rs *RS) EncodeWords(data []uint16) []uint16 {
+	symbols := make([]int, len(data))
+	for i, w := range data {
+		symbols[i] = int(w) & rs.nn
+	}
+
+	parity := rs.encodeSymbols(symbols)
+
+	result := make([]uint16, len(data)+rs.nroots)
+	copy(result, data)
+	for i, p := range parity {
+		result[len(data)+i] = uint16(p)
+	}
+
+	return result
+}
+
+func (rs *RS) DecodeWords(received []uint16, erasures []int) ([]uint16, error) {
+	if len(received) <= rs.nroots || len(received) > rs.TotalSize() {
+		return nil, ErrInvalidLength
+	}
+
+	symbols := make([]int, len(received))
+	for i, w := range received {
+		if int(w) > rs.nn {
+			return nil, ErrSymbolRange
+		}
+		symbols[i] = int(w)
+	}
+
+	if _, err := rs.decodeSymbols(symbols, erasures); err != nil {
+		return nil, err
+	}
+
+	result := make([]uint16, len(received))
+	for i, s := range symbols {
+		result[i] = uint16(s)
+	}
+
+	return result, nil
+}
+
+func BytesToWords(data []byte) []uint16 {
+	words := make([]uint16, (len(data)+1)/2)
+	for i := range words {
+		if 2*i+1 < len(data) {
+			words[i] = binary.BigEndian.Uint16(data[2*i:])
+		} else {
+			words[i] = uint16(data[2*i]) << 8
+		}
+	}
+	return words
+}
+
+func WordsToBytes(words []uint16, n int) []byte {
+	data := make([]byte, 0, len(words)*2)
+	for _, w := range words {
+		data = binary.BigEndian.AppendUint16(data, w)
+	}
+	if n >= 0 && n < len(data) {
+		data = data[:n]
+	}
+	return data
+}
+
+func NewWideRS(frameBytes, parityBytes int) (*RS, error) {
+	rs, err := NewRSPoly(16, DefaultGFPoly(16), PresetFCR, PresetPrim, (parityBytes+1)/2)
+	if err != nil {
+		return nil, err
+	}
+
+	n := (frameBytes+1)/2 + rs.nroots
+	if n > rs.nn {
+		return nil, ErrInvalidLength
+	}
+	rs.padding = rs.nn - n
+
+	return rs, nil
+}
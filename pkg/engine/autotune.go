@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"time"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/screen"
+)
+
+const (
+	AutoTuneWindow = 4 * time.Second
+	MaxCaptureFPS  = 30
+
+	autoTuneMinFrames  = 6
+	autoTuneGood       = 0.9
+	autoTunePoor       = 0.6
+	autoTuneStep       = 1.25
+	autoTuneBackoff    = 2
+	autoTuneOversample = 4
+)
+
+type RateTuner struct {
+	Min, Max float64
+
+	started   time.Time
+	attempts  float64
+	successes float64
+	backoff   int
+	ratio     float64
+}
+
+func (t *RateTuner) Observe(now time.Time, rate, attempts, successes float64) float64 {
+	if t.started.IsZero() {
+		t.started = now
+	}
+	t.attempts += attempts
+	t.successes += successes
+	if now.Sub(t.started) < AutoTuneWindow || t.attempts < autoTuneMinFrames {
+		return rate
+	}
+
+	t.ratio = min(t.successes/t.attempts, 1)
+	t.started, t.attempts, t.successes = now, 0, 0
+
+	next := rate
+	switch {
+	case t.ratio < autoTunePoor:
+		next = rate / autoTuneStep
+		t.backoff = autoTuneBackoff
+	case t.backoff > 0:
+		t.backoff--
+	case t.ratio >= autoTuneGood:
+		next = rate * autoTuneStep
+	}
+	if t.Min > 0 {
+		next = max(next, t.Min)
+	}
+	if t.Max > 0 {
+		next = min(next, t.Max)
+	}
+	return next
+}
+
+func (t *RateTuner) Ratio() float64 {
+	return t.ratio
+}
+
+func (t *RateTuner) Reset() {
+	*t = RateTuner{Min: t.Min, Max: t.Max}
+}
+
+type captureTuner struct {
+	started time.Time
+	start   screen.MetricsSnapshot
+	session uint64
+	first   uint32
+	last    uint32
+	caught  int
+}
+
+func (t *captureTuner) observe(now time.Time, results []FrameResult, perf screen.MetricsSnapshot) int {
+	fps := perf.TargetFPS
+	if fps <= 0 {
+		return fps
+	}
+	if t.started.IsZero() {
+		t.reset(now, perf)
+	}
+
+	for _, res := range results {
+		if !res.New || res.Metadata || chunk.IsParity(res.Chunk) {
+			continue
+		}
+		index := res.Chunk.Index
+		if res.Session != t.session || t.caught == 0 {
+			t.session, t.first, t.last, t.caught = res.Session, index, index, 0
+		}
+		t.first = min(t.first, index)
+		t.last = max(t.last, index)
+		t.caught++
+	}
+	if now.Sub(t.started) < AutoTuneWindow {
+		return fps
+	}
+
+	spanned := int(t.last-t.first) + 1
+	captures := perf.Frames - t.start.Frames
+	next := fps
+	switch {
+	case perf.Dropped > t.start.Dropped:
+		next = min(fps-1, fps*4/5)
+	case t.caught >= autoTuneMinFrames && float64(t.caught) < autoTuneGood*float64(spanned):
+		next = max(fps+1, fps*5/4)
+	case t.caught > 0 && captures > autoTuneOversample*t.caught:
+		next = min(fps-1, fps*4/5)
+	}
+	t.reset(now, perf)
+	if next == fps {
+		return fps
+	}
+	return min(max(next, 1), max(fps, MaxCaptureFPS))
+}
+
+func (t *captureTuner) reset(now time.Time, perf screen.MetricsSnapshot) {
+	t.started, t.start, t.caught = now, perf, 0
+}
@@ -0,0 +1,456 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/qr"
+	"qrtransfer/pkg/screen"
+)
+
+const (
+	calibrationIDBits   = 24
+	calibrationHold     = 1500 * time.Millisecond
+	calibrationRepeats  = 2
+	calibrationBurst    = 10
+	calibrationMaxFail  = 0.01
+	calibrationMinScore = 0.9
+	calibrationRateSide = 41
+)
+
+var (
+	CalibrationSides     = []int{27, 41, 57, 81, 113, 161, 225}
+	CalibrationIntervals = []time.Duration{
+		time.Second,
+		750 * time.Millisecond,
+		500 * time.Millisecond,
+		350 * time.Millisecond,
+		250 * time.Millisecond,
+		150 * time.Millisecond,
+		100 * time.Millisecond,
+	}
+
+	ErrNoCalibration = errors.New("no calibration frames were read")
+)
+
+type CalibrationFrame struct {
+	Rate  bool
+	Step  int
+	Count int
+}
+
+func CalibrationSequence(size image.Point) []CalibrationFrame {
+	var frames []CalibrationFrame
+	for step, side := range CalibrationSides {
+		if min(size.X, size.Y)/(side+2) < qr.DefaultMinBlockPixels {
+			break
+		}
+		for count := range calibrationRepeats {
+			frames = append(frames, CalibrationFrame{Step: step, Count: count})
+		}
+	}
+	for step := range CalibrationIntervals {
+		for count := range calibrationBurst {
+			frames = append(frames, CalibrationFrame{Rate: true, Step: step, Count: count})
+		}
+	}
+	return frames
+}
+
+func (f CalibrationFrame) Side() int {
+	if f.Rate {
+		return calibrationRateSide
+	}
+	return CalibrationSides[f.Step]
+}
+
+func (f CalibrationFrame) Hold() time.Duration {
+	if f.Rate {
+		return CalibrationIntervals[f.Step]
+	}
+	return calibrationHold
+}
+
+func (f CalibrationFrame) id() uint32 {
+	id := uint32(f.Step&0x7f)<<8 | uint32(f.Count&0xff)
+	if f.Rate {
+		id |= 1 << 15
+	}
+	return id<<8 | (id>>8^id)&0xff
+}
+
+func parseCalibrationID(id uint32) (CalibrationFrame, bool) {
+	v := id >> 8
+	if (v>>8^v)&0xff != id&0xff {
+		return CalibrationFrame{}, false
+	}
+
+	f := CalibrationFrame{Rate: v&(1<<15) != 0, Step: int(v>>8) & 0x7f, Count: int(v & 0xff)}
+	if f.Rate && f.Step >= len(CalibrationIntervals) || !f.Rate && f.Step >= len(CalibrationSides) {
+		return CalibrationFrame{}, false
+	}
+	return f, true
+}
+
+func (f CalibrationFrame) blocks() []qr.Block {
+	side := f.Side()
+	blocks := make([]qr.Block, side*side)
+	id := f.id()
+
+	for y := range side {
+		for x := range side {
+			var b qr.Block
+			switch {
+			case y == 0 || y == side-1:
+				b = timingBlock(x)
+			case x == side-1:
+				b = timingBlock(y)
+			case x == 0 && y <= calibrationIDBits:
+				b = bitBlock(id>>(calibrationIDBits-y)&1 == 1)
+			default:
+				b = calibrationColor(side, x, y)
+			}
+			blocks[y*side+x] = b
+		}
+	}
+	return blocks
+}
+
+func (f CalibrationFrame) Render(size image.Point) (image.Image, error) {
+	side := f.Side()
+	config := qr.Config{GridWidth: side, GridHeight: side, BorderSize: 1}
+	width, height := frameDimensions(config, size)
+	return qr.NewEncoder(config).CreateImage(f.blocks(), width, height)
+}
+
+func timingBlock(i int) qr.Block {
+	return bitBlock(i%2 == 0)
+}
+
+func bitBlock(dark bool) qr.Block {
+	if dark {
+		return qr.Block{}
+	}
+	return qr.Block{R: 255, G: 255, B: 255}
+}
+
+func calibrationColor(side, x, y int) qr.Block {
+	h := uint64(side)<<32 | uint64(y)<<16 | uint64(x)
+	h += 0x9e3779b97f4a7c15
+	h = (h ^ h>>30) * 0xbf58476d1ce4e5b9
+	h = (h ^ h>>27) * 0x94d049bb133111eb
+	h ^= h >> 31
+	return qr.Block{R: uint8(h), G: uint8(h >> 8), B: uint8(h >> 16)}
+}
+
+func PlayCalibration(ctx context.Context, surface Surface, progress func(done, total int)) error {
+	size := surface.Size()
+	frames := CalibrationSequence(size)
+	for i, f := range frames {
+		img, err := f.Render(size)
+		if err != nil {
+			return fmt.Errorf("calibration frame %d: %w", i, err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := surface.Show(img); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(i+1, len(frames))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(f.Hold()):
+		}
+	}
+	return nil
+}
+
+type sideSample struct {
+	frames int
+	blocks int
+	pixels float64
+	errors [256]int
+}
+
+type Calibrator struct {
+	mu     sync.Mutex
+	sides  map[int]*sideSample
+	rates  map[int]map[int]bool
+	frames int
+}
+
+func NewCalibrator() *Calibrator {
+	return &Calibrator{
+		sides: make(map[int]*sideSample),
+		rates: make(map[int]map[int]bool),
+	}
+}
+
+func (c *Calibrator) Process(img image.Image) []CalibrationFrame {
+	regions := screen.DetectQRRegions(img)
+	if len(regions) == 0 {
+		regions = []image.Rectangle{img.Bounds()}
+	}
+
+	var found []CalibrationFrame
+	for _, region := range regions {
+		if f, ok := c.measure(img, region); ok {
+			found = append(found, f)
+		}
+	}
+	return found
+}
+
+func (c *Calibrator) measure(img image.Image, region image.Rectangle) (CalibrationFrame, bool) {
+	code, ok := darkBounds(img, region)
+	if !ok {
+		return CalibrationFrame{}, false
+	}
+
+	side := 0
+	for _, s := range CalibrationSides {
+		if timingScore(img, code, s) >= calibrationMinScore {
+			side = s
+			break
+		}
+	}
+	if side == 0 {
+		return CalibrationFrame{}, false
+	}
+
+	var id uint32
+	for y := 1; y <= calibrationIDBits; y++ {
+		id <<= 1
+		if luminance(sampleBlock(img, code, side, 0, y)) < 128 {
+			id |= 1
+		}
+	}
+	f, ok := parseCalibrationID(id)
+	if !ok || f.Side() != side {
+		return CalibrationFrame{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.frames++
+
+	if f.Rate {
+		seen := c.rates[f.Step]
+		if seen == nil {
+			seen = make(map[int]bool)
+			c.rates[f.Step] = seen
+		}
+		seen[f.Count] = true
+		return f, true
+	}
+
+	s := c.sides[side]
+	if s == nil {
+		s = &sideSample{}
+		c.sides[side] = s
+	}
+	s.frames++
+	s.pixels += float64(code.Dx()) / float64(side)
+	for y := 1; y < side-1; y++ {
+		for x := 1; x < side-1; x++ {
+			want := calibrationColor(side, x, y)
+			got := sampleBlock(img, code, side, x, y)
+			s.errors[max(absDiff(got.R, want.R), absDiff(got.G, want.G), absDiff(got.B, want.B))]++
+			s.blocks++
+		}
+	}
+	return f, true
+}
+
+func darkBounds(img image.Image, region image.Rectangle) (image.Rectangle, bool) {
+	region = region.Intersect(img.Bounds())
+	box := image.Rectangle{Min: region.Max, Max: region.Min}
+	for y := region.Min.Y; y < region.Max.Y; y++ {
+		for x := region.Min.X; x < region.Max.X; x++ {
+			if luminance(pixelBlock(img, x, y)) >= 64 {
+				continue
+			}
+			box.Min.X, box.Min.Y = min(box.Min.X, x), min(box.Min.Y, y)
+			box.Max.X, box.Max.Y = max(box.Max.X, x+1), max(box.Max.Y, y+1)
+		}
+	}
+	return box, !box.Empty()
+}
+
+func timingScore(img image.Image, code image.Rectangle, side int) float64 {
+	if min(code.Dx(), code.Dy()) < side {
+		return 0
+	}
+
+	match := 0
+	for i := range side {
+		for _, p := range [][2]int{{i, 0}, {i, side - 1}, {side - 1, i}} {
+			dark := luminance(sampleBlock(img, code, side, p[0], p[1])) < 128
+			if dark == (i%2 == 0) {
+				match++
+			}
+		}
+	}
+	return float64(match) / float64(3*side)
+}
+
+func sampleBlock(img image.Image, code image.Rectangle, side, x, y int) qr.Block {
+	px := code.Min.X + (2*x+1)*code.Dx()/(2*side)
+	py := code.Min.Y + (2*y+1)*code.Dy()/(2*side)
+	return pixelBlock(img, px, py)
+}
+
+func pixelBlock(img image.Image, x, y int) qr.Block {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return qr.Block{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8)}
+}
+
+func luminance(b qr.Block) int {
+	return (299*int(b.R) + 587*int(b.G) + 114*int(b.B)) / 1000
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+type CalibrationSize struct {
+	Side        int
+	Frames      int
+	BlockPixels float64
+	MeanError   float64
+	FailRate    [3]float64
+}
+
+type CalibrationRate struct {
+	Interval time.Duration
+	Seen     int
+	Shown    int
+}
+
+type Calibration struct {
+	Frames int
+	Sizes  []CalibrationSize
+	Rates  []CalibrationRate
+
+	ErrorLevel qr.ErrorLevel
+	ChunkSize  int
+	Interval   time.Duration
+	BlockSize  int
+}
+
+func (c *Calibrator) Frames() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.frames
+}
+
+func (c *Calibrator) Result() (Calibration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := Calibration{Frames: c.frames}
+	best := -1
+	for _, side := range CalibrationSides {
+		s := c.sides[side]
+		if s == nil || s.blocks == 0 {
+			continue
+		}
+
+		size := CalibrationSize{Side: side, Frames: s.frames, BlockPixels: s.pixels / float64(s.frames)}
+		total := 0
+		for e, n := range s.errors {
+			total += e * n
+		}
+		size.MeanError = float64(total) / float64(s.blocks)
+
+		for level := qr.ErrorLevelLow; level <= qr.ErrorLevelHigh; level++ {
+			limit := 255 / ((1 << level.BitsPerChannel()) - 1) / 2
+			failed := 0
+			for e := limit + 1; e < len(s.errors); e++ {
+				failed += s.errors[e]
+			}
+			size.FailRate[level] = float64(failed) / float64(s.blocks)
+
+			capacity := level.Capacity(side * side)
+			if size.FailRate[level] <= calibrationMaxFail && capacity > chunk.Overhead && capacity >= best {
+				best = capacity
+				res.ErrorLevel = level
+				res.ChunkSize = capacity - chunk.Overhead
+				res.BlockSize = max(int(math.Round(size.BlockPixels)), 1)
+			}
+		}
+		res.Sizes = append(res.Sizes, size)
+	}
+
+	for step, interval := range CalibrationIntervals {
+		seen := len(c.rates[step])
+		if seen == 0 {
+			continue
+		}
+		res.Rates = append(res.Rates, CalibrationRate{Interval: interval, Seen: seen, Shown: calibrationBurst})
+		if seen == calibrationBurst {
+			res.Interval = interval
+		}
+	}
+
+	if best < 0 {
+		return res, ErrNoCalibration
+	}
+	return res, nil
+}
+
+func (c Calibration) String() string {
+	s := fmt.Sprintf("level=%s chunk=%d", c.ErrorLevel, c.ChunkSize)
+	if c.Interval > 0 {
+		s += " interval=" + c.Interval.String()
+	}
+	return s
+}
+
+func ParseCalibration(text string) (Calibration, error) {
+	var c Calibration
+	for _, field := range strings.Fields(text) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return c, fmt.Errorf("calibration field %q: want key=value", field)
+		}
+
+		var err error
+		switch key {
+		case "level":
+			c.ErrorLevel, err = qr.ParseErrorLevel(value)
+		case "chunk":
+			c.ChunkSize, err = strconv.Atoi(value)
+			if err == nil && c.ChunkSize <= 0 {
+				err = ErrChunkSize
+			}
+		case "interval":
+			c.Interval, err = time.ParseDuration(value)
+		default:
+			err = fmt.Errorf("unknown calibration setting %q", key)
+		}
+		if err != nil {
+			return c, err
+		}
+	}
+	if c.ChunkSize == 0 {
+		return c, errors.New("calibration has no chunk size")
+	}
+	return c, nil
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"qrtransfer/pkg/chunk"
+)
+
+var ErrInconsistent = errors.New("chunk contradicts the transfer's metadata")
+
+func (s *session) check(c chunk.Chunk) error {
+	m := s.metadata
+	if !s.described {
+		return nil
+	}
+
+	index := c.Index &^ chunk.ParityFlag
+	switch {
+	case c.Total != m.TotalChunks:
+		return fmt.Errorf("%w: chunk %d claims %d chunks, the metadata has %d", ErrInconsistent, index, c.Total, m.TotalChunks)
+	case chunk.IsParity(c) && m.ParityGroup == 0:
+		return fmt.Errorf("%w: parity chunk %d for a transfer sent without parity", ErrInconsistent, index)
+	case chunk.IsParity(c) && len(c.Data) != int(m.ChunkSize):
+		return fmt.Errorf("%w: parity chunk %d is %d bytes, chunks are %d", ErrInconsistent, index, len(c.Data), m.ChunkSize)
+	case !chunk.IsParity(c) && c.Index >= m.TotalChunks:
+		return fmt.Errorf("%w: chunk %d is past the last chunk %d", ErrInconsistent, index, m.TotalChunks-1)
+	case !chunk.IsParity(c) && len(c.Data) != m.ChunkLength(c.Index):
+		return fmt.Errorf("%w: chunk %d is %d bytes, the metadata expects %d", ErrInconsistent, index, len(c.Data), m.ChunkLength(c.Index))
+	}
+
+	if n := c.Timestamp - chunk.SessionID(c); n > uint64(m.Redundancy) {
+		return fmt.Errorf("%w: chunk %d is copy %d of a transfer sent %d times", ErrInconsistent, index, n+1, int(m.Redundancy)+1)
+	}
+	return nil
+}
+
+func (s *session) checkCopy(c chunk.Chunk) error {
+	sum := binary.BigEndian.Uint64(c.Checksum[:])
+	if prev, ok := s.sums[c.Index]; ok && prev != sum {
+		return fmt.Errorf("%w: chunk %d differs from the copy already received", ErrInconsistent, c.Index&^chunk.ParityFlag)
+	}
+	return nil
+}
+
+func (s *session) record(c chunk.Chunk) {
+	s.sums[c.Index] = binary.BigEndian.Uint64(c.Checksum[:])
+	if !s.described {
+		if s.totals == nil {
+			s.totals = make(map[uint32]uint32)
+		}
+		s.totals[c.Index] = c.Total
+	}
+}
+
+func (s *session) checkMetadata(metadata chunk.FileMetadata) error {
+	if id := chunk.SessionID(chunk.Chunk{Timestamp: metadata.Timestamp}); id != s.id {
+		return fmt.Errorf("%w: metadata names session %d, it arrived in session %d", ErrInconsistent, id, s.id)
+	}
+	if s.described && metadata != s.metadata {
+		return fmt.Errorf("%w: metadata for %q differs from the accepted metadata for %q", ErrInconsistent, metadata.Filename, s.metadata.Filename)
+	}
+	return nil
+}
+
+func (s *session) revalidate(wipe bool) int {
+	m := s.metadata
+	dropped := 0
+	drop := func(index uint32, reason string) {
+		slog.Warn("dropping chunk received before the metadata", "index", index&^chunk.ParityFlag, "reason", reason)
+		if s.received[index] {
+			s.bytes -= uint64(s.length(index))
+		}
+		if wipe {
+			clear(s.data[index])
+		}
+		delete(s.received, index)
+		delete(s.parity, index)
+		delete(s.data, index)
+		delete(s.copies, index)
+		delete(s.seen, index)
+		delete(s.sums, index)
+		dropped++
+	}
+	total := func(index uint32) bool {
+		t, ok := s.totals[index]
+		return !ok || t == m.TotalChunks
+	}
+
+	for index := range s.received {
+		switch {
+		case !total(index):
+			drop(index, "chunk count differs")
+		case index >= m.TotalChunks:
+			drop(index, "past the last chunk")
+		case s.length(index) != m.ChunkLength(index):
+			drop(index, "length differs")
+		}
+	}
+	for index := range s.parity {
+		switch {
+		case !total(index):
+			drop(index, "chunk count differs")
+		case m.ParityGroup == 0:
+			drop(index, "transfer has no parity")
+		case s.length(index) != int(m.ChunkSize):
+			drop(index, "length differs")
+		}
+	}
+	s.totals = nil
+	return dropped
+}
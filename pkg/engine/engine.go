@@ -0,0 +1,24 @@
+package engine
+
+import (
+	"errors"
+	"image"
+)
+
+var (
+	ErrNoPayload = errors.New("no file loaded")
+	ErrRunning   = errors.New("transfer already running")
+	ErrChunkSize = errors.New("chunk size must be a positive number of bytes")
+
+	ErrSizeMismatch = errors.New("assembled file does not match the transmitted size")
+)
+
+type Surface interface {
+	Size() image.Point
+	Show(img image.Image) error
+}
+
+type Source interface {
+	Capture() (image.Image, error)
+	Close() error
+}
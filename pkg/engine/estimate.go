@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"time"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/qr"
+)
+
+type Estimate struct {
+	Grid          int
+	FrameBytes    int
+	Capacity      int
+	Chunks        int
+	Frames        int
+	BitsPerSecond float64
+	Duration      time.Duration
+}
+
+func EstimateThroughput(size int64, config qr.Config, chunks chunk.Config, strategy chunk.Strategy, interval time.Duration) Estimate {
+	chunkSize := max(chunks.ChunkSize, 1)
+	redundancy := max(chunks.Redundancy, 1)
+
+	e := Estimate{
+		FrameBytes: chunk.SerializedSize(chunkSize),
+		Chunks:     int((max(size, 0) + int64(chunkSize) - 1) / int64(chunkSize)),
+	}
+	e.Grid = FrameSide(e.FrameBytes, config)
+	e.Capacity = FrameCapacity(e.Grid, config)
+
+	e.Frames = e.Chunks * redundancy
+	if strategy == chunk.StrategyParity {
+		rows := (e.Chunks + chunk.DefaultParityGroup - 1) / chunk.DefaultParityGroup
+		e.Frames = e.Chunks + rows
+		if redundancy >= 3 && rows > 1 {
+			e.Frames += min(chunk.DefaultParityGroup, e.Chunks)
+		}
+	}
+	e.Frames++
+	if !e.Intact() {
+		return e
+	}
+
+	e.Duration = time.Duration(e.Frames) * interval
+	if e.Duration > 0 {
+		e.BitsPerSecond = float64(size) * 8 / e.Duration.Seconds()
+	}
+	return e
+}
+
+func (e Estimate) BytesPerSecond() float64 {
+	return e.BitsPerSecond / 8
+}
+
+func (e Estimate) Intact() bool {
+	return e.FrameBytes <= e.Capacity
+}
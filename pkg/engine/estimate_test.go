@@ -0,0 +1,30 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/qr"
+)
+
+func TestEstimateUsesIntactCapacity(t *testing.T) {
+	chunks := chunk.NewConfig(100, 1)
+	for _, config := range []qr.Config{
+		{ErrorLevel: qr.ErrorLevelLow},
+		{Monochrome: true},
+		{Palette: true},
+	} {
+		e := EstimateThroughput(1000, config, chunks, chunk.StrategyImmediate, time.Second)
+		if !e.Intact() || e.Capacity < e.FrameBytes || e.BitsPerSecond <= 0 {
+			t.Errorf("%+v: capacity %d for %d byte frames at %.0f bits/s, want every frame intact", config, e.Capacity, e.FrameBytes, e.BitsPerSecond)
+		}
+	}
+
+	for _, level := range []qr.ErrorLevel{qr.ErrorLevelMedium, qr.ErrorLevelHigh} {
+		e := EstimateThroughput(1000, qr.Config{ErrorLevel: level}, chunks, chunk.StrategyImmediate, time.Second)
+		if e.Intact() || e.BitsPerSecond != 0 || e.Duration != 0 {
+			t.Errorf("%s: intact %v at %.0f bits/s over %v, want no throughput", level, e.Intact(), e.BitsPerSecond, e.Duration)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log/slog"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/secure"
+)
+
+const (
+	publicKeyMagic   = "OWLP"
+	handshakeMagic   = "OWLH"
+	handshakeVersion = 1
+
+	publicKeySize = 32
+)
+
+var (
+	ErrNotPublicKey = errors.New("not a receiver key code")
+	ErrNotHandshake = errors.New("not a key exchange frame")
+	ErrKeyConflict  = errors.New("use either a passphrase or a receiver key, not both")
+	ErrNoSessionKey = errors.New("no key for this transfer yet")
+)
+
+type Handshake struct {
+	Session   uint64
+	Sender    []byte
+	Recipient [secure.KeyIDSize]byte
+}
+
+func IsPublicKeyCode(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(publicKeyMagic))
+}
+
+func IsHandshake(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(handshakeMagic))
+}
+
+func PublicKeyCode(pub []byte) []byte {
+	buf := append([]byte(publicKeyMagic), handshakeVersion)
+	buf = append(buf, pub...)
+	return binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+}
+
+func ParsePublicKeyCode(data []byte) ([]byte, error) {
+	body, err := checkFrame(data, publicKeyMagic, publicKeySize, ErrNotPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(body), nil
+}
+
+func (h Handshake) MarshalBinary() ([]byte, error) {
+	if len(h.Sender) != publicKeySize {
+		return nil, fmt.Errorf("%w: sender key is %d bytes", ErrNotHandshake, len(h.Sender))
+	}
+	buf := append([]byte(handshakeMagic), handshakeVersion)
+	buf = binary.BigEndian.AppendUint64(buf, h.Session)
+	buf = append(buf, h.Sender...)
+	buf = append(buf, h.Recipient[:]...)
+	return binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf)), nil
+}
+
+func ParseHandshake(data []byte) (Handshake, error) {
+	var h Handshake
+	body, err := checkFrame(data, handshakeMagic, 8+publicKeySize+secure.KeyIDSize, ErrNotHandshake)
+	if err != nil {
+		return h, err
+	}
+	h.Session = binary.BigEndian.Uint64(body)
+	h.Sender = bytes.Clone(body[8 : 8+publicKeySize])
+	copy(h.Recipient[:], body[8+publicKeySize:])
+	return h, nil
+}
+
+func checkFrame(data []byte, magic string, size int, notFrame error) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(magic)) || len(data) < len(magic)+1+size+4 {
+		return nil, notFrame
+	}
+
+	body, sum := data[:len(magic)+1+size], data[len(magic)+1+size:]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(sum) {
+		return nil, fmt.Errorf("%w: checksum mismatch", notFrame)
+	}
+	if v := body[len(magic)]; v != handshakeVersion {
+		return nil, fmt.Errorf("unsupported key exchange version %d", v)
+	}
+	return body[len(magic)+1:], nil
+}
+
+func (r *Receiver) SetIdentity(id *secure.Identity) {
+	r.mu.Lock()
+	r.identity = id
+	r.handshakes = make(map[uint64]chunk.Keys)
+	r.mu.Unlock()
+	r.updateKeys()
+}
+
+func (r *Receiver) Identity() *secure.Identity {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.identity
+}
+
+func (r *Receiver) acceptHandshake(data []byte) {
+	h, err := ParseHandshake(data)
+	if err != nil {
+		slog.Debug("ignoring unreadable key exchange frame", "err", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.identity == nil || h.Recipient != r.identity.KeyID() {
+		slog.Debug("ignoring key exchange for another receiver", "session", h.Session)
+		return
+	}
+	if _, ok := r.handshakes[h.Session]; ok {
+		return
+	}
+
+	keys, err := r.identity.Keys(h.Sender)
+	if err != nil {
+		slog.Warn("rejected key exchange", "session", h.Session, "err", err)
+		return
+	}
+	slog.Info("key exchange received", "session", h.Session, "sender", secure.Fingerprint(h.Sender))
+	r.handshakes[h.Session] = keys
+}
+
+func (r *Receiver) keysFor(session uint64) chunk.Keys {
+	if keys, ok := r.handshakes[session]; ok {
+		return keys
+	}
+	return r.passphrase
+}
+
+func (r *Receiver) sessionKeys(session uint64) (cipher.AEAD, error) {
+	r.mu.Lock()
+	keys := r.keysFor(session)
+	r.mu.Unlock()
+
+	if keys == nil {
+		return nil, ErrNoSessionKey
+	}
+	return keys(session)
+}
@@ -0,0 +1,23 @@
+package engine
+
+import (
+	"bytes"
+	"testing"
+
+	"qrtransfer/pkg/secure"
+)
+
+func TestPublicKeyCodeRoundTrip(t *testing.T) {
+	id, err := secure.NewIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParsePublicKeyCode(renderAndDecode(t, PublicKeyCode(id.PublicKey()), BackchannelConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, id.PublicKey()) {
+		t.Errorf("parsed key %x, want %x", got, id.PublicKey())
+	}
+}
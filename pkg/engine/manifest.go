@@ -0,0 +1,386 @@
+package engine
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log/slog"
+	"slices"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/secure"
+)
+
+const (
+	manifestMagic   = "OWLM"
+	manifestVersion = 1
+	manifestLabel   = "owl-transfer manifest"
+	manifestSealed  = 1
+
+	ChunkHashSize = 8
+
+	manifestHeaderSize = len(manifestMagic) + 1 + 1 + 8 + 4 + 4 + 2
+	minManifestPart    = 64
+	signerSize         = 32
+	signatureSize      = 64
+)
+
+var (
+	ErrNotManifest       = errors.New("not a manifest frame")
+	ErrManifestSignature = errors.New("manifest signature is invalid")
+	ErrManifestMismatch  = errors.New("file does not match its signed manifest")
+	ErrManifestPending   = errors.New("signed manifest is not fully received yet")
+)
+
+type ManifestStatus int
+
+const (
+	ManifestNone ManifestStatus = iota
+	ManifestPending
+	ManifestSigned
+	ManifestVerified
+	ManifestInvalid
+)
+
+func (s ManifestStatus) String() string {
+	switch s {
+	case ManifestPending:
+		return "pending"
+	case ManifestSigned:
+		return "signed"
+	case ManifestVerified:
+		return "verified"
+	case ManifestInvalid:
+		return "invalid"
+	default:
+		return "unsigned"
+	}
+}
+
+type Verification struct {
+	Status  ManifestStatus
+	Signer  []byte
+	Trusted bool
+	Err     error
+}
+
+type Manifest struct {
+	Session     uint64
+	Filename    string
+	FileSize    uint64
+	ChunkSize   uint32
+	FileHash    [32]byte
+	ChunkHashes [][ChunkHashSize]byte
+	Signer      []byte
+	Signature   []byte
+}
+
+func IsManifest(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(manifestMagic))
+}
+
+func NewManifest(metadata chunk.FileMetadata, chunks [][]chunk.Chunk, signer *secure.Signer) Manifest {
+	m := Manifest{
+		Session:     chunk.SessionID(chunk.Chunk{Timestamp: metadata.Timestamp}),
+		Filename:    metadata.Filename,
+		FileSize:    metadata.FileSize,
+		ChunkSize:   metadata.ChunkSize,
+		ChunkHashes: make([][ChunkHashSize]byte, len(chunks)),
+		Signer:      signer.PublicKey(),
+	}
+
+	h := sha256.New()
+	for i, copies := range chunks {
+		h.Write(copies[0].Data)
+		m.ChunkHashes[i] = chunkHash(copies[0].Data)
+	}
+	h.Sum(m.FileHash[:0])
+
+	m.Signature = signer.Sign(m.signed())
+	return m
+}
+
+func chunkHash(data []byte) [ChunkHashSize]byte {
+	var hash [ChunkHashSize]byte
+	sum := sha256.Sum256(data)
+	copy(hash[:], sum[:])
+	return hash
+}
+
+func (m Manifest) body() []byte {
+	buf := []byte{manifestVersion}
+	buf = binary.BigEndian.AppendUint64(buf, m.Session)
+	buf = binary.BigEndian.AppendUint64(buf, m.FileSize)
+	buf = binary.BigEndian.AppendUint32(buf, m.ChunkSize)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(m.ChunkHashes)))
+	buf = append(buf, m.FileHash[:]...)
+	buf = append(buf, m.Signer...)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(m.Filename)))
+	buf = append(buf, m.Filename...)
+	for _, hash := range m.ChunkHashes {
+		buf = append(buf, hash[:]...)
+	}
+	return buf
+}
+
+func (m Manifest) signed() []byte {
+	return append([]byte(manifestLabel), m.body()...)
+}
+
+func (m Manifest) MarshalBinary() ([]byte, error) {
+	if len(m.Signer) != signerSize || len(m.Signature) != signatureSize {
+		return nil, errors.New("manifest is not signed")
+	}
+	if len(m.Filename) > 0xffff {
+		return nil, fmt.Errorf("manifest filename is %d bytes", len(m.Filename))
+	}
+	return append(m.body(), m.Signature...), nil
+}
+
+func ParseManifest(data []byte) (Manifest, error) {
+	var m Manifest
+	fixed := 1 + 8 + 8 + 4 + 4 + 32 + signerSize + 2
+	if len(data) < fixed+signatureSize {
+		return m, fmt.Errorf("%w: manifest is %d bytes", ErrNotManifest, len(data))
+	}
+	if data[0] != manifestVersion {
+		return m, fmt.Errorf("unsupported manifest version %d", data[0])
+	}
+
+	m.Session = binary.BigEndian.Uint64(data[1:])
+	m.FileSize = binary.BigEndian.Uint64(data[9:])
+	m.ChunkSize = binary.BigEndian.Uint32(data[17:])
+	count := int(binary.BigEndian.Uint32(data[21:]))
+	copy(m.FileHash[:], data[25:57])
+	m.Signer = bytes.Clone(data[57 : 57+signerSize])
+	nameLen := int(binary.BigEndian.Uint16(data[fixed-2:]))
+
+	if len(data) != fixed+nameLen+count*ChunkHashSize+signatureSize {
+		return m, fmt.Errorf("%w: manifest length does not match its contents", ErrNotManifest)
+	}
+	rest := data[fixed:]
+	m.Filename = string(rest[:nameLen])
+	rest = rest[nameLen:]
+	m.ChunkHashes = make([][ChunkHashSize]byte, count)
+	for i := range m.ChunkHashes {
+		copy(m.ChunkHashes[i][:], rest[i*ChunkHashSize:])
+	}
+	m.Signature = bytes.Clone(rest[count*ChunkHashSize:])
+
+	if !secure.Verify(m.Signer, m.signed(), m.Signature) {
+		return m, ErrManifestSignature
+	}
+	return m, nil
+}
+
+func (m Manifest) Check(metadata chunk.FileMetadata) error {
+	switch {
+	case m.Filename != metadata.Filename:
+		return fmt.Errorf("%w: signed for %q, received %q", ErrManifestMismatch, m.Filename, metadata.Filename)
+	case m.FileSize != metadata.FileSize:
+		return fmt.Errorf("%w: signed for %d bytes, received %d", ErrManifestMismatch, m.FileSize, metadata.FileSize)
+	case m.ChunkSize != metadata.ChunkSize || len(m.ChunkHashes) != int(metadata.TotalChunks):
+		return fmt.Errorf("%w: signed for %d chunks of %d bytes", ErrManifestMismatch, len(m.ChunkHashes), m.ChunkSize)
+	}
+	return nil
+}
+
+func manifestNonce(aead cipher.AEAD) []byte {
+	n := make([]byte, aead.NonceSize())
+	copy(n[len(n)-len(manifestMagic):], manifestMagic)
+	return n
+}
+
+func manifestAD(session uint64) []byte {
+	return binary.BigEndian.AppendUint64([]byte(manifestMagic), session)
+}
+
+func manifestFrames(m Manifest, keys chunk.Keys, partSize int) ([][]byte, error) {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	var flags byte
+	if keys != nil {
+		aead, err := keys(m.Session)
+		if err != nil {
+			return nil, err
+		}
+		data = aead.Seal(nil, manifestNonce(aead), data, manifestAD(m.Session))
+		flags |= manifestSealed
+	}
+
+	partSize = max(partSize, minManifestPart)
+	parts := (len(data) + partSize - 1) / partSize
+	frames := make([][]byte, 0, parts)
+	for i := range parts {
+		part := data[i*partSize : min((i+1)*partSize, len(data))]
+		buf := append([]byte(manifestMagic), manifestVersion, flags)
+		buf = binary.BigEndian.AppendUint64(buf, m.Session)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(i))
+		buf = binary.BigEndian.AppendUint32(buf, uint32(parts))
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(part)))
+		buf = append(buf, part...)
+		frames = append(frames, binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf)))
+	}
+	return frames, nil
+}
+
+type manifestPart struct {
+	session uint64
+	index   uint32
+	count   uint32
+	sealed  bool
+	data    []byte
+}
+
+func parseManifestPart(data []byte) (manifestPart, error) {
+	var p manifestPart
+	if !IsManifest(data) || len(data) < manifestHeaderSize+4 {
+		return p, ErrNotManifest
+	}
+
+	size := int(binary.BigEndian.Uint16(data[manifestHeaderSize-2:]))
+	if len(data) < manifestHeaderSize+size+4 {
+		return p, fmt.Errorf("%w: truncated", ErrNotManifest)
+	}
+	body := data[:manifestHeaderSize+size]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(data[len(body):]) {
+		return p, fmt.Errorf("%w: checksum mismatch", ErrNotManifest)
+	}
+	if v := body[len(manifestMagic)]; v != manifestVersion {
+		return p, fmt.Errorf("unsupported manifest version %d", v)
+	}
+
+	p.sealed = body[len(manifestMagic)+1]&manifestSealed != 0
+	p.session = binary.BigEndian.Uint64(body[len(manifestMagic)+2:])
+	p.index = binary.BigEndian.Uint32(body[len(manifestMagic)+10:])
+	p.count = binary.BigEndian.Uint32(body[len(manifestMagic)+14:])
+	p.data = bytes.Clone(body[manifestHeaderSize:])
+	if p.count == 0 || p.index >= p.count {
+		return p, fmt.Errorf("%w: part %d of %d", ErrNotManifest, p.index, p.count)
+	}
+	return p, nil
+}
+
+func (r *Receiver) acceptManifest(data []byte) {
+	p, err := parseManifestPart(data)
+	if err != nil {
+		slog.Debug("ignoring unreadable manifest frame", "err", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.discarded[p.session] {
+		return
+	}
+	s := r.session(p.session)
+	if s.manifest != nil || s.manifestErr != nil {
+		return
+	}
+	if s.manifestParts == nil || s.manifestCount != p.count {
+		s.manifestParts = make(map[uint32][]byte, p.count)
+		s.manifestCount = p.count
+	}
+	s.manifestParts[p.index] = p.data
+	if len(s.manifestParts) < int(p.count) {
+		return
+	}
+
+	var buf []byte
+	for i := range p.count {
+		buf = append(buf, s.manifestParts[i]...)
+	}
+	if p.sealed {
+		keys := r.keysFor(p.session)
+		if keys == nil {
+			slog.Debug("manifest is encrypted and no key is set yet", "session", p.session)
+			return
+		}
+		aead, err := keys(p.session)
+		if err == nil {
+			buf, err = aead.Open(nil, manifestNonce(aead), buf, manifestAD(p.session))
+		}
+		if err != nil {
+			slog.Debug("manifest failed to decrypt", "session", p.session, "err", err)
+			return
+		}
+	}
+
+	m, err := ParseManifest(buf)
+	if err == nil && m.Session != p.session {
+		err = fmt.Errorf("%w: signed for session %d", ErrManifestMismatch, m.Session)
+	}
+	s.manifestParts = nil
+	r.dirty = true
+	if err != nil {
+		slog.Warn("manifest rejected", "session", p.session, "err", err)
+		s.manifestErr = err
+		return
+	}
+	slog.Info("manifest received", "session", p.session, "file", m.Filename, "signer", secure.Fingerprint(m.Signer))
+	s.manifest = &m
+}
+
+func (r *Receiver) Verification() Verification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v := r.cur.verification()
+	v.Trusted = v.Signer != nil && r.trusts(v.Signer)
+	return v
+}
+
+func (r *Receiver) SetTrustedSigners(keys [][]byte) {
+	r.mu.Lock()
+	r.trusted = slices.Clone(keys)
+	r.mu.Unlock()
+}
+
+func (r *Receiver) TrustedSigners() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.trusted)
+}
+
+func (r *Receiver) trusts(signer []byte) bool {
+	return slices.ContainsFunc(r.trusted, func(key []byte) bool {
+		return bytes.Equal(key, signer)
+	})
+}
+
+func (s *session) verification() Verification {
+	switch {
+	case s.manifestErr != nil:
+		return Verification{Status: ManifestInvalid, Err: s.manifestErr}
+	case s.manifest == nil && len(s.manifestParts) > 0:
+		return Verification{Status: ManifestPending}
+	case s.manifest == nil:
+		return Verification{Status: ManifestNone}
+	}
+
+	v := Verification{Status: ManifestSigned, Signer: s.manifest.Signer}
+	if s.described {
+		if err := s.manifest.Check(s.metadata); err != nil {
+			v.Status, v.Err = ManifestInvalid, err
+			return v
+		}
+	}
+	if s.verified {
+		v.Status = ManifestVerified
+	}
+	return v
+}
+
+func (s *session) checkChunk(index uint32, data []byte) bool {
+	if s.manifest == nil || int(index) >= len(s.manifest.ChunkHashes) {
+		return true
+	}
+	return chunkHash(data) == s.manifest.ChunkHashes[index]
+}
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"time"
+
+	"qrtransfer/pkg/metrics"
+	"qrtransfer/pkg/screen"
+)
+
+func (s ReceiveStats) Throughput(now time.Time) float64 {
+	elapsed := now.Sub(s.Started).Seconds()
+	if s.Started.IsZero() || elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / elapsed
+}
+
+func (r *Receiver) Metrics(perf screen.MetricsSnapshot) []metrics.Sample {
+	stats := r.Stats()
+	expected := r.Metadata().TotalChunks
+
+	return []metrics.Sample{
+		{Name: "owl_receiver_frames_captured_total", Help: "Frames captured from the source.", Kind: metrics.Counter, Value: float64(perf.Frames)},
+		{Name: "owl_receiver_frames_dropped_total", Help: "Frames dropped because decoding fell behind.", Kind: metrics.Counter, Value: float64(perf.Dropped)},
+		{Name: "owl_receiver_frames_decoded_total", Help: "QR codes decoded from captured frames.", Kind: metrics.Counter, Value: float64(stats.Frames)},
+		{Name: "owl_receiver_regions_failed_total", Help: "Detected code regions that could not be decoded.", Kind: metrics.Counter, Value: float64(stats.DecodeFailures)},
+		{Name: "owl_receiver_blocks_read_total", Help: "Reed-Solomon blocks read.", Kind: metrics.Counter, Value: float64(stats.Blocks.BlocksRead)},
+		{Name: "owl_receiver_blocks_corrected_total", Help: "Reed-Solomon blocks that needed correction.", Kind: metrics.Counter, Value: float64(stats.Blocks.BlocksCorrected)},
+		{Name: "owl_receiver_blocks_uncorrectable_total", Help: "Reed-Solomon blocks that could not be corrected.", Kind: metrics.Counter, Value: float64(stats.Blocks.BlocksUncorrectable)},
+		{Name: "owl_receiver_header_failures_total", Help: "Chunks rejected by the header CRC.", Kind: metrics.Counter, Value: float64(stats.HeaderFailures)},
+		{Name: "owl_receiver_checksum_failures_total", Help: "Chunks rejected by the payload checksum.", Kind: metrics.Counter, Value: float64(stats.ChecksumFails)},
+		{Name: "owl_receiver_chunks_received_total", Help: "Verified data chunks received, including repeats.", Kind: metrics.Counter, Value: float64(stats.Chunks)},
+		{Name: "owl_receiver_chunks_duplicate_total", Help: "Verified data chunks that were already held.", Kind: metrics.Counter, Value: float64(stats.Duplicates)},
+		{Name: "owl_receiver_chunks_unique", Help: "Distinct data chunks held for assembly.", Kind: metrics.Gauge, Value: float64(stats.Unique)},
+		{Name: "owl_receiver_chunks_expected", Help: "Data chunks in the current file, 0 until metadata arrives.", Kind: metrics.Gauge, Value: float64(expected)},
+		{Name: "owl_receiver_bytes_assembled", Help: "Payload bytes held for assembly.", Kind: metrics.Gauge, Value: float64(stats.Bytes)},
+		{Name: "owl_receiver_throughput_bytes_per_second", Help: "Payload bytes received per second since the first chunk.", Kind: metrics.Gauge, Value: stats.Throughput(time.Now())},
+		{Name: "owl_receiver_capture_fps", Help: "Current capture rate.", Kind: metrics.Gauge, Value: perf.FPS},
+	}
+}
@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand/v2"
+	"testing"
+
+	"qrtransfer/pkg/qr"
+	"qrtransfer/pkg/screen"
+)
+
+func capture(img image.Image, size image.Point, offset image.Point) image.Image {
+	shot := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(shot, shot.Bounds(), &image.Uniform{color.RGBA{235, 235, 230, 255}}, image.Point{}, draw.Src)
+	draw.Draw(shot, img.Bounds().Add(offset), img, img.Bounds().Min, draw.Src)
+	return shot
+}
+
+func TestMonoFrameLoopback(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	renderer := NewRenderer(qr.Config{Monochrome: true})
+
+	for _, n := range []int{20, 57, 133, 250} {
+		for _, size := range []int{300, 396, 500, 720} {
+			data := make([]byte, n)
+			for i := range data {
+				data[i] = byte(rng.IntN(256))
+			}
+			for _, marker := range []bool{false, true} {
+				renderer.Marker = marker
+				img, err := renderer.Render(data, image.Pt(size, size), "")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				shot := capture(img, image.Pt(size+160, size+120), image.Pt(80, 50))
+				results := screen.DecodeRegionsTuned(shot, DefaultBlockSize, screen.DecodeTuning{EInk: true})
+				if len(results) != 1 || results[0].Err != nil {
+					t.Fatalf("%d bytes at %dpx: decoded %d regions, want one", n, size, len(results))
+				}
+				got := results[0]
+				if len(got.Data) < n || !bytes.Equal(got.Data[:n], data) {
+					t.Errorf("%d bytes at %dpx: decoded data differs", n, size)
+				}
+				if got.Marker != marker {
+					t.Errorf("%d bytes at %dpx: marker %v, want %v", n, size, got.Marker, marker)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"path/filepath"
+	"strings"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/screen"
+)
+
+var (
+	ErrCodeHeader   = errors.New("chunk header unreadable")
+	ErrCodeChecksum = errors.New("chunk checksum mismatch")
+	ErrCodeLocked   = errors.New("chunk is encrypted, passphrase needed")
+	ErrCodeAuth     = errors.New("chunk failed authentication")
+	ErrCodeUnpaired = errors.New("chunk is from a sender without the pairing token")
+
+	ErrCodeInconsistent = errors.New("chunk contradicts the transfer's metadata")
+)
+
+type PageCode struct {
+	Row, Column int
+	Region      image.Rectangle
+	Result      FrameResult
+	Err         error
+}
+
+type PageResult struct {
+	Path  string
+	Codes []PageCode
+	Err   error
+}
+
+func (p PageResult) Decoded() int {
+	n := 0
+	for _, c := range p.Codes {
+		if c.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func (p PageResult) Results() []FrameResult {
+	var results []FrameResult
+	for _, c := range p.Codes {
+		if c.Err == nil || c.Err == ErrCodeHeader || c.Err == ErrCodeChecksum || c.Err == ErrCodeLocked || c.Err == ErrCodeAuth || c.Err == ErrCodeUnpaired || c.Err == ErrCodeInconsistent {
+			results = append(results, c.Result)
+		}
+	}
+	return results
+}
+
+func (p PageResult) Failures() []string {
+	var failures []string
+	for _, c := range p.Codes {
+		if c.Err == nil {
+			continue
+		}
+		what := c.Err.Error()
+		switch c.Err {
+		case ErrCodeChecksum:
+			what = fmt.Sprintf("%v (chunk %d)", c.Err, c.Result.Chunk.Index)
+		case ErrCodeInconsistent:
+			what = c.Result.Inconsistent.Error()
+		}
+		failures = append(failures, fmt.Sprintf("row %d, column %d: %s", c.Row, c.Column, what))
+	}
+	return failures
+}
+
+func (r *Receiver) DecodePages(ctx context.Context, paths []string, fn func(PageResult)) []PageResult {
+	tuning := r.Tuning()
+	tuning.Projector = true
+
+	pages := make([]PageResult, 0, len(paths))
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+
+		page := PageResult{Path: path}
+		if img, err := screen.LoadImage(path); err != nil {
+			page.Err = err
+		} else {
+			page.Codes = r.decodePage(screen.DecodeRegionsTuned(img, r.BlockSize, tuning))
+		}
+
+		pages = append(pages, page)
+		if fn != nil {
+			fn(page)
+		}
+	}
+	return pages
+}
+
+func (r *Receiver) decodePage(regions []screen.RegionResult) []PageCode {
+	codes := make([]PageCode, 0, len(regions))
+	failures := 0
+	row, rowBottom, column := 0, 0, 0
+	for _, region := range regions {
+		if region.Err == nil && IsHandshake(region.Data) {
+			r.acceptHandshake(region.Data)
+			continue
+		}
+		if region.Err == nil && IsManifest(region.Data) {
+			r.acceptManifest(region.Data)
+			continue
+		}
+		if region.Region.Min.Y >= rowBottom {
+			row, column = row+1, 0
+		}
+		rowBottom = max(rowBottom, region.Region.Max.Y)
+		column++
+
+		code := PageCode{Row: row, Column: column, Region: region.Region, Err: region.Err}
+		if region.Err != nil {
+			failures++
+			codes = append(codes, code)
+			continue
+		}
+
+		code.Result = r.ProcessPayload(region.Data, region.Stats)
+		switch {
+		case code.Result.Header != HeaderOK:
+			code.Err = ErrCodeHeader
+		case code.Result.Auth == AuthLocked:
+			code.Err = ErrCodeLocked
+		case code.Result.Auth == AuthFailed:
+			code.Err = ErrCodeAuth
+		case code.Result.Auth == AuthUnpaired:
+			code.Err = ErrCodeUnpaired
+		case !code.Result.ChecksumOK:
+			code.Err = ErrCodeChecksum
+		case code.Result.Inconsistent != nil && !code.Result.Metadata:
+			code.Err = ErrCodeInconsistent
+		}
+		codes = append(codes, code)
+	}
+
+	r.mu.Lock()
+	r.stats.Captures++
+	r.stats.Regions += len(regions)
+	r.stats.DecodeFailures += failures
+	r.mu.Unlock()
+	return codes
+}
+
+func FormatPages(pages []PageResult, missing []uint32) string {
+	var b strings.Builder
+	for _, p := range pages {
+		name := filepath.Base(p.Path)
+		if p.Err != nil {
+			fmt.Fprintf(&b, "%s: not read: %v\n", name, p.Err)
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s: %d of %d codes read\n", name, p.Decoded(), len(p.Codes))
+		for _, f := range p.Failures() {
+			fmt.Fprintf(&b, "  %s\n", f)
+		}
+	}
+
+	if len(missing) > 0 {
+		fmt.Fprintf(&b, "Missing chunks: %s\n", chunk.FormatIndices(missing))
+	}
+	return b.String()
+}
@@ -0,0 +1,29 @@
+package engine
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+
+	"qrtransfer/pkg/secure"
+)
+
+func pairedTimestamp(p *secure.Pairing) (uint64, error) {
+	var n [2]byte
+	if _, err := rand.Read(n[:]); err != nil {
+		return 0, err
+	}
+	return p.Session(binary.BigEndian.Uint16(n[:])), nil
+}
+
+func (r *Receiver) SetPairing(p *secure.Pairing) {
+	r.mu.Lock()
+	r.pairing = p
+	r.mu.Unlock()
+	r.updateKeys()
+}
+
+func (r *Receiver) Pairing() *secure.Pairing {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pairing
+}
@@ -0,0 +1,229 @@
+package engine
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/secure"
+)
+
+type Options struct {
+	ChunkSize  int
+	Redundancy int
+	Strategy   chunk.Strategy
+	Keys       chunk.Keys
+	Recipient  []byte
+	Signer     *secure.Signer
+	Pairing    *secure.Pairing
+}
+
+type Payload struct {
+	Metadata chunk.FileMetadata
+	Chunks   [][]chunk.Chunk
+	Frames   []chunk.Chunk
+	Options  Options
+	Hash     [32]byte
+
+	proc     *chunk.Processor
+	control  [][]byte
+	captions []string
+}
+
+func Prepare(r io.Reader, size int64, name, contentType string, opts Options) (*Payload, error) {
+	if opts.ChunkSize <= 0 {
+		return nil, ErrChunkSize
+	}
+	opts.Redundancy = max(opts.Redundancy, 1)
+
+	metadata := chunk.FileMetadata{
+		Filename:    name,
+		FileSize:    uint64(size),
+		ChunkSize:   uint32(opts.ChunkSize),
+		TotalChunks: uint32((size + int64(opts.ChunkSize) - 1) / int64(opts.ChunkSize)),
+		Timestamp:   chunk.SessionTimestamp(time.Now()),
+		Redundancy:  uint8(opts.Redundancy - 1),
+		ContentType: contentType,
+	}
+
+	if opts.Pairing != nil {
+		var err error
+		if metadata.Timestamp, err = pairedTimestamp(opts.Pairing); err != nil {
+			return nil, err
+		}
+	}
+
+	copies := metadata.Redundancy
+	if opts.Strategy == chunk.StrategyParity {
+		metadata.ParityGroup = chunk.DefaultParityGroup
+		copies = 0
+	}
+
+	var control [][]byte
+	var captions []string
+	if opts.Recipient != nil {
+		if opts.Keys != nil {
+			return nil, ErrKeyConflict
+		}
+		keys, sender, err := secure.NewRecipient(opts.Recipient)
+		if err != nil {
+			return nil, err
+		}
+		h := Handshake{Session: chunk.SessionID(chunk.Chunk{Timestamp: metadata.Timestamp}), Sender: sender, Recipient: secure.KeyID(opts.Recipient)}
+		handshake, err := h.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		control = append(control, handshake)
+		captions = append(captions, "key exchange - "+name)
+		opts.Keys = keys
+	}
+
+	proc := chunk.NewProcessor(chunk.NewConfig(opts.ChunkSize, opts.Redundancy))
+	proc.SetKeys(opts.Keys)
+	if opts.Pairing != nil {
+		proc.SetMAC(opts.Pairing.MACKey())
+	}
+	hash := sha256.New()
+	chunks, err := proc.CreateChunks(io.TeeReader(r, hash), metadata, copies)
+	if err != nil {
+		return nil, err
+	}
+	metadata.Checksum = [32]byte(hash.Sum(nil))
+
+	if opts.Signer != nil {
+		frames, err := manifestFrames(NewManifest(metadata, chunks, opts.Signer), opts.Keys, opts.ChunkSize)
+		if err != nil {
+			return nil, err
+		}
+		for i, frame := range frames {
+			control = append(control, frame)
+			captions = append(captions, fmt.Sprintf("manifest %d/%d - %s", i+1, len(frames), name))
+		}
+	}
+
+	return &Payload{
+		Metadata: metadata,
+		Chunks:   chunks,
+		Frames:   chunk.Schedule(chunks, opts.Strategy, metadata),
+		Options:  opts,
+		Hash:     metadata.Checksum,
+		proc:     proc,
+		control:  control,
+		captions: captions,
+	}, nil
+}
+
+func PrepareFile(path, name string, opts Options) (*Payload, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = filepath.Base(path)
+	}
+	return Prepare(file, info.Size(), name, "", opts)
+}
+
+func (p *Payload) Session() uint64 {
+	return chunk.SessionID(chunk.Chunk{Timestamp: p.Metadata.Timestamp})
+}
+
+func (p *Payload) Encrypted() bool {
+	return p.proc.Encrypted()
+}
+
+func (p *Payload) Signed() bool {
+	return p.Options.Signer != nil
+}
+
+func (p *Payload) FrameCount() int {
+	return len(p.control) + len(p.Frames) + 1
+}
+
+func (p *Payload) IsControl(i int) bool {
+	return i >= 0 && i < len(p.control)
+}
+
+func (p *Payload) IsMetadata(i int) bool {
+	return i == len(p.control)
+}
+
+func (p *Payload) ControlCaption(i int) string {
+	if !p.IsControl(i) {
+		return ""
+	}
+	return p.captions[i]
+}
+
+func (p *Payload) MetadataChunk() (chunk.Chunk, error) {
+	data, err := p.proc.SerializeMetadata(p.Metadata)
+	if err != nil {
+		return chunk.Chunk{}, err
+	}
+
+	return chunk.Chunk{
+		Index:     0,
+		Total:     p.Metadata.TotalChunks + 1,
+		Data:      data,
+		Checksum:  sha256.Sum256(data),
+		Timestamp: p.Metadata.Timestamp,
+	}, nil
+}
+
+func (p *Payload) Frame(i int) (chunk.Chunk, error) {
+	if i < 0 || i >= p.FrameCount() {
+		return chunk.Chunk{}, fmt.Errorf("frame %d out of range (0-%d)", i, p.FrameCount()-1)
+	}
+	if p.IsControl(i) {
+		return chunk.Chunk{}, fmt.Errorf("frame %d is a control frame, not a chunk", i)
+	}
+	if p.IsMetadata(i) {
+		return p.MetadataChunk()
+	}
+	return p.Frames[i-1-len(p.control)], nil
+}
+
+func (p *Payload) FrameData(i int) ([]byte, error) {
+	if p.IsControl(i) {
+		return bytes.Clone(p.control[i]), nil
+	}
+	c, err := p.Frame(i)
+	if err != nil {
+		return nil, err
+	}
+	return p.proc.SerializeChunk(c)
+}
+
+func (p *Payload) FrameOf(index uint32) int {
+	for i, f := range p.Frames {
+		if !chunk.IsParity(f) && f.Index == index {
+			return i + 1 + len(p.control)
+		}
+	}
+	return -1
+}
+
+func (p *Payload) Caption(c chunk.Chunk, metadata bool) string {
+	name := p.Metadata.Filename
+	if metadata {
+		return "metadata - " + name
+	}
+
+	if chunk.IsParity(c) {
+		return "parity - " + name
+	}
+	return fmt.Sprintf("chunk %d/%d - %s", c.Index+1, p.Metadata.TotalChunks, name)
+}
@@ -0,0 +1,18 @@
+package engine
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestPrepareSetsChecksum(t *testing.T) {
+	data := bytes.Repeat([]byte("owl"), 100)
+	payload, err := Prepare(bytes.NewReader(data), int64(len(data)), "owl.txt", "", Options{ChunkSize: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Metadata.Checksum != sha256.Sum256(data) {
+		t.Error("metadata checksum is not the file's SHA-256")
+	}
+}
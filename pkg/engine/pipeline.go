@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"qrtransfer/pkg/screen"
+)
+
+const maxDecoders = 4
+
+type decodedFrame struct {
+	frame   screen.Frame
+	regions []screen.RegionResult
+}
+
+func (r *Receiver) decoders() int {
+	if r.Decoders > 0 {
+		return r.Decoders
+	}
+	return min(runtime.GOMAXPROCS(0), maxDecoders)
+}
+
+func (r *Receiver) decode(frames <-chan screen.Frame, metrics *screen.Metrics) <-chan decodedFrame {
+	workers := r.decoders()
+	decoded := make(chan decodedFrame, workers)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range frames {
+				d := decodedFrame{frame: f}
+				if f.Err == nil {
+					start := time.Now()
+					d.regions = screen.DecodeRegionsTuned(f.Image, r.BlockSize, r.Tuning())
+					metrics.RecordDecode(time.Since(start))
+				}
+				decoded <- d
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(decoded)
+	}()
+	return decoded
+}
@@ -0,0 +1,197 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"qrtransfer/pkg/chunk"
+)
+
+var ErrPolicy = errors.New("transfer refused by the receive policy")
+
+var executableExtensions = map[string]bool{
+	".app": true, ".apk": true, ".appimage": true, ".bash": true, ".bat": true, ".bin": true,
+	".cmd": true, ".com": true, ".command": true, ".cpl": true, ".csh": true, ".deb": true,
+	".dll": true, ".dmg": true, ".dylib": true, ".elf": true, ".exe": true, ".hta": true,
+	".jar": true, ".js": true, ".jse": true, ".ksh": true, ".lnk": true, ".msi": true,
+	".msp": true, ".pif": true, ".pkg": true, ".pl": true, ".ps1": true, ".psm1": true,
+	".py": true, ".rb": true, ".reg": true, ".rpm": true, ".run": true, ".scr": true,
+	".sh": true, ".so": true, ".sys": true, ".vbe": true, ".vbs": true, ".wsf": true,
+	".wsh": true, ".zsh": true,
+}
+
+var executableTypes = map[string]bool{
+	"application/java-archive":                      true,
+	"application/vnd.android.package-archive":       true,
+	"application/vnd.microsoft.portable-executable": true,
+	"application/x-dosexec":                         true,
+	"application/x-elf":                             true,
+	"application/x-executable":                      true,
+	"application/x-mach-binary":                     true,
+	"application/x-msdos-program":                   true,
+	"application/x-msdownload":                      true,
+	"application/x-msi":                             true,
+	"application/x-sh":                              true,
+	"application/x-sharedlib":                       true,
+	"application/x-shellscript":                     true,
+	"text/x-shellscript":                            true,
+}
+
+var executableMagic = [][]byte{
+	[]byte("MZ"),
+	[]byte("\x7fELF"),
+	[]byte("#!"),
+	{0xfe, 0xed, 0xfa, 0xce},
+	{0xfe, 0xed, 0xfa, 0xcf},
+	{0xce, 0xfa, 0xed, 0xfe},
+	{0xcf, 0xfa, 0xed, 0xfe},
+	{0xca, 0xfe, 0xba, 0xbe},
+}
+
+type Policy struct {
+	MaxSize           uint64
+	Extensions        []string
+	ContentTypes      []string
+	RejectExecutables bool
+}
+
+func (p Policy) Enabled() bool {
+	return p.MaxSize > 0 || len(p.Extensions) > 0 || len(p.ContentTypes) > 0 || p.RejectExecutables
+}
+
+func (p Policy) Check(m chunk.FileMetadata) error {
+	name := SanitizeFilename(m.Filename)
+	ext := strings.ToLower(filepath.Ext(name))
+	contentType := mediaType(m.ContentType)
+	if contentType == "" {
+		contentType = mediaType(mime.TypeByExtension(ext))
+	}
+
+	switch {
+	case p.MaxSize > 0 && m.FileSize > p.MaxSize:
+		return fmt.Errorf("%w: %s is %d bytes, the limit is %d", ErrPolicy, name, m.FileSize, p.MaxSize)
+	case len(p.Extensions) > 0 && !matchExtension(p.Extensions, ext):
+		return fmt.Errorf("%w: %s does not have an allowed extension", ErrPolicy, name)
+	case len(p.ContentTypes) > 0 && !matchType(p.ContentTypes, contentType):
+		return fmt.Errorf("%w: %s has type %q, which is not allowed", ErrPolicy, name, contentType)
+	case p.RejectExecutables && (executableExtensions[ext] || executableTypes[contentType]):
+		return fmt.Errorf("%w: %s looks like an executable", ErrPolicy, name)
+	}
+	return nil
+}
+
+func (p Policy) CheckContent(m chunk.FileMetadata, first []byte) error {
+	if !p.RejectExecutables {
+		return nil
+	}
+	for _, magic := range executableMagic {
+		if bytes.HasPrefix(first, magic) {
+			return fmt.Errorf("%w: %s starts like an executable", ErrPolicy, SanitizeFilename(m.Filename))
+		}
+	}
+	return nil
+}
+
+func matchExtension(allowed []string, ext string) bool {
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a != "" && !strings.HasPrefix(a, ".") {
+			a = "." + a
+		}
+		if a == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func matchType(allowed []string, contentType string) bool {
+	if contentType == "" {
+		return false
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func mediaType(contentType string) string {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return t
+}
+
+func ParseSize(size string) (uint64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	if s == "" {
+		return 0, nil
+	}
+
+	mult := uint64(1)
+	for _, unit := range []struct {
+		suffix string
+		mult   uint64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}} {
+		if n, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s, mult = strings.TrimSpace(n), unit.mult
+			break
+		}
+	}
+
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || n > (1<<64-1)/mult {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	return n * mult, nil
+}
+
+func (r *Receiver) SetPolicy(p Policy) {
+	r.mu.Lock()
+	r.policy = p
+	r.mu.Unlock()
+}
+
+func (r *Receiver) Policy() Policy {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.policy
+}
+
+func (r *Receiver) checkPolicy(s *session) error {
+	if !r.policy.Enabled() || !s.described {
+		return nil
+	}
+	if err := r.policy.Check(s.metadata); err != nil {
+		return err
+	}
+	if !s.received[0] {
+		return nil
+	}
+
+	first, err := s.load(0)
+	if err != nil {
+		return nil
+	}
+	err = r.policy.CheckContent(s.metadata, first)
+	r.wipeLoaded(s, 0, first)
+	return err
+}
+
+func (r *Receiver) refuse(s *session, err error) {
+	slog.Warn("transfer refused", "session", s.id, "file", s.metadata.Filename, "err", err)
+	r.discard(s.id)
+}
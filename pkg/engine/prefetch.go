@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/qr"
+)
+
+const PrefetchDepth = 3
+
+type frameSettings struct {
+	config    qr.Config
+	projector bool
+}
+
+type preparedFrame struct {
+	payload  *Payload
+	settings frameSettings
+	done     chan struct{}
+
+	chunk   chunk.Chunk
+	data    []byte
+	caption string
+	encoded EncodedFrame
+	err     error
+}
+
+func (s *Sender) frameSettings() frameSettings {
+	return frameSettings{
+		config:    qr.Config{ErrorLevel: s.config.ErrorLevel, Monochrome: s.config.EInk},
+		projector: s.config.Projector,
+	}
+}
+
+func newPreparedFrame(p *Payload, settings frameSettings) *preparedFrame {
+	return &preparedFrame{payload: p, settings: settings, done: make(chan struct{})}
+}
+
+func (f *preparedFrame) prepare(i int) {
+	defer close(f.done)
+
+	p := f.payload
+	switch {
+	case p.IsControl(i):
+		if f.data, f.err = p.FrameData(i); f.err == nil {
+			f.caption = p.ControlCaption(i)
+		}
+	default:
+		if f.chunk, f.err = p.Frame(i); f.err == nil {
+			f.data, f.err = p.proc.SerializeChunk(f.chunk)
+			f.caption = p.Caption(f.chunk, p.IsMetadata(i))
+		}
+	}
+	if f.err == nil {
+		f.encoded, f.err = EncodeFrame(f.data, f.settings.config, f.settings.projector)
+	}
+}
+
+func (s *Sender) prepared(i int) *preparedFrame {
+	settings := s.frameSettings()
+	if f, ok := s.ahead[i]; ok {
+		delete(s.ahead, i)
+		<-f.done
+		if f.payload == s.payload && f.settings == settings {
+			return f
+		}
+		s.discard(f)
+	}
+	f := newPreparedFrame(s.payload, settings)
+	f.prepare(i)
+	return f
+}
+
+func (s *Sender) prefetch() {
+	if s.payload == nil || !s.state.Active() {
+		return
+	}
+
+	settings := s.frameSettings()
+	count := s.payload.FrameCount()
+	want := make(map[int]bool, PrefetchDepth)
+	for k := 0; k < PrefetchDepth; k++ {
+		i := s.position + k
+		if i >= count {
+			if !s.config.Loop || len(s.queue) > 0 {
+				break
+			}
+			i %= count
+		}
+		want[i] = true
+	}
+
+	for i, f := range s.ahead {
+		if !want[i] || f.payload != s.payload || f.settings != settings {
+			delete(s.ahead, i)
+			s.discard(f)
+		}
+	}
+	for i := range want {
+		if _, ok := s.ahead[i]; ok {
+			continue
+		}
+		f := newPreparedFrame(s.payload, settings)
+		s.ahead[i] = f
+		go f.prepare(i)
+	}
+}
+
+func (s *Sender) dropPrefetched() {
+	for i, f := range s.ahead {
+		delete(s.ahead, i)
+		s.discard(f)
+	}
+}
+
+func (s *Sender) discard(f *preparedFrame) {
+	if s.config.Wipe {
+		<-f.done
+		clear(f.data)
+		clear(f.encoded.Blocks)
+	}
+}
@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const DefaultHookTimeout = 10 * time.Minute
+
+var ErrHookRejected = errors.New("file rejected by the post-receive hook")
+
+type Quarantine struct {
+	Dir         string
+	Hook        string
+	HookTimeout time.Duration
+}
+
+func (q Quarantine) Enabled() bool {
+	return q.Dir != ""
+}
+
+func (r *Receiver) SaveQuarantined(q Quarantine) (string, int, error) {
+	if err := os.MkdirAll(q.Dir, 0o700); err != nil {
+		return "", 0, err
+	}
+	if err := os.Chmod(q.Dir, 0o700); err != nil {
+		return "", 0, err
+	}
+
+	path := UniquePath(q.Dir, SanitizeFilename(r.Metadata().Filename))
+	var missing int
+	err := writeAtomic(path, 0o600, func(w io.Writer) error {
+		var err error
+		missing, err = r.Assemble(w)
+		return err
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	slog.Info("file quarantined", "path", path, "missing", missing)
+	return path, missing, nil
+}
+
+func (q Quarantine) Release(ctx context.Context, path, dest string) (string, error) {
+	if strings.TrimSpace(q.Hook) == "" {
+		return path, nil
+	}
+	if err := q.runHook(ctx, path, dest); err != nil {
+		if rmErr := os.Remove(path); rmErr != nil {
+			slog.Error("removing rejected file failed", "path", path, "err", rmErr)
+		}
+		slog.Warn("quarantined file deleted", "path", path, "err", err)
+		return "", err
+	}
+
+	if err := os.Chmod(path, 0o644); err != nil {
+		return "", err
+	}
+	if err := moveFile(path, dest); err != nil {
+		return "", err
+	}
+	slog.Info("file released from quarantine", "path", dest)
+	return dest, nil
+}
+
+func (q Quarantine) runHook(ctx context.Context, path, dest string) error {
+	args := splitCommand(q.Hook)
+	substituted := false
+	for i, a := range args {
+		if strings.Contains(a, "{}") {
+			args[i] = strings.ReplaceAll(a, "{}", path)
+			substituted = true
+		}
+	}
+	if !substituted {
+		args = append(args, path)
+	}
+
+	timeout := q.HookTimeout
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), "OWL_FILE="+path, "OWL_NAME="+filepath.Base(dest), "OWL_DEST="+dest)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+
+	var exit *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		return fmt.Errorf("%w: %s did not finish within %v", ErrHookRejected, args[0], timeout)
+	case errors.As(err, &exit):
+		return fmt.Errorf("%w: %s exited with status %d%s", ErrHookRejected, args[0], exit.ExitCode(), lastLine(out.String()))
+	case err != nil:
+		return fmt.Errorf("%w: %v", ErrHookRejected, err)
+	}
+	return nil
+}
+
+func splitCommand(command string) []string {
+	var args []string
+	var arg strings.Builder
+	var quote rune
+	inArg := false
+	for _, c := range command {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(c)
+		case c == '\'' || c == '"':
+			quote, inArg = c, true
+		case c == ' ' || c == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
+
+func lastLine(out string) string {
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return ""
+	}
+	return ": " + out[strings.LastIndexByte(out, '\n')+1:]
+}
+
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	err = WriteAtomic(dst, func(w io.Writer) error {
+		_, err := io.Copy(w, f)
+		return err
+	})
+	f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
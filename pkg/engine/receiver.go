@@ -0,0 +1,657 @@
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/qr"
+	"qrtransfer/pkg/screen"
+	"qrtransfer/pkg/secure"
+)
+
+const (
+	DefaultBlockSize        = 20
+	DefaultSnapshotInterval = 10 * time.Second
+)
+
+type HeaderStatus int
+
+const (
+	HeaderUnknown HeaderStatus = iota
+	HeaderOK
+	HeaderCorrupt
+)
+
+type AuthStatus int
+
+const (
+	AuthNone AuthStatus = iota
+	AuthOK
+	AuthLocked
+	AuthFailed
+	AuthUnpaired
+)
+
+func (a AuthStatus) Rejected() bool {
+	return a == AuthLocked || a == AuthFailed || a == AuthUnpaired
+}
+
+type ChunkState uint8
+
+const (
+	ChunkMissing ChunkState = iota
+	ChunkReceived
+	ChunkVerified
+)
+
+type FrameResult struct {
+	qr.DecodeStats
+	Header     HeaderStatus
+	Auth       AuthStatus
+	ChecksumOK bool
+	Chunk      chunk.Chunk
+	Session    uint64
+	Metadata   bool
+	Stored     bool
+	New        bool
+
+	Inconsistent error
+	Refused      error
+}
+
+type ReceiveStats struct {
+	Captures       int
+	Regions        int
+	DecodeFailures int
+	Frames         int
+	Blocks         qr.DecodeStats
+	HeaderFailures int
+	ChecksumFails  int
+	Locked         int
+	AuthFailures   int
+	Unpaired       int
+	Inconsistent   int
+	Chunks         int
+	Unique         int
+	Duplicates     int
+	Bytes          int64
+	Started        time.Time
+	LastNew        time.Time
+	Last           FrameResult
+}
+
+func (s *ReceiveStats) Add(f FrameResult) {
+	s.Frames++
+	s.Blocks.Add(f.DecodeStats)
+	if f.Header == HeaderCorrupt {
+		s.HeaderFailures++
+	}
+	if f.Header == HeaderOK && !f.ChecksumOK && !f.Auth.Rejected() {
+		s.ChecksumFails++
+	}
+	switch f.Auth {
+	case AuthLocked:
+		s.Locked++
+	case AuthFailed:
+		s.AuthFailures++
+	case AuthUnpaired:
+		s.Unpaired++
+	}
+	if f.Inconsistent != nil {
+		s.Inconsistent++
+	}
+	if f.Stored {
+		s.Chunks++
+	}
+	if f.Stored && !f.New {
+		s.Duplicates++
+	}
+	if f.New {
+		s.Unique++
+		s.Bytes += int64(len(f.Chunk.Data))
+	}
+	s.Last = f
+}
+
+type ReceiverObserver interface {
+	Frame(img image.Image, results []FrameResult, perf screen.MetricsSnapshot)
+	CaptureError(err error)
+}
+
+type Receiver struct {
+	BlockSize        int
+	SnapshotPath     string
+	SnapshotInterval time.Duration
+	SpoolDir         string
+	Decoders         int
+	AutoFPS          bool
+
+	mu       sync.Mutex
+	proc     *chunk.Processor
+	sessions map[uint64]*session
+	cur      *session
+	pinned   bool
+	stats    ReceiveStats
+	dirty    bool
+	tuning   screen.DecodeTuning
+	settle   settleGate
+
+	wipe      bool
+	discarded map[uint64]bool
+	policy    Policy
+
+	passphrase chunk.Keys
+	identity   *secure.Identity
+	handshakes map[uint64]chunk.Keys
+	pairing    *secure.Pairing
+	trusted    [][]byte
+}
+
+type settleGate struct {
+	known   bool
+	marker  bool
+	waiting bool
+}
+
+func NewReceiver() *Receiver {
+	return &Receiver{
+		BlockSize:        DefaultBlockSize,
+		SnapshotInterval: DefaultSnapshotInterval,
+		proc:             chunk.NewProcessor(chunk.NewConfig(100, 1)),
+		sessions:         make(map[uint64]*session),
+		cur:              newSession(0),
+	}
+}
+
+func (r *Receiver) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range r.sessions {
+		s.discard(r.wipe)
+	}
+	r.sessions = make(map[uint64]*session)
+	r.cur = newSession(0)
+	r.pinned = false
+	r.discarded = nil
+	r.stats = ReceiveStats{}
+	r.dirty = false
+	r.settle = settleGate{}
+}
+
+func (r *Receiver) Metadata() chunk.FileMetadata {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cur.metadata
+}
+
+func (r *Receiver) Stats() ReceiveStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+func (r *Receiver) Received() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.cur.received)
+}
+
+func (r *Receiver) ChunkStates() []ChunkState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cur.states()
+}
+
+func (r *Receiver) Missing() []uint32 {
+	var missing []uint32
+	for i, state := range r.ChunkStates() {
+		if state != ChunkVerified {
+			missing = append(missing, uint32(i))
+		}
+	}
+	return missing
+}
+
+func (r *Receiver) Complete() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cur.complete()
+}
+
+func (r *Receiver) Capture(ctx context.Context, src Source, fps int, metrics *screen.Metrics, obs ReceiverObserver) error {
+	if metrics == nil {
+		metrics = screen.NewMetrics()
+	}
+
+	frames, err := screen.StreamWithMetrics(ctx, src, fps, metrics)
+	if err != nil {
+		return err
+	}
+	return r.Run(ctx, frames, metrics, obs)
+}
+
+func (r *Receiver) Run(ctx context.Context, frames <-chan screen.Frame, metrics *screen.Metrics, obs ReceiverObserver) error {
+	if metrics == nil {
+		metrics = screen.NewMetrics()
+	}
+
+	var snapshots <-chan time.Time
+	if r.SnapshotPath != "" && r.SnapshotInterval > 0 {
+		ticker := time.NewTicker(r.SnapshotInterval)
+		defer ticker.Stop()
+		defer r.snapshot()
+		snapshots = ticker.C
+	}
+
+	decoded := r.decode(frames, metrics)
+
+	var tuner *captureTuner
+	if r.AutoFPS {
+		tuner = &captureTuner{}
+	}
+
+	var lastErr error
+	for {
+		var d decodedFrame
+		select {
+		case <-snapshots:
+			r.snapshot()
+			continue
+		case frame, ok := <-decoded:
+			if !ok {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				return lastErr
+			}
+			d = frame
+		}
+
+		f := d.frame
+		lastErr = f.Err
+		if f.Err != nil {
+			slog.Warn("capture error", "err", f.Err)
+			obs.CaptureError(f.Err)
+			continue
+		}
+
+		results := r.ingest(d.regions)
+		perf := metrics.Snapshot()
+		if tuner != nil {
+			if fps := tuner.observe(time.Now(), results, perf); fps != perf.TargetFPS {
+				slog.Info("capture rate adjusted", "from", perf.TargetFPS, "to", fps)
+				metrics.SetTargetFPS(fps)
+				perf.TargetFPS = fps
+			}
+		}
+		obs.Frame(f.Image, results, perf)
+	}
+}
+
+func (r *Receiver) snapshot() {
+	if err := r.WriteSnapshot(r.SnapshotPath); err != nil {
+		slog.Warn("writing receive snapshot failed", "path", r.SnapshotPath, "err", err)
+	}
+
+}
+
+func (r *Receiver) SetKeys(keys chunk.Keys) {
+	r.mu.Lock()
+	r.passphrase = keys
+	r.mu.Unlock()
+	r.updateKeys()
+}
+
+func (r *Receiver) updateKeys() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	proc := chunk.NewProcessor(chunk.NewConfig(100, 1))
+	if r.passphrase != nil || r.identity != nil {
+		proc.SetKeys(r.sessionKeys)
+	}
+	if r.pairing != nil {
+		proc.SetMAC(r.pairing.MACKey())
+	}
+	r.proc = proc
+}
+
+func (r *Receiver) Encrypted() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.proc.Encrypted()
+}
+
+func (r *Receiver) ProcessFrame(img image.Image) []FrameResult {
+	return r.ingest(screen.DecodeRegionsTuned(img, r.BlockSize, r.Tuning()))
+}
+
+func (r *Receiver) Tuning() screen.DecodeTuning {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.tuning
+}
+
+func (r *Receiver) SetTuning(t screen.DecodeTuning) {
+	r.mu.Lock()
+	r.tuning = t
+	r.mu.Unlock()
+}
+
+func (r *Receiver) ingest(regions []screen.RegionResult) []FrameResult {
+	eink := r.Tuning().EInk
+	failures := 0
+	var results []FrameResult
+	for _, region := range regions {
+		if region.Err != nil {
+			failures++
+			continue
+		}
+		if IsHandshake(region.Data) {
+			r.acceptHandshake(region.Data)
+			continue
+		}
+		if IsManifest(region.Data) {
+			r.acceptManifest(region.Data)
+			continue
+		}
+		if IsStatus(region.Data) || IsPublicKeyCode(region.Data) {
+			continue
+		}
+		if eink && !r.settled(region.Marker) {
+			slog.Debug("waiting for frame to settle", "marker", region.Marker)
+			continue
+		}
+
+		res := r.ProcessPayload(region.Data, region.Stats)
+		if eink && res.ChecksumOK {
+			r.mu.Lock()
+			r.settle.waiting = false
+			r.mu.Unlock()
+		}
+		results = append(results, res)
+	}
+
+	r.mu.Lock()
+	r.stats.Captures++
+	r.stats.Regions += len(regions)
+	r.stats.DecodeFailures += failures
+	r.mu.Unlock()
+	return results
+}
+
+func (r *Receiver) settled(marker bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.settle.known || marker != r.settle.marker {
+		r.settle = settleGate{known: true, marker: marker, waiting: true}
+	}
+	return r.settle.waiting
+}
+
+func (r *Receiver) countFailed(session uint64, c chunk.Chunk) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[session]; ok && !chunk.IsParity(c) {
+		s.failed[c.Index]++
+	}
+}
+
+func (r *Receiver) ProcessPayload(data []byte, stats qr.DecodeStats) FrameResult {
+	res := FrameResult{DecodeStats: stats}
+	defer func() {
+		r.mu.Lock()
+		r.stats.Add(res)
+		if res.New {
+			r.stats.LastNew = time.Now()
+			if r.stats.Started.IsZero() {
+				r.stats.Started = r.stats.LastNew
+			}
+		}
+		r.mu.Unlock()
+	}()
+
+	r.mu.Lock()
+	proc, pairing := r.proc, r.pairing
+	r.mu.Unlock()
+
+	c, err := proc.DeserializeChunk(data)
+	if pairing != nil && c.Timestamp != 0 && !pairing.Matches(chunk.SessionID(c)) {
+		res.Header, res.Auth, res.Session = HeaderOK, AuthUnpaired, chunk.SessionID(c)
+		return res
+	}
+	switch {
+	case errors.Is(err, chunk.ErrHeaderCorrupt):
+		res.Header = HeaderCorrupt
+	case errors.Is(err, chunk.ErrSealed):
+		res.Header, res.Chunk, res.Auth = HeaderOK, c, AuthLocked
+		res.Session = chunk.SessionID(c)
+	case errors.Is(err, chunk.ErrCorrupt):
+		res.Header, res.Session = HeaderOK, chunk.SessionID(c)
+		r.countFailed(res.Session, c)
+	case errors.Is(err, chunk.ErrUnsealed), errors.Is(err, chunk.ErrUnauthentic):
+		res.Header, res.Chunk, res.Auth = HeaderOK, c, AuthFailed
+		res.Session = chunk.SessionID(c)
+		slog.Debug("rejected chunk", "index", c.Index, "session", res.Session, "err", err)
+	}
+	if err != nil {
+		return res
+	}
+	res.Header = HeaderOK
+	res.Chunk = c
+	if c.Sealed || pairing != nil {
+		res.Auth = AuthOK
+	}
+
+	res.Session = chunk.SessionID(c)
+
+	if !chunk.VerifyChunk(c) {
+		r.countFailed(res.Session, c)
+		return res
+	}
+	res.ChecksumOK = true
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.discarded[res.Session] {
+		r.wipeChunk(c)
+		return res
+	}
+	s := r.session(res.Session)
+	if c.Index == 0 {
+		if metadata, err := r.proc.DeserializeMetadata(c.Data); err == nil && c.Total == metadata.TotalChunks+1 {
+			if err := s.checkMetadata(metadata); err != nil {
+				slog.Warn("rejected metadata", "session", res.Session, "err", err)
+				res.Inconsistent = err
+				return res
+			}
+			if !s.described {
+				slog.Info("metadata received", "file", metadata.Filename, "size", metadata.FileSize, "chunks", metadata.TotalChunks, "session", res.Session)
+				s.metadata = metadata
+				s.sealed = c.Sealed
+				s.described = true
+				if n := s.revalidate(r.wipe); n > 0 {
+					res.Inconsistent = fmt.Errorf("%w: dropped %d chunks received before the metadata", ErrInconsistent, n)
+				}
+				if err := r.checkPolicy(s); err != nil {
+					r.refuse(s, err)
+					res.Refused = err
+					return res
+				}
+				if r.wipe && s.sealed {
+					slog.Info("keeping encrypted transfer in memory only", "session", res.Session)
+				} else {
+					s.startSpool(r.SpoolDir)
+				}
+				r.dirty = true
+				res.Metadata = true
+			}
+			return res
+		}
+	}
+
+	err = s.check(c)
+	if err == nil {
+		err = s.checkCopy(c)
+	}
+	if err != nil {
+		slog.Warn("rejected chunk", "index", c.Index&^chunk.ParityFlag, "session", res.Session, "err", err)
+		res.Inconsistent = err
+		r.wipeChunk(c)
+		return res
+	}
+
+	if chunk.IsParity(c) {
+		slog.Debug("parity chunk received", "index", c.Index&^chunk.ParityFlag)
+		if !s.parity[c.Index] {
+			s.store(c)
+			s.record(c)
+			s.parity[c.Index] = true
+			r.dirty = true
+		} else {
+			r.wipeChunk(c)
+		}
+		return res
+	}
+
+	res.Stored = true
+	res.New = !s.received[c.Index]
+	s.copies[c.Index]++
+	if res.New {
+		s.seen[c.Index] = time.Now()
+		slog.Debug("chunk received", "index", c.Index)
+		s.store(c)
+		s.record(c)
+		s.received[c.Index] = true
+		s.bytes += uint64(len(c.Data))
+		r.dirty = true
+		if c.Index == 0 {
+			if err := r.checkPolicy(s); err != nil {
+				r.refuse(s, err)
+				res.Refused = err
+			}
+		}
+	} else {
+		r.wipeChunk(c)
+	}
+	return res
+}
+
+func (r *Receiver) Assemble(w io.Writer) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.cur
+	received := make(map[uint32][]byte)
+	for i := uint32(0); i < s.metadata.TotalChunks; i++ {
+		if !s.received[i] {
+			continue
+		}
+
+		data, err := s.load(i)
+		if err != nil {
+			return 0, err
+		}
+		received[i] = data
+	}
+
+	if len(s.parity) > 0 {
+		parity := make([]chunk.Chunk, 0, len(s.parity))
+		for index := range s.parity {
+			data, err := s.load(index)
+			if err != nil {
+				return 0, err
+			}
+			parity = append(parity, chunk.Chunk{Index: index, Total: s.metadata.TotalChunks, Data: data})
+		}
+		if n := chunk.RecoverParity(received, parity, s.metadata); n > 0 {
+			slog.Debug("chunks recovered from parity", "count", n)
+		}
+		for _, p := range parity {
+			r.wipeLoaded(s, p.Index, p.Data)
+		}
+	}
+
+	if r.policy.Enabled() {
+		if err := r.policy.Check(s.metadata); err != nil {
+			return 0, err
+		}
+		if first, ok := received[0]; ok {
+			if err := r.policy.CheckContent(s.metadata, first); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	v := s.verification()
+	hash := sha256.New()
+	w = io.MultiWriter(w, hash)
+
+	missing := 0
+	var written uint64
+	for i := uint32(0); i < s.metadata.TotalChunks; i++ {
+		data, ok := received[i]
+		if !ok {
+			missing++
+			continue
+		}
+
+		if want := s.metadata.ChunkLength(i); len(data) > want {
+			slog.Debug("trimming padded chunk", "index", i, "length", len(data), "want", want)
+			data = data[:want]
+		}
+		if v.Status == ManifestSigned && !s.checkChunk(i, data) {
+			slog.Warn("chunk does not match the signed manifest, dropping it", "index", i)
+			if s.received[i] {
+				delete(s.received, i)
+				s.bytes -= uint64(len(data))
+				r.dirty = true
+			}
+			missing++
+			continue
+		}
+		n, err := w.Write(data)
+		written += uint64(n)
+		r.wipeLoaded(s, i, received[i])
+		if err != nil {
+			return missing, err
+		}
+	}
+
+	if missing == 0 && written != s.metadata.FileSize {
+		return missing, fmt.Errorf("%w: assembled %d bytes, expected %d", ErrSizeMismatch, written, s.metadata.FileSize)
+	}
+	if missing > 0 {
+		return missing, nil
+	}
+	sum := [32]byte(hash.Sum(nil))
+	s.hash = &sum
+	if v.Status == ManifestNone {
+		return missing, nil
+	}
+
+	switch {
+	case v.Status == ManifestInvalid && errors.Is(v.Err, ErrManifestMismatch):
+		return missing, v.Err
+	case v.Status == ManifestInvalid:
+		return missing, fmt.Errorf("%w: %v", ErrManifestMismatch, v.Err)
+	case v.Status == ManifestPending:
+		return missing, ErrManifestPending
+	case sum != s.manifest.FileHash:
+		s.manifestErr = fmt.Errorf("%w: file hash differs", ErrManifestMismatch)
+		return missing, s.manifestErr
+	}
+	s.verified = true
+	return missing, nil
+}
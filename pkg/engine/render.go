@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"image"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/ec"
+	"qrtransfer/pkg/qr"
+)
+
+const ProjectorChunkSize = 40
+
+type Renderer struct {
+	Config  qr.Config
+	Caption bool
+	Marker  bool
+
+	Projector bool
+
+	enc      *qr.Encoder
+	canvases [2]canvas
+	next     int
+	size     image.Point
+}
+
+type canvas struct {
+	img    *image.RGBA
+	config qr.Config
+	blocks []qr.Block
+}
+
+func NewRenderer(config qr.Config) *Renderer {
+	return &Renderer{
+		Config: config,
+		enc:    qr.NewEncoder(config),
+	}
+}
+
+func (r *Renderer) Reset() {
+	r.canvases = [2]canvas{}
+}
+
+type EncodedFrame struct {
+	Config qr.Config
+	Blocks []qr.Block
+}
+
+func EncodeFrame(data []byte, config qr.Config, projector bool) (EncodedFrame, error) {
+	config.GridWidth, config.GridHeight = qr.OptimalGridSize(len(data))
+	switch {
+	case projector:
+		frame, side, err := ProjectorFrame(data)
+		if err != nil {
+			return EncodedFrame{}, err
+		}
+		data = frame
+		config.GridWidth, config.GridHeight = side, side
+		config.BorderSize = qr.TimingRings + 1
+		config.Monochrome, config.Palette, config.Timing = false, true, true
+	case config.Monochrome:
+		config.GridWidth, config.GridHeight = qr.MonoGridSize(len(data))
+		config.BorderSize = qr.TimingRings + 1
+		config.Timing = true
+	}
+
+	return EncodedFrame{Config: config, Blocks: qr.NewEncoder(config).Encode(data)}, nil
+}
+
+func (r *Renderer) Render(data []byte, size image.Point, caption string) (image.Image, error) {
+	frame, err := EncodeFrame(data, r.Config, r.Projector)
+	if err != nil {
+		return nil, err
+	}
+	return r.Draw(frame, size, caption)
+}
+
+func (r *Renderer) Draw(frame EncodedFrame, size image.Point, caption string) (image.Image, error) {
+	if size != r.size {
+		r.Reset()
+		r.size = size
+	}
+
+	r.enc = qr.NewEncoder(frame.Config)
+	if frame.Config.Monochrome {
+		qr.SetMarker(frame.Blocks, r.Marker)
+	}
+
+	img, err := r.draw(frame.Config, frame.Blocks, CodeArea(size, r.Caption))
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Caption {
+		img = qr.AddCaption(img, caption, qr.CaptionScale(size.Y))
+	}
+	return img, nil
+}
+
+func (r *Renderer) draw(config qr.Config, blocks []qr.Block, area image.Point) (image.Image, error) {
+	width, height := frameDimensions(config, area)
+	c := &r.canvases[r.next]
+	r.next = (r.next + 1) % len(r.canvases)
+
+	if c.fits(config, len(blocks), width, height) {
+		r.enc.UpdateImage(c.img, c.blocks, blocks)
+	} else {
+		img, err := r.enc.CreateImage(blocks, width, height)
+		if err != nil {
+			return nil, err
+		}
+		c.img, _ = img.(*image.RGBA)
+		c.config = config
+	}
+
+	c.blocks = append(c.blocks[:0], blocks...)
+	return c.img, nil
+}
+
+func (c *canvas) fits(config qr.Config, blocks, width, height int) bool {
+	return c.img != nil && c.config == config && len(c.blocks) == blocks &&
+		c.img.Rect.Dx() == width && c.img.Rect.Dy() == height
+}
+
+func frameDimensions(config qr.Config, area image.Point) (int, int) {
+	size := config.BlockPixelSize(area.X, area.Y)
+	if size <= 0 {
+		return area.X, area.Y
+	}
+
+	cols := config.GridWidth + 2*config.BorderSize
+	rows := config.GridHeight + 2*config.BorderSize
+	return cols * size, rows * size
+}
+
+func CodeArea(size image.Point, caption bool) image.Point {
+	if !caption {
+		return size
+	}
+	return image.Pt(size.X, size.Y-qr.CaptionHeight(qr.CaptionScale(size.Y)))
+}
+
+func MaxChunkSize(size image.Point, caption bool, config qr.Config) int {
+	area := CodeArea(size, caption)
+	payload := qr.MaxPayloadSize(area.X, area.Y, config.BorderSize, config.MinBlockPixels)
+	switch {
+	case config.Palette:
+		cols, rows := qr.MaxGridSize(area.X, area.Y, qr.TimingRings+1, config.MinBlockPixels)
+		side := min(cols, rows)
+		side -= 1 - side%2
+		payload = ec.NewRS255_191().FrameDataSize(qr.PaletteCapacity(max(side, 0) * max(side, 0)))
+	case config.Monochrome:
+		payload = qr.MonoCapacity(qr.MaxPayloadSize(area.X, area.Y, qr.TimingRings+1, config.MinBlockPixels) / 3)
+	}
+	return payload - chunk.Overhead
+}
+
+func ProjectorFrame(data []byte) ([]byte, int, error) {
+	rs := ec.NewRS255_191()
+	side := projectorSide(rs, len(data))
+	frame, err := rs.EncodeFrame(data, qr.PaletteCapacity(side*side))
+	return frame, side, err
+}
+
+func projectorSide(rs *ec.RS, size int) int {
+	side, _ := qr.PaletteGridSize(size + rs.TotalSize() - rs.DataSize())
+	for rs.FrameDataSize(qr.PaletteCapacity(side*side)) < size {
+		side += 2
+	}
+	return side
+}
+
+func FrameSide(size int, config qr.Config) int {
+	switch {
+	case config.Palette:
+		return projectorSide(ec.NewRS255_191(), size)
+	case config.Monochrome:
+		side, _ := qr.MonoGridSize(size)
+		return side
+	}
+	side, _ := qr.OptimalGridSize(size)
+	return side
+}
+
+func FrameCapacity(side int, config qr.Config) int {
+	blocks := side * side
+	switch {
+	case config.Palette:
+		return ec.NewRS255_191().FrameDataSize(qr.PaletteCapacity(blocks))
+	case config.Monochrome:
+		return qr.MonoCapacity(blocks)
+	}
+	return config.ErrorLevel.Capacity(blocks)
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"qrtransfer/pkg/chunk"
+)
+
+var chunkStateNames = []string{"missing", "checksum_failed", "verified"}
+
+func (s ChunkState) String() string {
+	if int(s) < len(chunkStateNames) {
+		return chunkStateNames[s]
+	}
+	return fmt.Sprintf("ChunkState(%d)", int(s))
+}
+
+type ChunkReport struct {
+	Index            uint32     `json:"index"`
+	State            string     `json:"state"`
+	Copies           int        `json:"copies"`
+	ChecksumFailures int        `json:"checksum_failures"`
+	FirstVerified    *time.Time `json:"first_verified,omitempty"`
+}
+
+type Report struct {
+	Generated        time.Time     `json:"generated"`
+	Session          uint64        `json:"session"`
+	Filename         string        `json:"filename"`
+	ContentType      string        `json:"content_type,omitempty"`
+	FileSize         uint64        `json:"file_size"`
+	ChunkSize        uint32        `json:"chunk_size"`
+	TotalChunks      uint32        `json:"total_chunks"`
+	Verified         int           `json:"verified"`
+	Missing          string        `json:"missing"`
+	ParityChunks     int           `json:"parity_chunks"`
+	FirstSeen        time.Time     `json:"first_seen"`
+	LastSeen         time.Time     `json:"last_seen"`
+	CodesDecoded     int           `json:"codes_decoded"`
+	HeaderFailures   int           `json:"header_failures"`
+	ChecksumFailures int           `json:"checksum_failures"`
+	Chunks           []ChunkReport `json:"chunks"`
+}
+
+func (r *Receiver) Report() Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.cur
+	states := s.states()
+	rep := Report{
+		Generated:        time.Now(),
+		Session:          s.id,
+		Filename:         s.metadata.Filename,
+		ContentType:      s.metadata.ContentType,
+		FileSize:         s.metadata.FileSize,
+		ChunkSize:        s.metadata.ChunkSize,
+		TotalChunks:      s.metadata.TotalChunks,
+		Verified:         len(s.received),
+		ParityChunks:     len(s.parity),
+		FirstSeen:        s.firstSeen,
+		LastSeen:         s.lastSeen,
+		CodesDecoded:     r.stats.Frames,
+		HeaderFailures:   r.stats.HeaderFailures,
+		ChecksumFailures: r.stats.ChecksumFails,
+		Chunks:           make([]ChunkReport, len(states)),
+	}
+
+	var missing []uint32
+	for i, state := range states {
+		index := uint32(i)
+		c := ChunkReport{
+			Index:            index,
+			State:            state.String(),
+			Copies:           s.copies[index],
+			ChecksumFailures: s.failed[index],
+		}
+		if t, ok := s.seen[index]; ok {
+			c.FirstVerified = &t
+		}
+		if state != ChunkVerified {
+			missing = append(missing, index)
+		}
+		rep.Chunks[i] = c
+	}
+	rep.Missing = chunk.FormatIndices(missing)
+	return rep
+}
+
+func (rep Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+func (rep Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"session", "filename", "index", "state", "copies", "checksum_failures", "first_verified"})
+
+	session := strconv.FormatUint(rep.Session, 10)
+	for _, c := range rep.Chunks {
+		verified := ""
+		if c.FirstVerified != nil {
+			verified = c.FirstVerified.Format(time.RFC3339Nano)
+		}
+		cw.Write([]string{
+			session,
+			rep.Filename,
+			strconv.FormatUint(uint64(c.Index), 10),
+			c.State,
+			strconv.Itoa(c.Copies),
+			strconv.Itoa(c.ChecksumFailures),
+			verified,
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,275 @@
+package engine
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"qrtransfer/pkg/chunk"
+)
+
+const snapshotVersion = 2
+
+var ErrSnapshotVersion = errors.New("unsupported snapshot version")
+
+type snapshot struct {
+	Version     int
+	Session     uint64
+	Metadata    chunk.FileMetadata
+	Received    map[uint32]bool
+	Parity      map[uint32]bool
+	Data        map[uint32][]byte
+	Spool       string
+	SpoolParity map[uint32]int64
+	Manifest    []byte
+}
+
+func WriteAtomic(path string, write func(io.Writer) error) error {
+	return writeAtomic(path, 0o644, write)
+}
+
+func writeAtomic(path string, perm os.FileMode, write func(io.Writer) error) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.part")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if err = write(f); err != nil {
+		return err
+	}
+	if err = f.Chmod(perm); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (r *Receiver) SaveFile(path string) (int, error) {
+	var missing int
+	err := WriteAtomic(path, func(w io.Writer) error {
+		var err error
+		missing, err = r.Assemble(w)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	slog.Info("file saved", "path", path, "missing", missing)
+	return missing, nil
+}
+
+func (r *Receiver) Snapshot(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := snapshot{
+		Version:  snapshotVersion,
+		Session:  r.cur.id,
+		Metadata: r.cur.metadata,
+		Received: r.cur.received,
+		Parity:   r.cur.parity,
+		Data:     r.cur.data,
+	}
+	if sp := r.cur.spool; sp != nil {
+		if err := sp.f.Sync(); err != nil {
+			return err
+		}
+		s.Spool = sp.path
+		s.SpoolParity = sp.parity
+	}
+	if m := r.cur.manifest; m != nil {
+		data, err := m.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		s.Manifest = data
+	}
+	return gob.NewEncoder(w).Encode(s)
+}
+
+func (r *Receiver) Restore(rd io.Reader) error {
+	var s snapshot
+	if err := gob.NewDecoder(rd).Decode(&s); err != nil {
+		return fmt.Errorf("reading snapshot: %w", err)
+	}
+	if s.Version != snapshotVersion {
+		return fmt.Errorf("%w: %d", ErrSnapshotVersion, s.Version)
+	}
+
+	if s.Metadata.TotalChunks > 0 {
+		if err := r.Policy().Check(s.Metadata); err != nil {
+			return err
+		}
+	}
+
+	cur := newSession(s.Session)
+	cur.metadata = s.Metadata
+	cur.described = s.Metadata.TotalChunks > 0
+	cur.firstSeen = time.Now()
+	cur.lastSeen = cur.firstSeen
+	if s.Received != nil {
+		cur.received = s.Received
+	}
+	if s.Parity != nil {
+		cur.parity = s.Parity
+	}
+	if s.Data != nil {
+		cur.data = s.Data
+	}
+	if len(s.Manifest) > 0 {
+		m, err := ParseManifest(s.Manifest)
+		if err != nil {
+			return fmt.Errorf("reading snapshot manifest: %w", err)
+		}
+		cur.manifest = &m
+	}
+	if s.Spool != "" {
+		sp, err := openSpool(s.Spool, os.O_RDWR, s.Metadata, s.SpoolParity)
+		if err != nil {
+			return fmt.Errorf("reopening spool: %w", err)
+		}
+		cur.spool = sp
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, old := range r.sessions {
+		if old.spool != nil && old.spool.path == s.Spool {
+			old.spool.close()
+		} else {
+			old.discard(r.wipe)
+		}
+	}
+	r.sessions = map[uint64]*session{cur.id: cur}
+	r.cur = cur
+	r.pinned = false
+	r.stats = ReceiveStats{}
+	for index := range cur.received {
+		cur.bytes += uint64(cur.length(index))
+	}
+	r.stats.Unique = len(cur.received)
+	r.stats.Bytes = int64(cur.bytes)
+	r.dirty = false
+	return nil
+}
+
+func (r *Receiver) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+	for _, s := range r.sessions {
+		if r.wipe {
+			s.wipe()
+		}
+		if s.spool != nil {
+			errs = append(errs, s.spool.close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Receiver) ReadSnapshot(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return r.Restore(f)
+}
+
+func (r *Receiver) WriteSnapshot(path string) error {
+	r.mu.Lock()
+	dirty := r.dirty
+	r.dirty = false
+	sealed := r.wipe && r.cur.sealed
+	r.mu.Unlock()
+	if !dirty {
+		return nil
+	}
+	if sealed {
+		slog.Debug("not writing a receive snapshot of an encrypted transfer")
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := WriteAtomic(path, r.Snapshot); err != nil {
+		r.mu.Lock()
+		r.dirty = true
+		r.mu.Unlock()
+		return err
+	}
+
+	slog.Debug("receive snapshot written", "path", path, "chunks", r.Received())
+	return nil
+}
+
+const fallbackFilename = "received.bin"
+
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+func SanitizeFilename(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = name[strings.LastIndex(name, "/")+1:]
+
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, " .")
+
+	if stem, _, _ := strings.Cut(name, "."); reservedNames[strings.ToUpper(stem)] {
+		name = "_" + name
+	}
+	if len(name) > 255 {
+		ext := filepath.Ext(name)
+		if len(ext) > 32 {
+			ext = ""
+		}
+		name = strings.ToValidUTF8(name[:255-len(ext)], "") + ext
+	}
+	if name == "" {
+		return fallbackFilename
+	}
+	return name
+}
+
+func UniquePath(dir, name string) string {
+	path := filepath.Join(dir, name)
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+			return path
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d%s", stem, i, ext))
+	}
+}
@@ -0,0 +1,602 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"qrtransfer/pkg/chunk"
+	"qrtransfer/pkg/qr"
+)
+
+const (
+	DefaultInterval = 2 * time.Second
+	EInkInterval    = 10 * time.Second
+	EInkSettle      = 4 * time.Second
+
+	commandBuffer = 64
+)
+
+type SenderState int
+
+const (
+	SenderIdle SenderState = iota
+	SenderRunning
+	SenderPaused
+	SenderStopped
+	SenderFinished
+	SenderFailed
+)
+
+func (s SenderState) Active() bool {
+	return s == SenderRunning || s == SenderPaused
+}
+
+type SenderConfig struct {
+	ErrorLevel qr.ErrorLevel
+	Interval   time.Duration
+	Loop       bool
+	Manual     bool
+	Caption    bool
+	EInk       bool
+	Projector  bool
+	Wipe       bool
+}
+
+type QueueItem struct {
+	Name       string
+	ErrorLevel qr.ErrorLevel
+	Interval   time.Duration
+	Load       func() (*Payload, error)
+}
+
+type SenderStatus struct {
+	State    SenderState
+	Config   SenderConfig
+	Payload  *Payload
+	Position int
+	Frames   int
+	Pass     int
+	Pending  int
+	Sent     int
+	QueuePos int
+	QueueLen int
+	Err      error
+}
+
+type Sender struct {
+	surface  Surface
+	notify   func(SenderStatus)
+	renderer *Renderer
+	config   SenderConfig
+
+	payload  *Payload
+	queue    []QueueItem
+	queuePos int
+	position int
+	pending  []chunk.Chunk
+	state    SenderState
+	pass     int
+	sent     int
+	err      error
+
+	commands chan func()
+	done     chan struct{}
+	ticker   *time.Ticker
+	settle   *time.Timer
+	shown    []byte
+	caption  string
+
+	frame   []byte
+	encoded EncodedFrame
+	ahead   map[int]*preparedFrame
+}
+
+func NewSender(surface Surface, config SenderConfig, notify func(SenderStatus)) *Sender {
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+
+	s := &Sender{
+		surface:  surface,
+		notify:   notify,
+		renderer: NewRenderer(qr.Config{ErrorLevel: config.ErrorLevel}),
+		config:   config,
+		commands: make(chan func(), commandBuffer),
+		done:     make(chan struct{}),
+		ticker:   time.NewTicker(config.Interval),
+		settle:   time.NewTimer(config.Interval),
+		ahead:    make(map[int]*preparedFrame),
+	}
+	s.settle.Stop()
+	return s
+}
+
+func SettleTime(interval time.Duration) time.Duration {
+	return min(EInkSettle, interval/2)
+}
+
+func (s *Sender) Run(ctx context.Context) {
+	defer close(s.done)
+	defer s.ticker.Stop()
+	defer s.settle.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case cmd := <-s.commands:
+			cmd()
+		case <-s.ticker.C:
+			if s.state == SenderRunning && !s.config.Manual {
+				s.advance()
+			}
+		case <-s.settle.C:
+			s.settled()
+		}
+	}
+}
+
+func (s *Sender) call(cmd func()) {
+	done := make(chan struct{})
+	select {
+	case s.commands <- func() {
+		defer close(done)
+		cmd()
+	}:
+	case <-s.done:
+		return
+	}
+
+	select {
+	case <-done:
+	case <-s.done:
+	}
+}
+
+func (s *Sender) Status() SenderStatus {
+	var st SenderStatus
+	s.call(func() { st = s.snapshot() })
+	return st
+}
+
+func (s *Sender) Load(p *Payload) error {
+	var err error
+	s.call(func() {
+		if s.state.Active() {
+			err = ErrRunning
+			return
+		}
+		s.setPayload(p)
+		s.publish()
+	})
+	return err
+}
+
+func (s *Sender) SetQueue(items []QueueItem) error {
+	var err error
+	s.call(func() {
+		if s.state.Active() {
+			err = ErrRunning
+			return
+		}
+		s.queue = append([]QueueItem(nil), items...)
+		s.queuePos = 0
+		s.publish()
+	})
+	return err
+}
+
+func (s *Sender) Enqueue(item QueueItem) {
+	s.call(func() {
+		s.queue = append(s.queue, item)
+		s.publish()
+	})
+}
+
+func (s *Sender) LoadQueue(i int) error {
+	var err error
+	s.call(func() {
+		switch {
+		case s.state.Active():
+			err = ErrRunning
+		case i < 0 || i >= len(s.queue):
+			err = fmt.Errorf("queue item %d out of range (0-%d)", i, len(s.queue)-1)
+		default:
+			err = s.loadQueue(i)
+		}
+		s.publish()
+	})
+	return err
+}
+
+func (s *Sender) Start() error {
+	var err error
+	s.call(func() { err = s.start() })
+	return err
+}
+
+func (s *Sender) Stop() {
+	s.call(func() {
+		if s.state.Active() {
+			slog.Info("transfer stopped", "position", s.position)
+		}
+		s.state = SenderStopped
+		s.pending = nil
+		s.position = 0
+		s.dropPrefetched()
+		if s.config.Wipe {
+			clear(s.shown)
+			clear(s.encoded.Blocks)
+		}
+		s.shown, s.encoded = nil, EncodedFrame{}
+		s.settle.Stop()
+		s.renderer.Reset()
+		s.publish()
+	})
+}
+
+func (s *Sender) Pause() {
+	s.call(func() {
+		if s.state == SenderRunning {
+			s.state = SenderPaused
+			s.publish()
+		}
+	})
+}
+
+func (s *Sender) Resume() {
+	s.call(s.resume)
+}
+
+func (s *Sender) TogglePause() {
+	s.call(func() {
+		switch s.state {
+		case SenderRunning:
+			s.state = SenderPaused
+			s.publish()
+		case SenderPaused:
+			s.resume()
+		}
+	})
+}
+
+func (s *Sender) Step() {
+	s.call(func() {
+		if s.state == SenderRunning && s.config.Manual {
+			s.advance()
+		}
+	})
+}
+
+func (s *Sender) Seek(frame int) {
+	s.call(func() { s.seek(frame) })
+}
+
+func (s *Sender) SeekChunk(n int) error {
+	var err error
+	s.call(func() {
+		if s.payload == nil {
+			err = ErrNoPayload
+			return
+		}
+
+		total := int(s.payload.Metadata.TotalChunks)
+		if n < 1 || n > total {
+			err = fmt.Errorf("chunk must be between 1 and %d", total)
+			return
+		}
+
+		if frame := s.payload.FrameOf(uint32(n - 1)); frame >= 0 {
+			s.seek(frame)
+		}
+	})
+	return err
+}
+
+func (s *Sender) Retransmit(indices []uint32) error {
+	var err error
+	s.call(func() {
+		if s.payload == nil {
+			err = ErrNoPayload
+			return
+		}
+
+		for _, i := range indices {
+			if int(i) >= len(s.payload.Chunks) {
+				err = fmt.Errorf("chunk index %d out of range (0-%d)", i, len(s.payload.Chunks)-1)
+				return
+			}
+		}
+		for _, i := range indices {
+			s.pending = append(s.pending, s.payload.Chunks[i][0])
+		}
+
+		if !s.state.Active() {
+			s.position = s.payload.FrameCount()
+			err = s.start()
+			return
+		}
+		s.publish()
+	})
+	return err
+}
+
+func (s *Sender) SetInterval(d time.Duration) {
+	s.call(func() {
+		if d <= 0 {
+			return
+		}
+		s.config.Interval = d
+		s.ticker.Reset(d)
+		s.publish()
+	})
+}
+
+func (s *Sender) SetErrorLevel(level qr.ErrorLevel) {
+	s.call(func() {
+		s.config.ErrorLevel = level
+		s.publish()
+	})
+}
+
+func (s *Sender) SetLoop(loop bool) {
+	s.call(func() {
+		s.config.Loop = loop
+		s.publish()
+	})
+}
+
+func (s *Sender) SetManual(manual bool) {
+	s.call(func() {
+		s.config.Manual = manual
+		s.ticker.Reset(s.config.Interval)
+		s.publish()
+	})
+}
+
+func (s *Sender) SetCaption(caption bool) {
+	s.call(func() {
+		s.config.Caption = caption
+		s.renderer.Reset()
+		s.publish()
+	})
+}
+
+func (s *Sender) SetEInk(eink bool) {
+	s.call(func() {
+		s.config.EInk = eink
+		s.settle.Stop()
+		s.renderer.Reset()
+		s.publish()
+	})
+}
+
+func (s *Sender) SetWipe(wipe bool) {
+	s.call(func() {
+		s.config.Wipe = wipe
+	})
+}
+
+func (s *Sender) SetProjector(projector bool) {
+	s.call(func() {
+		s.config.Projector = projector
+		s.renderer.Reset()
+		s.publish()
+	})
+}
+
+func (s *Sender) snapshot() SenderStatus {
+	st := SenderStatus{
+		State:    s.state,
+		Config:   s.config,
+		Payload:  s.payload,
+		Position: s.position,
+		Pass:     s.pass,
+		Pending:  len(s.pending),
+		Sent:     s.sent,
+		QueuePos: s.queuePos,
+		QueueLen: len(s.queue),
+		Err:      s.err,
+	}
+	if s.payload != nil {
+		st.Frames = s.payload.FrameCount()
+	}
+	return st
+}
+
+func (s *Sender) publish() {
+	if s.notify != nil {
+		s.notify(s.snapshot())
+	}
+}
+
+func (s *Sender) setPayload(p *Payload) {
+	s.dropPrefetched()
+	if s.config.Wipe && s.payload != nil && s.payload != p {
+		s.payload.Wipe()
+	}
+	s.payload = p
+	s.position = 0
+	s.renderer.Reset()
+}
+
+func (s *Sender) loadQueue(i int) error {
+	item := s.queue[i]
+	p, err := item.Load()
+	if err != nil {
+		return fmt.Errorf("%s: %w", item.Name, err)
+	}
+
+	s.queuePos = i
+	s.config.ErrorLevel = item.ErrorLevel
+	if item.Interval > 0 {
+		s.config.Interval = item.Interval
+	}
+	s.setPayload(p)
+	return nil
+}
+
+func (s *Sender) start() error {
+	switch {
+	case s.state.Active():
+		return ErrRunning
+	case s.payload == nil:
+		return ErrNoPayload
+	}
+
+	if s.position >= s.payload.FrameCount() && len(s.pending) == 0 {
+		s.position = 0
+	}
+
+	s.state = SenderRunning
+	s.pass = 1
+	s.err = nil
+	slog.Info("transfer started", "file", s.payload.Metadata.Filename, "frames", s.payload.FrameCount(), "pending", len(s.pending))
+	s.advance()
+	return nil
+}
+
+func (s *Sender) resume() {
+	if s.state != SenderPaused {
+		return
+	}
+	s.state = SenderRunning
+	s.ticker.Reset(s.config.Interval)
+	s.publish()
+}
+
+func (s *Sender) seek(frame int) {
+	if s.payload == nil {
+		return
+	}
+
+	s.position = min(max(frame, 0), s.payload.FrameCount()-1)
+	s.renderer.Reset()
+
+	if s.state.Active() {
+		s.advance()
+		return
+	}
+	s.publish()
+}
+
+func (s *Sender) rewind() bool {
+	if len(s.queue) > 0 {
+		next := s.queuePos + 1
+		if next >= len(s.queue) {
+			if !s.config.Loop {
+				return false
+			}
+			next = 0
+			s.pass++
+		}
+
+		if err := s.loadQueue(next); err != nil {
+			s.err = err
+			return false
+		}
+		return true
+	}
+
+	if !s.config.Loop {
+		return false
+	}
+	s.position = 0
+	s.pass++
+	return true
+}
+
+func (s *Sender) finish() {
+	s.state = SenderFinished
+	if s.err != nil {
+		s.state = SenderFailed
+		slog.Error("transfer failed", "err", s.err)
+	} else {
+		slog.Info("transfer finished", "passes", s.pass)
+	}
+	s.publish()
+}
+
+func (s *Sender) fail(err error) {
+	s.err = err
+	s.finish()
+}
+
+func (s *Sender) advance() {
+	retransmit := len(s.pending) > 0
+
+	if !retransmit && s.position >= s.payload.FrameCount() && !s.rewind() {
+		s.finish()
+		return
+	}
+
+	var f *preparedFrame
+	if retransmit {
+		f = newPreparedFrame(s.payload, s.frameSettings())
+		f.chunk = s.pending[0]
+		s.pending = s.pending[1:]
+		if f.data, f.err = s.serialize(f.chunk); f.err == nil {
+			f.caption = s.payload.Caption(f.chunk, false)
+			f.encoded, f.err = EncodeFrame(f.data, f.settings.config, f.settings.projector)
+		}
+	} else {
+		f = s.prepared(s.position)
+	}
+	if f.err != nil {
+		s.fail(f.err)
+		return
+	}
+
+	s.renderer.Caption = s.config.Caption
+
+	s.shown, s.caption, s.encoded = f.data, f.caption, f.encoded
+	if err := s.show(); err != nil {
+		s.fail(err)
+		return
+	}
+	if s.config.EInk {
+		s.settle.Reset(SettleTime(s.config.Interval))
+	}
+	s.sent++
+
+	slog.Debug("frame shown", "position", s.position, "index", f.chunk.Index, "retransmit", retransmit, "bytes", len(f.data))
+	if !retransmit {
+		s.position++
+	}
+
+	s.ticker.Reset(s.config.Interval)
+	s.publish()
+	s.prefetch()
+}
+
+func (s *Sender) serialize(c chunk.Chunk) ([]byte, error) {
+	frame, err := s.payload.proc.AppendChunk(s.frame[:0], c)
+	if err != nil {
+		return nil, err
+	}
+	s.frame = frame
+	return frame, nil
+}
+
+func (s *Sender) show() error {
+	img, err := s.renderer.Draw(s.encoded, s.surface.Size(), s.caption)
+	if err != nil {
+		return err
+	}
+	return s.surface.Show(img)
+}
+
+func (s *Sender) settled() {
+	if !s.config.EInk || s.shown == nil {
+		return
+	}
+
+	s.renderer.Marker = !s.renderer.Marker
+	if err := s.show(); err != nil {
+		s.fail(err)
+		return
+	}
+	slog.Debug("frame settled", "position", s.position, "marker", s.renderer.Marker)
+}
@@ -0,0 +1,248 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"qrtransfer/pkg/chunk"
+)
+
+type SessionInfo struct {
+	ID        uint64
+	Metadata  chunk.FileMetadata
+	Received  int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+type session struct {
+	id        uint64
+	received  map[uint32]bool
+	parity    map[uint32]bool
+	failed    map[uint32]int
+	copies    map[uint32]int
+	seen      map[uint32]time.Time
+	data      map[uint32][]byte
+	bytes     uint64
+	spool     *spool
+	metadata  chunk.FileMetadata
+	firstSeen time.Time
+	lastSeen  time.Time
+
+	manifestParts map[uint32][]byte
+	manifestCount uint32
+	manifest      *Manifest
+	manifestErr   error
+	verified      bool
+	sealed        bool
+	described     bool
+
+	sums   map[uint32]uint64
+	totals map[uint32]uint32
+	hash   *[32]byte
+}
+
+func newSession(id uint64) *session {
+	return &session{
+		id:       id,
+		received: make(map[uint32]bool),
+		parity:   make(map[uint32]bool),
+		failed:   make(map[uint32]int),
+		copies:   make(map[uint32]int),
+		seen:     make(map[uint32]time.Time),
+		data:     make(map[uint32][]byte),
+		sums:     make(map[uint32]uint64),
+	}
+}
+
+func (s *session) idle() bool {
+	if !s.described {
+		return len(s.received) == 0 && len(s.parity) == 0
+	}
+	return len(s.received) >= int(s.metadata.TotalChunks)
+}
+
+func (s *session) complete() bool {
+	total := int(s.metadata.TotalChunks)
+	if !s.described || len(s.received)+len(s.parity) < total {
+		return false
+	}
+	if len(s.received) < total && chunk.Recoverable(s.received, s.parity, s.metadata) < total {
+		return false
+	}
+	return s.verification().Status != ManifestPending
+}
+
+func (s *session) states() []ChunkState {
+	states := make([]ChunkState, s.metadata.TotalChunks)
+	for i := range states {
+		switch {
+		case s.received[uint32(i)]:
+			states[i] = ChunkVerified
+		case s.failed[uint32(i)] > 0:
+			states[i] = ChunkReceived
+		}
+	}
+	return states
+}
+
+func (s *session) info() SessionInfo {
+	return SessionInfo{
+		ID:        s.id,
+		Metadata:  s.metadata,
+		Received:  len(s.received),
+		FirstSeen: s.firstSeen,
+		LastSeen:  s.lastSeen,
+	}
+}
+
+func (s *session) store(c chunk.Chunk) {
+	if s.spool != nil && s.spool.fits(c) {
+		err := s.spool.write(c)
+		if err == nil {
+			chunk.Release(c)
+			return
+		}
+		slog.Warn("spooling chunk failed, keeping it in memory", "index", c.Index, "err", err)
+	}
+	s.data[c.Index] = c.Data
+}
+
+func (s *session) load(index uint32) ([]byte, error) {
+	if data, ok := s.data[index]; ok {
+		return data, nil
+	}
+	if s.spool == nil {
+		return nil, fmt.Errorf("chunk %d has no data", index)
+	}
+	return s.spool.read(index)
+}
+
+func (s *session) length(index uint32) int {
+	if data, ok := s.data[index]; ok {
+		return len(data)
+	}
+	if s.spool != nil {
+		return s.spool.length(index)
+	}
+	return 0
+}
+
+func (s *session) startSpool(dir string) {
+	if dir == "" || s.spool != nil || s.metadata.ChunkSize == 0 {
+		return
+	}
+
+	sp, err := openSpool(spoolPath(dir, s.id), os.O_RDWR|os.O_CREATE|os.O_TRUNC, s.metadata, nil)
+	if err != nil {
+		slog.Warn("spool unavailable, keeping chunks in memory", "err", err)
+		return
+	}
+	s.spool = sp
+
+	for index, data := range s.data {
+		c := chunk.Chunk{Index: index, Data: data}
+		if !sp.fits(c) {
+			continue
+		}
+		if err := sp.write(c); err != nil {
+			slog.Warn("spooling chunk failed, keeping it in memory", "index", index, "err", err)
+			continue
+		}
+		delete(s.data, index)
+	}
+}
+
+func (s *session) discard(wipe bool) {
+	if wipe {
+		s.wipe()
+	}
+	if s.spool == nil {
+		return
+	}
+	if wipe {
+		if err := s.spool.shred(); err != nil {
+			slog.Warn("overwriting spool failed", "path", s.spool.path, "err", err)
+		}
+	}
+	if err := s.spool.remove(); err != nil {
+		slog.Warn("removing spool failed", "path", s.spool.path, "err", err)
+	}
+	s.spool = nil
+}
+
+func (r *Receiver) Sessions() []SessionInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	infos := make([]SessionInfo, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		infos = append(infos, s.info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].FirstSeen.Before(infos[j].FirstSeen)
+	})
+	return infos
+}
+
+func (r *Receiver) Progress() chunk.Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.cur
+	return chunk.CalculateProgress(uint32(len(s.received)), s.metadata.TotalChunks, s.bytes, s.metadata.FileSize)
+}
+
+func (r *Receiver) FileHash() ([32]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cur.hash == nil {
+		return [32]byte{}, false
+	}
+	return *r.cur.hash, true
+}
+
+func (r *Receiver) Sealed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cur.sealed
+}
+
+func (r *Receiver) Session() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cur.id
+}
+
+func (r *Receiver) Select(id uint64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[id]
+	if !ok {
+		return false
+	}
+	r.cur = s
+	r.pinned = true
+	r.dirty = true
+	return true
+}
+
+func (r *Receiver) session(id uint64) *session {
+	s, ok := r.sessions[id]
+	if !ok {
+		s = newSession(id)
+		s.firstSeen = time.Now()
+		r.sessions[id] = s
+	}
+	s.lastSeen = time.Now()
+
+	if s != r.cur && !r.pinned && r.cur.idle() {
+		r.cur = s
+	}
+	return s
+}
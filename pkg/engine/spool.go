@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"qrtransfer/pkg/chunk"
+)
+
+const spoolExt = ".spool"
+
+type spool struct {
+	path     string
+	f        *os.File
+	metadata chunk.FileMetadata
+	parity   map[uint32]int64
+}
+
+func spoolPath(dir string, id uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%016x%s", id, spoolExt))
+}
+
+func openSpool(path string, flag int, metadata chunk.FileMetadata, parity map[uint32]int64) (*spool, error) {
+	if metadata.ChunkSize == 0 {
+		return nil, fmt.Errorf("spool %s: chunk size unknown", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, flag, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if parity == nil {
+		parity = make(map[uint32]int64)
+	}
+	return &spool{path: path, f: f, metadata: metadata, parity: parity}, nil
+}
+
+func (s *spool) dataSize() int64 {
+	return int64(s.metadata.TotalChunks) * int64(s.metadata.ChunkSize)
+}
+
+func (s *spool) length(index uint32) int {
+	if chunk.IsParity(chunk.Chunk{Index: index}) {
+		return int(s.metadata.ChunkSize)
+	}
+	return s.metadata.ChunkLength(index)
+}
+
+func (s *spool) offset(index uint32) (int64, bool) {
+	if chunk.IsParity(chunk.Chunk{Index: index}) {
+		off, ok := s.parity[index]
+		return off, ok
+	}
+	if index >= s.metadata.TotalChunks {
+		return 0, false
+	}
+	return int64(index) * int64(s.metadata.ChunkSize), true
+}
+
+func (s *spool) fits(c chunk.Chunk) bool {
+	if chunk.IsParity(c) {
+		return len(c.Data) == int(s.metadata.ChunkSize)
+	}
+	return c.Index < s.metadata.TotalChunks && len(c.Data) == s.length(c.Index)
+}
+
+func (s *spool) write(c chunk.Chunk) error {
+	off, ok := s.offset(c.Index)
+	if !ok && chunk.IsParity(c) {
+		off = s.dataSize() + int64(len(s.parity))*int64(s.metadata.ChunkSize)
+		ok = true
+	}
+	if !ok || !s.fits(c) {
+		return fmt.Errorf("chunk %d does not fit the spool", c.Index)
+	}
+
+	if _, err := s.f.WriteAt(c.Data, off); err != nil {
+		return err
+	}
+	if chunk.IsParity(c) {
+		s.parity[c.Index] = off
+	}
+	return nil
+}
+
+func (s *spool) read(index uint32) ([]byte, error) {
+	off, ok := s.offset(index)
+	if !ok {
+		return nil, fmt.Errorf("chunk %d is not spooled", index)
+	}
+
+	data := make([]byte, s.length(index))
+	if _, err := s.f.ReadAt(data, off); err != nil {
+		return nil, fmt.Errorf("reading chunk %d from spool: %w", index, err)
+	}
+	return data, nil
+}
+
+func (s *spool) close() error {
+	return s.f.Close()
+}
+
+func (s *spool) remove() error {
+	s.f.Close()
+	return os.Remove(s.path)
+}
+
+func (r *Receiver) PruneSpool() error {
+	if r.SpoolDir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(r.SpoolDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	keep := make(map[string]bool)
+	for _, s := range r.sessions {
+		if s.spool != nil {
+			keep[filepath.Base(s.spool.path)] = true
+		}
+	}
+	r.mu.Unlock()
+
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), spoolExt) || keep[e.Name()] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(r.SpoolDir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"slices"
+
+	"qrtransfer/pkg/qr"
+)
+
+const (
+	statusMagic   = "OWLS"
+	statusVersion = 1
+
+	MaxStatusRanges = 64
+
+	maxStatusMissing = 1 << 20
+)
+
+var ErrNotStatus = errors.New("not a receiver status code")
+
+var BackchannelConfig = qr.Config{ErrorLevel: qr.ErrorLevelLow}
+
+type ReceiverStatus struct {
+	Session   uint64
+	Total     uint32
+	Received  uint32
+	Missing   []uint32
+	Truncated bool
+}
+
+func IsStatus(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(statusMagic))
+}
+
+func (s ReceiverStatus) MarshalBinary() ([]byte, error) {
+	buf := []byte(statusMagic)
+	buf = append(buf, statusVersion)
+	buf = binary.BigEndian.AppendUint64(buf, s.Session)
+	buf = binary.BigEndian.AppendUint32(buf, s.Total)
+	buf = binary.BigEndian.AppendUint32(buf, s.Received)
+
+	ranges := missingRanges(s.Missing)
+	truncated := s.Truncated || len(ranges) > MaxStatusRanges
+	if len(ranges) > MaxStatusRanges {
+		ranges = ranges[:MaxStatusRanges]
+	}
+	flags := byte(0)
+	if truncated {
+		flags = 1
+	}
+	buf = append(buf, flags)
+
+	buf = binary.AppendUvarint(buf, uint64(len(ranges)))
+	next := uint32(0)
+	for _, r := range ranges {
+		buf = binary.AppendUvarint(buf, uint64(r[0]-next))
+		buf = binary.AppendUvarint(buf, uint64(r[1]-r[0]))
+		next = r[1] + 1
+	}
+
+	return binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf)), nil
+}
+
+func ParseStatus(data []byte) (ReceiverStatus, error) {
+	var s ReceiverStatus
+	const header = len(statusMagic) + 1 + 8 + 4 + 4 + 1
+	if !IsStatus(data) || len(data) < header+4 {
+		return s, ErrNotStatus
+	}
+
+	end := header
+	count, n := binary.Uvarint(data[end:])
+	if n <= 0 || count > MaxStatusRanges {
+		return s, fmt.Errorf("%w: bad range count", ErrNotStatus)
+	}
+	end += n
+	ranges := end
+	for range 2 * count {
+		if _, n = binary.Uvarint(data[end:]); n <= 0 {
+			return s, fmt.Errorf("%w: truncated ranges", ErrNotStatus)
+		}
+		end += n
+	}
+
+	if len(data) < end+4 || crc32.ChecksumIEEE(data[:end]) != binary.BigEndian.Uint32(data[end:]) {
+		return s, fmt.Errorf("%w: checksum mismatch", ErrNotStatus)
+	}
+	if v := data[len(statusMagic)]; v != statusVersion {
+		return s, fmt.Errorf("unsupported status version %d", v)
+	}
+
+	p := data[len(statusMagic)+1:]
+	s.Session = binary.BigEndian.Uint64(p)
+	s.Total = binary.BigEndian.Uint32(p[8:])
+	s.Received = binary.BigEndian.Uint32(p[12:])
+	s.Truncated = p[16]&1 != 0
+	p = data[ranges:end]
+
+	next := uint64(0)
+	for range count {
+		gap, n := binary.Uvarint(p)
+		if n <= 0 {
+			return s, fmt.Errorf("%w: truncated ranges", ErrNotStatus)
+		}
+		p = p[n:]
+		length, n := binary.Uvarint(p)
+		if n <= 0 {
+			return s, fmt.Errorf("%w: truncated ranges", ErrNotStatus)
+		}
+		p = p[n:]
+
+		start := next + gap
+		end := start + length
+		if end >= uint64(s.Total) {
+			return s, fmt.Errorf("%w: range %d-%d beyond %d chunks", ErrNotStatus, start, end, s.Total)
+		}
+		if uint64(len(s.Missing))+length >= maxStatusMissing {
+			return s, fmt.Errorf("%w: more than %d missing chunks", ErrNotStatus, maxStatusMissing)
+		}
+		for i := start; i <= end; i++ {
+			s.Missing = append(s.Missing, uint32(i))
+		}
+		next = end + 1
+	}
+	return s, nil
+}
+
+func missingRanges(indices []uint32) [][2]uint32 {
+	var ranges [][2]uint32
+	for _, i := range slices.Compact(slices.Sorted(slices.Values(indices))) {
+		if n := len(ranges); n > 0 && ranges[n-1][1]+1 == i {
+			ranges[n-1][1] = i
+			continue
+		}
+		ranges = append(ranges, [2]uint32{i, i})
+	}
+	return ranges
+}
+
+func (r *Receiver) Status() ReceiverStatus {
+	metadata := r.Metadata()
+	s := ReceiverStatus{
+		Session:  r.Session(),
+		Total:    metadata.TotalChunks,
+		Received: uint32(r.Received()),
+	}
+
+	ranges := missingRanges(r.Missing())
+	if len(ranges) > MaxStatusRanges {
+		ranges = ranges[:MaxStatusRanges]
+		s.Truncated = true
+	}
+	for _, rg := range ranges {
+		for i := rg[0]; i <= rg[1]; i++ {
+			s.Missing = append(s.Missing, i)
+		}
+	}
+	return s
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"image"
+	"slices"
+	"testing"
+
+	"qrtransfer/pkg/qr"
+)
+
+func renderAndDecode(t *testing.T, data []byte, config qr.Config) []byte {
+	t.Helper()
+	frame, err := EncodeFrame(data, config, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := NewRenderer(config).Draw(frame, image.Pt(400, 400), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec := qr.NewDecoder(frame.Config)
+	blocks, err := dec.Decode(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dec.BlocksToData(blocks)
+}
+
+func TestStatusCodeRoundTrip(t *testing.T) {
+	want := ReceiverStatus{Session: 1792042983494825472, Total: 500, Received: 480, Missing: []uint32{3, 4, 5, 97, 250, 499}}
+	data, err := want.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseStatus(renderAndDecode(t, data, BackchannelConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Session != want.Session || got.Total != want.Total || got.Received != want.Received || !slices.Equal(got.Missing, want.Missing) {
+		t.Errorf("parsed %+v, want %+v", got, want)
+	}
+}
+
+func TestParseStatusRejectsCorruption(t *testing.T) {
+	data, err := ReceiverStatus{Session: 7, Total: 10, Missing: []uint32{2}}.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(statusMagic)+3] ^= 0x10
+	if _, err := ParseStatus(append(data, 0, 0, 0)); err == nil {
+		t.Error("corrupted status parsed without error")
+	}
+}
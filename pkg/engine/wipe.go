@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"log/slog"
+
+	"qrtransfer/pkg/chunk"
+)
+
+const shredBlock = 64 << 10
+
+func (p *Payload) Wipe() {
+	for _, copies := range p.Chunks {
+		for _, c := range copies {
+			clear(c.Data)
+		}
+	}
+	for _, f := range p.Frames {
+		clear(f.Data)
+	}
+	for _, frame := range p.control {
+		clear(frame)
+	}
+}
+
+func (s *session) wipe() {
+	for _, data := range s.data {
+		clear(data)
+	}
+	clear(s.data)
+	clear(s.received)
+	clear(s.parity)
+	clear(s.sums)
+	s.bytes = 0
+}
+
+func (sp *spool) shred() error {
+	info, err := sp.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	zeros := make([]byte, shredBlock)
+	for off := int64(0); off < info.Size(); off += shredBlock {
+		n := min(info.Size()-off, shredBlock)
+		if _, err := sp.f.WriteAt(zeros[:n], off); err != nil {
+			return err
+		}
+	}
+	return sp.f.Sync()
+}
+
+func (r *Receiver) Discard(id uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.discard(id)
+}
+
+func (r *Receiver) discard(id uint64) {
+	s, ok := r.sessions[id]
+	if !ok {
+		return
+	}
+	s.discard(r.wipe)
+	delete(r.sessions, id)
+	if r.discarded == nil {
+		r.discarded = make(map[uint64]bool)
+	}
+	r.discarded[id] = true
+	if s == r.cur {
+		r.cur = newSession(0)
+		r.pinned = false
+	}
+	r.dirty = true
+	slog.Info("transfer discarded", "session", id, "wiped", r.wipe)
+}
+
+func (r *Receiver) wipeChunk(c chunk.Chunk) {
+	if r.wipe && c.Sealed {
+		clear(c.Data)
+	}
+	chunk.Release(c)
+}
+
+func (r *Receiver) wipeLoaded(s *session, index uint32, data []byte) {
+	if !r.wipe || len(data) == 0 {
+		return
+	}
+	if kept := s.data[index]; len(kept) > 0 && &kept[0] == &data[0] {
+		return
+	}
+	clear(data)
+}
+
+func (r *Receiver) SetSecureWipe(wipe bool) {
+	r.mu.Lock()
+	r.wipe = wipe
+	r.mu.Unlock()
+}
+
+func (r *Receiver) SecureWipe() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.wipe
+}
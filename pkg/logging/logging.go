@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	DefaultHistory = 500
+
+	debugEnv = "QRTRANSFER_DEBUG"
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+var levels = []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+
+func Levels() []string {
+	return append([]string(nil), levelNames...)
+}
+
+func DefaultLevel() string {
+	if os.Getenv(debugEnv) != "" {
+		return "debug"
+	}
+	return "warn"
+}
+
+func ParseLevel(name string) (slog.Level, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			return levels[i], nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q", name)
+}
+
+func LevelName(level slog.Level) string {
+	for i, l := range levels {
+		if level <= l {
+			return levelNames[i]
+		}
+	}
+	return levelNames[len(levelNames)-1]
+}
+
+type History struct {
+	mu      sync.Mutex
+	lines   []string
+	limit   int
+	partial string
+	onLine  func(string)
+}
+
+func NewHistory(limit int) *History {
+	return &History{limit: max(limit, 1)}
+}
+
+func (h *History) Write(p []byte) (int, error) {
+	h.mu.Lock()
+	text := h.partial + string(p)
+	lines := strings.Split(text, "\n")
+	h.partial = lines[len(lines)-1]
+	lines = lines[:len(lines)-1]
+
+	h.lines = append(h.lines, lines...)
+	if over := len(h.lines) - h.limit; over > 0 {
+		h.lines = append(h.lines[:0], h.lines[over:]...)
+	}
+	onLine := h.onLine
+	h.mu.Unlock()
+
+	if onLine != nil {
+		for _, line := range lines {
+			onLine(line)
+		}
+	}
+	return len(p), nil
+}
+
+func (h *History) Lines() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.lines...)
+}
+
+func (h *History) Clear() {
+	h.mu.Lock()
+	h.lines = nil
+	h.mu.Unlock()
+}
+
+func (h *History) OnLine(fn func(string)) {
+	h.mu.Lock()
+	h.onLine = fn
+	h.mu.Unlock()
+}
+
+type Log struct {
+	Level   *slog.LevelVar
+	History *History
+}
+
+func Setup(level slog.Level, w io.Writer) *Log {
+	l := &Log{
+		Level:   new(slog.LevelVar),
+		History: NewHistory(DefaultHistory),
+	}
+	l.Level.Set(level)
+
+	handler := slog.NewTextHandler(io.MultiWriter(w, l.History), &slog.HandlerOptions{Level: l.Level})
+	slog.SetDefault(slog.New(handler))
+	return l
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type Kind string
+
+const (
+	Counter Kind = "counter"
+	Gauge   Kind = "gauge"
+)
+
+type Sample struct {
+	Name  string
+	Help  string
+	Kind  Kind
+	Value float64
+}
+
+func Write(w io.Writer, samples []Sample) error {
+	bw := bufio.NewWriter(w)
+	for _, s := range samples {
+		fmt.Fprintf(bw, "# HELP %s %s\n", s.Name, s.Help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", s.Name, s.Kind)
+		fmt.Fprintf(bw, "%s %s\n", s.Name, strconv.FormatFloat(s.Value, 'g', -1, 64))
+	}
+	return bw.Flush()
+}
+
+func Handler(collect func() []Sample) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		if err := Write(w, collect()); err != nil {
+			slog.Debug("writing metrics failed", "err", err)
+		}
+	})
+}
+
+func Serve(addr string, collect func() []Sample) error {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", Handler(collect))
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return server.ListenAndServe()
+}
@@ -0,0 +1,254 @@
+package paper
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+)
+
+const (
+	margin      = 36.0
+	gutter      = 18.0
+	headerSize  = 10.0
+	labelSize   = 9.0
+	coverSize   = 12.0
+	titleSize   = 20.0
+	lineSpacing = 1.5
+)
+
+var ErrNoFrames = errors.New("no frames to lay out")
+
+type Size struct {
+	Name          string
+	Width, Height float64
+}
+
+var (
+	A4     = Size{Name: "a4", Width: 595.28, Height: 841.89}
+	Letter = Size{Name: "letter", Width: 612, Height: 792}
+)
+
+func ParseSize(name string) (Size, error) {
+	for _, s := range []Size{A4, Letter} {
+		if strings.EqualFold(name, s.Name) {
+			return s, nil
+		}
+	}
+	return Size{}, fmt.Errorf("unknown paper size %q (want a4 or letter)", name)
+}
+
+type Frame struct {
+	Image image.Image
+	Label string
+}
+
+type Document struct {
+	Title   string
+	Cover   []string
+	Frames  []Frame
+	Size    Size
+	Columns int
+}
+
+type layout struct {
+	side, cellHeight float64
+	columns, rows    int
+	top              float64
+}
+
+func (d Document) layout() layout {
+	columns := max(d.Columns, 1)
+	side := (d.Size.Width - 2*margin - float64(columns-1)*gutter) / float64(columns)
+	cellHeight := side + labelSize*lineSpacing
+	top := d.Size.Height - margin - headerSize*lineSpacing*2
+	rows := max(int((top-margin+gutter)/(cellHeight+gutter)), 1)
+	return layout{side: side, cellHeight: cellHeight, columns: columns, rows: rows, top: top}
+}
+
+func (d Document) PerPage() int {
+	l := d.layout()
+	return l.columns * l.rows
+}
+
+func (d Document) Pages() int {
+	per := d.PerPage()
+	return 1 + (len(d.Frames)+per-1)/per
+}
+
+func Write(w io.Writer, d Document) error {
+	if len(d.Frames) == 0 {
+		return ErrNoFrames
+	}
+	if d.Size.Width <= 0 || d.Size.Height <= 0 {
+		d.Size = A4
+	}
+
+	p := newPDFWriter(w)
+	p.header()
+	catalog, pages, font := p.reserve(), p.reserve(), p.reserve()
+	p.object(font, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+
+	var kids []int
+	kids = append(kids, p.page(pages, font, d.coverPage(), nil))
+
+	l := d.layout()
+	total := d.Pages()
+	per := d.PerPage()
+	for start, n := 0, 2; start < len(d.Frames); start, n = start+per, n+1 {
+		frames := d.Frames[start:min(start+per, len(d.Frames))]
+
+		var content bytes.Buffer
+		text(&content, margin, d.Size.Height-margin-headerSize, headerSize, fmt.Sprintf("%s - page %d/%d", d.Title, n, total))
+
+		images := make([]int, len(frames))
+		for i, f := range frames {
+			images[i] = p.image(f.Image)
+			col, row := i%l.columns, i/l.columns
+			x := margin + float64(col)*(l.side+gutter)
+			y := l.top - float64(row)*(l.cellHeight+gutter) - l.side
+			fmt.Fprintf(&content, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", l.side, l.side, x, y, i)
+			text(&content, x, y-labelSize*lineSpacing, labelSize, f.Label)
+		}
+		kids = append(kids, p.page(pages, font, content.Bytes(), images))
+	}
+
+	refs := make([]string, len(kids))
+	for i, k := range kids {
+		refs[i] = fmt.Sprintf("%d 0 R", k)
+	}
+	p.object(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %.2f %.2f] >>", strings.Join(refs, " "), len(kids), d.Size.Width, d.Size.Height))
+	p.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	return p.finish(catalog)
+}
+
+func (d Document) coverPage() []byte {
+	var content bytes.Buffer
+	y := d.Size.Height - margin - titleSize
+	text(&content, margin, y, titleSize, d.Title)
+	y -= titleSize * lineSpacing
+	for _, line := range d.Cover {
+		y -= coverSize * lineSpacing
+		text(&content, margin, y, coverSize, line)
+	}
+	return content.Bytes()
+}
+
+func text(w *bytes.Buffer, x, y, size float64, s string) {
+	fmt.Fprintf(w, "BT /F1 %.1f Tf %.2f %.2f Td (%s) Tj ET\n", size, x, y, escape(s))
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+type pdfWriter struct {
+	w       *bufio.Writer
+	offset  int
+	offsets []int
+	err     error
+}
+
+func newPDFWriter(w io.Writer) *pdfWriter {
+	return &pdfWriter{w: bufio.NewWriter(w)}
+}
+
+func (p *pdfWriter) write(format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(p.w, format, args...)
+	p.offset += n
+	p.err = err
+}
+
+func (p *pdfWriter) header() {
+	p.write("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+}
+
+func (p *pdfWriter) reserve() int {
+	p.offsets = append(p.offsets, 0)
+	return len(p.offsets)
+}
+
+func (p *pdfWriter) object(id int, body string) {
+	p.offsets[id-1] = p.offset
+	p.write("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (p *pdfWriter) stream(id int, dict string, data []byte) {
+	p.offsets[id-1] = p.offset
+	p.write("%d 0 obj\n<< %s /Length %d >>\nstream\n", id, dict, len(data))
+	if p.err == nil {
+		n, err := p.w.Write(data)
+		p.offset += n
+		p.err = err
+	}
+	p.write("\nendstream\nendobj\n")
+}
+
+func (p *pdfWriter) page(parent, font int, content []byte, images []int) int {
+	contents := p.reserve()
+	p.stream(contents, "/Filter /FlateDecode", deflate(content))
+
+	var xobjects strings.Builder
+	for i, img := range images {
+		fmt.Fprintf(&xobjects, " /Im%d %d 0 R", i, img)
+	}
+
+	id := p.reserve()
+	p.object(id, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /Resources << /Font << /F1 %d 0 R >> /XObject <<%s >> >> /Contents %d 0 R >>", parent, font, xobjects.String(), contents))
+	return id
+}
+
+func (p *pdfWriter) image(img image.Image) int {
+	b := img.Bounds()
+	pix := make([]byte, 0, b.Dx()*b.Dy()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			pix = append(pix, byte(r>>8), byte(g>>8), byte(bl>>8))
+		}
+	}
+
+	id := p.reserve()
+	p.stream(id, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Interpolate false /Filter /FlateDecode", b.Dx(), b.Dy()), deflate(pix))
+	return id
+}
+
+func (p *pdfWriter) finish(root int) error {
+	xref := p.offset
+	p.write("xref\n0 %d\n0000000000 65535 f \n", len(p.offsets)+1)
+	for _, off := range p.offsets {
+		p.write("%010d 00000 n \n", off)
+	}
+	p.write("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(p.offsets)+1, root, xref)
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.Flush()
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,64 @@
+package qr
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	captionPadding   = 3
+	captionReference = 400
+)
+
+func CaptionScale(frameHeight int) int {
+	return max(1, frameHeight/captionReference)
+}
+
+func CaptionHeight(scale int) int {
+	return (basicfont.Face7x13.Height + 2*captionPadding) * scale
+}
+
+func AddCaption(img image.Image, text string, scale int) *image.RGBA {
+	bounds := img.Bounds()
+	strip := CaptionHeight(scale)
+
+	out := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()+strip))
+	draw.Draw(out, out.Bounds(), &image.Uniform{QuietZoneColor}, image.Point{}, draw.Src)
+	draw.Draw(out, image.Rect(0, 0, bounds.Dx(), bounds.Dy()), img, bounds.Min, draw.Src)
+
+	face := basicfont.Face7x13
+	maxChars := bounds.Dx() / scale / face.Advance
+	if maxChars <= 0 {
+		return out
+	}
+	if len(text) > maxChars {
+		text = text[:max(maxChars-3, 0)] + "..."
+	}
+
+	width := len(text) * face.Advance
+	line := image.NewRGBA(image.Rect(0, 0, width, face.Height+2*captionPadding))
+	draw.Draw(line, line.Bounds(), &image.Uniform{QuietZoneColor}, image.Point{}, draw.Src)
+
+	d := font.Drawer{
+		Dst:  line,
+		Src:  image.NewUniform(color.Gray{Y: 64}),
+		Face: face,
+		Dot:  fixed.P(0, captionPadding+face.Ascent),
+	}
+	d.DrawString(text)
+
+	left := (bounds.Dx() - width*scale) / 2
+	top := bounds.Dy()
+	for y := 0; y < line.Bounds().Dy()*scale; y++ {
+		for x := 0; x < width*scale; x++ {
+			out.SetRGBA(left+x, top+y, line.RGBAAt(x/scale, y/scale))
+		}
+	}
+
+	return out
+}
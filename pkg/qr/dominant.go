@@ -0,0 +1,45 @@
+package qr
+
+import (
+	"image"
+	"image/color"
+)
+
+const dominantBinBits = 3
+
+func DominantColor(img *image.RGBA, rect image.Rectangle) color.RGBA {
+	r, g, b := dominant(img, rect)
+	return color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 255}
+}
+
+func dominant(img *image.RGBA, rect image.Rectangle) (int, int, int) {
+	const shift = 8 - dominantBinBits
+	var counts [1 << (3 * dominantBinBits)]int
+	var sums [len(counts)][3]int
+
+	rect = rect.Intersect(img.Rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(rect.Min.X, y):]
+		for i := 0; i < 4*rect.Dx(); i += 4 {
+			r, g, b := row[i], row[i+1], row[i+2]
+			bin := int(r>>shift)<<(2*dominantBinBits) | int(g>>shift)<<dominantBinBits | int(b>>shift)
+			counts[bin]++
+			sums[bin][0] += int(r)
+			sums[bin][1] += int(g)
+			sums[bin][2] += int(b)
+		}
+	}
+
+	best := 0
+	for bin, n := range counts {
+		if n > counts[best] {
+			best = bin
+		}
+	}
+
+	n := counts[best]
+	if n == 0 {
+		return 0, 0, 0
+	}
+	return (sums[best][0] + n/2) / n, (sums[best][1] + n/2) / n, (sums[best][2] + n/2) / n
+}
@@ -1,10 +1,23 @@
 package qr
 
 import (
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
+	"log/slog"
 	"math"
+	"strings"
+)
+
+const (
+	DefaultMinBlockPixels = 4
+	DefaultTolerance      = 0.25
+)
+
+var (
+	ErrBlocksTooSmall = errors.New("payload too large for output size, reduce chunk size")
 )
 
 type Block struct {
@@ -12,14 +25,23 @@ type Block struct {
 }
 
 type Config struct {
-	BlockSize     int
-	GridWidth     int
-	GridHeight    int
-	BorderSize    int
-	ErrorLevel    ErrorLevel
-	UseColors     bool
+	BlockSize      int
+	GridWidth      int
+	GridHeight     int
+	BorderSize     int
+	ErrorLevel     ErrorLevel
+	UseColors      bool
+	MinBlockPixels int
+	Tolerance      float64
+	SampleKernel   int
+	SampleDominant bool
+	Monochrome     bool
+	Palette        bool
+	Timing         bool
 }
 
+var QuietZoneColor = color.RGBA{255, 255, 255, 255}
+
 type ErrorLevel int
 
 const (
@@ -28,6 +50,41 @@ const (
 	ErrorLevelHigh
 )
 
+var errorLevelNames = []string{"low", "medium", "high"}
+
+func (l ErrorLevel) String() string {
+	if l < 0 || int(l) >= len(errorLevelNames) {
+		return fmt.Sprintf("ErrorLevel(%d)", int(l))
+	}
+	return errorLevelNames[l]
+}
+
+func ParseErrorLevel(name string) (ErrorLevel, error) {
+	for i, n := range errorLevelNames {
+		if strings.EqualFold(name, n) {
+			return ErrorLevel(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown error level %q", name)
+}
+
+func (l ErrorLevel) BitsPerChannel() int {
+	switch l {
+	case ErrorLevelMedium:
+		return 6
+	case ErrorLevelHigh:
+		return 4
+	}
+	return 8
+}
+
+func (l ErrorLevel) Capacity(blocks int) int {
+	if l.BitsPerChannel() < 8 {
+		return 0
+	}
+	return blocks * 3
+}
+
 type Encoder struct {
 	config Config
 }
@@ -37,6 +94,13 @@ func NewEncoder(config Config) *Encoder {
 }
 
 func (e *Encoder) Encode(data []byte) []Block {
+	if e.config.Monochrome {
+		return e.encodeMono(data)
+	}
+	if e.config.Palette {
+		return e.encodePalette(data)
+	}
+	
 	blocks := make([]Block, e.config.GridWidth*e.config.GridHeight)
 	
 	redBits := 8
@@ -103,10 +167,77 @@ func (e *Encoder) Encode(data []byte) []Block {
 	return blocks
 }
 
-func (e *Encoder) CreateImage(blocks []Block, width, height int) image.Image {
+func (c Config) minBlockPixels() int {
+	if c.MinBlockPixels <= 0 {
+		return DefaultMinBlockPixels
+	}
+	return c.MinBlockPixels
+}
+
+func (c Config) BlockPixelSize(width, height int) int {
+	cols := c.GridWidth + 2*c.BorderSize
+	rows := c.GridHeight + 2*c.BorderSize
+	if cols <= 0 || rows <= 0 {
+		return 0
+	}
+	
+	size := width / cols
+	if h := height / rows; h < size {
+		size = h
+	}
+	return size
+}
+
+func (c Config) CheckFit(width, height int) error {
+	size := c.BlockPixelSize(width, height)
+	if size < c.minBlockPixels() {
+		return fmt.Errorf("%w: %dx%d grid gives %dpx blocks at %dx%d, need at least %dpx",
+			ErrBlocksTooSmall, c.GridWidth, c.GridHeight, size, width, height, c.minBlockPixels())
+	}
+	return nil
+}
+
+func MaxGridSize(width, height, borderSize, minBlockPixels int) (int, int) {
+	if minBlockPixels <= 0 {
+		minBlockPixels = DefaultMinBlockPixels
+	}
+	
+	cols := width/minBlockPixels - 2*borderSize
+	rows := height/minBlockPixels - 2*borderSize
+	if cols < 0 {
+		cols = 0
+	}
+	if rows < 0 {
+		rows = 0
+	}
+	
+	return cols, rows
+}
+
+func MaxPayloadSize(width, height, borderSize, minBlockPixels int) int {
+	cols, rows := MaxGridSize(width, height, borderSize, minBlockPixels)
+	
+	side := min(cols, rows)
+	if side%2 == 0 {
+		side--
+	}
+	if side <= 0 {
+		return 0
+	}
+	
+	return side * side * 3
+}
+
+func (e *Encoder) CreateImage(blocks []Block, width, height int) (image.Image, error) {
+	if err := e.config.CheckFit(width, height); err != nil {
+		slog.Warn("frame does not fit output", "grid", fmt.Sprintf("%dx%d", e.config.GridWidth, e.config.GridHeight), "width", width, "height", height)
+		return nil, err
+	}
+	
 	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{QuietZoneColor}, image.Point{}, draw.Src)
 	
-	blockPixelSize := width / (e.config.GridWidth + 2*e.config.BorderSize)
+	blockPixelSize := e.config.BlockPixelSize(width, height)
 	
 	for y := 0; y < e.config.GridHeight; y++ {
 		for x := 0; x < e.config.GridWidth; x++ {
@@ -122,95 +253,94 @@ func (e *Encoder) CreateImage(blocks []Block, width, height int) image.Image {
 		}
 	}
 	
-	borderColor := color.RGBA{255, 255, 255, 255}
-	
-	borderWidth := e.config.BorderSize * blockPixelSize
-	borderRect := image.Rect(0, 0, width, borderWidth)
-	draw.Draw(img, borderRect, &image.Uniform{borderColor}, image.Point{}, draw.Src)
-	
-	borderRect = image.Rect(0, height-borderWidth, width, height)
-	draw.Draw(img, borderRect, &image.Uniform{borderColor}, image.Point{}, draw.Src)
-	
-	borderRect = image.Rect(0, 0, borderWidth, height)
-	draw.Draw(img, borderRect, &image.Uniform{borderColor}, image.Point{}, draw.Src)
-	
-	borderRect = image.Rect(width-borderWidth, 0, width, height)
-	draw.Draw(img, borderRect, &image.Uniform{borderColor}, image.Point{}, draw.Src)
+	if e.config.Timing {
+		e.drawTiming(img, blockPixelSize)
+	}
 	
-	return img
+	return img, nil
 }
 
 type Decoder struct {
 	config Config
+	
+	rgba     *image.RGBA
+	blocks   []Block
+	data     []byte
+	erasures []int
 }
 
 func NewDecoder(config Config) *Decoder {
 	return &Decoder{config: config}
 }
 
+func (d *Decoder) Reset() {
+	d.rgba = nil
+	d.blocks = nil
+	d.data = nil
+	d.erasures = nil
+}
+
 func (d *Decoder) Decode(img image.Image) ([]Block, error) {
-	bounds := img.Bounds()
+	blocks, _, err := d.DecodeWithStats(img)
+	return blocks, err
+}
+
+func (d *Decoder) DecodeWithStats(img image.Image) ([]Block, DecodeStats, error) {
+	rgba := d.toRGBA(img)
+	bounds := rgba.Rect
 	
-	blockPixelSize := bounds.Dx() / (d.config.GridWidth + 2*d.config.BorderSize)
+	blockPixelSize := d.config.BlockPixelSize(bounds.Dx(), bounds.Dy())
 	
-	blocks := make([]Block, d.config.GridWidth*d.config.GridHeight)
+	n := d.config.GridWidth * d.config.GridHeight
+	if cap(d.blocks) < n {
+		d.blocks = make([]Block, n)
+	}
+	blocks := d.blocks[:n]
+	stats := DecodeStats{Erasures: d.erasures[:0]}
 	
-	redBits := 8
-	greenBits := 8
-	blueBits := 8
+	bits := 8
 	
 	switch d.config.ErrorLevel {
 	case ErrorLevelLow:
-		redBits = 8
-		greenBits = 8
-		blueBits = 8
+		bits = 8
 	case ErrorLevelMedium:
-		redBits = 6
-		greenBits = 6
-		blueBits = 6
+		bits = 6
 	case ErrorLevelHigh:
-		redBits = 4
-		greenBits = 4
-		blueBits = 4
+		bits = 4
 	}
-	
-	redMask := (1 << redBits) - 1
-	greenMask := (1 << greenBits) - 1
-	blueMask := (1 << blueBits) - 1
+	if d.config.Monochrome || d.config.Palette {
+		bits = 1
+	}
+	ambiguous := d.config.ambiguousDistance(bits)
 	
 	for y := 0; y < d.config.GridHeight; y++ {
 		for x := 0; x < d.config.GridWidth; x++ {
-			startX := (x + d.config.BorderSize) * blockPixelSize
-			startY := (y + d.config.BorderSize) * blockPixelSize
-			
-			centerX := startX + blockPixelSize/2
-			centerY := startY + blockPixelSize/2
+			startX := bounds.Min.X + (x+d.config.BorderSize)*blockPixelSize
+			startY := bounds.Min.Y + (y+d.config.BorderSize)*blockPixelSize
 			
-			r, g, b, _ := img.At(centerX, centerY).RGBA()
-			
-			rVal := int(r >> 8)
-			gVal := int(g >> 8)
-			bVal := int(b >> 8)
+			r, g, b := d.sample(rgba, startX, startY, blockPixelSize)
+			if d.config.Monochrome {
+				r = gray(r, g, b)
+				g, b = r, r
+			}
 			
-			rShifted := (rVal * redMask) / 255
-			gShifted := (gVal * greenMask) / 255
-			bShifted := (bVal * blueMask) / 255
+			rExpanded, rDist := quantizeChannel(r, bits)
+			gExpanded, gDist := quantizeChannel(g, bits)
+			bExpanded, bDist := quantizeChannel(b, bits)
 			
-			rExpanded := (rShifted << (8 - redBits))
-			gExpanded := (gShifted << (8 - greenBits))
-			bExpanded := (bShifted << (8 - blueBits))
+			index := y*d.config.GridWidth + x
+			stats.BlocksRead++
 			
-			if rShifted == redMask {
-				rExpanded = 255
-			}
-			if gShifted == greenMask {
-				gExpanded = 255
-			}
-			if bShifted == blueMask {
-				bExpanded = 255
+			dist := max(rDist, gDist, bDist)
+			switch {
+			case dist > ambiguous:
+				stats.BlocksUncorrectable++
+				stats.Erasures = append(stats.Erasures, index)
+			case dist > 0:
+				stats.BlocksCorrected++
 			}
 			
-			blocks[y*d.config.GridWidth+x] = Block{
+			blocks[index] = Block{
 				R: uint8(rExpanded),
 				G: uint8(gExpanded),
 				B: uint8(bExpanded),
@@ -218,16 +348,133 @@ func (d *Decoder) Decode(img image.Image) ([]Block, error) {
 		}
 	}
 	
-	return blocks, nil
+	d.erasures = stats.Erasures
+	if len(stats.Erasures) == 0 {
+		stats.Erasures = nil
+	}
+	if stats.BlocksUncorrectable > 0 {
+		slog.Debug("frame decoded with erasures", "blocks", stats.BlocksRead, "corrected", stats.BlocksCorrected, "erased", stats.BlocksUncorrectable)
+	}
+	
+	return blocks, stats, nil
+}
+
+func (d *Decoder) toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	
+	bounds := img.Bounds()
+	size := 4 * bounds.Dx() * bounds.Dy()
+	if d.rgba == nil {
+		d.rgba = &image.RGBA{}
+	}
+	if cap(d.rgba.Pix) < size {
+		d.rgba.Pix = make([]uint8, size)
+	}
+	d.rgba.Pix = d.rgba.Pix[:size]
+	d.rgba.Stride = 4 * bounds.Dx()
+	d.rgba.Rect = bounds
+	
+	draw.Draw(d.rgba, bounds, img, bounds.Min, draw.Src)
+	return d.rgba
+}
+
+func quantizeChannel(v, bits int) (int, int) {
+	mask := (1 << bits) - 1
+	
+	shifted := (v*mask + 127) / 255
+	level := (shifted * 255) / mask
+	
+	expanded := shifted << (8 - bits)
+	if shifted == mask {
+		expanded = 255
+	}
+	
+	dist := v - level
+	if dist < 0 {
+		dist = -dist
+	}
+	
+	return expanded, dist
+}
+
+func (c Config) ambiguousDistance(bits int) int {
+	tolerance := c.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	spacing := 255 / ((1 << bits) - 1)
+	return int(float64(spacing) * tolerance)
+}
+
+func (d *Decoder) sample(img *image.RGBA, startX, startY, blockPixelSize int) (int, int, int) {
+	if d.config.SampleDominant {
+		return dominant(img, image.Rect(startX, startY, startX+blockPixelSize, startY+blockPixelSize))
+	}
+	k := min(max(d.config.SampleKernel, 1), max(blockPixelSize, 1))
+	x0 := startX + blockPixelSize/2 - k/2
+	y0 := startY + blockPixelSize/2 - k/2
+	kernel := image.Rect(x0, y0, x0+k, y0+k)
+	
+	var r, g, b uint32
+	n := uint32(k * k)
+	if kernel.In(img.Rect) {
+		for y := y0; y < y0+k; y++ {
+			row := img.Pix[img.PixOffset(x0, y):]
+			for i := 0; i < 4*k; i += 4 {
+				r += uint32(row[i])
+				g += uint32(row[i+1])
+				b += uint32(row[i+2])
+			}
+		}
+	} else {
+		kernel = kernel.Intersect(img.Rect)
+		n = uint32(kernel.Dx() * kernel.Dy())
+		if n == 0 {
+			return 0, 0, 0
+		}
+		for y := kernel.Min.Y; y < kernel.Max.Y; y++ {
+			for x := kernel.Min.X; x < kernel.Max.X; x++ {
+				i := img.PixOffset(x, y)
+				r += uint32(img.Pix[i])
+				g += uint32(img.Pix[i+1])
+				b += uint32(img.Pix[i+2])
+			}
+		}
+	}
+	
+	return int((r + n/2) / n), int((g + n/2) / n), int((b + n/2) / n)
 }
 
 func (d *Decoder) BlocksToData(blocks []Block) []byte {
-	data := make([]byte, 0, len(blocks)*3)
+	var size int
+	switch {
+	case d.config.Monochrome:
+		size = MonoCapacity(len(blocks))
+	case d.config.Palette:
+		size = PaletteCapacity(len(blocks))
+	default:
+		size = len(blocks) * 3
+	}
 	
-	for _, block := range blocks {
-		data = append(data, block.R)
-		data = append(data, block.G)
-		data = append(data, block.B)
+	if cap(d.data) < size {
+		d.data = make([]byte, size)
+	}
+	data := d.data[:size]
+	clear(data)
+	
+	switch {
+	case d.config.Monochrome:
+		monoData(data, blocks)
+	case d.config.Palette:
+		paletteData(data, blocks)
+	default:
+		for i, block := range blocks {
+			data[3*i] = block.R
+			data[3*i+1] = block.G
+			data[3*i+2] = block.B
+		}
 	}
 	
 	return data
@@ -0,0 +1,119 @@
+package qr
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"slices"
+	"testing"
+)
+
+func TestCapacityMatchesRoundTrip(t *testing.T) {
+	data := make([]byte, 300)
+	for i := range data {
+		data[i] = byte(i*37 + 11)
+	}
+
+	for level := ErrorLevelLow; level <= ErrorLevelHigh; level++ {
+		width, height := OptimalGridSize(len(data))
+		config := Config{GridWidth: width, GridHeight: height, BorderSize: 1, ErrorLevel: level}
+		enc := NewEncoder(config)
+		img, err := enc.CreateImage(enc.Encode(data), 20*(width+2), 20*(height+2))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dec := NewDecoder(config)
+		blocks, err := dec.Decode(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := dec.BlocksToData(blocks)[:len(data)]
+
+		intact := bytes.Equal(got, data)
+		fits := level.Capacity(width*height) >= len(data)
+		if intact != fits {
+			t.Errorf("%s: capacity %d for %d bytes, but round trip intact = %v", level, level.Capacity(width*height), len(data), intact)
+		}
+	}
+}
+
+func TestSampleClippedKernel(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{200, 100, 50, 255}}, image.Point{}, draw.Src)
+
+	dec := NewDecoder(Config{SampleKernel: 6})
+	for _, start := range []image.Point{{0, 0}, {6, 6}, {-4, 2}, {8, -3}} {
+		r, g, b := dec.sample(img, start.X, start.Y, 6)
+		if r != 200 || g != 100 || b != 50 {
+			t.Errorf("block at %v sampled %d,%d,%d, want 200,100,50", start, r, g, b)
+		}
+	}
+}
+
+func TestSampleDominantIgnoresGridLines(t *testing.T) {
+	const pitch = 8
+	data := make([]byte, 192)
+	for i := range data {
+		data[i] = byte(i*53 + 7)
+	}
+	width, height := OptimalGridSize(len(data))
+	config := Config{GridWidth: width, GridHeight: height, BorderSize: 1, ErrorLevel: ErrorLevelHigh}
+	enc := NewEncoder(config)
+	img, err := enc.CreateImage(enc.Encode(data), pitch*(width+2), pitch*(height+2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rgba := img.(*image.RGBA)
+	blend := func(px, edge []uint8) {
+		for c := range px {
+			px[c] = uint8((int(px[c]) + 3*int(edge[c])) / 4)
+		}
+	}
+	want, err := NewDecoder(config).Decode(rgba)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = slices.Clone(want)
+
+	bounds := rgba.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			i := rgba.PixOffset(x, y)
+			switch {
+			case x%pitch == 0 || y%pitch == 0:
+				copy(rgba.Pix[i:i+3], []uint8{20, 20, 20})
+			case x%pitch == 1:
+				blend(rgba.Pix[i:i+3], rgba.Pix[i-4:i-1])
+			case y%pitch == 1:
+				blend(rgba.Pix[i:i+3], rgba.Pix[i-rgba.Stride:i-rgba.Stride+3])
+			}
+		}
+	}
+
+	misread := func(config Config) int {
+		blocks, err := NewDecoder(config).Decode(rgba)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wrong := 0
+		for i := range want {
+			if blocks[i] != want[i] {
+				wrong++
+			}
+		}
+		return wrong
+	}
+
+	mean := config
+	mean.SampleKernel = pitch
+	if misread(mean) == 0 {
+		t.Fatal("averaging whole blocks read every block despite the grid lines")
+	}
+	dominant := config
+	dominant.SampleDominant = true
+	if n := misread(dominant); n > 0 {
+		t.Errorf("dominant sampling misread %d of %d blocks", n, len(want))
+	}
+}
@@ -0,0 +1,66 @@
+package qr
+
+import "math"
+
+var (
+	MonoDark  = Block{0, 0, 0}
+	MonoLight = Block{255, 255, 255}
+)
+
+const markerBlocks = 2
+
+func MonoGridSize(dataSize int) (width, height int) {
+	side := int(math.Ceil(math.Sqrt(float64(dataSize*8 + markerBlocks))))
+	if side%2 == 0 {
+		side++
+	}
+	return side, side
+}
+
+func MonoCapacity(blocks int) int {
+	if blocks <= markerBlocks {
+		return 0
+	}
+	return (blocks - markerBlocks) / 8
+}
+
+func (e *Encoder) encodeMono(data []byte) []Block {
+	blocks := make([]Block, e.config.GridWidth*e.config.GridHeight)
+	for i := range blocks {
+		blocks[i] = MonoLight
+	}
+
+	for i := 0; i < len(data)*8 && i < len(blocks)-markerBlocks; i++ {
+		if data[i/8]&(0x80>>(i%8)) != 0 {
+			blocks[i] = MonoDark
+		}
+	}
+	return blocks
+}
+
+func SetMarker(blocks []Block, state bool) {
+	if len(blocks) < markerBlocks {
+		return
+	}
+	n := len(blocks)
+	blocks[n-2], blocks[n-1] = MonoDark, MonoLight
+	if state {
+		blocks[n-2], blocks[n-1] = MonoLight, MonoDark
+	}
+}
+
+func Marker(blocks []Block) bool {
+	return len(blocks) >= markerBlocks && blocks[len(blocks)-1] == MonoDark
+}
+
+func monoData(data []byte, blocks []Block) {
+	for i := 0; i < len(data)*8; i++ {
+		if blocks[i] == MonoDark {
+			data[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+}
+
+func gray(r, g, b int) int {
+	return (299*r + 587*g + 114*b) / 1000
+}
@@ -0,0 +1,108 @@
+package qr
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+const TimingRings = 2
+
+func PaletteGridSize(dataSize int) (width, height int) {
+	blocks := (dataSize*8 + 2) / 3
+	side := int(math.Ceil(math.Sqrt(float64(blocks))))
+	if side%2 == 0 {
+		side++
+	}
+	return side, side
+}
+
+func PaletteCapacity(blocks int) int {
+	return blocks * 3 / 8
+}
+
+func (e *Encoder) encodePalette(data []byte) []Block {
+	blocks := make([]Block, e.config.GridWidth*e.config.GridHeight)
+	for i := 0; i < len(data)*8 && i/3 < len(blocks); i++ {
+		if data[i/8]&(0x80>>(i%8)) == 0 {
+			continue
+		}
+		b := &blocks[i/3]
+		switch i % 3 {
+		case 0:
+			b.R = 255
+		case 1:
+			b.G = 255
+		case 2:
+			b.B = 255
+		}
+	}
+	return blocks
+}
+
+func paletteData(data []byte, blocks []Block) {
+	for i := 0; i < len(data)*8; i++ {
+		b := blocks[i/3]
+		v := [3]uint8{b.R, b.G, b.B}[i%3]
+		if v >= 128 {
+			data[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+}
+
+func TimingDark(x, y int) bool {
+	return (x+y)%2 == 0
+}
+
+func (e *Encoder) drawTiming(img *image.RGBA, blockPixelSize int) {
+	origin := e.config.BorderSize - TimingRings
+	if origin < 0 {
+		return
+	}
+
+	width := e.config.GridWidth + 2*TimingRings
+	height := e.config.GridHeight + 2*TimingRings
+	dark := &image.Uniform{color.RGBA{0, 0, 0, 255}}
+	light := &image.Uniform{QuietZoneColor}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ring := min(x, y, width-1-x, height-1-y)
+			if ring >= TimingRings {
+				continue
+			}
+
+			fill := dark
+			if ring == 1 && !TimingDark(x, y) {
+				fill = light
+			}
+			startX := (x + origin) * blockPixelSize
+			startY := (y + origin) * blockPixelSize
+			rect := image.Rect(startX, startY, startX+blockPixelSize, startY+blockPixelSize)
+			draw.Draw(img, rect, fill, image.Point{}, draw.Src)
+		}
+	}
+}
+
+func (d *Decoder) ByteErasures(stats DecodeStats) []int {
+	if !d.config.Monochrome && !d.config.Palette {
+		return stats.ByteErasures()
+	}
+
+	bits := 3
+	if d.config.Monochrome {
+		bits = 1
+	}
+
+	var positions []int
+	for _, index := range stats.Erasures {
+		first, last := index*bits/8, (index*bits+bits-1)/8
+		for p := first; p <= last; p++ {
+			if len(positions) == 0 || positions[len(positions)-1] < p {
+				positions = append(positions, p)
+			}
+		}
+	}
+	return positions
+}
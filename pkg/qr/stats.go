@@ -0,0 +1,36 @@
+package qr
+
+type DecodeStats struct {
+	BlocksRead          int
+	BlocksCorrected     int
+	BlocksUncorrectable int
+	Erasures            []int
+}
+
+func (s DecodeStats) ErrorRate() float64 {
+	if s.BlocksRead == 0 {
+		return 0
+	}
+	return float64(s.BlocksCorrected) / float64(s.BlocksRead)
+}
+
+func (s DecodeStats) ErasureRate() float64 {
+	if s.BlocksRead == 0 {
+		return 0
+	}
+	return float64(s.BlocksUncorrectable) / float64(s.BlocksRead)
+}
+
+func (s *DecodeStats) Add(other DecodeStats) {
+	s.BlocksRead += other.BlocksRead
+	s.BlocksCorrected += other.BlocksCorrected
+	s.BlocksUncorrectable += other.BlocksUncorrectable
+}
+
+func (s DecodeStats) ByteErasures() []int {
+	positions := make([]int, 0, len(s.Erasures)*3)
+	for _, index := range s.Erasures {
+		positions = append(positions, index*3, index*3+1, index*3+2)
+	}
+	return positions
+}
@@ -0,0 +1,38 @@
+package qr
+
+import "image"
+
+func (e *Encoder) UpdateImage(img *image.RGBA, prev, next []Block) int {
+	bounds := img.Bounds()
+	blockPixelSize := e.config.BlockPixelSize(bounds.Dx(), bounds.Dy())
+
+	changed := 0
+	for i, b := range next {
+		if i < len(prev) && prev[i] == b {
+			continue
+		}
+		e.fillBlock(img, i, blockPixelSize, b)
+		changed++
+	}
+	return changed
+}
+
+func (e *Encoder) fillBlock(img *image.RGBA, index, blockPixelSize int, b Block) {
+	startX := (index%e.config.GridWidth + e.config.BorderSize) * blockPixelSize
+	startY := (index/e.config.GridWidth + e.config.BorderSize) * blockPixelSize
+
+	rect := image.Rect(startX, startY, startX+blockPixelSize, startY+blockPixelSize).Intersect(img.Rect)
+	if rect.Empty() {
+		return
+	}
+
+	off := img.PixOffset(rect.Min.X, rect.Min.Y)
+	row := img.Pix[off : off+rect.Dx()*4]
+	for i := 0; i < len(row); i += 4 {
+		row[i], row[i+1], row[i+2], row[i+3] = b.R, b.G, b.B, 255
+	}
+	for y := rect.Min.Y + 1; y < rect.Max.Y; y++ {
+		off = img.PixOffset(rect.Min.X, y)
+		copy(img.Pix[off:off+len(row)], row)
+	}
+}
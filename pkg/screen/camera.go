@@ -0,0 +1,136 @@
+package screen
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+)
+
+type CameraConfig struct {
+	Device string
+	Width  int
+	Height int
+	FPS    int
+	Native bool
+}
+
+type Camera struct {
+	config CameraConfig
+	dev    cameraDevice
+}
+
+type cameraDevice interface {
+	read() (image.Image, error)
+	close() error
+}
+
+func OpenCamera(config CameraConfig) (*Camera, error) {
+	if !config.Native && (config.Width == 0 || config.Height == 0) {
+		config.Width, config.Height = 1280, 720
+	}
+
+	dev, err := openCameraDevice(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Camera{config: config, dev: dev}, nil
+}
+
+func (c *Camera) Capture() (image.Image, error) {
+	return c.dev.read()
+}
+
+func (c *Camera) Close() error {
+	return c.dev.close()
+}
+
+func ListCameras() ([]string, error) {
+	return listCameraDevices()
+}
+
+func decodeMJPEG(frame []byte) (image.Image, error) {
+	if !bytes.Contains(frame, []byte{0xff, 0xc4}) {
+		frame = insertDefaultHuffman(frame)
+	}
+	return jpeg.Decode(bytes.NewReader(frame))
+}
+
+func insertDefaultHuffman(frame []byte) []byte {
+	sos := bytes.Index(frame, []byte{0xff, 0xda})
+	if sos < 0 {
+		return frame
+	}
+
+	out := make([]byte, 0, len(frame)+len(defaultDHT))
+	out = append(out, frame[:sos]...)
+	out = append(out, defaultDHT...)
+	out = append(out, frame[sos:]...)
+	return out
+}
+
+func yuyvToRGBA(data []byte, width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i, p := 0, 0; i+3 < len(data) && p+7 < len(img.Pix); i, p = i+4, p+8 {
+		y0, cb, y1, cr := data[i], data[i+1], data[i+2], data[i+3]
+		img.Pix[p], img.Pix[p+1], img.Pix[p+2] = ycbcrToRGB(y0, cb, cr)
+		img.Pix[p+3] = 255
+		img.Pix[p+4], img.Pix[p+5], img.Pix[p+6] = ycbcrToRGB(y1, cb, cr)
+		img.Pix[p+7] = 255
+	}
+	return img
+}
+
+func ycbcrToRGB(y, cb, cr uint8) (uint8, uint8, uint8) {
+	yy := int32(y) * 0x10101
+	cbb := int32(cb) - 128
+	crr := int32(cr) - 128
+
+	r := clampChannel(yy + 91881*crr)
+	g := clampChannel(yy - 22554*cbb - 46802*crr)
+	b := clampChannel(yy + 116130*cbb)
+	return r, g, b
+}
+
+func clampChannel(v int32) uint8 {
+	v += 1 << 15
+	if uint32(v)&0xff000000 == 0 {
+		return uint8(v >> 16)
+	}
+	if v < 0 {
+		return 0
+	}
+	return 255
+}
+
+var defaultDHT = []byte{
+	0xff, 0xc4, 0x01, 0xa2,
+	0x00, 0x00, 0x01, 0x05, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b,
+	0x01, 0x00, 0x03, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b,
+	0x10, 0x00, 0x02, 0x01, 0x03, 0x03, 0x02, 0x04, 0x03, 0x05, 0x05, 0x04, 0x04, 0x00, 0x00, 0x01, 0x7d,
+	0x01, 0x02, 0x03, 0x00, 0x04, 0x11, 0x05, 0x12, 0x21, 0x31, 0x41, 0x06, 0x13, 0x51, 0x61, 0x07,
+	0x22, 0x71, 0x14, 0x32, 0x81, 0x91, 0xa1, 0x08, 0x23, 0x42, 0xb1, 0xc1, 0x15, 0x52, 0xd1, 0xf0,
+	0x24, 0x33, 0x62, 0x72, 0x82, 0x09, 0x0a, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x25, 0x26, 0x27, 0x28,
+	0x29, 0x2a, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39, 0x3a, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48, 0x49,
+	0x4a, 0x53, 0x54, 0x55, 0x56, 0x57, 0x58, 0x59, 0x5a, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x69,
+	0x6a, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78, 0x79, 0x7a, 0x83, 0x84, 0x85, 0x86, 0x87, 0x88, 0x89,
+	0x8a, 0x92, 0x93, 0x94, 0x95, 0x96, 0x97, 0x98, 0x99, 0x9a, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7,
+	0xa8, 0xa9, 0xaa, 0xb2, 0xb3, 0xb4, 0xb5, 0xb6, 0xb7, 0xb8, 0xb9, 0xba, 0xc2, 0xc3, 0xc4, 0xc5,
+	0xc6, 0xc7, 0xc8, 0xc9, 0xca, 0xd2, 0xd3, 0xd4, 0xd5, 0xd6, 0xd7, 0xd8, 0xd9, 0xda, 0xe1, 0xe2,
+	0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8, 0xe9, 0xea, 0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8,
+	0xf9, 0xfa,
+	0x11, 0x00, 0x02, 0x01, 0x02, 0x04, 0x04, 0x03, 0x04, 0x07, 0x05, 0x04, 0x04, 0x00, 0x01, 0x02, 0x77,
+	0x00, 0x01, 0x02, 0x03, 0x11, 0x04, 0x05, 0x21, 0x31, 0x06, 0x12, 0x41, 0x51, 0x07, 0x61, 0x71,
+	0x13, 0x22, 0x32, 0x81, 0x08, 0x14, 0x42, 0x91, 0xa1, 0xb1, 0xc1, 0x09, 0x23, 0x33, 0x52, 0xf0,
+	0x15, 0x62, 0x72, 0xd1, 0x0a, 0x16, 0x24, 0x34, 0xe1, 0x25, 0xf1, 0x17, 0x18, 0x19, 0x1a, 0x26,
+	0x27, 0x28, 0x29, 0x2a, 0x35, 0x36, 0x37, 0x38, 0x39, 0x3a, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48,
+	0x49, 0x4a, 0x53, 0x54, 0x55, 0x56, 0x57, 0x58, 0x59, 0x5a, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68,
+	0x69, 0x6a, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78, 0x79, 0x7a, 0x82, 0x83, 0x84, 0x85, 0x86, 0x87,
+	0x88, 0x89, 0x8a, 0x92, 0x93, 0x94, 0x95, 0x96, 0x97, 0x98, 0x99, 0x9a, 0xa2, 0xa3, 0xa4, 0xa5,
+	0xa6, 0xa7, 0xa8, 0xa9, 0xaa, 0xb2, 0xb3, 0xb4, 0xb5, 0xb6, 0xb7, 0xb8, 0xb9, 0xba, 0xc2, 0xc3,
+	0xc4, 0xc5, 0xc6, 0xc7, 0xc8, 0xc9, 0xca, 0xd2, 0xd3, 0xd4, 0xd5, 0xd6, 0xd7, 0xd8, 0xd9, 0xda,
+	0xe2, 0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8, 0xe9, 0xea, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8,
+	0xf9, 0xfa,
+}
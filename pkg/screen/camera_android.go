@@ -0,0 +1,338 @@
+//go:build android && cgo
+
+package screen
+
+/*
+#cgo LDFLAGS: -lcamera2ndk -lmediandk
+#include <camera/NdkCameraCaptureSession.h>
+#include <camera/NdkCameraDevice.h>
+#include <camera/NdkCameraManager.h>
+#include <media/NdkImageReader.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+typedef struct {
+	ACameraManager *manager;
+	ACameraDevice *device;
+	AImageReader *reader;
+	ACaptureSessionOutputContainer *outputs;
+	ACaptureSessionOutput *output;
+	ACameraOutputTarget *target;
+	ACaptureRequest *request;
+	ACameraCaptureSession *session;
+} owl_camera;
+
+typedef struct {
+	uint8_t *y, *cb, *cr;
+	int yLen, cbLen, crLen;
+	int32_t yStride, cStride, cPixel;
+	int32_t width, height;
+} owl_planes;
+
+static void owl_device_disconnected(void *ctx, ACameraDevice *device) {}
+static void owl_device_error(void *ctx, ACameraDevice *device, int err) {}
+static void owl_session_state(void *ctx, ACameraCaptureSession *session) {}
+
+static ACameraDevice_StateCallbacks owl_device_callbacks = {
+	NULL, owl_device_disconnected, owl_device_error,
+};
+
+static ACameraCaptureSession_stateCallbacks owl_session_callbacks = {
+	NULL, owl_session_state, owl_session_state, owl_session_state,
+};
+
+static int owl_camera_list(char *buf, int size) {
+	ACameraManager *manager = ACameraManager_create();
+	ACameraIdList *ids = NULL;
+	camera_status_t status = ACameraManager_getCameraIdList(manager, &ids);
+	if (status != ACAMERA_OK) {
+		ACameraManager_delete(manager);
+		return status;
+	}
+
+	int n = 0;
+	buf[0] = 0;
+	for (int i = 0; i < ids->numCameras && n < size; i++) {
+		int facing = -1;
+		ACameraMetadata *meta = NULL;
+		if (ACameraManager_getCameraCharacteristics(manager, ids->cameraIds[i], &meta) == ACAMERA_OK) {
+			ACameraMetadata_const_entry entry;
+			if (ACameraMetadata_getConstEntry(meta, ACAMERA_LENS_FACING, &entry) == ACAMERA_OK && entry.count > 0) {
+				facing = entry.data.u8[0];
+			}
+			ACameraMetadata_free(meta);
+		}
+		n += snprintf(buf + n, size - n, "%s\t%d\n", ids->cameraIds[i], facing);
+	}
+
+	ACameraManager_deleteCameraIdList(ids);
+	ACameraManager_delete(manager);
+	return ACAMERA_OK;
+}
+
+static void owl_camera_size(ACameraManager *manager, const char *id, int32_t *width, int32_t *height) {
+	ACameraMetadata *meta = NULL;
+	if (ACameraManager_getCameraCharacteristics(manager, id, &meta) != ACAMERA_OK) {
+		return;
+	}
+
+	ACameraMetadata_const_entry entry;
+	if (ACameraMetadata_getConstEntry(meta, ACAMERA_SCALER_AVAILABLE_STREAM_CONFIGURATIONS, &entry) == ACAMERA_OK) {
+		int64_t limit = (int64_t)*width * *height;
+		int64_t best = 0;
+		int32_t bestW = 0, bestH = 0;
+		for (uint32_t i = 0; i + 3 < entry.count; i += 4) {
+			const int32_t *c = entry.data.i32 + i;
+			if (c[0] != AIMAGE_FORMAT_YUV_420_888 || c[3] != ACAMERA_SCALER_AVAILABLE_STREAM_CONFIGURATIONS_OUTPUT) {
+				continue;
+			}
+			int64_t area = (int64_t)c[1] * c[2];
+			if (area <= limit && area > best) {
+				best = area;
+				bestW = c[1];
+				bestH = c[2];
+			}
+		}
+		if (best > 0) {
+			*width = bestW;
+			*height = bestH;
+		}
+	}
+	ACameraMetadata_free(meta);
+}
+
+static void owl_camera_close(owl_camera *c) {
+	if (c->session != NULL) {
+		ACameraCaptureSession_stopRepeating(c->session);
+		ACameraCaptureSession_close(c->session);
+	}
+	if (c->request != NULL) {
+		ACaptureRequest_free(c->request);
+	}
+	if (c->target != NULL) {
+		ACameraOutputTarget_free(c->target);
+	}
+	if (c->outputs != NULL) {
+		if (c->output != NULL) {
+			ACaptureSessionOutputContainer_remove(c->outputs, c->output);
+		}
+		ACaptureSessionOutputContainer_free(c->outputs);
+	}
+	if (c->output != NULL) {
+		ACaptureSessionOutput_free(c->output);
+	}
+	if (c->device != NULL) {
+		ACameraDevice_close(c->device);
+	}
+	if (c->reader != NULL) {
+		AImageReader_delete(c->reader);
+	}
+	ACameraManager_delete(c->manager);
+	free(c);
+}
+
+static camera_status_t owl_camera_open(const char *id, int32_t width, int32_t height, owl_camera **out) {
+	owl_camera *c = calloc(1, sizeof(owl_camera));
+	c->manager = ACameraManager_create();
+	owl_camera_size(c->manager, id, &width, &height);
+
+	ANativeWindow *window = NULL;
+	camera_status_t status = ACameraManager_openCamera(c->manager, id, &owl_device_callbacks, &c->device);
+	if (status != ACAMERA_OK) {
+		goto fail;
+	}
+	if (AImageReader_new(width, height, AIMAGE_FORMAT_YUV_420_888, 2, &c->reader) != AMEDIA_OK ||
+		AImageReader_getWindow(c->reader, &window) != AMEDIA_OK) {
+		status = ACAMERA_ERROR_UNKNOWN;
+		goto fail;
+	}
+
+	if ((status = ACaptureSessionOutputContainer_create(&c->outputs)) != ACAMERA_OK ||
+		(status = ACaptureSessionOutput_create(window, &c->output)) != ACAMERA_OK ||
+		(status = ACaptureSessionOutputContainer_add(c->outputs, c->output)) != ACAMERA_OK ||
+		(status = ACameraOutputTarget_create(window, &c->target)) != ACAMERA_OK ||
+		(status = ACameraDevice_createCaptureRequest(c->device, TEMPLATE_PREVIEW, &c->request)) != ACAMERA_OK ||
+		(status = ACaptureRequest_addTarget(c->request, c->target)) != ACAMERA_OK ||
+		(status = ACameraDevice_createCaptureSession(c->device, c->outputs, &owl_session_callbacks, &c->session)) != ACAMERA_OK ||
+		(status = ACameraCaptureSession_setRepeatingRequest(c->session, NULL, 1, &c->request, NULL)) != ACAMERA_OK) {
+		goto fail;
+	}
+
+	*out = c;
+	return ACAMERA_OK;
+
+fail:
+	owl_camera_close(c);
+	return status;
+}
+
+static media_status_t owl_camera_acquire(owl_camera *c, AImage **image, owl_planes *p) {
+	media_status_t status = AImageReader_acquireLatestImage(c->reader, image);
+	if (status != AMEDIA_OK) {
+		return status;
+	}
+
+	AImage_getWidth(*image, &p->width);
+	AImage_getHeight(*image, &p->height);
+	AImage_getPlaneData(*image, 0, &p->y, &p->yLen);
+	AImage_getPlaneRowStride(*image, 0, &p->yStride);
+	AImage_getPlaneData(*image, 1, &p->cb, &p->cbLen);
+	AImage_getPlaneData(*image, 2, &p->cr, &p->crLen);
+	AImage_getPlaneRowStride(*image, 1, &p->cStride);
+	AImage_getPlanePixelStride(*image, 1, &p->cPixel);
+	return AMEDIA_OK;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+const cameraFrameTimeout = 2 * time.Second
+
+var cameraFacings = map[int]string{0: "Front Camera", 1: "Back Camera", 2: "External Camera"}
+
+type ndkCamera struct {
+	cam *C.owl_camera
+}
+
+type ndkCameraInfo struct {
+	id     string
+	name   string
+	facing int
+}
+
+func ndkCameras() ([]ndkCameraInfo, error) {
+	buf := make([]byte, 4096)
+	if status := C.owl_camera_list((*C.char)(unsafe.Pointer(&buf[0])), C.int(len(buf))); status != C.ACAMERA_OK {
+		return nil, fmt.Errorf("listing cameras: camera status %d", int(status))
+	}
+
+	var cameras []ndkCameraInfo
+	for _, line := range strings.Split(C.GoString((*C.char)(unsafe.Pointer(&buf[0]))), "\n") {
+		id, f, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		facing, _ := strconv.Atoi(f)
+		label, ok := cameraFacings[facing]
+		if !ok {
+			label = "Camera"
+		}
+		cameras = append(cameras, ndkCameraInfo{id: id, name: label + " " + id, facing: facing})
+	}
+
+	sort.SliceStable(cameras, func(i, j int) bool {
+		return cameras[i].facing == 1 && cameras[j].facing != 1
+	})
+	return cameras, nil
+}
+
+func listCaptureCards() ([]CaptureCardInfo, error) {
+	return nil, ErrUnsupported
+}
+
+func listCameraDevices() ([]string, error) {
+	cameras, err := ndkCameras()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(cameras))
+	for i, c := range cameras {
+		names[i] = c.name
+	}
+	return names, nil
+}
+
+func openCameraDevice(config CameraConfig) (cameraDevice, error) {
+	cameras, err := ndkCameras()
+	if err != nil {
+		return nil, err
+	}
+
+	var id string
+	for _, c := range cameras {
+		if config.Device == "" || c.name == config.Device || c.id == config.Device {
+			id = c.id
+			break
+		}
+	}
+	if id == "" {
+		return nil, fmt.Errorf("camera %q not found", config.Device)
+	}
+
+	cid := C.CString(id)
+	defer C.free(unsafe.Pointer(cid))
+
+	var cam *C.owl_camera
+	status := C.owl_camera_open(cid, C.int32_t(config.Width), C.int32_t(config.Height), &cam)
+	switch status {
+	case C.ACAMERA_OK:
+		return &ndkCamera{cam: cam}, nil
+	case C.ACAMERA_ERROR_PERMISSION_DENIED:
+		return nil, &PermissionError{Kind: PermissionCamera}
+	default:
+		return nil, fmt.Errorf("opening camera %s: camera status %d", id, int(status))
+	}
+}
+
+func (c *ndkCamera) read() (image.Image, error) {
+	deadline := time.Now().Add(cameraFrameTimeout)
+	for {
+		var frame *C.AImage
+		var planes C.owl_planes
+		status := C.owl_camera_acquire(c.cam, &frame, &planes)
+		if status == C.AMEDIA_OK {
+			img := yuv420Image(&planes)
+			C.AImage_delete(frame)
+			return img, nil
+		}
+		if status != C.AMEDIA_IMGREADER_NO_BUFFER_AVAILABLE {
+			return nil, fmt.Errorf("camera frame: media status %d", int(status))
+		}
+
+		if time.Now().After(deadline) {
+			return nil, errors.New("camera produced no frames")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func yuv420Image(p *C.owl_planes) image.Image {
+	width, height := int(p.width), int(p.height)
+	ys := unsafe.Slice((*byte)(unsafe.Pointer(p.y)), int(p.yLen))
+	cbs := unsafe.Slice((*byte)(unsafe.Pointer(p.cb)), int(p.cbLen))
+	crs := unsafe.Slice((*byte)(unsafe.Pointer(p.cr)), int(p.crLen))
+	yStride, cStride, cPixel := int(p.yStride), int(p.cStride), int(p.cPixel)
+
+	img := image.NewYCbCr(image.Rect(0, 0, width, height), image.YCbCrSubsampleRatio420)
+	for y := 0; y < height; y++ {
+		copy(img.Y[y*img.YStride:y*img.YStride+width], ys[y*yStride:])
+	}
+	for y := 0; y < (height+1)/2; y++ {
+		for x := 0; x < (width+1)/2; x++ {
+			src := y*cStride + x*cPixel
+			if src >= len(cbs) || src >= len(crs) {
+				break
+			}
+			dst := y*img.CStride + x
+			img.Cb[dst] = cbs[src]
+			img.Cr[dst] = crs[src]
+		}
+	}
+	return img
+}
+
+func (c *ndkCamera) close() error {
+	C.owl_camera_close(c.cam)
+	return nil
+}
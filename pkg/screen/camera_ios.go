@@ -0,0 +1,264 @@
+//go:build ios && cgo
+
+package screen
+
+/*
+#cgo CFLAGS: -x objective-c -fblocks
+#cgo LDFLAGS: -framework AVFoundation -framework CoreMedia -framework CoreVideo -framework Foundation
+#import <AVFoundation/AVFoundation.h>
+#include <pthread.h>
+#include <stdlib.h>
+#include <string.h>
+
+typedef struct {
+	void *session;
+	void *delegate;
+	dispatch_queue_t queue;
+	pthread_mutex_t lock;
+	uint8_t *pixels;
+	size_t width;
+	size_t height;
+	size_t stride;
+	uint64_t seq;
+} owl_camera;
+
+@interface OwlCameraDelegate : NSObject <AVCaptureVideoDataOutputSampleBufferDelegate> {
+@public
+	owl_camera *camera;
+}
+@end
+
+@implementation OwlCameraDelegate
+- (void)captureOutput:(AVCaptureOutput *)output didOutputSampleBuffer:(CMSampleBufferRef)buffer fromConnection:(AVCaptureConnection *)connection {
+	CVImageBufferRef frame = CMSampleBufferGetImageBuffer(buffer);
+	if (frame == NULL) {
+		return;
+	}
+
+	CVPixelBufferLockBaseAddress(frame, kCVPixelBufferLock_ReadOnly);
+	size_t stride = CVPixelBufferGetBytesPerRow(frame);
+	size_t h = CVPixelBufferGetHeight(frame);
+	size_t w = CVPixelBufferGetWidth(frame);
+
+	owl_camera *c = camera;
+	pthread_mutex_lock(&c->lock);
+	if (c->pixels == NULL || c->stride * c->height != stride * h) {
+		free(c->pixels);
+		c->pixels = malloc(stride * h);
+	}
+	memcpy(c->pixels, CVPixelBufferGetBaseAddress(frame), stride * h);
+	c->width = w;
+	c->height = h;
+	c->stride = stride;
+	c->seq++;
+	pthread_mutex_unlock(&c->lock);
+
+	CVPixelBufferUnlockBaseAddress(frame, kCVPixelBufferLock_ReadOnly);
+}
+@end
+
+static int owl_camera_authorized(void) {
+	AVAuthorizationStatus status = [AVCaptureDevice authorizationStatusForMediaType:AVMediaTypeVideo];
+	if (status == AVAuthorizationStatusNotDetermined) {
+		[AVCaptureDevice requestAccessForMediaType:AVMediaTypeVideo completionHandler:^(BOOL granted) {}];
+	}
+	return status == AVAuthorizationStatusAuthorized;
+}
+
+static int owl_camera_list(char *buf, int size) {
+	@autoreleasepool {
+		NSArray *types = @[AVCaptureDeviceTypeBuiltInWideAngleCamera, AVCaptureDeviceTypeBuiltInUltraWideCamera, AVCaptureDeviceTypeBuiltInTelephotoCamera];
+		AVCaptureDeviceDiscoverySession *discovery = [AVCaptureDeviceDiscoverySession
+			discoverySessionWithDeviceTypes:types mediaType:AVMediaTypeVideo position:AVCaptureDevicePositionUnspecified];
+
+		int n = 0;
+		buf[0] = 0;
+		for (AVCaptureDevice *device in discovery.devices) {
+			if (n >= size) {
+				break;
+			}
+			n += snprintf(buf + n, size - n, "%s\t%s\n", device.uniqueID.UTF8String, device.localizedName.UTF8String);
+		}
+		return n;
+	}
+}
+
+static owl_camera *owl_camera_open(const char *uid, int width) {
+	@autoreleasepool {
+		AVCaptureDevice *device = nil;
+		if (uid[0] != 0) {
+			device = [AVCaptureDevice deviceWithUniqueID:[NSString stringWithUTF8String:uid]];
+		} else {
+			device = [AVCaptureDevice defaultDeviceWithDeviceType:AVCaptureDeviceTypeBuiltInWideAngleCamera
+				mediaType:AVMediaTypeVideo position:AVCaptureDevicePositionBack];
+		}
+		if (device == nil) {
+			return NULL;
+		}
+
+		AVCaptureDeviceInput *input = [AVCaptureDeviceInput deviceInputWithDevice:device error:nil];
+		AVCaptureSession *session = [[AVCaptureSession alloc] init];
+		session.sessionPreset = width > 1280 ? AVCaptureSessionPreset1920x1080 : AVCaptureSessionPreset1280x720;
+
+		AVCaptureVideoDataOutput *output = [[[AVCaptureVideoDataOutput alloc] init] autorelease];
+		output.videoSettings = @{(id)kCVPixelBufferPixelFormatTypeKey: @(kCVPixelFormatType_32BGRA)};
+		output.alwaysDiscardsLateVideoFrames = YES;
+
+		if (input == nil || ![session canAddInput:input] || ![session canAddOutput:output]) {
+			[session release];
+			return NULL;
+		}
+		[session addInput:input];
+		[session addOutput:output];
+
+		owl_camera *c = calloc(1, sizeof(owl_camera));
+		pthread_mutex_init(&c->lock, NULL);
+		c->queue = dispatch_queue_create("owl.camera", DISPATCH_QUEUE_SERIAL);
+
+		OwlCameraDelegate *delegate = [[OwlCameraDelegate alloc] init];
+		delegate->camera = c;
+		[output setSampleBufferDelegate:delegate queue:c->queue];
+		[session startRunning];
+
+		c->session = session;
+		c->delegate = delegate;
+		return c;
+	}
+}
+
+static void owl_camera_lock(owl_camera *c) {
+	pthread_mutex_lock(&c->lock);
+}
+
+static void owl_camera_unlock(owl_camera *c) {
+	pthread_mutex_unlock(&c->lock);
+}
+
+static void owl_camera_close(owl_camera *c) {
+	AVCaptureSession *session = (AVCaptureSession *)c->session;
+	[session stopRunning];
+	dispatch_sync(c->queue, ^{});
+	[session release];
+	[(OwlCameraDelegate *)c->delegate release];
+	dispatch_release(c->queue);
+	free(c->pixels);
+	pthread_mutex_destroy(&c->lock);
+	free(c);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+const cameraFrameTimeout = 2 * time.Second
+
+type avCamera struct {
+	cam  *C.owl_camera
+	seen C.uint64_t
+}
+
+type avCameraInfo struct {
+	id   string
+	name string
+}
+
+func avCameras() []avCameraInfo {
+	buf := make([]byte, 4096)
+	C.owl_camera_list((*C.char)(unsafe.Pointer(&buf[0])), C.int(len(buf)))
+
+	var cameras []avCameraInfo
+	for _, line := range strings.Split(C.GoString((*C.char)(unsafe.Pointer(&buf[0]))), "\n") {
+		if id, name, ok := strings.Cut(line, "\t"); ok {
+			cameras = append(cameras, avCameraInfo{id: id, name: name})
+		}
+	}
+	return cameras
+}
+
+func listCaptureCards() ([]CaptureCardInfo, error) {
+	return nil, ErrUnsupported
+}
+
+func listCameraDevices() ([]string, error) {
+	var names []string
+	for _, c := range avCameras() {
+		names = append(names, c.name)
+	}
+	return names, nil
+}
+
+func openCameraDevice(config CameraConfig) (cameraDevice, error) {
+	if C.owl_camera_authorized() == 0 {
+		return nil, &PermissionError{Kind: PermissionCamera}
+	}
+
+	id := ""
+	if config.Device != "" {
+		for _, c := range avCameras() {
+			if c.name == config.Device || c.id == config.Device {
+				id = c.id
+				break
+			}
+		}
+		if id == "" {
+			return nil, fmt.Errorf("camera %q not found", config.Device)
+		}
+	}
+
+	cid := C.CString(id)
+	defer C.free(unsafe.Pointer(cid))
+
+	cam := C.owl_camera_open(cid, C.int(config.Width))
+	if cam == nil {
+		return nil, errors.New("camera unavailable")
+	}
+	return &avCamera{cam: cam}, nil
+}
+
+func (c *avCamera) read() (image.Image, error) {
+	deadline := time.Now().Add(cameraFrameTimeout)
+	for {
+		C.owl_camera_lock(c.cam)
+		if c.cam.seq > c.seen {
+			break
+		}
+		C.owl_camera_unlock(c.cam)
+
+		if time.Now().After(deadline) {
+			return nil, errors.New("camera produced no frames")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer C.owl_camera_unlock(c.cam)
+
+	c.seen = c.cam.seq
+	width := int(c.cam.width)
+	height := int(c.cam.height)
+	stride := int(c.cam.stride)
+	pixels := unsafe.Slice((*byte)(unsafe.Pointer(c.cam.pixels)), stride*height)
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		src := pixels[y*stride:]
+		dst := img.Pix[y*img.Stride:]
+		for x := 0; x < width; x++ {
+			dst[x*4] = src[x*4+2]
+			dst[x*4+1] = src[x*4+1]
+			dst[x*4+2] = src[x*4]
+			dst[x*4+3] = 255
+		}
+	}
+	return img, nil
+}
+
+func (c *avCamera) close() error {
+	C.owl_camera_close(c.cam)
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build (!linux || android || !(amd64 || arm64)) && !((android || ios) && cgo)
+
+package screen
+
+func listCameraDevices() ([]string, error) {
+	return nil, ErrUnsupported
+}
+
+func openCameraDevice(config CameraConfig) (cameraDevice, error) {
+	return nil, ErrUnsupported
+}
+
+func listCaptureCards() ([]CaptureCardInfo, error) {
+	return nil, ErrUnsupported
+}
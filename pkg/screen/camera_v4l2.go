@@ -0,0 +1,407 @@
+//go:build linux && !android && (amd64 || arm64)
+
+package screen
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	vidiocQueryCap  = 0x80685600
+	vidiocEnumFmt   = 0xc0405602
+	vidiocEnumSizes = 0xc02c564a
+	vidiocSFmt      = 0xc0d05605
+	vidiocSParm     = 0xc0cc5616
+	vidiocReqBufs   = 0xc0145608
+	vidiocQueryBuf  = 0xc0585609
+	vidiocQBuf      = 0xc058560f
+	vidiocDQBuf     = 0xc0585611
+	vidiocStreamOn  = 0x40045612
+	vidiocStreamOff = 0x40045613
+
+	v4l2BufTypeVideoCapture = 1
+	v4l2MemoryMmap          = 1
+	v4l2FieldAny            = 0
+	v4l2CapVideoCapture     = 0x00000001
+	v4l2CapStreaming        = 0x04000000
+
+	v4l2PixFmtYUYV  = 'Y' | 'U'<<8 | 'Y'<<16 | 'V'<<24
+	v4l2PixFmtMJPEG = 'M' | 'J'<<8 | 'P'<<16 | 'G'<<24
+	v4l2PixFmtUYVY  = 'U' | 'Y'<<8 | 'V'<<16 | 'Y'<<24
+	v4l2PixFmtNV12  = 'N' | 'V'<<8 | '1'<<16 | '2'<<24
+	v4l2PixFmtRGB24 = 'R' | 'G'<<8 | 'B'<<16 | '3'<<24
+	v4l2PixFmtBGR24 = 'B' | 'G'<<8 | 'R'<<16 | '3'<<24
+
+	v4l2FrmSizeDiscrete = 1
+
+	v4l2ColorspaceRec709 = 3
+	v4l2ColorspaceJPEG   = 7
+	v4l2YCbCrEnc601      = 1
+	v4l2YCbCrEnc709      = 2
+	v4l2QuantFullRange   = 1
+	v4l2QuantLimRange    = 2
+
+	signalTimeoutMillis = 2000
+
+	v4l2BufferCount = 4
+)
+
+type v4l2Capability struct {
+	Driver       [16]byte
+	Card         [32]byte
+	BusInfo      [32]byte
+	Version      uint32
+	Capabilities uint32
+	DeviceCaps   uint32
+	Reserved     [3]uint32
+}
+
+type v4l2FmtDesc struct {
+	Index       uint32
+	Type        uint32
+	Flags       uint32
+	Description [32]byte
+	PixelFormat uint32
+	MbusCode    uint32
+	Reserved    [3]uint32
+}
+
+type v4l2FrmSizeEnum struct {
+	Index       uint32
+	PixelFormat uint32
+	Type        uint32
+	Size        [6]uint32
+	Reserved    [2]uint32
+}
+
+type v4l2PixFormat struct {
+	Width        uint32
+	Height       uint32
+	PixelFormat  uint32
+	Field        uint32
+	BytesPerLine uint32
+	SizeImage    uint32
+	Colorspace   uint32
+	Priv         uint32
+	Flags        uint32
+	YCbCrEnc     uint32
+	Quantization uint32
+	XferFunc     uint32
+}
+
+type v4l2Format struct {
+	Type uint32
+	_    uint32
+	Pix  v4l2PixFormat
+	_    [200 - 48]byte
+}
+
+type v4l2CaptureParm struct {
+	Type         uint32
+	Capability   uint32
+	CaptureMode  uint32
+	Numerator    uint32
+	Denominator  uint32
+	ExtendedMode uint32
+	ReadBuffers  uint32
+	_            [200 - 24]byte
+}
+
+type v4l2RequestBuffers struct {
+	Count        uint32
+	Type         uint32
+	Memory       uint32
+	Capabilities uint32
+	Flags        uint32
+}
+
+type v4l2Buffer struct {
+	Index     uint32
+	Type      uint32
+	BytesUsed uint32
+	Flags     uint32
+	Field     uint32
+	_         uint32
+	Timestamp unix.Timeval
+	Timecode  [16]byte
+	Sequence  uint32
+	Memory    uint32
+	Offset    uint64
+	Length    uint32
+	Reserved2 uint32
+	RequestFD uint32
+	_         uint32
+}
+
+type v4l2Device struct {
+	fd      int
+	width   int
+	height  int
+	stride  int
+	format  uint32
+	native  bool
+	yuv     yuvMatrix
+	buffers [][]byte
+}
+
+var nativeFormats = []uint32{v4l2PixFmtRGB24, v4l2PixFmtBGR24, v4l2PixFmtYUYV, v4l2PixFmtUYVY, v4l2PixFmtNV12, v4l2PixFmtMJPEG}
+
+func ioctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	for {
+		_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), req, uintptr(arg))
+		if errno == unix.EINTR {
+			continue
+		}
+		if errno != 0 {
+			return errno
+		}
+		return nil
+	}
+}
+
+func captureDevices(fn func(dev string, cp v4l2Capability)) error {
+	devices, err := filepath.Glob("/dev/video*")
+	if err != nil {
+		return err
+	}
+	sort.Strings(devices)
+
+	for _, dev := range devices {
+		fd, err := unix.Open(dev, unix.O_RDWR|unix.O_NONBLOCK, 0)
+		if err != nil {
+			continue
+		}
+
+		var cp v4l2Capability
+		if ioctl(fd, vidiocQueryCap, unsafe.Pointer(&cp)) == nil && cp.DeviceCaps&v4l2CapVideoCapture != 0 {
+			fn(dev, cp)
+		}
+		unix.Close(fd)
+	}
+	return nil
+}
+
+func listCameraDevices() ([]string, error) {
+	var cameras []string
+	err := captureDevices(func(dev string, _ v4l2Capability) {
+		cameras = append(cameras, dev)
+	})
+	return cameras, err
+}
+
+func listCaptureCards() ([]CaptureCardInfo, error) {
+	var cards []CaptureCardInfo
+	err := captureDevices(func(dev string, cp v4l2Capability) {
+		if name := unix.ByteSliceToString(cp.Card[:]); isCaptureCard(name) {
+			cards = append(cards, CaptureCardInfo{Device: dev, Name: name})
+		}
+	})
+	return cards, err
+}
+
+func openCameraDevice(config CameraConfig) (cameraDevice, error) {
+	path := config.Device
+	if path == "" {
+		path = "/dev/video0"
+	}
+
+	fd, err := unix.Open(path, unix.O_RDWR, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("camera %s: %w", path, os.ErrNotExist)
+		}
+		return nil, err
+	}
+
+	dev := &v4l2Device{fd: fd}
+	if err := dev.init(config); err != nil {
+		dev.close()
+		return nil, fmt.Errorf("camera %s: %w", path, err)
+	}
+
+	return dev, nil
+}
+
+func (d *v4l2Device) init(config CameraConfig) error {
+	var cp v4l2Capability
+	if err := ioctl(d.fd, vidiocQueryCap, unsafe.Pointer(&cp)); err != nil {
+		return err
+	}
+	if cp.Capabilities&v4l2CapVideoCapture == 0 || cp.Capabilities&v4l2CapStreaming == 0 {
+		return errors.New("device does not support streaming video capture")
+	}
+
+	if config.Native {
+		if err := d.setNativeFormat(); err != nil {
+			return err
+		}
+	} else if err := d.setFormat(config, v4l2PixFmtYUYV); err != nil {
+		if err := d.setFormat(config, v4l2PixFmtMJPEG); err != nil {
+			return err
+		}
+	}
+	if !slices.Contains(nativeFormats, d.format) || !d.native && d.format != v4l2PixFmtYUYV && d.format != v4l2PixFmtMJPEG {
+		return errors.New("device offers no supported pixel format")
+	}
+
+	if config.FPS > 0 {
+		parm := v4l2CaptureParm{Type: v4l2BufTypeVideoCapture, Numerator: 1, Denominator: uint32(config.FPS)}
+		ioctl(d.fd, vidiocSParm, unsafe.Pointer(&parm))
+	}
+
+	req := v4l2RequestBuffers{Count: v4l2BufferCount, Type: v4l2BufTypeVideoCapture, Memory: v4l2MemoryMmap}
+	if err := ioctl(d.fd, vidiocReqBufs, unsafe.Pointer(&req)); err != nil {
+		return err
+	}
+
+	for i := uint32(0); i < req.Count; i++ {
+		buf := v4l2Buffer{Index: i, Type: v4l2BufTypeVideoCapture, Memory: v4l2MemoryMmap}
+		if err := ioctl(d.fd, vidiocQueryBuf, unsafe.Pointer(&buf)); err != nil {
+			return err
+		}
+
+		mem, err := unix.Mmap(d.fd, int64(uint32(buf.Offset)), int(buf.Length), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+		if err != nil {
+			return err
+		}
+		d.buffers = append(d.buffers, mem)
+
+		if err := ioctl(d.fd, vidiocQBuf, unsafe.Pointer(&buf)); err != nil {
+			return err
+		}
+	}
+
+	bufType := uint32(v4l2BufTypeVideoCapture)
+	return ioctl(d.fd, vidiocStreamOn, unsafe.Pointer(&bufType))
+}
+
+func (d *v4l2Device) setNativeFormat() error {
+	var best CameraConfig
+	bestFormat, bestRank := uint32(0), len(nativeFormats)
+	for i := uint32(0); ; i++ {
+		desc := v4l2FmtDesc{Index: i, Type: v4l2BufTypeVideoCapture}
+		if ioctl(d.fd, vidiocEnumFmt, unsafe.Pointer(&desc)) != nil {
+			break
+		}
+		rank := slices.Index(nativeFormats, desc.PixelFormat)
+		if rank < 0 {
+			continue
+		}
+		if best.Width == 0 && rank < bestRank {
+			bestFormat, bestRank = desc.PixelFormat, rank
+		}
+
+		for j := uint32(0); ; j++ {
+			size := v4l2FrmSizeEnum{Index: j, PixelFormat: desc.PixelFormat}
+			if ioctl(d.fd, vidiocEnumSizes, unsafe.Pointer(&size)) != nil {
+				break
+			}
+
+			width, height := size.Size[0], size.Size[1]
+			if size.Type != v4l2FrmSizeDiscrete {
+				width, height = size.Size[1], size.Size[4]
+			}
+			area, bestArea := int(width)*int(height), best.Width*best.Height
+			if area > bestArea || area == bestArea && rank < bestRank {
+				best = CameraConfig{Width: int(width), Height: int(height)}
+				bestFormat, bestRank = desc.PixelFormat, rank
+			}
+		}
+	}
+	if bestFormat == 0 {
+		return errors.New("device offers no supported pixel format")
+	}
+	if best.Width == 0 {
+		best = CameraConfig{Width: 1920, Height: 1080}
+	}
+
+	d.native = true
+	slog.Info("capture card format", "width", best.Width, "height", best.Height, "format", unix.ByteSliceToString(binary.LittleEndian.AppendUint32(nil, bestFormat)))
+	return d.setFormat(best, bestFormat)
+}
+
+func (d *v4l2Device) setFormat(config CameraConfig, pixelFormat uint32) error {
+	f := v4l2Format{
+		Type: v4l2BufTypeVideoCapture,
+		Pix: v4l2PixFormat{
+			Width:       uint32(config.Width),
+			Height:      uint32(config.Height),
+			PixelFormat: pixelFormat,
+			Field:       v4l2FieldAny,
+		},
+	}
+	if err := ioctl(d.fd, vidiocSFmt, unsafe.Pointer(&f)); err != nil {
+		return err
+	}
+	if f.Pix.PixelFormat != pixelFormat {
+		return errors.New("pixel format rejected")
+	}
+
+	d.width = int(f.Pix.Width)
+	d.height = int(f.Pix.Height)
+	d.format = f.Pix.PixelFormat
+	d.stride = int(f.Pix.BytesPerLine)
+
+	hd := f.Pix.YCbCrEnc == v4l2YCbCrEnc709 || f.Pix.YCbCrEnc != v4l2YCbCrEnc601 && f.Pix.Colorspace == v4l2ColorspaceRec709
+	full := f.Pix.Quantization == v4l2QuantFullRange || f.Pix.Quantization != v4l2QuantLimRange && f.Pix.Colorspace == v4l2ColorspaceJPEG
+	d.yuv = newYUVMatrix(hd, full)
+	return nil
+}
+
+func (d *v4l2Device) read() (image.Image, error) {
+	if d.native {
+		fds := []unix.PollFd{{Fd: int32(d.fd), Events: unix.POLLIN}}
+		if n, err := unix.Poll(fds, signalTimeoutMillis); err == nil && n == 0 {
+			return nil, ErrNoSignal
+		}
+	}
+
+	buf := v4l2Buffer{Type: v4l2BufTypeVideoCapture, Memory: v4l2MemoryMmap}
+	if err := ioctl(d.fd, vidiocDQBuf, unsafe.Pointer(&buf)); err != nil {
+		if d.native && (err == unix.ENOLINK || err == unix.ENODATA || err == unix.EIO) {
+			return nil, fmt.Errorf("%w: %v", ErrNoSignal, err)
+		}
+		return nil, err
+	}
+	defer ioctl(d.fd, vidiocQBuf, unsafe.Pointer(&buf))
+
+	data := d.buffers[buf.Index][:buf.BytesUsed]
+
+	if d.format == v4l2PixFmtMJPEG {
+		return decodeMJPEG(data)
+	}
+	if !d.native {
+		return yuyvToRGBA(data, d.width, d.height), nil
+	}
+
+	switch d.format {
+	case v4l2PixFmtRGB24, v4l2PixFmtBGR24:
+		return rgb24ToRGBA(data, d.width, d.height, max(d.stride, d.width*3), d.format == v4l2PixFmtBGR24), nil
+	case v4l2PixFmtNV12:
+		return nv12ToRGBA(data, d.width, d.height, max(d.stride, d.width), d.yuv), nil
+	}
+	return packedYUVToRGBA(data, d.width, d.height, max(d.stride, d.width*2), d.format == v4l2PixFmtUYVY, d.yuv), nil
+}
+
+func (d *v4l2Device) close() error {
+	bufType := uint32(v4l2BufTypeVideoCapture)
+	ioctl(d.fd, vidiocStreamOff, unsafe.Pointer(&bufType))
+
+	for _, mem := range d.buffers {
+		unix.Munmap(mem)
+	}
+	d.buffers = nil
+
+	return unix.Close(d.fd)
+}
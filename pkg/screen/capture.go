@@ -4,137 +4,187 @@ import (
 	"image"
 	"image/color"
 	"math"
-	"os/exec"
-	"runtime"
+	"time"
+
+	"qrtransfer/pkg/qr"
 )
 
 type CaptureConfig struct {
-	Region image.Rectangle
-	FPS    int
+	Region      image.Rectangle
+	FPS         int
+	Scale       float64
+	HideCursor  bool
+	Masks       []image.Rectangle
+	MaskWindows []WindowMatcher
+	Track       bool
 }
 
 type Capturer struct {
-	config CaptureConfig
+	config   CaptureConfig
+	native   nativeCapturer
+	displays []Display
+	metrics  *Metrics
+
+	maskedWindows []image.Rectangle
+	maskedAt      time.Time
+
+	focus     image.Rectangle
+	trackedAt time.Time
+}
+
+type nativeCapturer interface {
+	capture(rect image.Rectangle) (image.Image, error)
+	close() error
 }
 
 func NewCapturer(config CaptureConfig) *Capturer {
-	return &Capturer{config: config}
+	return &Capturer{config: config, metrics: NewMetrics()}
 }
 
-func DetectQRRegion(img image.Image) image.Rectangle {
-	bounds := img.Bounds()
-	
-	step := 10
-	minX, minY := bounds.Max.X, bounds.Max.Y
-	maxX, maxY := bounds.Min.X, bounds.Min.Y
-	
-	nonWhiteCount := 0
-	totalCount := 0
-	
-	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
-		for x := bounds.Min.X; x < bounds.Max.X; x += step {
-			totalCount++
-			
-			r, g, b, _ := img.At(x, y).RGBA()
-			
-			if r < 0xFF00 || g < 0xFF00 || b < 0xFF00 {
-				nonWhiteCount++
-				
-				if x < minX {
-					minX = x
-				}
-				if x > maxX {
-					maxX = x
-				}
-				if y < minY {
-					minY = y
-				}
-				if y > maxY {
-					maxY = y
-				}
-			}
+func (c *Capturer) Close() error {
+	if c.native == nil {
+		return nil
+	}
+	err := c.native.close()
+	c.native = nil
+	return err
+}
+
+func (c *Capturer) Displays() []Display {
+	if c.displays == nil {
+		displays, err := listDisplays()
+		if err != nil {
+			displays = []Display{}
 		}
+		c.displays = displays
 	}
-	
-	if nonWhiteCount == 0 || float64(nonWhiteCount)/float64(totalCount) < 0.1 {
-		return image.Rectangle{}
+	return c.displays
+}
+
+func (c *Capturer) RefreshDisplays() {
+	c.displays = nil
+}
+
+func (c *Capturer) PixelRect(rect image.Rectangle) image.Rectangle {
+	if rect.Empty() {
+		return rect
 	}
-	
-	padding := 20
-	minX -= padding
-	minY -= padding
-	maxX += padding
-	maxY += padding
-	
-	if minX < bounds.Min.X {
-		minX = bounds.Min.X
+	if c.config.Scale > 0 {
+		return Display{Scale: c.config.Scale}.ToPixels(rect)
+	}
+
+	d, ok := displayAt(c.Displays(), rect.Min, false)
+	if !ok {
+		return rect
 	}
-	if minY < bounds.Min.Y {
-		minY = bounds.Min.Y
+	return d.ToPixels(rect)
+}
+
+func (c *Capturer) LogicalRect(rect image.Rectangle) image.Rectangle {
+	if rect.Empty() {
+		return rect
 	}
-	if maxX > bounds.Max.X {
-		maxX = bounds.Max.X
+	if c.config.Scale > 0 {
+		return Display{Scale: c.config.Scale}.ToLogical(rect)
 	}
-	if maxY > bounds.Max.Y {
-		maxY = bounds.Max.Y
+
+	d, ok := displayAt(c.Displays(), rect.Min, true)
+	if !ok {
+		return rect
+	}
+	return d.ToLogical(rect)
+}
+
+func DetectQRRegion(img image.Image) image.Rectangle {
+	return detectRegion(img, 0)
+}
+
+func detectRegion(img image.Image, threshold int) image.Rectangle {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return image.Rectangle{}
 	}
 	
-	return image.Rect(minX, minY, maxX, maxY)
+	grid := sampleLuminance(img, regionSampleStep)
+	component, count := grid.largestComponent(grid.foreground(threshold))
+	
+	if count == 0 || float64(count)/float64(len(grid.lum)) < regionMinFraction {
+		return image.Rectangle{}
+	}
+	
+	region := grid.toImage(component)
+	region.Min = region.Min.Sub(image.Pt(regionPadding, regionPadding))
+	region.Max = region.Max.Add(image.Pt(regionPadding, regionPadding))
+	
+	return region.Intersect(bounds)
 }
 
 func FindGridLines(img image.Image, blockSize int) (int, int, int, int) {
 	bounds := img.Bounds()
-	
-	rows := make([]int, 0)
-	cols := make([]int, 0)
+	w, h := bounds.Dx(), bounds.Dy()
+	plane := grayPlane(img)
 	
 	threshold := 128
 	
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+	rows := 0
+	colEdges := make([]int, w)
+	for y := 0; y < h; y++ {
+		line := plane[y*w : (y+1)*w]
+		
 		edgeCount := 0
-		for x := bounds.Min.X; x < bounds.Max.X-1; x++ {
-			c1 := img.At(x, y)
-			c2 := img.At(x+1, y)
-			
-			r1, g1, b1, _ := c1.RGBA()
-			r2, g2, b2, _ := c2.RGBA()
-			
-			gray1 := int((r1+g1+b1)/3) >> 8
-			gray2 := int((r2+g2+b2)/3) >> 8
-			
-			if abs(gray1-gray2) > threshold {
+		for x := 1; x < w; x++ {
+			if abs(int(line[x-1])-int(line[x])) > threshold {
 				edgeCount++
 			}
 		}
+		if edgeCount > w/4 {
+			rows++
+		}
 		
-		if edgeCount > bounds.Dx()/4 {
-			rows = append(rows, y)
+		if y+1 < h {
+			below := plane[(y+1)*w : (y+2)*w]
+			for x, v := range line {
+				if abs(int(v)-int(below[x])) > threshold {
+					colEdges[x]++
+				}
+			}
 		}
 	}
 	
-	for x := bounds.Min.X; x < bounds.Max.X; x++ {
-		edgeCount := 0
-		for y := bounds.Min.Y; y < bounds.Max.Y-1; y++ {
-			c1 := img.At(x, y)
-			c2 := img.At(x, y+1)
-			
-			r1, g1, b1, _ := c1.RGBA()
-			r2, g2, b2, _ := c2.RGBA()
-			
-			gray1 := int((r1+g1+b1)/3) >> 8
-			gray2 := int((r2+g2+b2)/3) >> 8
-			
-			if abs(gray1-gray2) > threshold {
-				edgeCount++
-			}
+	cols := 0
+	for _, edgeCount := range colEdges {
+		if edgeCount > h/4 {
+			cols++
 		}
-		
-		if edgeCount > bounds.Dy()/4 {
-			cols = append(cols, x)
+	}
+	
+	return rows, cols, w, h
+}
+
+func grayPlane(img image.Image) []uint8 {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	plane := make([]uint8, w*h)
+	
+	if rgba, ok := img.(*image.RGBA); ok {
+		for y := 0; y < h; y++ {
+			pix := rgba.Pix[rgba.PixOffset(bounds.Min.X, bounds.Min.Y+y):]
+			line := plane[y*w : (y+1)*w]
+			for x := range line {
+				sum := uint32(pix[4*x]) + uint32(pix[4*x+1]) + uint32(pix[4*x+2])
+				line[x] = uint8(sum * 0x101 / 3 >> 8)
+			}
 		}
+		return plane
 	}
 	
-	return len(rows), len(cols), bounds.Dx(), bounds.Dy()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			plane[y*w+x] = uint8((r + g + b) / 3 >> 8)
+		}
+	}
+	return plane
 }
 
 func abs(n int) int {
@@ -145,19 +195,19 @@ func abs(n int) int {
 }
 
 func EstimateGridSize(img image.Image, blockSize int) (int, int) {
-	detected := DetectQRRegion(img)
+	return estimateGridSize(img, blockSize, 0)
+}
+
+func estimateGridSize(img image.Image, blockSize, threshold int) (int, int) {
+	detected := detectRegion(img, threshold)
 	if detected.Empty() {
 		bounds := img.Bounds()
 		return bounds.Dx() / blockSize, bounds.Dy() / blockSize
 	}
 	
-	rows, cols, _, _ := FindGridLines(img, blockSize)
+	rows, cols, _, _ := FindGridLines(cropImage(img, detected.Sub(img.Bounds().Min)), blockSize)
 	
 	if rows == 0 || cols == 0 {
-		detected = DetectQRRegion(img)
-		if detected.Empty() {
-			return 0, 0
-		}
 		rows = detected.Dy() / blockSize
 		cols = detected.Dx() / blockSize
 	}
@@ -172,39 +222,27 @@ func EstimateGridSize(img image.Image, blockSize int) (int, int) {
 	return cols, rows
 }
 
-func GetDisplaySize() (int, int) {
-	switch runtime.GOOS {
-	case "darwin":
-		cmd := exec.Command("system_profiler", "SPDisplaysDataType", "-json")
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			return 1920, 1080
-		}
-		_ = output
-		return 1920, 1080
-	case "linux":
-		cmd := exec.Command("xrandr", "--query", "--current")
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			return 1920, 1080
-		}
-		_ = output
-		return 1920, 1080
-	case "windows":
-		return 1920, 1080
-	default:
-		return 1920, 1080
-	}
-}
+type AnalysisMode int
+
+const (
+	AnalysisMean AnalysisMode = iota
+	AnalysisDominant
+)
 
-type ColorAnalyzer struct{}
+type ColorAnalyzer struct {
+	Mode AnalysisMode
+}
 
 func (c *ColorAnalyzer) AnalyzeBlock(block image.Image) color.RGBA {
+	if c.Mode == AnalysisDominant {
+		return c.dominantColor(block)
+	}
+
 	bounds := block.Bounds()
-	
+
 	totalR, totalG, totalB := 0, 0, 0
 	count := 0
-	
+
 	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
 		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 			r, g, b, _ := block.At(x, y).RGBA()
@@ -214,11 +252,11 @@ func (c *ColorAnalyzer) AnalyzeBlock(block image.Image) color.RGBA {
 			count++
 		}
 	}
-	
+
 	if count == 0 {
 		return color.RGBA{0, 0, 0, 255}
 	}
-	
+
 	return color.RGBA{
 		R: uint8(totalR / count),
 		G: uint8(totalG / count),
@@ -227,6 +265,10 @@ func (c *ColorAnalyzer) AnalyzeBlock(block image.Image) color.RGBA {
 	}
 }
 
+func (c *ColorAnalyzer) dominantColor(block image.Image) color.RGBA {
+	return qr.DominantColor(asRGBA(block), block.Bounds())
+}
+
 func (c *ColorAnalyzer) CalculateBlockSize(img image.Image, expectedGridWidth, expectedGridHeight int) int {
 	bounds := img.Bounds()
 	
@@ -241,3 +283,18 @@ func (c *ColorAnalyzer) CalculateBlockSize(img image.Image, expectedGridWidth, e
 	
 	return int(math.Ceil(float64(blockSize)))
 }
+
+func cropImage(img image.Image, rect image.Rectangle) image.Image {
+	if rect.Empty() {
+		return img
+	}
+
+	rect = rect.Add(img.Bounds().Min).Intersect(img.Bounds())
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+
+	return img
+}
@@ -1,20 +1,29 @@
-//go:build darwin
+//go:build darwin && !ios
 
 package screen
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"image"
 	"image/png"
 	"os/exec"
 )
 
-func (c *Capturer) Capture() (image.Image, error) {
-	cmd := exec.Command("screencapture", "-x", "-t", "png", "-")
+func screencapture(rect image.Rectangle) (image.Image, error) {
+	args := []string{"-x", "-t", "png"}
+	if !rect.Empty() {
+		args = append(args, "-R", fmt.Sprintf("%d,%d,%d,%d", rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy()))
+	}
+	args = append(args, "-")
 
-	output, err := cmd.Output()
+	output, err := exec.Command("screencapture", args...).Output()
 	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && bytes.Contains(exitErr.Stderr, []byte("could not create image")) {
+			return nil, &PermissionError{Kind: PermissionScreenRecording, Err: err}
+		}
 		return nil, err
 	}
 
@@ -26,23 +35,9 @@ func (c *Capturer) Capture() (image.Image, error) {
 	return img, nil
 }
 
-func (c *Capturer) CaptureRegion(rect image.Rectangle) (image.Image, error) {
-	if rect.Empty() {
-		return c.Capture()
+func openPermissionSettings(kind PermissionKind) error {
+	if kind != PermissionScreenRecording {
+		return ErrUnsupported
 	}
-
-	cmd := exec.Command("screencapture", "-x", "-t", "png", "-R",
-		fmt.Sprintf("%d,%d,%d,%d", rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy()), "-")
-
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
-	}
-
-	img, err := png.Decode(bytes.NewReader(output))
-	if err != nil {
-		return nil, err
-	}
-
-	return img, nil
+	return exec.Command("open", "x-apple.systempreferences:com.apple.preference.security?Privacy_ScreenCapture").Run()
 }
@@ -0,0 +1,24 @@
+//go:build darwin && !ios && !cgo
+
+package screen
+
+import (
+	"image"
+)
+
+func (c *Capturer) Capture() (image.Image, error) {
+	return c.CaptureRegion(image.Rectangle{})
+}
+
+func (c *Capturer) CaptureRegion(rect image.Rectangle) (image.Image, error) {
+	img, err := screencapture(rect)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.applyMasks(img, rect), nil
+}
+
+func (c *Capturer) ListWindows() ([]WindowInfo, error) {
+	return nil, ErrUnsupported
+}
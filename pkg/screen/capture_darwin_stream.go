@@ -0,0 +1,287 @@
+//go:build darwin && !ios && cgo
+
+package screen
+
+/*
+#cgo CFLAGS: -x objective-c -fblocks
+#cgo LDFLAGS: -framework CoreGraphics -framework IOSurface -framework CoreFoundation
+#include <CoreGraphics/CoreGraphics.h>
+#include <IOSurface/IOSurface.h>
+#include <dispatch/dispatch.h>
+#include <pthread.h>
+#include <stdlib.h>
+#include <string.h>
+
+typedef struct {
+	CGDisplayStreamRef stream;
+	dispatch_queue_t queue;
+	pthread_mutex_t lock;
+	uint8_t *pixels;
+	size_t width;
+	size_t height;
+	size_t stride;
+	uint64_t seq;
+} owl_stream;
+
+static owl_stream *owl_stream_start(CGDirectDisplayID display, double frameTime, int showCursor) {
+	CGDisplayModeRef mode = CGDisplayCopyDisplayMode(display);
+	if (mode == NULL) {
+		return NULL;
+	}
+	size_t width = CGDisplayModeGetPixelWidth(mode);
+	size_t height = CGDisplayModeGetPixelHeight(mode);
+	CGDisplayModeRelease(mode);
+
+	owl_stream *s = calloc(1, sizeof(owl_stream));
+	pthread_mutex_init(&s->lock, NULL);
+
+	CFNumberRef minTime = CFNumberCreate(NULL, kCFNumberDoubleType, &frameTime);
+	const void *keys[] = { kCGDisplayStreamMinimumFrameTime, kCGDisplayStreamShowCursor };
+	const void *values[] = { minTime, showCursor ? kCFBooleanTrue : kCFBooleanFalse };
+	CFDictionaryRef props = CFDictionaryCreate(NULL, keys, values, 2,
+		&kCFTypeDictionaryKeyCallBacks, &kCFTypeDictionaryValueCallBacks);
+	CFRelease(minTime);
+
+	s->queue = dispatch_queue_create("owl.capture", DISPATCH_QUEUE_SERIAL);
+	s->stream = CGDisplayStreamCreateWithDispatchQueue(display, width, height, 'BGRA', props, s->queue,
+		^(CGDisplayStreamFrameStatus status, uint64_t time, IOSurfaceRef frame, CGDisplayStreamUpdateRef update) {
+			if (status != kCGDisplayStreamFrameStatusFrameComplete || frame == NULL) {
+				return;
+			}
+
+			IOSurfaceLock(frame, kIOSurfaceLockReadOnly, NULL);
+			size_t stride = IOSurfaceGetBytesPerRow(frame);
+			size_t h = IOSurfaceGetHeight(frame);
+			size_t w = IOSurfaceGetWidth(frame);
+
+			pthread_mutex_lock(&s->lock);
+			if (s->pixels == NULL || s->stride * s->height != stride * h) {
+				free(s->pixels);
+				s->pixels = malloc(stride * h);
+			}
+			memcpy(s->pixels, IOSurfaceGetBaseAddress(frame), stride * h);
+			s->width = w;
+			s->height = h;
+			s->stride = stride;
+			s->seq++;
+			pthread_mutex_unlock(&s->lock);
+
+			IOSurfaceUnlock(frame, kIOSurfaceLockReadOnly, NULL);
+		});
+	CFRelease(props);
+
+	if (s->stream == NULL || CGDisplayStreamStart(s->stream) != kCGErrorSuccess) {
+		if (s->stream != NULL) {
+			CFRelease(s->stream);
+		}
+		dispatch_release(s->queue);
+		pthread_mutex_destroy(&s->lock);
+		free(s);
+		return NULL;
+	}
+
+	return s;
+}
+
+static void owl_stream_lock(owl_stream *s) {
+	pthread_mutex_lock(&s->lock);
+}
+
+static void owl_stream_unlock(owl_stream *s) {
+	pthread_mutex_unlock(&s->lock);
+}
+
+typedef struct {
+	uint64_t id;
+	char title[256];
+	char owner[256];
+	double x, y, width, height;
+} owl_window;
+
+static void owl_copy_string(CFDictionaryRef info, CFStringRef key, char *dst, size_t size) {
+	dst[0] = 0;
+	CFStringRef value = CFDictionaryGetValue(info, key);
+	if (value != NULL) {
+		CFStringGetCString(value, dst, size, kCFStringEncodingUTF8);
+	}
+}
+
+static int owl_list_windows(owl_window *out, int max) {
+	CFArrayRef list = CGWindowListCopyWindowInfo(
+		kCGWindowListOptionOnScreenOnly | kCGWindowListExcludeDesktopElements, kCGNullWindowID);
+	if (list == NULL) {
+		return -1;
+	}
+
+	int count = 0;
+	for (CFIndex i = 0; i < CFArrayGetCount(list) && count < max; i++) {
+		CFDictionaryRef info = CFArrayGetValueAtIndex(list, i);
+
+		CFNumberRef number = CFDictionaryGetValue(info, kCGWindowNumber);
+		CFDictionaryRef boundsDict = CFDictionaryGetValue(info, kCGWindowBounds);
+		CGRect bounds;
+		if (number == NULL || boundsDict == NULL || !CGRectMakeWithDictionaryRepresentation(boundsDict, &bounds)) {
+			continue;
+		}
+
+		int64_t id = 0;
+		CFNumberGetValue(number, kCFNumberSInt64Type, &id);
+
+		owl_window *w = &out[count++];
+		w->id = (uint64_t)id;
+		owl_copy_string(info, kCGWindowName, w->title, sizeof(w->title));
+		owl_copy_string(info, kCGWindowOwnerName, w->owner, sizeof(w->owner));
+		w->x = bounds.origin.x;
+		w->y = bounds.origin.y;
+		w->width = bounds.size.width;
+		w->height = bounds.size.height;
+	}
+
+	CFRelease(list);
+	return count;
+}
+
+static void owl_stream_stop(owl_stream *s) {
+	CGDisplayStreamStop(s->stream);
+	dispatch_sync(s->queue, ^{});
+	CFRelease(s->stream);
+	dispatch_release(s->queue);
+	free(s->pixels);
+	pthread_mutex_destroy(&s->lock);
+	free(s);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"image"
+	"time"
+	"unsafe"
+)
+
+const (
+	firstFrameTimeout = 2 * time.Second
+	maxWindows        = 512
+)
+
+type displayStream struct {
+	stream *C.owl_stream
+}
+
+func (c *Capturer) Capture() (image.Image, error) {
+	return c.CaptureRegion(image.Rectangle{})
+}
+
+func (c *Capturer) CaptureRegion(rect image.Rectangle) (image.Image, error) {
+	if c.native == nil {
+		if !C.CGPreflightScreenCaptureAccess() {
+			C.CGRequestScreenCaptureAccess()
+			return nil, &PermissionError{Kind: PermissionScreenRecording}
+		}
+
+		stream, err := startDisplayStream(C.CGMainDisplayID(), c.config.FPS, !c.config.HideCursor)
+		if err != nil {
+			img, err := screencapture(rect)
+			if err != nil {
+				return nil, err
+			}
+			return c.applyMasks(img, rect), nil
+		}
+		c.native = stream
+	}
+
+	img, err := c.native.capture(c.PixelRect(rect))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.applyMasks(img, rect), nil
+}
+
+func (c *Capturer) ListWindows() ([]WindowInfo, error) {
+	buf := make([]C.owl_window, maxWindows)
+	n := int(C.owl_list_windows(&buf[0], C.int(len(buf))))
+	if n < 0 {
+		return nil, errors.New("window list unavailable")
+	}
+
+	windows := make([]WindowInfo, 0, n)
+	for _, w := range buf[:n] {
+		x, y := int(w.x), int(w.y)
+		windows = append(windows, WindowInfo{
+			ID:     uint64(w.id),
+			Title:  C.GoString(&w.title[0]),
+			Owner:  C.GoString(&w.owner[0]),
+			Bounds: image.Rect(x, y, x+int(w.width), y+int(w.height)),
+		})
+	}
+
+	return windows, nil
+}
+
+func startDisplayStream(display C.CGDirectDisplayID, fps int, showCursor bool) (*displayStream, error) {
+	if fps <= 0 {
+		fps = 30
+	}
+
+	cursor := C.int(0)
+	if showCursor {
+		cursor = 1
+	}
+
+	stream := C.owl_stream_start(display, C.double(1.0/float64(fps)), cursor)
+	if stream == nil {
+		return nil, errors.New("display stream unavailable")
+	}
+
+	return &displayStream{stream: stream}, nil
+}
+
+func (d *displayStream) capture(rect image.Rectangle) (image.Image, error) {
+	deadline := time.Now().Add(firstFrameTimeout)
+	for {
+		C.owl_stream_lock(d.stream)
+		if d.stream.seq > 0 {
+			break
+		}
+		C.owl_stream_unlock(d.stream)
+
+		if time.Now().After(deadline) {
+			return nil, errors.New("display stream produced no frames")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer C.owl_stream_unlock(d.stream)
+
+	width := int(d.stream.width)
+	height := int(d.stream.height)
+	stride := int(d.stream.stride)
+	pixels := unsafe.Slice((*byte)(unsafe.Pointer(d.stream.pixels)), stride*height)
+
+	full := image.Rect(0, 0, width, height)
+	if rect.Empty() {
+		rect = full
+	} else {
+		rect = rect.Intersect(full)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	for y := 0; y < rect.Dy(); y++ {
+		src := pixels[(rect.Min.Y+y)*stride+rect.Min.X*4:]
+		dst := img.Pix[y*img.Stride:]
+		for x := 0; x < rect.Dx(); x++ {
+			dst[x*4] = src[x*4+2]
+			dst[x*4+1] = src[x*4+1]
+			dst[x*4+2] = src[x*4]
+			dst[x*4+3] = 255
+		}
+	}
+
+	return img, nil
+}
+
+func (d *displayStream) close() error {
+	C.owl_stream_stop(d.stream)
+	return nil
+}
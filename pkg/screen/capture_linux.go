@@ -0,0 +1,283 @@
+//go:build linux && !android
+
+package screen
+
+import (
+	"errors"
+	"image"
+	"os"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/shm"
+	"github.com/jezek/xgb/xproto"
+	"golang.org/x/sys/unix"
+)
+
+var ErrWaylandCapture = errors.New("screen capture through the xdg-desktop-portal ScreenCast interface is not implemented, so Wayland sessions cannot be captured; log in to an X11 session or capture from a camera or capture card")
+
+func (c *Capturer) Capture() (image.Image, error) {
+	return c.CaptureRegion(image.Rectangle{})
+}
+
+func (c *Capturer) CaptureRegion(rect image.Rectangle) (image.Image, error) {
+	if c.native == nil {
+		native, err := newLinuxCapturer()
+		if err != nil {
+			return nil, err
+		}
+		c.native = native
+	}
+
+	img, err := c.native.capture(c.PixelRect(rect))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.applyMasks(img, rect), nil
+}
+
+func (c *Capturer) ListWindows() ([]WindowInfo, error) {
+	if c.native == nil {
+		native, err := newLinuxCapturer()
+		if err != nil {
+			return nil, err
+		}
+		c.native = native
+	}
+
+	x, ok := c.native.(*x11Capturer)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+
+	windows, err := x.listWindows()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range windows {
+		windows[i].Bounds = c.LogicalRect(windows[i].Bounds)
+	}
+	return windows, nil
+}
+
+func newLinuxCapturer() (nativeCapturer, error) {
+	if os.Getenv("XDG_SESSION_TYPE") == "wayland" || (os.Getenv("WAYLAND_DISPLAY") != "" && os.Getenv("DISPLAY") == "") {
+		return nil, ErrWaylandCapture
+	}
+
+	return newX11Capturer()
+}
+
+type x11Capturer struct {
+	conn   *xgb.Conn
+	screen *xproto.ScreenInfo
+
+	useShm  bool
+	seg     shm.Seg
+	shmBuf  []byte
+	shmSize int
+}
+
+func newX11Capturer() (*x11Capturer, error) {
+	conn, err := xgb.NewConn()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &x11Capturer{
+		conn:   conn,
+		screen: xproto.Setup(conn).DefaultScreen(conn),
+	}
+
+	if err := shm.Init(conn); err == nil {
+		c.useShm = true
+	}
+
+	return c, nil
+}
+
+func (c *x11Capturer) bounds() image.Rectangle {
+	return image.Rect(0, 0, int(c.screen.WidthInPixels), int(c.screen.HeightInPixels))
+}
+
+func (c *x11Capturer) capture(rect image.Rectangle) (image.Image, error) {
+	full := c.bounds()
+	if rect.Empty() {
+		rect = full
+	} else {
+		rect = rect.Intersect(full)
+	}
+	if rect.Empty() {
+		return nil, errors.New("capture region is outside the screen")
+	}
+
+	if c.useShm {
+		img, err := c.captureShm(rect)
+		if err == nil {
+			return img, nil
+		}
+		c.releaseShm()
+		c.useShm = false
+	}
+
+	reply, err := xproto.GetImage(c.conn, xproto.ImageFormatZPixmap, xproto.Drawable(c.screen.Root),
+		int16(rect.Min.X), int16(rect.Min.Y), uint16(rect.Dx()), uint16(rect.Dy()), 0xffffffff).Reply()
+	if err != nil {
+		return nil, err
+	}
+
+	return bgraToRGBA(reply.Data, rect.Dx(), rect.Dy()), nil
+}
+
+func (c *x11Capturer) captureShm(rect image.Rectangle) (image.Image, error) {
+	size := rect.Dx() * rect.Dy() * 4
+	if size > c.shmSize {
+		c.releaseShm()
+		if err := c.allocShm(size); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err := shm.GetImage(c.conn, xproto.Drawable(c.screen.Root),
+		int16(rect.Min.X), int16(rect.Min.Y), uint16(rect.Dx()), uint16(rect.Dy()),
+		0xffffffff, xproto.ImageFormatZPixmap, c.seg, 0).Reply()
+	if err != nil {
+		return nil, err
+	}
+
+	return bgraToRGBA(c.shmBuf[:size], rect.Dx(), rect.Dy()), nil
+}
+
+func (c *x11Capturer) allocShm(size int) error {
+	id, err := unix.SysvShmGet(unix.IPC_PRIVATE, size, unix.IPC_CREAT|0600)
+	if err != nil {
+		return err
+	}
+	defer unix.SysvShmCtl(id, unix.IPC_RMID, nil)
+
+	buf, err := unix.SysvShmAttach(id, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	seg, err := shm.NewSegId(c.conn)
+	if err != nil {
+		unix.SysvShmDetach(buf)
+		return err
+	}
+
+	if err := shm.AttachChecked(c.conn, seg, uint32(id), false).Check(); err != nil {
+		unix.SysvShmDetach(buf)
+		return err
+	}
+
+	c.seg = seg
+	c.shmBuf = buf
+	c.shmSize = size
+	return nil
+}
+
+func (c *x11Capturer) releaseShm() {
+	if c.shmBuf == nil {
+		return
+	}
+	shm.Detach(c.conn, c.seg)
+	unix.SysvShmDetach(c.shmBuf)
+	c.shmBuf = nil
+	c.shmSize = 0
+}
+
+func (c *x11Capturer) close() error {
+	c.releaseShm()
+	c.conn.Close()
+	return nil
+}
+
+func (c *x11Capturer) atom(name string) (xproto.Atom, error) {
+	reply, err := xproto.InternAtom(c.conn, true, uint16(len(name)), name).Reply()
+	if err != nil {
+		return xproto.AtomNone, err
+	}
+	return reply.Atom, nil
+}
+
+func (c *x11Capturer) listWindows() ([]WindowInfo, error) {
+	ids, err := c.clientWindows()
+	if err != nil {
+		return nil, err
+	}
+
+	windows := make([]WindowInfo, 0, len(ids))
+	for _, id := range ids {
+		geom, err := xproto.GetGeometry(c.conn, xproto.Drawable(id)).Reply()
+		if err != nil {
+			continue
+		}
+
+		pos, err := xproto.TranslateCoordinates(c.conn, id, c.screen.Root, 0, 0).Reply()
+		if err != nil {
+			continue
+		}
+
+		windows = append(windows, WindowInfo{
+			ID:     uint64(id),
+			Title:  c.windowTitle(id),
+			Bounds: image.Rect(int(pos.DstX), int(pos.DstY), int(pos.DstX)+int(geom.Width), int(pos.DstY)+int(geom.Height)),
+		})
+	}
+
+	return windows, nil
+}
+
+func (c *x11Capturer) clientWindows() ([]xproto.Window, error) {
+	clientList, err := c.atom("_NET_CLIENT_LIST")
+	if err == nil && clientList != xproto.AtomNone {
+		prop, err := xproto.GetProperty(c.conn, false, c.screen.Root, clientList, xproto.AtomWindow, 0, 1<<16).Reply()
+		if err == nil && len(prop.Value) > 0 {
+			ids := make([]xproto.Window, 0, len(prop.Value)/4)
+			for i := 0; i+4 <= len(prop.Value); i += 4 {
+				ids = append(ids, xproto.Window(xgb.Get32(prop.Value[i:])))
+			}
+			return ids, nil
+		}
+	}
+
+	tree, err := xproto.QueryTree(c.conn, c.screen.Root).Reply()
+	if err != nil {
+		return nil, err
+	}
+	return tree.Children, nil
+}
+
+func (c *x11Capturer) windowTitle(id xproto.Window) string {
+	netName, err := c.atom("_NET_WM_NAME")
+	if err == nil && netName != xproto.AtomNone {
+		utf8, _ := c.atom("UTF8_STRING")
+		prop, err := xproto.GetProperty(c.conn, false, id, netName, utf8, 0, 1024).Reply()
+		if err == nil && len(prop.Value) > 0 {
+			return string(prop.Value)
+		}
+	}
+
+	prop, err := xproto.GetProperty(c.conn, false, id, xproto.AtomWmName, xproto.AtomString, 0, 1024).Reply()
+	if err != nil {
+		return ""
+	}
+	return string(prop.Value)
+}
+
+func bgraToRGBA(data []byte, width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i+3 < len(data) && i+3 < len(img.Pix); i += 4 {
+		img.Pix[i] = data[i+2]
+		img.Pix[i+1] = data[i+1]
+		img.Pix[i+2] = data[i]
+		img.Pix[i+3] = 255
+	}
+	return img
+}
+
+func openPermissionSettings(kind PermissionKind) error {
+	return ErrUnsupported
+}
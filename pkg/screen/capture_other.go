@@ -1,4 +1,4 @@
-//go:build !darwin
+//go:build (!darwin && !linux) || ios || android
 
 package screen
 
@@ -13,3 +13,11 @@ func (c *Capturer) Capture() (image.Image, error) {
 func (c *Capturer) CaptureRegion(rect image.Rectangle) (image.Image, error) {
 	return nil, nil
 }
+
+func (c *Capturer) ListWindows() ([]WindowInfo, error) {
+	return nil, ErrUnsupported
+}
+
+func openPermissionSettings(kind PermissionKind) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,137 @@
+package screen
+
+import (
+	"errors"
+	"image"
+	"strings"
+)
+
+var (
+	ErrNoSignal      = errors.New("capture card has no input signal")
+	ErrNoCaptureCard = errors.New("no capture card found")
+)
+
+type CaptureCardConfig struct {
+	Device string
+	FPS    int
+}
+
+type CaptureCardInfo struct {
+	Device string
+	Name   string
+}
+
+var captureCardNames = []string{"capture", "hdmi", "cam link", "elgato", "magewell", "avermedia", "grabber", "usb3"}
+
+func isCaptureCard(name string) bool {
+	name = strings.ToLower(name)
+	for _, n := range captureCardNames {
+		if strings.Contains(name, n) {
+			return true
+		}
+	}
+	return false
+}
+
+func OpenCaptureCard(config CaptureCardConfig) (*Camera, error) {
+	if config.Device == "" {
+		cards, err := ListCaptureCards()
+		if err != nil {
+			return nil, err
+		}
+		if len(cards) == 0 {
+			return nil, ErrNoCaptureCard
+		}
+		config.Device = cards[0].Device
+	}
+	return OpenCamera(CameraConfig{Device: config.Device, FPS: config.FPS, Native: true})
+}
+
+func ListCaptureCards() ([]CaptureCardInfo, error) {
+	return listCaptureCards()
+}
+
+type yuvMatrix struct {
+	yOffset, yScale, cScale int32
+	rv, gu, gv, bu          int32
+}
+
+var (
+	bt601 = yuvMatrix{rv: 91881, gu: 22554, gv: 46802, bu: 116130}
+	bt709 = yuvMatrix{rv: 103206, gu: 12276, gv: 30679, bu: 121609}
+)
+
+func newYUVMatrix(hd, full bool) yuvMatrix {
+	m := bt601
+	if hd {
+		m = bt709
+	}
+	m.yScale, m.cScale = 1<<16, 1<<16
+	if !full {
+		m.yOffset, m.yScale, m.cScale = 16, 255<<16/219, 255<<16/224
+	}
+	return m
+}
+
+func (m yuvMatrix) rgb(y, cb, cr uint8) (uint8, uint8, uint8) {
+	yy := (int32(y) - m.yOffset) * m.yScale
+	u := (int32(cb) - 128) * m.cScale >> 16
+	v := (int32(cr) - 128) * m.cScale >> 16
+	return clampChannel(yy + m.rv*v), clampChannel(yy - m.gu*u - m.gv*v), clampChannel(yy + m.bu*u)
+}
+
+func packedYUVToRGBA(data []byte, width, height, stride int, uyvy bool, m yuvMatrix) *image.RGBA {
+	y0, cb, y1, cr := 0, 1, 2, 3
+	if uyvy {
+		y0, cb, y1, cr = 1, 0, 3, 2
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for row := 0; row < height && (row+1)*stride <= len(data); row++ {
+		line := data[row*stride:]
+		p := img.PixOffset(0, row)
+		for x := 0; x+1 < width; x, p = x+2, p+8 {
+			px := line[x*2 : x*2+4]
+			img.Pix[p], img.Pix[p+1], img.Pix[p+2] = m.rgb(px[y0], px[cb], px[cr])
+			img.Pix[p+4], img.Pix[p+5], img.Pix[p+6] = m.rgb(px[y1], px[cb], px[cr])
+			img.Pix[p+3], img.Pix[p+7] = 255, 255
+		}
+	}
+	return img
+}
+
+func nv12ToRGBA(data []byte, width, height, stride int, m yuvMatrix) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	chroma := stride * height
+	if len(data) < chroma+stride*(height/2) {
+		return img
+	}
+
+	for row := 0; row < height; row++ {
+		luma := data[row*stride:]
+		uv := data[chroma+(row/2)*stride:]
+		p := img.PixOffset(0, row)
+		for x := 0; x < width; x, p = x+1, p+4 {
+			c := x &^ 1
+			img.Pix[p], img.Pix[p+1], img.Pix[p+2] = m.rgb(luma[x], uv[c], uv[c+1])
+			img.Pix[p+3] = 255
+		}
+	}
+	return img
+}
+
+func rgb24ToRGBA(data []byte, width, height, stride int, bgr bool) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for row := 0; row < height && (row+1)*stride <= len(data); row++ {
+		line := data[row*stride:]
+		p := img.PixOffset(0, row)
+		for x := 0; x < width; x, p = x+1, p+4 {
+			r, g, b := line[x*3], line[x*3+1], line[x*3+2]
+			if bgr {
+				r, b = b, r
+			}
+			img.Pix[p], img.Pix[p+1], img.Pix[p+2], img.Pix[p+3] = r, g, b, 255
+		}
+	}
+	return img
+}
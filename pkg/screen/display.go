@@ -0,0 +1,110 @@
+package screen
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	fallbackDisplayWidth  = 1920
+	fallbackDisplayHeight = 1080
+)
+
+type Display struct {
+	ID      string
+	Name    string
+	Bounds  image.Rectangle
+	Pixels  image.Rectangle
+	Scale   float64
+	Primary bool
+}
+
+func ListDisplays() ([]Display, error) {
+	return listDisplays()
+}
+
+func PrimaryDisplay() (Display, error) {
+	displays, err := listDisplays()
+	if err != nil {
+		return Display{}, err
+	}
+	if len(displays) == 0 {
+		return Display{}, ErrUnsupported
+	}
+
+	for _, d := range displays {
+		if d.Primary {
+			return d, nil
+		}
+	}
+	return displays[0], nil
+}
+
+func hasPrimary(displays []Display) bool {
+	for _, d := range displays {
+		if d.Primary {
+			return true
+		}
+	}
+	return false
+}
+
+func GetDisplaySize() (int, int) {
+	d, err := PrimaryDisplay()
+	if err != nil || d.Pixels.Empty() {
+		return fallbackDisplayWidth, fallbackDisplayHeight
+	}
+	return d.Pixels.Dx(), d.Pixels.Dy()
+}
+
+func (d Display) ToPixels(rect image.Rectangle) image.Rectangle {
+	return image.Rectangle{
+		Min: mapPoint(rect.Min, d.Bounds.Min, d.Pixels.Min, d.Scale),
+		Max: mapPoint(rect.Max, d.Bounds.Min, d.Pixels.Min, d.Scale),
+	}
+}
+
+func (d Display) ToLogical(rect image.Rectangle) image.Rectangle {
+	return image.Rectangle{
+		Min: mapPoint(rect.Min, d.Pixels.Min, d.Bounds.Min, 1/d.Scale),
+		Max: mapPoint(rect.Max, d.Pixels.Min, d.Bounds.Min, 1/d.Scale),
+	}
+}
+
+func mapPoint(p, from, to image.Point, scale float64) image.Point {
+	return image.Point{
+		X: to.X + int(math.Round(float64(p.X-from.X)*scale)),
+		Y: to.Y + int(math.Round(float64(p.Y-from.Y)*scale)),
+	}
+}
+
+func displayAt(displays []Display, p image.Point, pixels bool) (Display, bool) {
+	for _, d := range displays {
+		bounds := d.Bounds
+		if pixels {
+			bounds = d.Pixels
+		}
+		if p.In(bounds) {
+			return d, true
+		}
+	}
+	return Display{}, false
+}
+
+func newDisplay(id, name string, origin image.Point, pixels image.Rectangle, scale float64, primary bool) Display {
+	if scale <= 0 {
+		scale = 1
+	}
+
+	w := int(math.Round(float64(pixels.Dx()) / scale))
+	h := int(math.Round(float64(pixels.Dy()) / scale))
+
+	return Display{
+		ID:      id,
+		Name:    name,
+		Bounds:  image.Rectangle{Min: origin, Max: origin.Add(image.Pt(w, h))},
+		Pixels:  pixels,
+		Scale:   scale,
+		Primary: primary,
+	}
+}
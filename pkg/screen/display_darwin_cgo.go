@@ -0,0 +1,81 @@
+//go:build darwin && !ios && cgo
+
+package screen
+
+/*
+#cgo LDFLAGS: -framework CoreGraphics
+#include <CoreGraphics/CoreGraphics.h>
+
+typedef struct {
+	uint32_t id;
+	double x, y, width, height;
+	size_t pixelWidth, pixelHeight;
+	int main;
+} owl_display;
+
+static int owl_list_displays(owl_display *out, int max) {
+	CGDirectDisplayID ids[32];
+	uint32_t count = 0;
+	if (CGGetActiveDisplayList(32, ids, &count) != kCGErrorSuccess) {
+		return -1;
+	}
+
+	int n = 0;
+	for (uint32_t i = 0; i < count && n < max; i++) {
+		CGRect bounds = CGDisplayBounds(ids[i]);
+		size_t pw = CGDisplayPixelsWide(ids[i]);
+		size_t ph = CGDisplayPixelsHigh(ids[i]);
+
+		CGDisplayModeRef mode = CGDisplayCopyDisplayMode(ids[i]);
+		if (mode != NULL) {
+			pw = CGDisplayModeGetPixelWidth(mode);
+			ph = CGDisplayModeGetPixelHeight(mode);
+			CGDisplayModeRelease(mode);
+		}
+
+		out[n].id = ids[i];
+		out[n].x = bounds.origin.x;
+		out[n].y = bounds.origin.y;
+		out[n].width = bounds.size.width;
+		out[n].height = bounds.size.height;
+		out[n].pixelWidth = pw;
+		out[n].pixelHeight = ph;
+		out[n].main = CGDisplayIsMain(ids[i]);
+		n++;
+	}
+	return n;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"image"
+	"strconv"
+)
+
+const maxDisplays = 32
+
+func listDisplays() ([]Display, error) {
+	var raw [maxDisplays]C.owl_display
+	n := int(C.owl_list_displays(&raw[0], maxDisplays))
+	if n < 0 {
+		return nil, errors.New("CGGetActiveDisplayList failed")
+	}
+
+	displays := make([]Display, 0, n)
+	for _, d := range raw[:n] {
+		scale := 1.0
+		if d.width > 0 {
+			scale = float64(d.pixelWidth) / float64(d.width)
+		}
+
+		id := strconv.FormatUint(uint64(d.id), 10)
+		origin := image.Pt(int(d.x), int(d.y))
+		pixelOrigin := image.Pt(int(float64(d.x)*scale), int(float64(d.y)*scale))
+		pixels := image.Rectangle{Min: pixelOrigin, Max: pixelOrigin.Add(image.Pt(int(d.pixelWidth), int(d.pixelHeight)))}
+		displays = append(displays, newDisplay(id, "Display "+id, origin, pixels, scale, d.main != 0))
+	}
+
+	return displays, nil
+}
@@ -0,0 +1,64 @@
+//go:build darwin && !ios && !cgo
+
+package screen
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os/exec"
+)
+
+type systemProfilerDisplays struct {
+	Cards []struct {
+		Displays []struct {
+			Name       string `json:"_name"`
+			ID         string `json:"_spdisplays_displayID"`
+			Pixels     string `json:"_spdisplays_pixels"`
+			Resolution string `json:"_spdisplays_resolution"`
+			Main       string `json:"spdisplays_main"`
+		} `json:"spdisplays_ndrvs"`
+	} `json:"SPDisplaysDataType"`
+}
+
+func listDisplays() ([]Display, error) {
+	out, err := exec.Command("system_profiler", "SPDisplaysDataType", "-json").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSystemProfiler(out)
+}
+
+func parseSystemProfiler(out []byte) ([]Display, error) {
+	var report systemProfilerDisplays
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, err
+	}
+
+	displays := make([]Display, 0)
+	for _, card := range report.Cards {
+		for _, d := range card.Displays {
+			var lw, lh, pw, ph int
+			if _, err := fmt.Sscanf(d.Resolution, "%d x %d", &lw, &lh); err != nil {
+				continue
+			}
+			if _, err := fmt.Sscanf(d.Pixels, "%d x %d", &pw, &ph); err != nil {
+				pw, ph = lw, lh
+			}
+
+			scale := 1.0
+			if lw > 0 {
+				scale = float64(pw) / float64(lw)
+			}
+
+			displays = append(displays, newDisplay(d.ID, d.Name, image.Point{}, image.Rect(0, 0, pw, ph), scale, d.Main == "spdisplays_yes"))
+		}
+	}
+
+	if len(displays) > 0 && !hasPrimary(displays) {
+		displays[0].Primary = true
+	}
+
+	return displays, nil
+}
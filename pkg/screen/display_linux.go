@@ -0,0 +1,79 @@
+//go:build linux && !android
+
+package screen
+
+import (
+	"bufio"
+	"bytes"
+	"image"
+	"math"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var xrandrOutput = regexp.MustCompile(`^(\S+) connected( primary)? (\d+)x(\d+)([+-]\d+)([+-]\d+)`)
+
+func listDisplays() ([]Display, error) {
+	out, err := exec.Command("xrandr", "--query", "--current").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseXrandr(out, x11Scale()), nil
+}
+
+func parseXrandr(out []byte, scale float64) []Display {
+	displays := make([]Display, 0)
+
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		m := xrandrOutput.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+
+		w, _ := strconv.Atoi(m[3])
+		h, _ := strconv.Atoi(m[4])
+		x, _ := strconv.Atoi(m[5])
+		y, _ := strconv.Atoi(m[6])
+
+		origin := image.Pt(int(math.Round(float64(x)/scale)), int(math.Round(float64(y)/scale)))
+		displays = append(displays, newDisplay(m[1], m[1], origin, image.Rect(x, y, x+w, y+h), scale, m[2] != ""))
+	}
+
+	if len(displays) > 0 && !hasPrimary(displays) {
+		displays[0].Primary = true
+	}
+
+	return displays
+}
+
+func x11Scale() float64 {
+	if v, err := strconv.ParseFloat(os.Getenv("GDK_SCALE"), 64); err == nil && v > 0 {
+		return v
+	}
+
+	out, err := exec.Command("xrdb", "-query").Output()
+	if err != nil {
+		return 1
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		key, value, ok := strings.Cut(sc.Text(), ":")
+		if !ok || strings.TrimSpace(key) != "Xft.dpi" {
+			continue
+		}
+
+		dpi, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || dpi <= 0 {
+			return 1
+		}
+		return dpi / 96
+	}
+
+	return 1
+}
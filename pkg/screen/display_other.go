@@ -0,0 +1,7 @@
+//go:build (!darwin && !linux && !windows) || ios || android
+
+package screen
+
+func listDisplays() ([]Display, error) {
+	return nil, ErrUnsupported
+}
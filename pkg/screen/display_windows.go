@@ -0,0 +1,77 @@
+//go:build windows
+
+package screen
+
+import (
+	"image"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	monitorInfoPrimary = 1
+	mdtEffectiveDPI    = 0
+)
+
+var (
+	user32                  = windows.NewLazySystemDLL("user32.dll")
+	shcore                  = windows.NewLazySystemDLL("shcore.dll")
+	procEnumDisplayMonitors = user32.NewProc("EnumDisplayMonitors")
+	procGetMonitorInfoW     = user32.NewProc("GetMonitorInfoW")
+	procGetDpiForMonitor    = shcore.NewProc("GetDpiForMonitor")
+)
+
+type monitorInfoEx struct {
+	Size    uint32
+	Monitor windows.Rect
+	Work    windows.Rect
+	Flags   uint32
+	Device  [32]uint16
+}
+
+var (
+	enumMu       sync.Mutex
+	enumDisplays []Display
+	enumCallback = syscall.NewCallback(enumMonitor)
+)
+
+func enumMonitor(monitor, hdc uintptr, rect *windows.Rect, data uintptr) uintptr {
+	info := monitorInfoEx{Size: uint32(unsafe.Sizeof(monitorInfoEx{}))}
+	if ok, _, _ := procGetMonitorInfoW.Call(monitor, uintptr(unsafe.Pointer(&info))); ok == 0 {
+		return 1
+	}
+
+	scale := 1.0
+	if procGetDpiForMonitor.Find() == nil {
+		var dpiX, dpiY uint32
+		hr, _, _ := procGetDpiForMonitor.Call(monitor, mdtEffectiveDPI,
+			uintptr(unsafe.Pointer(&dpiX)), uintptr(unsafe.Pointer(&dpiY)))
+		if hr == 0 && dpiX > 0 {
+			scale = float64(dpiX) / 96
+		}
+	}
+
+	name := windows.UTF16ToString(info.Device[:])
+	r := info.Monitor
+	origin := image.Pt(int(float64(r.Left)/scale), int(float64(r.Top)/scale))
+	pixels := image.Rect(int(r.Left), int(r.Top), int(r.Right), int(r.Bottom))
+	enumDisplays = append(enumDisplays, newDisplay(name, name, origin, pixels, scale, info.Flags&monitorInfoPrimary != 0))
+	return 1
+}
+
+func listDisplays() ([]Display, error) {
+	enumMu.Lock()
+	defer enumMu.Unlock()
+
+	enumDisplays = make([]Display, 0)
+	defer func() { enumDisplays = nil }()
+
+	if ok, _, err := procEnumDisplayMonitors.Call(0, 0, enumCallback, 0); ok == 0 {
+		return nil, err
+	}
+
+	return enumDisplays, nil
+}
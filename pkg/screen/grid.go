@@ -0,0 +1,181 @@
+package screen
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"qrtransfer/pkg/qr"
+)
+
+const (
+	gridEdgeThreshold = 48
+	gridLineFraction  = 0.3
+	gridFitShare      = 0.9
+	gridMinPitch      = 3
+	gridFlushShare    = 0.5
+)
+
+func findGrid(img image.Image) (image.Rectangle, int, int, bool) {
+	rgba := asRGBA(img)
+	colEdges, rowEdges := edgeProfiles(rgba)
+
+	minX, maxX, cols, ok := fitLattice(colEdges)
+	if !ok {
+		return image.Rectangle{}, 0, 0, false
+	}
+	minY, maxY, rows, ok := fitLattice(rowEdges)
+	if !ok {
+		return image.Rectangle{}, 0, 0, false
+	}
+
+	bounds := rgba.Bounds()
+	code := image.Rect(minX, minY, maxX, maxY).Add(bounds.Min)
+	return code, cols, rows, true
+}
+
+func flushGrid(img image.Image) (image.Rectangle, int, int, bool) {
+	bounds := img.Bounds()
+	if !flushEdge(img, bounds.Min, image.Pt(1, 0), bounds.Dx()) || !flushEdge(img, bounds.Min, image.Pt(0, 1), bounds.Dy()) {
+		return image.Rectangle{}, 0, 0, false
+	}
+
+	code, cols, rows, ok := findGrid(img)
+	if !ok || code.Min != bounds.Min || code.Dx() != bounds.Dx() {
+		return image.Rectangle{}, 0, 0, false
+	}
+	return code, cols, rows, true
+}
+
+func flushEdge(img image.Image, start, dir image.Point, length int) bool {
+	quiet, total := 0, 0
+	for i := 0; i < length; i += regionSampleStep {
+		r, g, b, _ := img.At(start.X+dir.X*i, start.Y+dir.Y*i).RGBA()
+		if luminance(r>>8, g>>8, b>>8) >= qr.QuietZoneColor.R-gridEdgeThreshold {
+			quiet++
+		}
+		total++
+	}
+	return total > 0 && float64(total-quiet) > gridFlushShare*float64(total)
+}
+
+func asRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba
+}
+
+func edgeProfiles(img *image.RGBA) ([]int, []int) {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	cols := make([]int, w+1)
+	rows := make([]int, h+1)
+
+	quiet := []uint8{qr.QuietZoneColor.R, qr.QuietZoneColor.G, qr.QuietZoneColor.B}
+	at := func(x, y int) []uint8 {
+		if x < 0 || y < 0 || x >= w || y >= h {
+			return quiet
+		}
+		i := img.PixOffset(bounds.Min.X+x, bounds.Min.Y+y)
+		return img.Pix[i : i+3]
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x <= w; x++ {
+			if edge(at(x-1, y), at(x, y)) {
+				cols[x]++
+			}
+		}
+	}
+	for x := 0; x < w; x++ {
+		for y := 0; y <= h; y++ {
+			if edge(at(x, y-1), at(x, y)) {
+				rows[y]++
+			}
+		}
+	}
+
+	return cols, rows
+}
+
+func edge(a, b []uint8) bool {
+	for c := 0; c < 3; c++ {
+		if abs(int(a[c])-int(b[c])) > gridEdgeThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+func fitLattice(profile []int) (int, int, int, bool) {
+	peak := 0
+	for _, v := range profile {
+		peak = max(peak, v)
+	}
+	if peak == 0 {
+		return 0, 0, 0, false
+	}
+
+	strong := int(math.Ceil(float64(peak) * gridLineFraction))
+	lo, hi := -1, -1
+	for i, v := range profile {
+		if v >= strong {
+			if lo < 0 {
+				lo = i
+			}
+			hi = i
+		}
+	}
+	span := hi - lo
+	if span < gridMinPitch {
+		return 0, 0, 0, false
+	}
+
+	total := 0
+	for _, v := range profile[lo : hi+1] {
+		total += v
+	}
+
+	for n := 1; n <= span/gridMinPitch; n += 2 {
+		pitch := float64(span) / float64(n)
+		tolerance := max(0.5, 0.15*pitch)
+
+		explained := 0
+		for i, v := range profile[lo : hi+1] {
+			if v == 0 {
+				continue
+			}
+			offset := math.Mod(float64(i), pitch)
+			if math.Min(offset, pitch-offset) <= tolerance {
+				explained += v
+			}
+		}
+		if float64(explained) >= gridFitShare*float64(total) {
+			return lo, hi, n, true
+		}
+	}
+
+	return 0, 0, 0, false
+}
+
+func resampleGrid(img image.Image, code image.Rectangle, cols, rows int) image.Image {
+	w, h := code.Dx(), code.Dy()
+	if w%cols == 0 && h%rows == 0 && w/cols == h/rows {
+		return cropImage(img, code.Sub(img.Bounds().Min))
+	}
+
+	src := asRGBA(img)
+	pitch := max(1, int(math.Round(math.Min(float64(w)/float64(cols), float64(h)/float64(rows)))))
+	dst := image.NewRGBA(image.Rect(0, 0, cols*pitch, rows*pitch))
+	for y := 0; y < dst.Rect.Dy(); y++ {
+		sy := code.Min.Y + (2*y+1)*h/(2*dst.Rect.Dy())
+		for x := 0; x < dst.Rect.Dx(); x++ {
+			sx := code.Min.X + (2*x+1)*w/(2*dst.Rect.Dx())
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,97 @@
+package screen
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"image"
+)
+
+var frameSeed = maphash.MakeSeed()
+
+func FrameHash(img image.Image) uint64 {
+	var h maphash.Hash
+	h.SetSeed(frameSeed)
+
+	bounds := img.Bounds()
+	var size [16]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(bounds.Dx()))
+	binary.LittleEndian.PutUint64(size[8:], uint64(bounds.Dy()))
+	h.Write(size[:])
+
+	switch m := img.(type) {
+	case *image.RGBA:
+		hashRows(&h, m.Pix, m.PixOffset(bounds.Min.X, bounds.Min.Y), m.Stride, bounds.Dx()*4, bounds.Dy())
+	case *image.NRGBA:
+		hashRows(&h, m.Pix, m.PixOffset(bounds.Min.X, bounds.Min.Y), m.Stride, bounds.Dx()*4, bounds.Dy())
+	case *image.YCbCr:
+		hashRows(&h, m.Y, m.YOffset(bounds.Min.X, bounds.Min.Y), m.YStride, bounds.Dx(), bounds.Dy())
+		cw, ch := chromaSize(m.SubsampleRatio, bounds.Dx(), bounds.Dy())
+		c := m.COffset(bounds.Min.X, bounds.Min.Y)
+		hashRows(&h, m.Cb, c, m.CStride, cw, ch)
+		hashRows(&h, m.Cr, c, m.CStride, cw, ch)
+	default:
+		var px [4]byte
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				r, g, b, a := img.At(x, y).RGBA()
+				px[0], px[1], px[2], px[3] = uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8)
+				h.Write(px[:])
+			}
+		}
+	}
+
+	return h.Sum64()
+}
+
+func hashRows(h *maphash.Hash, pix []byte, offset, stride, rowLen, rows int) {
+	for y := 0; y < rows; y++ {
+		start := offset + y*stride
+		end := min(start+rowLen, len(pix))
+		if start >= end {
+			return
+		}
+		h.Write(pix[start:end])
+	}
+}
+
+func chromaSize(ratio image.YCbCrSubsampleRatio, w, h int) (int, int) {
+	switch ratio {
+	case image.YCbCrSubsampleRatio422:
+		return (w + 1) / 2, h
+	case image.YCbCrSubsampleRatio420:
+		return (w + 1) / 2, (h + 1) / 2
+	case image.YCbCrSubsampleRatio440:
+		return w, (h + 1) / 2
+	case image.YCbCrSubsampleRatio411:
+		return (w + 3) / 4, h
+	case image.YCbCrSubsampleRatio410:
+		return (w + 3) / 4, (h + 1) / 2
+	}
+	return w, h
+}
+
+type DuplicateFilter struct {
+	last    uint64
+	seen    bool
+	dropped int
+}
+
+func (d *DuplicateFilter) Duplicate(img image.Image) bool {
+	sum := FrameHash(img)
+	if d.seen && sum == d.last {
+		d.dropped++
+		return true
+	}
+
+	d.last = sum
+	d.seen = true
+	return false
+}
+
+func (d *DuplicateFilter) Dropped() int {
+	return d.dropped
+}
+
+func (d *DuplicateFilter) Reset() {
+	d.seen = false
+}
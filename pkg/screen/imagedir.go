@@ -0,0 +1,126 @@
+package screen
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var ErrNoImages = errors.New("no PNG or JPEG images found")
+
+type ImageOrder int
+
+const (
+	OrderByName ImageOrder = iota
+	OrderByModTime
+)
+
+type ImageDirConfig struct {
+	Path  string
+	Order ImageOrder
+}
+
+type ImageDir struct {
+	config ImageDirConfig
+	files  []string
+	next   int
+}
+
+func OpenImageDir(config ImageDirConfig) (*ImageDir, error) {
+	entries, err := os.ReadDir(config.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	type imageFile struct {
+		path    string
+		modTime int64
+	}
+
+	images := make([]imageFile, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !isImageFile(e.Name()) {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		images = append(images, imageFile{
+			path:    filepath.Join(config.Path, e.Name()),
+			modTime: info.ModTime().UnixNano(),
+		})
+	}
+
+	if len(images) == 0 {
+		return nil, fmt.Errorf("%s: %w", config.Path, ErrNoImages)
+	}
+
+	sort.SliceStable(images, func(i, j int) bool {
+		if config.Order == OrderByModTime && images[i].modTime != images[j].modTime {
+			return images[i].modTime < images[j].modTime
+		}
+		return images[i].path < images[j].path
+	})
+
+	files := make([]string, len(images))
+	for i, img := range images {
+		files[i] = img.path
+	}
+
+	return &ImageDir{config: config, files: files}, nil
+}
+
+func isImageFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg":
+		return true
+	}
+	return false
+}
+
+func (d *ImageDir) Capture() (image.Image, error) {
+	if d.next >= len(d.files) {
+		return nil, io.EOF
+	}
+
+	path := d.files[d.next]
+	d.next++
+	return LoadImage(path)
+}
+
+func LoadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+
+	return img, nil
+}
+
+func (d *ImageDir) Files() []string {
+	return d.files
+}
+
+func (d *ImageDir) Position() (int, int) {
+	return d.next, len(d.files)
+}
+
+func (d *ImageDir) Close() error {
+	d.next = len(d.files)
+	return nil
+}
@@ -0,0 +1,58 @@
+package screen
+
+import (
+	"image"
+	"image/draw"
+	"time"
+)
+
+const maskRefreshInterval = time.Second
+
+func (c *Capturer) maskRects() []image.Rectangle {
+	if len(c.config.MaskWindows) == 0 {
+		return c.config.Masks
+	}
+
+	if time.Since(c.maskedAt) > maskRefreshInterval {
+		c.maskedWindows = c.maskedWindows[:0]
+		for _, m := range c.config.MaskWindows {
+			if w, err := c.FindWindow(m); err == nil {
+				c.maskedWindows = append(c.maskedWindows, w.Bounds)
+			}
+		}
+		c.maskedAt = time.Now()
+	}
+
+	masks := make([]image.Rectangle, 0, len(c.config.Masks)+len(c.maskedWindows))
+	masks = append(masks, c.config.Masks...)
+	return append(masks, c.maskedWindows...)
+}
+
+func (c *Capturer) applyMasks(img image.Image, region image.Rectangle) image.Image {
+	if img == nil {
+		return nil
+	}
+
+	masks := c.maskRects()
+	if len(masks) == 0 {
+		return img
+	}
+
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(img.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), img, rgba.Bounds().Min, draw.Src)
+	}
+
+	offset := rgba.Bounds().Min.Sub(c.PixelRect(region).Min)
+	fill := image.NewUniform(rgba.At(rgba.Bounds().Min.X, rgba.Bounds().Min.Y))
+
+	for _, m := range masks {
+		r := c.PixelRect(m).Add(offset).Intersect(rgba.Bounds())
+		if !r.Empty() {
+			draw.Draw(rgba, r, fill, image.Point{}, draw.Src)
+		}
+	}
+
+	return rgba
+}
@@ -0,0 +1,147 @@
+package screen
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	metricsSmoothing = 0.2
+	metricsWindow    = 32
+)
+
+const (
+	BottleneckNone    = "none"
+	BottleneckCapture = "capture"
+	BottleneckDecode  = "decode"
+	BottleneckSender  = "sender"
+)
+
+type MetricsSnapshot struct {
+	Frames     int
+	Dropped    int
+	Duplicates int
+	Errors     int
+
+	CaptureLatency    time.Duration
+	MaxCaptureLatency time.Duration
+	DecodeLatency     time.Duration
+	MaxDecodeLatency  time.Duration
+
+	FPS       float64
+	TargetFPS int
+}
+
+func (s MetricsSnapshot) Bottleneck() string {
+	if s.Frames == 0 {
+		return BottleneckNone
+	}
+
+	var budget time.Duration
+	if s.TargetFPS > 0 {
+		budget = time.Second / time.Duration(s.TargetFPS)
+	}
+
+	switch {
+	case budget > 0 && s.DecodeLatency > budget:
+		return BottleneckDecode
+	case budget > 0 && s.CaptureLatency > budget:
+		return BottleneckCapture
+	case s.Duplicates > s.Frames:
+		return BottleneckSender
+	}
+	return BottleneckNone
+}
+
+type Metrics struct {
+	mu       sync.Mutex
+	snap     MetricsSnapshot
+	times    [metricsWindow]time.Time
+	timesLen int
+	timesPos int
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{}
+}
+
+func (m *Metrics) SetTargetFPS(fps int) {
+	m.mu.Lock()
+	m.snap.TargetFPS = fps
+	m.mu.Unlock()
+}
+
+func (m *Metrics) TargetFPS() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snap.TargetFPS
+}
+
+func (m *Metrics) RecordCapture(at time.Time, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.snap.Frames++
+	m.snap.CaptureLatency = smooth(m.snap.CaptureLatency, latency, m.snap.Frames == 1)
+	m.snap.MaxCaptureLatency = max(m.snap.MaxCaptureLatency, latency)
+
+	m.times[m.timesPos] = at
+	m.timesPos = (m.timesPos + 1) % metricsWindow
+	m.timesLen = min(m.timesLen+1, metricsWindow)
+}
+
+func (m *Metrics) RecordDecode(latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	first := m.snap.DecodeLatency == 0
+	m.snap.DecodeLatency = smooth(m.snap.DecodeLatency, latency, first)
+	m.snap.MaxDecodeLatency = max(m.snap.MaxDecodeLatency, latency)
+}
+
+func (m *Metrics) RecordDrop() {
+	m.mu.Lock()
+	m.snap.Dropped++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) RecordDuplicate() {
+	m.mu.Lock()
+	m.snap.Duplicates++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) RecordError() {
+	m.mu.Lock()
+	m.snap.Errors++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snap := m.snap
+	if m.timesLen > 1 {
+		newest := m.times[(m.timesPos+metricsWindow-1)%metricsWindow]
+		oldest := m.times[(m.timesPos+metricsWindow-m.timesLen)%metricsWindow]
+		if span := newest.Sub(oldest); span > 0 {
+			snap.FPS = float64(m.timesLen-1) / span.Seconds()
+		}
+	}
+	return snap
+}
+
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	m.snap = MetricsSnapshot{TargetFPS: m.snap.TargetFPS}
+	m.timesLen, m.timesPos = 0, 0
+	m.mu.Unlock()
+}
+
+func smooth(avg, sample time.Duration, first bool) time.Duration {
+	if first {
+		return sample
+	}
+	return avg + time.Duration(metricsSmoothing*float64(sample-avg))
+}
@@ -0,0 +1,146 @@
+package screen
+
+import (
+	"errors"
+	"image"
+	"log/slog"
+	"sort"
+
+	"qrtransfer/pkg/qr"
+)
+
+var ErrNoGrid = errors.New("no code grid found in region")
+
+type DecodeTuning struct {
+	Tolerance float64
+	Kernel    int
+	Dominant  bool
+	Threshold int
+	EInk      bool
+	Projector bool
+}
+
+type RegionResult struct {
+	Region image.Rectangle
+	Data   []byte
+	Stats  qr.DecodeStats
+	Marker bool
+	Err    error
+}
+
+func DetectQRRegions(img image.Image) []image.Rectangle {
+	return detectRegions(img, 0)
+}
+
+func detectRegions(img image.Image, threshold int) []image.Rectangle {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return nil
+	}
+
+	grid := sampleLuminance(img, regionSampleStep)
+	found := grid.components(grid.foreground(threshold))
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].count > found[j].count
+	})
+
+	minCount := int(regionMinFraction * float64(len(grid.lum)))
+	regions := make([]image.Rectangle, 0, len(found))
+	for _, c := range found {
+		if c.count == 0 || c.count < minCount {
+			break
+		}
+
+		region := grid.toImage(c.rect)
+		region.Min = region.Min.Sub(image.Pt(regionPadding, regionPadding))
+		region.Max = region.Max.Add(image.Pt(regionPadding, regionPadding))
+		regions = append(regions, region.Intersect(bounds))
+	}
+
+	sort.SliceStable(regions, func(i, j int) bool {
+		if regions[i].Min.Y != regions[j].Min.Y {
+			return regions[i].Min.Y < regions[j].Min.Y
+		}
+		return regions[i].Min.X < regions[j].Min.X
+	})
+
+	return regions
+}
+
+func DecodeRegions(img image.Image, blockSize int) []RegionResult {
+	return DecodeRegionsTuned(img, blockSize, DecodeTuning{})
+}
+
+func DecodeRegionsTuned(img image.Image, blockSize int, tuning DecodeTuning) []RegionResult {
+	if tuning.Projector {
+		return decodeProjector(img, tuning)
+	}
+
+	if code, cols, rows, ok := flushGrid(img); ok {
+		result := RegionResult{Region: code}
+		return []RegionResult{decodeGrid(result, resampleGrid(img, code, cols, rows), cols, rows, 0, tuning)}
+	}
+
+	regions := detectRegions(img, tuning.Threshold)
+	if len(regions) == 0 {
+		regions = []image.Rectangle{img.Bounds()}
+	}
+
+	results := make([]RegionResult, 0, len(regions))
+	for _, region := range regions {
+		results = append(results, decodeRegion(img, region, blockSize, tuning))
+	}
+
+	return results
+}
+
+func decodeRegion(img image.Image, region image.Rectangle, blockSize int, tuning DecodeTuning) RegionResult {
+	result := RegionResult{Region: region}
+	sub := cropImage(img, region.Sub(img.Bounds().Min))
+
+	if code, cols, rows, ok := findGrid(sub); ok {
+		return decodeGrid(result, resampleGrid(sub, code, cols, rows), cols, rows, 0, tuning)
+	}
+
+	gridWidth, gridHeight := estimateGridSize(sub, blockSize, tuning.Threshold)
+	if gridWidth == 0 || gridHeight == 0 {
+		slog.Debug("no grid found in region", "region", region)
+		result.Err = ErrNoGrid
+		return result
+	}
+	return decodeGrid(result, sub, gridWidth, gridHeight, 1, tuning)
+}
+
+func decodeGrid(result RegionResult, img image.Image, gridWidth, gridHeight, border int, tuning DecodeTuning) RegionResult {
+	if tuning.EInk && border == 0 {
+		gridWidth -= 2 * qr.TimingRings
+		gridHeight -= 2 * qr.TimingRings
+		border = qr.TimingRings
+		if gridWidth <= 0 || gridHeight <= 0 {
+			result.Err = ErrNoGrid
+			return result
+		}
+	}
+
+	dec := qr.NewDecoder(qr.Config{
+		GridWidth:      gridWidth,
+		GridHeight:     gridHeight,
+		BorderSize:     border,
+		Tolerance:      tuning.Tolerance,
+		SampleKernel:   tuning.Kernel,
+		SampleDominant: tuning.Dominant,
+		Monochrome:     tuning.EInk,
+	})
+
+	blocks, stats, err := dec.DecodeWithStats(img)
+	if err != nil {
+		slog.Debug("region decode failed", "region", result.Region, "err", err)
+		result.Err = err
+		return result
+	}
+
+	result.Data = dec.BlocksToData(blocks)
+	result.Stats = stats
+	result.Marker = tuning.EInk && qr.Marker(blocks)
+	return result
+}
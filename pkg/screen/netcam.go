@@ -0,0 +1,181 @@
+package screen
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const netFrameTimeout = 5 * time.Second
+
+var ErrNoFrame = errors.New("no frame received from stream")
+
+type NetCameraConfig struct {
+	URL string
+	FPS int
+}
+
+type NetCamera struct {
+	config NetCameraConfig
+	client *http.Client
+	frames chan image.Image
+	err    error
+	closer io.Closer
+}
+
+func OpenNetCamera(config NetCameraConfig) (*NetCamera, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &NetCamera{config: config}
+	switch u.Scheme {
+	case "http", "https":
+		return n, n.openHTTP()
+	case "":
+		return nil, fmt.Errorf("stream URL %q has no scheme", config.URL)
+	default:
+		return n, n.openFFmpeg()
+	}
+}
+
+func (n *NetCamera) openHTTP() error {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 10 * time.Second
+	n.client = &http.Client{Transport: transport}
+
+	resp, err := n.client.Get(n.config.URL)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return fmt.Errorf("stream %s: %s", n.config.URL, resp.Status)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case err == nil && strings.HasPrefix(mediaType, "multipart/"):
+		boundary := strings.TrimPrefix(params["boundary"], "--")
+		if boundary == "" {
+			resp.Body.Close()
+			return fmt.Errorf("stream %s: multipart response without boundary", n.config.URL)
+		}
+		mr := multipart.NewReader(resp.Body, boundary)
+		n.closer = resp.Body
+		n.start(func() (image.Image, error) {
+			return nextPart(mr)
+		})
+		return nil
+	case err == nil && strings.HasPrefix(mediaType, "image/"):
+		resp.Body.Close()
+		n.client.Timeout = 10 * time.Second
+		return nil
+	default:
+		resp.Body.Close()
+		return n.openFFmpeg()
+	}
+}
+
+func nextPart(mr *multipart.Reader) (image.Image, error) {
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+
+		img, err := decodeMJPEG(data)
+		if err != nil {
+			slog.Debug("skipping undecodable stream frame", "bytes", len(data), "err", err)
+			continue
+		}
+		return img, nil
+	}
+}
+
+func (n *NetCamera) openFFmpeg() error {
+	video, err := OpenVideo(VideoConfig{Path: n.config.URL, FPS: n.config.FPS})
+	if err != nil {
+		return err
+	}
+	n.closer = video
+	n.start(video.Capture)
+	return nil
+}
+
+func (n *NetCamera) start(next func() (image.Image, error)) {
+	n.frames = make(chan image.Image, 1)
+	go func() {
+		defer close(n.frames)
+		for {
+			img, err := next()
+			if err != nil {
+				n.err = err
+				return
+			}
+			select {
+			case <-n.frames:
+			default:
+			}
+			n.frames <- img
+		}
+	}()
+}
+
+func (n *NetCamera) snapshot() (image.Image, error) {
+	resp, err := n.client.Get(n.config.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("snapshot %s: %s", n.config.URL, resp.Status)
+	}
+	img, _, err := image.Decode(bufio.NewReader(resp.Body))
+	return img, err
+}
+
+func (n *NetCamera) Capture() (image.Image, error) {
+	if n.frames == nil {
+		return n.snapshot()
+	}
+
+	select {
+	case img, ok := <-n.frames:
+		if !ok {
+			if n.err == nil || errors.Is(n.err, io.ErrUnexpectedEOF) {
+				return nil, io.EOF
+			}
+			return nil, n.err
+		}
+		return img, nil
+	case <-time.After(netFrameTimeout):
+		return nil, fmt.Errorf("%w in %v", ErrNoFrame, netFrameTimeout)
+	}
+}
+
+func (n *NetCamera) URL() string {
+	return n.config.URL
+}
+
+func (n *NetCamera) Close() error {
+	if n.closer == nil {
+		return nil
+	}
+	return n.closer.Close()
+}
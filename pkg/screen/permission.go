@@ -0,0 +1,67 @@
+package screen
+
+import (
+	"errors"
+)
+
+var ErrPermissionDenied = errors.New("screen capture permission denied")
+
+type PermissionKind int
+
+const (
+	PermissionScreenRecording PermissionKind = iota
+	PermissionCamera
+)
+
+type PermissionError struct {
+	Kind PermissionKind
+	Err  error
+}
+
+func (e *PermissionError) Error() string {
+	msg := "screen capture permission denied"
+	switch e.Kind {
+	case PermissionScreenRecording:
+		msg = "screen recording permission has not been granted"
+	case PermissionCamera:
+		msg = "camera access has not been granted"
+	}
+
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *PermissionError) Unwrap() error {
+	return e.Err
+}
+
+func (e *PermissionError) Is(target error) bool {
+	return target == ErrPermissionDenied
+}
+
+func (e *PermissionError) Steps() []string {
+	switch e.Kind {
+	case PermissionScreenRecording:
+		return []string{
+			"Open System Settings and go to Privacy & Security > Screen Recording.",
+			"Enable the toggle for this application (or the terminal it was started from).",
+			"Quit and reopen the application so macOS applies the new permission.",
+		}
+	case PermissionCamera:
+		return []string{
+			"Choose Allow when the system asks for camera access, then select the camera again.",
+			"If you declined before, enable the camera for this app in the system Settings app.",
+		}
+	}
+	return nil
+}
+
+func (e *PermissionError) CanOpenSettings() bool {
+	return e.Kind == PermissionScreenRecording
+}
+
+func OpenPermissionSettings(kind PermissionKind) error {
+	return openPermissionSettings(kind)
+}
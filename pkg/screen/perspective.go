@@ -0,0 +1,347 @@
+package screen
+
+import (
+	"errors"
+	"image"
+	"log/slog"
+	"math"
+	"sort"
+
+	"qrtransfer/pkg/ec"
+	"qrtransfer/pkg/qr"
+)
+
+const (
+	quadSampleDivisor  = 240
+	quadMinFraction    = 0.002
+	quadMinShare       = 4
+	timingMinScore     = 0.85
+	minCellPixels      = 4
+	warpedCellPixels   = 8
+	minLevelSeparation = 32
+)
+
+var ErrNoTiming = errors.New("no timing pattern found around code")
+
+type Quad [4]image.Point
+
+func (q Quad) Bounds() image.Rectangle {
+	r := image.Rectangle{Min: q[0], Max: q[0]}
+	for _, p := range q[1:] {
+		r.Min.X, r.Min.Y = min(r.Min.X, p.X), min(r.Min.Y, p.Y)
+		r.Max.X, r.Max.Y = max(r.Max.X, p.X), max(r.Max.Y, p.Y)
+	}
+	return r
+}
+
+func (q Quad) Area() float64 {
+	area := 0
+	for i, p := range q {
+		n := q[(i+1)%4]
+		area += p.X*n.Y - n.X*p.Y
+	}
+	return math.Abs(float64(area)) / 2
+}
+
+func (q Quad) Convex() bool {
+	sign := 0
+	for i, p := range q {
+		a, b := q[(i+1)%4].Sub(p), q[(i+2)%4].Sub(q[(i+1)%4])
+		cross := a.X*b.Y - a.Y*b.X
+		switch {
+		case cross == 0:
+			return false
+		case sign == 0:
+			sign = cross
+		case (cross > 0) != (sign > 0):
+			return false
+		}
+	}
+	return true
+}
+
+func LocateCode(img image.Image, threshold int) (Quad, bool) {
+	quads, _ := locateCodes(img, threshold)
+	if len(quads) == 0 {
+		return Quad{}, false
+	}
+	return quads[0], true
+}
+
+func LocateCodes(img image.Image, threshold int) []Quad {
+	quads, _ := locateCodes(img, threshold)
+	return quads
+}
+
+func locateCodes(img image.Image, threshold int) ([]Quad, uint8) {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return nil, 0
+	}
+
+	step := max(2, min(bounds.Dx(), bounds.Dy())/quadSampleDivisor)
+	grid := sampleLuminance(img, step)
+
+	t := uint8(min(max(threshold, 0), 255))
+	if threshold <= 0 {
+		var hist [256]int
+		for _, l := range grid.lum {
+			hist[l]++
+		}
+		t = otsuThreshold(&hist)
+	}
+
+	dark := make([]bool, len(grid.lum))
+	for i, l := range grid.lum {
+		dark[i] = l <= t
+	}
+
+	var found []component
+	for _, c := range grid.components(dark) {
+		edge := c.rect.Min.X == 0 || c.rect.Min.Y == 0 || c.rect.Max.X == grid.w || c.rect.Max.Y == grid.h
+		if !edge && float64(c.count) >= quadMinFraction*float64(len(grid.lum)) {
+			found = append(found, c)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].count > found[j].count })
+
+	var quads []Quad
+	for _, c := range found {
+		if c.count*quadMinShare < found[0].count {
+			break
+		}
+
+		var q Quad
+		for i, p := range c.corners {
+			q[i] = refineCorner(img, grid.origin.Add(p.Mul(step)), i, step, t)
+		}
+		if q.Convex() {
+			quads = append(quads, q)
+		}
+	}
+	return quads, t
+}
+
+var cornerDirections = [4]image.Point{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}}
+
+func refineCorner(img image.Image, p image.Point, corner, radius int, threshold uint8) image.Point {
+	dir := cornerDirections[corner]
+	window := image.Rect(p.X-radius, p.Y-radius, p.X+radius+1, p.Y+radius+1).Intersect(img.Bounds().Inset(1))
+
+	best, bestScore := p, dir.X*p.X+dir.Y*p.Y
+	for y := window.Min.Y; y < window.Max.Y; y++ {
+		for x := window.Min.X; x < window.Max.X; x++ {
+			if score := dir.X*x + dir.Y*y; score <= bestScore || !darkAround(img, x, y, threshold) {
+				continue
+			}
+			best, bestScore = image.Pt(x, y), dir.X*x+dir.Y*y
+		}
+	}
+	return best
+}
+
+func darkAround(img image.Image, x, y int, threshold uint8) bool {
+	sum := 0
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			r, g, b, _ := img.At(x+dx, y+dy).RGBA()
+			sum += int(luminance(r>>8, g>>8, b>>8))
+		}
+	}
+	return sum <= int(threshold)*9
+}
+
+type homography struct {
+	a, b, c, d, e, f, g, h float64
+}
+
+func squareToQuad(q Quad) (homography, bool) {
+	x0, y0 := float64(q[0].X), float64(q[0].Y)
+	x1, y1 := float64(q[1].X), float64(q[1].Y)
+	x2, y2 := float64(q[2].X), float64(q[2].Y)
+	x3, y3 := float64(q[3].X), float64(q[3].Y)
+
+	dx1, dx2, dx3 := x1-x2, x3-x2, x0-x1+x2-x3
+	dy1, dy2, dy3 := y1-y2, y3-y2, y0-y1+y2-y3
+	den := dx1*dy2 - dx2*dy1
+	if den == 0 {
+		return homography{}, false
+	}
+
+	g := (dx3*dy2 - dx2*dy3) / den
+	h := (dx1*dy3 - dx3*dy1) / den
+	return homography{
+		a: x1 - x0 + g*x1, b: x3 - x0 + h*x3, c: x0,
+		d: y1 - y0 + g*y1, e: y3 - y0 + h*y3, f: y0,
+		g: g, h: h,
+	}, true
+}
+
+func (m homography) apply(u, v float64) (float64, float64) {
+	w := m.g*u + m.h*v + 1
+	return (m.a*u + m.b*v + m.c) / w, (m.d*u + m.e*v + m.f) / w
+}
+
+func (m homography) at(img image.Image, u, v float64) (uint32, uint32, uint32) {
+	x, y := m.apply(u, v)
+	r, g, b, _ := img.At(int(math.Round(x)), int(math.Round(y))).RGBA()
+	return r >> 8, g >> 8, b >> 8
+}
+
+func timingCells(total int, fn func(x, y int, dark bool)) {
+	last := total - 1
+	for i := 0; i < total; i++ {
+		fn(i, 0, true)
+		fn(i, last, true)
+		fn(0, i, true)
+		fn(last, i, true)
+	}
+	for i := 1; i < last; i++ {
+		fn(i, 1, qr.TimingDark(i, 1))
+		fn(i, last-1, qr.TimingDark(i, last-1))
+		fn(1, i, qr.TimingDark(1, i))
+		fn(last-1, i, qr.TimingDark(last-1, i))
+	}
+}
+
+func fitTiming(img image.Image, m homography, q Quad, threshold uint8) int {
+	side := math.MaxFloat64
+	for i, p := range q {
+		n := q[(i+1)%4].Sub(p)
+		side = min(side, math.Hypot(float64(n.X), float64(n.Y)))
+	}
+
+	best, bestScore := 0, timingMinScore
+	for total := 3 + 2*qr.TimingRings; float64(total)*minCellPixels <= side; total += 2 {
+		matches, samples := 0, 0
+		timingCells(total, func(x, y int, dark bool) {
+			r, g, b := m.at(img, (float64(x)+0.5)/float64(total), (float64(y)+0.5)/float64(total))
+			if (luminance(r, g, b) <= threshold) == dark {
+				matches++
+			}
+			samples++
+		})
+
+		if score := float64(matches) / float64(samples); score > bestScore {
+			best, bestScore = total, score
+		}
+	}
+	return best
+}
+
+func warp(img image.Image, m homography, total int) *image.RGBA {
+	size := total * warpedCellPixels
+	out := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			r, g, b := m.at(img, (float64(x)+0.5)/float64(size), (float64(y)+0.5)/float64(size))
+			i := out.PixOffset(x, y)
+			out.Pix[i], out.Pix[i+1], out.Pix[i+2], out.Pix[i+3] = uint8(r), uint8(g), uint8(b), 255
+		}
+	}
+	return out
+}
+
+func normalizeLevels(img *image.RGBA, total int) {
+	var lo, hi [3]int
+	var nLo, nHi int
+	timingCells(total, func(x, y int, dark bool) {
+		c := img.RGBAAt(x*warpedCellPixels+warpedCellPixels/2, y*warpedCellPixels+warpedCellPixels/2)
+		level, n := &hi, &nHi
+		if dark {
+			level, n = &lo, &nLo
+		}
+		level[0] += int(c.R)
+		level[1] += int(c.G)
+		level[2] += int(c.B)
+		*n++
+	})
+	if nLo == 0 || nHi == 0 {
+		return
+	}
+
+	var table [3][256]uint8
+	for ch := range table {
+		black, white := lo[ch]/nLo, hi[ch]/nHi
+		for v := range table[ch] {
+			table[ch][v] = uint8(v)
+			if white-black >= minLevelSeparation {
+				table[ch][v] = uint8(min(max((v-black)*255/(white-black), 0), 255))
+			}
+		}
+	}
+
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i] = table[0][img.Pix[i]]
+		img.Pix[i+1] = table[1][img.Pix[i+1]]
+		img.Pix[i+2] = table[2][img.Pix[i+2]]
+	}
+}
+
+func decodeProjector(img image.Image, tuning DecodeTuning) []RegionResult {
+	quads, threshold := locateCodes(img, tuning.Threshold)
+	if len(quads) == 0 {
+		return []RegionResult{{Region: img.Bounds(), Err: ErrNoGrid}}
+	}
+
+	sort.Slice(quads, func(i, j int) bool {
+		a, b := quads[i].Bounds(), quads[j].Bounds()
+		if a.Max.Y <= b.Min.Y || b.Max.Y <= a.Min.Y {
+			return a.Min.Y < b.Min.Y
+		}
+		return a.Min.X < b.Min.X
+	})
+
+	results := make([]RegionResult, 0, len(quads))
+	for _, q := range quads {
+		results = append(results, decodeQuad(img, q, threshold, tuning))
+	}
+	return results
+}
+
+func decodeQuad(img image.Image, q Quad, threshold uint8, tuning DecodeTuning) RegionResult {
+	result := RegionResult{Region: q.Bounds()}
+
+	m, ok := squareToQuad(q)
+	if !ok {
+		result.Err = ErrNoGrid
+		return result
+	}
+	total := fitTiming(img, m, q, threshold)
+	if total == 0 {
+		slog.Debug("no timing pattern in code", "quad", q)
+		result.Err = ErrNoTiming
+		return result
+	}
+
+	warped := warp(img, m, total)
+	normalizeLevels(warped, total)
+
+	side := total - 2*qr.TimingRings
+	dec := qr.NewDecoder(qr.Config{
+		GridWidth:      side,
+		GridHeight:     side,
+		BorderSize:     qr.TimingRings,
+		Palette:        true,
+		Tolerance:      tuning.Tolerance,
+		SampleKernel:   tuning.Kernel,
+		SampleDominant: tuning.Dominant,
+	})
+
+	blocks, stats, err := dec.DecodeWithStats(warped)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	data, err := ec.NewRS255_191().DecodeFrame(dec.BlocksToData(blocks), dec.ByteErasures(stats))
+	if err != nil {
+		slog.Debug("projector frame uncorrectable", "grid", side, "erased", stats.BlocksUncorrectable, "err", err)
+		result.Err = err
+		return result
+	}
+
+	result.Data = data
+	result.Stats = stats
+	return result
+}
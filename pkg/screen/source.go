@@ -0,0 +1,10 @@
+package screen
+
+import (
+	"image"
+)
+
+type Source interface {
+	Capture() (image.Image, error)
+	Close() error
+}
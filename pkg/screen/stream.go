@@ -0,0 +1,165 @@
+package screen
+
+import (
+	"context"
+	"errors"
+	"image"
+	"io"
+	"log/slog"
+	"time"
+)
+
+const DefaultFPS = 10
+
+type Frame struct {
+	Image image.Image
+	Time  time.Time
+	Seq   uint64
+	Err   error
+
+	Duplicates int
+}
+
+type SourceFunc func() (image.Image, error)
+
+func (f SourceFunc) Capture() (image.Image, error) {
+	return f()
+}
+
+func (f SourceFunc) Close() error {
+	return nil
+}
+
+func (c *Capturer) Metrics() *Metrics {
+	return c.metrics
+}
+
+func (c *Capturer) Stream(ctx context.Context) (<-chan Frame, error) {
+	fps := c.config.FPS
+	if fps <= 0 {
+		fps = DefaultFPS
+	}
+
+	region := c.config.Region
+	return StreamWithMetrics(ctx, SourceFunc(func() (image.Image, error) {
+		if c.config.Track {
+			return c.CaptureTracked()
+		}
+		return c.CaptureRegion(region)
+	}), fps, c.metrics)
+}
+
+func StreamSource(ctx context.Context, src Source, fps int) (<-chan Frame, error) {
+	return StreamWithMetrics(ctx, src, fps, nil)
+}
+
+func StreamWithMetrics(ctx context.Context, src Source, fps int, metrics *Metrics) (<-chan Frame, error) {
+	if metrics == nil {
+		metrics = NewMetrics()
+	}
+	metrics.SetTargetFPS(fps)
+
+	start := time.Now()
+	first, err := src.Capture()
+	if err != nil {
+		slog.Warn("capture failed", "err", err)
+		metrics.RecordError()
+		return nil, err
+	}
+	metrics.RecordCapture(start, time.Since(start))
+
+	frames := make(chan Frame, 1)
+
+	go func() {
+		defer close(frames)
+
+		var ticker *time.Ticker
+		var tick <-chan time.Time
+		if fps > 0 {
+			ticker = time.NewTicker(time.Second / time.Duration(fps))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		var dups DuplicateFilter
+		seq := uint64(0)
+		skipped := 0
+		send := func(f Frame) bool {
+			if f.Image != nil {
+				if dups.Duplicate(f.Image) {
+					skipped++
+					metrics.RecordDuplicate()
+					return true
+				}
+				f.Duplicates = skipped
+				skipped = 0
+			}
+
+			f.Seq = seq
+			seq++
+
+			if tick != nil && f.Err == nil {
+				select {
+				case frames <- f:
+				case <-ctx.Done():
+					return false
+				default:
+					slog.Debug("frame dropped, decoder busy", "seq", f.Seq)
+					metrics.RecordDrop()
+				}
+				return true
+			}
+
+			select {
+			case frames <- f:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if first != nil && !send(Frame{Image: first, Time: start}) {
+			return
+		}
+
+		for {
+			if tick != nil {
+				select {
+				case <-tick:
+				case <-ctx.Done():
+					return
+				}
+				if target := metrics.TargetFPS(); target > 0 && target != fps {
+					fps = target
+					ticker.Reset(time.Second / time.Duration(fps))
+				}
+			} else if ctx.Err() != nil {
+				return
+			}
+
+			now := time.Now()
+			img, err := src.Capture()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				slog.Warn("capture failed", "err", err)
+				metrics.RecordError()
+				if !send(Frame{Time: now, Err: err}) || errors.Is(err, ErrPermissionDenied) {
+					return
+				}
+				continue
+			}
+			if img == nil {
+				continue
+			}
+			metrics.RecordCapture(now, time.Since(now))
+
+			if !send(Frame{Image: img, Time: now}) {
+				return
+			}
+		}
+	}()
+
+	return frames, nil
+}
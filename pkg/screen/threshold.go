@@ -0,0 +1,214 @@
+package screen
+
+import (
+	"image"
+)
+
+const (
+	regionSampleStep   = 10
+	regionPadding      = 20
+	regionMinFraction  = 0.02
+	regionEdgeContrast = 24
+)
+
+type sampleGrid struct {
+	w, h   int
+	step   int
+	origin image.Point
+	lum    []uint8
+}
+
+func sampleLuminance(img image.Image, step int) sampleGrid {
+	bounds := img.Bounds()
+	g := sampleGrid{
+		w:      (bounds.Dx() + step - 1) / step,
+		h:      (bounds.Dy() + step - 1) / step,
+		step:   step,
+		origin: bounds.Min,
+	}
+	g.lum = make([]uint8, g.w*g.h)
+
+	for gy := 0; gy < g.h; gy++ {
+		for gx := 0; gx < g.w; gx++ {
+			r, gr, b, _ := img.At(bounds.Min.X+gx*step, bounds.Min.Y+gy*step).RGBA()
+			g.lum[gy*g.w+gx] = luminance(r>>8, gr>>8, b>>8)
+		}
+	}
+
+	return g
+}
+
+func luminance(r, g, b uint32) uint8 {
+	return uint8((299*r + 587*g + 114*b) / 1000)
+}
+
+func otsuThreshold(hist *[256]int) uint8 {
+	total := 0
+	sum := 0.0
+	for i, n := range hist {
+		total += n
+		sum += float64(i * n)
+	}
+	if total == 0 {
+		return 128
+	}
+
+	best, threshold := 0.0, 0
+	weightBg, sumBg := 0, 0.0
+	for t := 0; t < 256; t++ {
+		weightBg += hist[t]
+		if weightBg == 0 {
+			continue
+		}
+		weightFg := total - weightBg
+		if weightFg == 0 {
+			break
+		}
+
+		sumBg += float64(t * hist[t])
+		meanBg := sumBg / float64(weightBg)
+		meanFg := (sum - sumBg) / float64(weightFg)
+
+		between := float64(weightBg) * float64(weightFg) * (meanBg - meanFg) * (meanBg - meanFg)
+		if between > best {
+			best, threshold = between, t
+		}
+	}
+
+	return uint8(threshold)
+}
+
+func (g sampleGrid) foreground(threshold int) []bool {
+	t := uint8(min(max(threshold, 0), 255))
+	if threshold <= 0 {
+		var hist [256]int
+		for _, l := range g.lum {
+			hist[l]++
+		}
+		t = otsuThreshold(&hist)
+	}
+
+	borderDark, borderTotal := 0, 0
+	for gx := 0; gx < g.w; gx++ {
+		for _, gy := range []int{0, g.h - 1} {
+			if g.lum[gy*g.w+gx] <= t {
+				borderDark++
+			}
+			borderTotal++
+		}
+	}
+	for gy := 1; gy < g.h-1; gy++ {
+		for _, gx := range []int{0, g.w - 1} {
+			if g.lum[gy*g.w+gx] <= t {
+				borderDark++
+			}
+			borderTotal++
+		}
+	}
+	darkBackground := borderDark*2 > borderTotal
+
+	mask := make([]bool, len(g.lum))
+	for i, l := range g.lum {
+		mask[i] = (l <= t) != darkBackground
+	}
+
+	for gy := 0; gy < g.h; gy++ {
+		for gx := 0; gx < g.w; gx++ {
+			i := gy*g.w + gx
+			l := int(g.lum[i])
+			if gx+1 < g.w && abs(l-int(g.lum[i+1])) > regionEdgeContrast {
+				mask[i], mask[i+1] = true, true
+			}
+			if gy+1 < g.h && abs(l-int(g.lum[i+g.w])) > regionEdgeContrast {
+				mask[i], mask[i+g.w] = true, true
+			}
+		}
+	}
+
+	return mask
+}
+
+type component struct {
+	rect    image.Rectangle
+	count   int
+	corners [4]image.Point
+}
+
+func (g sampleGrid) largestComponent(mask []bool) (image.Rectangle, int) {
+	var best component
+	for _, c := range g.components(mask) {
+		if c.count > best.count {
+			best = c
+		}
+	}
+	return best.rect, best.count
+}
+
+func (g sampleGrid) components(mask []bool) []component {
+	labels := make([]bool, len(mask))
+	queue := make([]int, 0, len(mask))
+	found := make([]component, 0)
+
+	for start := range mask {
+		if !mask[start] || labels[start] {
+			continue
+		}
+
+		labels[start] = true
+		queue = append(queue[:0], start)
+		minX, minY, maxX, maxY := g.w, g.h, -1, -1
+		count := 0
+		corners := [4]image.Point{}
+
+		for len(queue) > 0 {
+			i := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			count++
+
+			x, y := i%g.w, i/g.w
+			minX, maxX = min(minX, x), max(maxX, x)
+			minY, maxY = min(minY, y), max(maxY, y)
+			p := image.Pt(x, y)
+			if count == 1 {
+				corners = [4]image.Point{p, p, p, p}
+			}
+			if x+y < corners[0].X+corners[0].Y {
+				corners[0] = p
+			}
+			if x-y > corners[1].X-corners[1].Y {
+				corners[1] = p
+			}
+			if x+y > corners[2].X+corners[2].Y {
+				corners[2] = p
+			}
+			if x-y < corners[3].X-corners[3].Y {
+				corners[3] = p
+			}
+
+			for _, n := range [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				nx, ny := n[0], n[1]
+				if nx < 0 || ny < 0 || nx >= g.w || ny >= g.h {
+					continue
+				}
+				j := ny*g.w + nx
+				if mask[j] && !labels[j] {
+					labels[j] = true
+					queue = append(queue, j)
+				}
+			}
+		}
+
+		found = append(found, component{rect: image.Rect(minX, minY, maxX+1, maxY+1), count: count, corners: corners})
+	}
+
+	return found
+}
+
+func (g sampleGrid) toImage(rect image.Rectangle) image.Rectangle {
+	return image.Rect(
+		g.origin.X+rect.Min.X*g.step,
+		g.origin.Y+rect.Min.Y*g.step,
+		g.origin.X+rect.Max.X*g.step,
+		g.origin.Y+rect.Max.Y*g.step,
+	)
+}
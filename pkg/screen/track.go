@@ -0,0 +1,65 @@
+package screen
+
+import (
+	"image"
+	"log/slog"
+	"time"
+)
+
+const (
+	trackRefreshInterval = 5 * time.Second
+	trackMarginDivisor   = 6
+	trackMaxCoverage     = 0.5
+)
+
+func (c *Capturer) CaptureTracked() (image.Image, error) {
+	rect := c.config.Region
+	if !c.focus.Empty() && time.Since(c.trackedAt) < trackRefreshInterval {
+		rect = c.focus
+	}
+
+	img, err := c.CaptureRegion(rect)
+	if err != nil || img == nil {
+		c.focus = image.Rectangle{}
+		return img, err
+	}
+	if rect == c.config.Region {
+		c.trackedAt = time.Now()
+	}
+
+	focus := c.locate(img, rect)
+	if focus != c.focus {
+		slog.Debug("capture region changed", "region", focus)
+	}
+	c.focus = focus
+	return img, nil
+}
+
+func (c *Capturer) locate(img image.Image, captured image.Rectangle) image.Rectangle {
+	bounds := img.Bounds()
+	var found image.Rectangle
+	for _, r := range DetectQRRegions(img) {
+		found = found.Union(r)
+	}
+	if found.Empty() {
+		return image.Rectangle{}
+	}
+	if captured != c.config.Region && !found.In(bounds.Inset(1)) {
+		return image.Rectangle{}
+	}
+
+	margin := max(found.Dx(), found.Dy()) / trackMarginDivisor
+	found = found.Inset(-margin).Intersect(bounds)
+	if float64(found.Dx()*found.Dy()) > trackMaxCoverage*float64(bounds.Dx()*bounds.Dy()) {
+		if captured == c.config.Region {
+			return image.Rectangle{}
+		}
+		return c.focus
+	}
+
+	focus := c.LogicalRect(found.Add(c.PixelRect(captured).Min.Sub(bounds.Min)))
+	if !c.config.Region.Empty() {
+		focus = focus.Intersect(c.config.Region)
+	}
+	return focus
+}
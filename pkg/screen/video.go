@@ -0,0 +1,132 @@
+package screen
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+var ErrFFmpegNotFound = errors.New("ffmpeg not found in PATH")
+
+type VideoConfig struct {
+	Path string
+	FPS  int
+}
+
+type VideoFile struct {
+	config VideoConfig
+	width  int
+	height int
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+	reader *bufio.Reader
+	frames int
+}
+
+func OpenVideo(config VideoConfig) (*VideoFile, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, ErrFFmpegNotFound
+	}
+
+	width, height, err := probeVideoSize(config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("probe %s: %w", config.Path, err)
+	}
+
+	args := []string{"-v", "error", "-i", config.Path}
+	if config.FPS > 0 {
+		args = append(args, "-r", strconv.Itoa(config.FPS))
+	}
+	args = append(args, "-f", "rawvideo", "-pix_fmt", "rgba", "-")
+
+	cmd := exec.Command(ffmpeg, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &VideoFile{
+		config: config,
+		width:  width,
+		height: height,
+		cmd:    cmd,
+		stdout: stdout,
+		reader: bufio.NewReaderSize(stdout, width*height*4),
+	}, nil
+}
+
+func probeVideoSize(path string) (int, int, error) {
+	ffprobe, err := exec.LookPath("ffprobe")
+	if err != nil {
+		return 0, 0, ErrFFmpegNotFound
+	}
+
+	out, err := exec.Command(ffprobe,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=width,height",
+		"-of", "csv=p=0:s=x",
+		path,
+	).Output()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	w, h, ok := strings.Cut(strings.TrimSpace(string(out)), "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("unexpected ffprobe output %q", out)
+	}
+
+	width, err := strconv.Atoi(w)
+	if err != nil {
+		return 0, 0, err
+	}
+	height, err := strconv.Atoi(strings.TrimSpace(h))
+	if err != nil {
+		return 0, 0, err
+	}
+	if width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid video size %dx%d", width, height)
+	}
+
+	return width, height, nil
+}
+
+func (v *VideoFile) Capture() (image.Image, error) {
+	img := image.NewRGBA(image.Rect(0, 0, v.width, v.height))
+	if _, err := io.ReadFull(v.reader, img.Pix); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return nil, err
+	}
+
+	v.frames++
+	return img, nil
+}
+
+func (v *VideoFile) Size() (int, int) {
+	return v.width, v.height
+}
+
+func (v *VideoFile) Frames() int {
+	return v.frames
+}
+
+func (v *VideoFile) Close() error {
+	v.stdout.Close()
+	if v.cmd.ProcessState == nil {
+		v.cmd.Process.Kill()
+	}
+	v.cmd.Wait()
+	return nil
+}
@@ -0,0 +1,83 @@
+package screen
+
+import (
+	"errors"
+	"image"
+	"strings"
+)
+
+var (
+	ErrWindowNotFound = errors.New("window not found")
+	ErrUnsupported    = errors.New("not supported on this platform")
+)
+
+type WindowInfo struct {
+	ID     uint64
+	Title  string
+	Owner  string
+	Bounds image.Rectangle
+}
+
+type WindowMatcher struct {
+	ID    uint64
+	Title string
+}
+
+func (m WindowMatcher) Matches(w WindowInfo) bool {
+	if m.ID != 0 {
+		return w.ID == m.ID
+	}
+	if m.Title == "" {
+		return false
+	}
+
+	title := strings.ToLower(m.Title)
+	return strings.Contains(strings.ToLower(w.Title), title) ||
+		strings.Contains(strings.ToLower(w.Owner), title)
+}
+
+func (c *Capturer) FindWindow(m WindowMatcher) (WindowInfo, error) {
+	windows, err := c.ListWindows()
+	if err != nil {
+		return WindowInfo{}, err
+	}
+
+	for _, w := range windows {
+		if m.Matches(w) && !w.Bounds.Empty() {
+			return w, nil
+		}
+	}
+
+	return WindowInfo{}, ErrWindowNotFound
+}
+
+func (c *Capturer) CaptureWindow(m WindowMatcher) (image.Image, error) {
+	w, err := c.FindWindow(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.CaptureRegion(w.Bounds)
+}
+
+type WindowSource struct {
+	capturer *Capturer
+	match    WindowMatcher
+}
+
+func OpenWindow(m WindowMatcher, config CaptureConfig) (*WindowSource, error) {
+	c := NewCapturer(config)
+	if _, err := c.FindWindow(m); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return &WindowSource{capturer: c, match: m}, nil
+}
+
+func (w *WindowSource) Capture() (image.Image, error) {
+	return w.capturer.CaptureWindow(w.match)
+}
+
+func (w *WindowSource) Close() error {
+	return w.capturer.Close()
+}
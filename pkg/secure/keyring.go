@@ -0,0 +1,147 @@
+package secure
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	KeyringService = "owl-transfer"
+
+	KeyringSigningKey         = "signing-key"
+	KeyringSenderPassphrase   = "sender-passphrase"
+	KeyringReceiverPassphrase = "receiver-passphrase"
+
+	KeyringLocation = "system keyring"
+)
+
+var (
+	ErrKeyringUnavailable = errors.New("system keyring unavailable")
+	ErrNotInKeyring       = errors.New("not in the system keyring")
+)
+
+func KeyringAvailable() bool {
+	return keyringAvailable()
+}
+
+func KeyringGet(account string) (string, error) {
+	if !keyringAvailable() {
+		return "", ErrKeyringUnavailable
+	}
+	return keyringGet(account)
+}
+
+func KeyringSet(account, secret string) error {
+	if !keyringAvailable() {
+		return ErrKeyringUnavailable
+	}
+	return keyringSet(account, secret)
+}
+
+func KeyringDelete(account string) error {
+	if !keyringAvailable() {
+		return ErrKeyringUnavailable
+	}
+	err := keyringDelete(account)
+	if errors.Is(err, ErrNotInKeyring) {
+		return nil
+	}
+	return err
+}
+
+func KeyringSigner() (*Signer, error) {
+	data, err := KeyringGet(KeyringSigningKey)
+	if err != nil {
+		return nil, err
+	}
+	s, err := ParseSigner([]byte(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", KeyringLocation, err)
+	}
+	return s, nil
+}
+
+func (s *Signer) StoreInKeyring() error {
+	data, err := s.MarshalPEM()
+	if err != nil {
+		return err
+	}
+	return KeyringSet(KeyringSigningKey, string(data))
+}
+
+func StoredSigner(path string) (*Signer, string, error) {
+	s, err := KeyringSigner()
+	if err == nil {
+		return s, KeyringLocation, nil
+	}
+	if _, statErr := os.Stat(path); statErr == nil {
+		s, err := LoadSigner(path)
+		return s, path, err
+	}
+	if errors.Is(err, ErrNotInKeyring) || errors.Is(err, ErrKeyringUnavailable) {
+		return nil, "", nil
+	}
+	return nil, "", err
+}
+
+func FindSigner(path string) (*Signer, string, error) {
+	s, where, err := StoredSigner(path)
+	if s != nil || err != nil {
+		return s, where, err
+	}
+
+	if KeyringAvailable() {
+		if s, err = NewSigner(); err != nil {
+			return nil, "", err
+		}
+		if err = s.StoreInKeyring(); err == nil {
+			return s, KeyringLocation, nil
+		}
+	}
+	s, err = LoadSigner(path)
+	return s, path, err
+}
+
+func MoveSignerToKeyring(path string) (*Signer, error) {
+	s, err := LoadSigner(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.StoreInKeyring(); err != nil {
+		return nil, err
+	}
+
+	stored, err := KeyringSigner()
+	if err != nil {
+		return nil, err
+	}
+	if !stored.key.Equal(s.key) {
+		return nil, fmt.Errorf("%s: stored key does not read back", KeyringLocation)
+	}
+	return s, os.Remove(path)
+}
+
+func ReplaceSigner(path string) (*Signer, string, error) {
+	s, err := NewSigner()
+	if err != nil {
+		return nil, "", err
+	}
+	if KeyringAvailable() {
+		if err := s.StoreInKeyring(); err != nil {
+			return nil, "", err
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, "", err
+		}
+		return s, KeyringLocation, nil
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, "", err
+	}
+	if err := writeSigner(path, s); err != nil {
+		return nil, "", err
+	}
+	return s, path, nil
+}
@@ -0,0 +1,62 @@
+//go:build darwin && !ios
+
+package secure
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const securityNotFound = 44
+
+func keyringAvailable() bool {
+	_, err := exec.LookPath("security")
+	return err == nil
+}
+
+func keyringGet(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", KeyringService, "-a", account, "-w").Output()
+	if err != nil {
+		return "", securityError(err)
+	}
+	secret, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+	if err != nil {
+		return "", fmt.Errorf("%s: %s: %w", KeyringLocation, account, err)
+	}
+	return string(secret), nil
+}
+
+func keyringSet(account, secret string) error {
+	// security -i reads the command from stdin so the secret never appears
+	// in the process list. It does not report failed commands in its exit
+	// status, so the item is read back instead.
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		KeyringService, account, base64.StdEncoding.EncodeToString([]byte(secret))))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w", KeyringLocation, err)
+	}
+	if stored, err := keyringGet(account); err != nil || stored != secret {
+		return fmt.Errorf("%s: storing %s failed: %s", KeyringLocation, account, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func keyringDelete(account string) error {
+	return securityError(exec.Command("security", "delete-generic-password", "-s", KeyringService, "-a", account).Run())
+}
+
+func securityError(err error) error {
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == securityNotFound {
+		return ErrNotInKeyring
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", KeyringLocation, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+//go:build linux && !android
+
+package secure
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+func keyringAvailable() bool {
+	_, err := exec.LookPath("secret-tool")
+	return err == nil
+}
+
+func keyringGet(account string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", KeyringService, "account", account)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+
+	var exit *exec.ExitError
+	switch {
+	case errors.As(err, &exit) && stderr.Len() == 0:
+		return "", ErrNotInKeyring
+	case err != nil:
+		return "", secretToolError(err, &stderr)
+	}
+	return string(out), nil
+}
+
+func keyringSet(account, secret string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "store", "--label", KeyringService+" "+account,
+		"service", KeyringService, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	cmd.Stderr = &stderr
+	return secretToolError(cmd.Run(), &stderr)
+}
+
+func keyringDelete(account string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "clear", "service", KeyringService, "account", account)
+	cmd.Stderr = &stderr
+	return secretToolError(cmd.Run(), &stderr)
+}
+
+func secretToolError(err error, stderr *bytes.Buffer) error {
+	if err == nil {
+		return nil
+	}
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Errorf("%s: %s", KeyringLocation, msg)
+	}
+	return fmt.Errorf("%s: %w", KeyringLocation, err)
+}
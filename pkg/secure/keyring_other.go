@@ -0,0 +1,19 @@
+//go:build (!darwin && !linux && !windows) || ios || android
+
+package secure
+
+func keyringAvailable() bool {
+	return false
+}
+
+func keyringGet(account string) (string, error) {
+	return "", ErrKeyringUnavailable
+}
+
+func keyringSet(account, secret string) error {
+	return ErrKeyringUnavailable
+}
+
+func keyringDelete(account string) error {
+	return ErrKeyringUnavailable
+}
@@ -0,0 +1,92 @@
+package secure
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+const (
+	tokenAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	tokenLength   = 10
+	tokenLabel    = "owl-transfer pairing"
+
+	pairingPrefixBits = 40
+	pairingMACSize    = 32
+)
+
+var ErrPairingToken = errors.New("pairing token must be 10 letters and digits, such as 7KQ2M-HX9RD")
+
+type Pairing struct {
+	Token  string
+	prefix uint64
+	mac    []byte
+}
+
+func NewPairingToken() (string, error) {
+	buf := make([]byte, tokenLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = tokenAlphabet[b&31]
+	}
+	return formatToken(string(buf)), nil
+}
+
+func ParsePairingToken(token string) (string, error) {
+	token = strings.Map(func(r rune) rune {
+		switch r {
+		case '-', ' ':
+			return -1
+		case 'O', 'o':
+			return '0'
+		case 'I', 'i', 'L', 'l':
+			return '1'
+		}
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return r
+	}, token)
+	if len(token) != tokenLength || strings.Trim(token, tokenAlphabet) != "" {
+		return "", ErrPairingToken
+	}
+	return formatToken(token), nil
+}
+
+func formatToken(token string) string {
+	return token[:tokenLength/2] + "-" + token[tokenLength/2:]
+}
+
+func Pair(token string) (*Pairing, error) {
+	token, err := ParsePairingToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := pbkdf2.Key(sha256.New, token, []byte(tokenLabel), kdfIterations, 8+pairingMACSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Pairing{
+		Token:  token,
+		prefix: binary.BigEndian.Uint64(key) >> (64 - pairingPrefixBits) << (64 - pairingPrefixBits),
+		mac:    key[8:],
+	}, nil
+}
+
+func (p *Pairing) Session(n uint16) uint64 {
+	return p.prefix | uint64(n)<<8
+}
+
+func (p *Pairing) Matches(session uint64) bool {
+	return session>>(64-pairingPrefixBits) == p.prefix>>(64-pairingPrefixBits)
+}
+
+func (p *Pairing) MACKey() []byte {
+	return p.mac
+}
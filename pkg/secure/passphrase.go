@@ -0,0 +1,89 @@
+package secure
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"qrtransfer/pkg/chunk"
+)
+
+const (
+	KeySize       = 32
+	PassphraseEnv = "OWL_PASSPHRASE"
+
+	kdfIterations = 600000
+	kdfLabel      = "owl-transfer passphrase"
+	maxCachedKeys = 16
+)
+
+func Passphrase(passphrase string) chunk.Keys {
+	if passphrase == "" {
+		return nil
+	}
+
+	return cachedKeys(func(session uint64) ([]byte, error) {
+		salt := binary.BigEndian.AppendUint64([]byte(kdfLabel), session)
+		return pbkdf2.Key(sha256.New, passphrase, salt, kdfIterations, KeySize)
+	})
+}
+
+func cachedKeys(derive func(session uint64) ([]byte, error)) chunk.Keys {
+	var mu sync.Mutex
+	cache := make(map[uint64]cipher.AEAD)
+	return func(session uint64) (cipher.AEAD, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if aead, ok := cache[session]; ok {
+			return aead, nil
+		}
+
+		key, err := derive(session)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := NewAEAD(key)
+		clear(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(cache) >= maxCachedKeys {
+			clear(cache)
+		}
+		cache[session] = aead
+		return aead, nil
+	}
+}
+
+func NewAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func LoadPassphrase(path string) (string, error) {
+	if path == "" {
+		return os.Getenv(PassphraseEnv), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	passphrase, _, _ := strings.Cut(string(data), "\n")
+	passphrase = strings.TrimSuffix(passphrase, "\r")
+	if passphrase == "" {
+		return "", fmt.Errorf("%s: passphrase is empty", path)
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,120 @@
+package secure
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const signingKeyType = "PRIVATE KEY"
+
+var ErrSigningKey = errors.New("not a signing key")
+
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+func NewSigner() (*Signer, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{key: key}, nil
+}
+
+func LoadSigner(path string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return createSigner(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := ParseSigner(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+func ParseSigner(data []byte) (*Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != signingKeyType {
+		return nil, ErrSigningKey
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrSigningKey
+	}
+	return &Signer{key: key}, nil
+}
+
+func (s *Signer) MarshalPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: signingKeyType, Bytes: der}), nil
+}
+
+func createSigner(path string) (*Signer, error) {
+	s, err := NewSigner()
+	if err != nil {
+		return nil, err
+	}
+	return s, writeSigner(path, s)
+}
+
+func writeSigner(path string, s *Signer) error {
+	data, err := s.MarshalPEM()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *Signer) PublicKey() []byte {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+func (s *Signer) Sign(message []byte) []byte {
+	return ed25519.Sign(s.key, message)
+}
+
+func Verify(pub, message, sig []byte) bool {
+	return len(pub) == ed25519.PublicKeySize && ed25519.Verify(pub, message, sig)
+}
+
+func FormatSigningKey(pub []byte) string {
+	return FormatPublicKey(pub)
+}
+
+func ParseSigningKey(text string) ([]byte, error) {
+	pub, err := decodeKey(text)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, ErrSigningKey
+	}
+	return pub, nil
+}
@@ -0,0 +1,129 @@
+package secure
+
+import (
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"qrtransfer/pkg/chunk"
+)
+
+const (
+	KeyIDSize = 8
+
+	hkdfLabel      = "owl-transfer x25519"
+	keyGroupLength = 4
+)
+
+var ErrPublicKey = errors.New("not a valid receiver key")
+
+var keyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type Identity struct {
+	key *ecdh.PrivateKey
+}
+
+func NewIdentity() (*Identity, error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{key: key}, nil
+}
+
+func (id *Identity) PublicKey() []byte {
+	return id.key.PublicKey().Bytes()
+}
+
+func (id *Identity) KeyID() [KeyIDSize]byte {
+	return KeyID(id.PublicKey())
+}
+
+func (id *Identity) Keys(sender []byte) (chunk.Keys, error) {
+	pub, err := ecdh.X25519().NewPublicKey(sender)
+	if err != nil {
+		return nil, fmt.Errorf("sender key: %w", err)
+	}
+	shared, err := id.key.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+	return sharedKeys(shared, sender, id.PublicKey()), nil
+}
+
+func NewRecipient(recipient []byte) (chunk.Keys, []byte, error) {
+	pub, err := ecdh.X25519().NewPublicKey(recipient)
+	if err != nil {
+		return nil, nil, ErrPublicKey
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	shared, err := ephemeral.ECDH(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sender := ephemeral.PublicKey().Bytes()
+	return sharedKeys(shared, sender, recipient), sender, nil
+}
+
+func sharedKeys(shared, sender, recipient []byte) chunk.Keys {
+	info := hkdfLabel + string(sender) + string(recipient)
+	return cachedKeys(func(session uint64) ([]byte, error) {
+		return hkdf.Key(sha256.New, shared, binary.BigEndian.AppendUint64(nil, session), info, KeySize)
+	})
+}
+
+func KeyID(pub []byte) [KeyIDSize]byte {
+	var id [KeyIDSize]byte
+	sum := sha256.Sum256(pub)
+	copy(id[:], sum[:])
+	return id
+}
+
+func Fingerprint(pub []byte) string {
+	id := KeyID(pub)
+	return groups(fmt.Sprintf("%x", id[:]))
+}
+
+func FormatPublicKey(pub []byte) string {
+	return groups(strings.ToLower(keyEncoding.EncodeToString(pub)))
+}
+
+func ParsePublicKey(text string) ([]byte, error) {
+	pub, err := decodeKey(text)
+	if err != nil || len(pub) != 32 {
+		return nil, ErrPublicKey
+	}
+	if _, err := ecdh.X25519().NewPublicKey(pub); err != nil {
+		return nil, ErrPublicKey
+	}
+	return pub, nil
+}
+
+func decodeKey(text string) ([]byte, error) {
+	text = strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(text))
+	return keyEncoding.DecodeString(text)
+}
+
+func groups(s string) string {
+	var parts []string
+	for len(s) > keyGroupLength {
+		parts = append(parts, s[:keyGroupLength])
+		s = s[keyGroupLength:]
+	}
+	return strings.Join(append(parts, s), "-")
+}
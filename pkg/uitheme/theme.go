@@ -0,0 +1,87 @@
+package uitheme
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+const Default = "system"
+
+var names = []string{"system", "light", "dark", "high-contrast"}
+
+func Names() []string {
+	return append([]string(nil), names...)
+}
+
+func Parse(name string) (fyne.Theme, error) {
+	switch strings.ToLower(name) {
+	case "", "system":
+		return theme.DefaultTheme(), nil
+	case "light":
+		return variantTheme{Theme: theme.DefaultTheme(), variant: theme.VariantLight}, nil
+	case "dark":
+		return variantTheme{Theme: theme.DefaultTheme(), variant: theme.VariantDark}, nil
+	case "high-contrast":
+		return highContrastTheme{Theme: theme.DefaultTheme()}, nil
+	}
+	return nil, fmt.Errorf("unknown theme %q", name)
+}
+
+func Apply(app fyne.App, name string) error {
+	t, err := Parse(name)
+	if err != nil {
+		return err
+	}
+	app.Settings().SetTheme(t)
+	return nil
+}
+
+type variantTheme struct {
+	fyne.Theme
+	variant fyne.ThemeVariant
+}
+
+func (t variantTheme) Color(name fyne.ThemeColorName, _ fyne.ThemeVariant) color.Color {
+	return t.Theme.Color(name, t.variant)
+}
+
+type highContrastTheme struct {
+	fyne.Theme
+}
+
+var highContrastColors = map[fyne.ThemeColorName]color.Color{
+	theme.ColorNameBackground:          color.Black,
+	theme.ColorNameOverlayBackground:   color.Black,
+	theme.ColorNameMenuBackground:      color.Black,
+	theme.ColorNameHeaderBackground:    color.Black,
+	theme.ColorNameInputBackground:     color.Black,
+	theme.ColorNameButton:              color.Black,
+	theme.ColorNameDisabledButton:      color.Black,
+	theme.ColorNameForeground:          color.White,
+	theme.ColorNameInputBorder:         color.White,
+	theme.ColorNameSeparator:           color.White,
+	theme.ColorNameScrollBar:           color.White,
+	theme.ColorNamePlaceHolder:         color.Gray{Y: 0xcc},
+	theme.ColorNameDisabled:            color.Gray{Y: 0xa0},
+	theme.ColorNamePrimary:             color.RGBA{R: 0xff, G: 0xd8, A: 0xff},
+	theme.ColorNameForegroundOnPrimary: color.Black,
+	theme.ColorNameFocus:               color.RGBA{R: 0xff, G: 0xd8, A: 0xff},
+	theme.ColorNameHover:               color.Gray{Y: 0x40},
+	theme.ColorNamePressed:             color.Gray{Y: 0x60},
+	theme.ColorNameSelection:           color.RGBA{R: 0x80, G: 0x6c, A: 0xff},
+	theme.ColorNameShadow:              color.Transparent,
+	theme.ColorNameError:               color.RGBA{R: 0xff, G: 0x6b, B: 0x6b, A: 0xff},
+	theme.ColorNameSuccess:             color.RGBA{G: 0xe6, B: 0x76, A: 0xff},
+	theme.ColorNameWarning:             color.RGBA{R: 0xff, G: 0xd8, A: 0xff},
+}
+
+func (t highContrastTheme) Color(name fyne.ThemeColorName, _ fyne.ThemeVariant) color.Color {
+	if c, ok := highContrastColors[name]; ok {
+		return c
+	}
+	return t.Theme.Color(name, theme.VariantDark)
+}